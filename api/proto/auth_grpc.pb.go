@@ -25,6 +25,7 @@ const _ = grpc.SupportPackageIsVersion7
 type AuthGRPCServiceClient interface {
 	ValidateAuthToken(ctx context.Context, in *ValidateAuthTokenRequest, opts ...grpc.CallOption) (*User, error)
 	UserPermission(ctx context.Context, in *UserPermissionRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	ValidateSession(ctx context.Context, in *ValidateSessionRequest, opts ...grpc.CallOption) (*User, error)
 }
 
 type authGRPCServiceClient struct {
@@ -53,12 +54,22 @@ func (c *authGRPCServiceClient) UserPermission(ctx context.Context, in *UserPerm
 	return out, nil
 }
 
+func (c *authGRPCServiceClient) ValidateSession(ctx context.Context, in *ValidateSessionRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/proto.AuthGRPCService/ValidateSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AuthGRPCServiceServer is the server API for AuthGRPCService service.
 // All implementations must embed UnimplementedAuthGRPCServiceServer
 // for forward compatibility
 type AuthGRPCServiceServer interface {
 	ValidateAuthToken(context.Context, *ValidateAuthTokenRequest) (*User, error)
 	UserPermission(context.Context, *UserPermissionRequest) (*empty.Empty, error)
+	ValidateSession(context.Context, *ValidateSessionRequest) (*User, error)
 	mustEmbedUnimplementedAuthGRPCServiceServer()
 }
 
@@ -72,6 +83,9 @@ func (UnimplementedAuthGRPCServiceServer) ValidateAuthToken(context.Context, *Va
 func (UnimplementedAuthGRPCServiceServer) UserPermission(context.Context, *UserPermissionRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UserPermission not implemented")
 }
+func (UnimplementedAuthGRPCServiceServer) ValidateSession(context.Context, *ValidateSessionRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateSession not implemented")
+}
 func (UnimplementedAuthGRPCServiceServer) mustEmbedUnimplementedAuthGRPCServiceServer() {}
 
 // UnsafeAuthGRPCServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -121,6 +135,24 @@ func _AuthGRPCService_UserPermission_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AuthGRPCService_ValidateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthGRPCServiceServer).ValidateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.AuthGRPCService/ValidateSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthGRPCServiceServer).ValidateSession(ctx, req.(*ValidateSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AuthGRPCService_ServiceDesc is the grpc.ServiceDesc for AuthGRPCService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -136,6 +168,10 @@ var AuthGRPCService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UserPermission",
 			Handler:    _AuthGRPCService_UserPermission_Handler,
 		},
+		{
+			MethodName: "ValidateSession",
+			Handler:    _AuthGRPCService_ValidateSession_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "auth.proto",
@@ -2,5 +2,5 @@ package assets
 
 import "embed"
 
-//go:embed "migrations" "emails"
+//go:embed "migrations" "emails" "html"
 var EmbeddedFiles embed.FS
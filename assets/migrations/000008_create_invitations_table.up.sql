@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS invitations (
+                                           hash bytea PRIMARY KEY,
+                                           email citext NOT NULL,
+                                           permissions text[] NOT NULL DEFAULT '{}',
+                                           invited_by bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+                                           created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+                                           expiry timestamp(0) with time zone NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS invitations_email_idx ON invitations (email);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+                                            id bigserial PRIMARY KEY,
+                                            created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+                                            user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+                                            actor_id bigint REFERENCES users ON DELETE SET NULL,
+                                            action text NOT NULL,
+                                            resource text NOT NULL,
+                                            resource_id bigint NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_user_id_idx ON audit_events (user_id);
+CREATE INDEX IF NOT EXISTS audit_events_actor_id_idx ON audit_events (actor_id);
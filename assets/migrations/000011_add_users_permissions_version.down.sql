@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS users_permissions_version_bump ON users_permissions;
+DROP FUNCTION IF EXISTS bump_users_permissions_version();
+ALTER TABLE users DROP COLUMN IF EXISTS permissions_version;
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS permissions_version integer NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION bump_users_permissions_version() RETURNS trigger AS $$
+BEGIN
+    UPDATE users SET permissions_version = permissions_version + 1
+    WHERE id = COALESCE(NEW.user_id, OLD.user_id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_permissions_version_bump
+    AFTER INSERT OR DELETE ON users_permissions
+    FOR EACH ROW EXECUTE FUNCTION bump_users_permissions_version();
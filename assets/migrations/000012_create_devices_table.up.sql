@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS devices (
+                                       id bigserial PRIMARY KEY,
+                                       user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+                                       fingerprint bytea NOT NULL,
+                                       ip_prefix text NOT NULL,
+                                       user_agent text NOT NULL,
+                                       created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+                                       last_seen_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+                                       UNIQUE (user_id, fingerprint)
+);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS sessions_revoked_at timestamp with time zone NOT NULL DEFAULT 'epoch';
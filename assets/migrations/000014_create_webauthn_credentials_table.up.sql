@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+                                                    id bigserial PRIMARY KEY,
+                                                    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+                                                    credential_id bytea NOT NULL UNIQUE,
+                                                    public_key bytea NOT NULL,
+                                                    sign_count bigint NOT NULL DEFAULT 0,
+                                                    transports text[] NOT NULL DEFAULT '{}',
+                                                    aaguid bytea NOT NULL,
+                                                    name text NOT NULL,
+                                                    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+                                                    last_used_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webauthn_credentials_user_id_idx ON webauthn_credentials (user_id);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS device_authorizations (
+                                                     id bigserial PRIMARY KEY,
+                                                     device_code_hash bytea NOT NULL UNIQUE,
+                                                     user_code_hash bytea NOT NULL UNIQUE,
+                                                     client_id text NOT NULL,
+                                                     user_id bigint REFERENCES users ON DELETE CASCADE,
+                                                     status text NOT NULL DEFAULT 'pending',
+                                                     poll_interval integer NOT NULL,
+                                                     last_polled_at timestamp with time zone,
+                                                     created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+                                                     expiry timestamp(0) with time zone NOT NULL
+);
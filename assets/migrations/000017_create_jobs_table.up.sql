@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS jobs (
+                                    id bigserial PRIMARY KEY,
+                                    kind text NOT NULL,
+                                    payload jsonb NOT NULL DEFAULT '{}',
+                                    status text NOT NULL DEFAULT 'pending',
+                                    attempts integer NOT NULL DEFAULT 0,
+                                    run_at timestamp with time zone NOT NULL DEFAULT NOW(),
+                                    last_error text NOT NULL DEFAULT '',
+                                    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+                                    finished_at timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS jobs_due_idx ON jobs (run_at) WHERE status = 'pending';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bulk_email_failures;
+DROP TABLE IF EXISTS bulk_emails;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS bulk_emails (
+                                           id bigserial PRIMARY KEY,
+                                           created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+                                           template text NOT NULL,
+                                           filter jsonb NOT NULL DEFAULT '{}',
+                                           status text NOT NULL DEFAULT 'pending',
+                                           total integer NOT NULL DEFAULT 0,
+                                           sent integer NOT NULL DEFAULT 0,
+                                           failed integer NOT NULL DEFAULT 0,
+                                           last_user_id bigint NOT NULL DEFAULT 0,
+                                           locked_until timestamp with time zone,
+                                           created_by bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+                                           updated_at timestamp with time zone NOT NULL DEFAULT NOW(),
+                                           completed_at timestamp with time zone
+);
+
+CREATE TABLE IF NOT EXISTS bulk_email_failures (
+                                                   bulk_email_id bigint NOT NULL REFERENCES bulk_emails ON DELETE CASCADE,
+                                                   user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+                                                   recipient text NOT NULL,
+                                                   error text NOT NULL,
+                                                   created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+                                                   PRIMARY KEY (bulk_email_id, user_id)
+);
@@ -0,0 +1,3 @@
+UPDATE email_outbox SET data = '{}' WHERE data IS NULL;
+
+ALTER TABLE email_outbox ALTER COLUMN data SET NOT NULL;
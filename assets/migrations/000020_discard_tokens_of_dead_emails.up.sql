@@ -0,0 +1,6 @@
+ALTER TABLE email_outbox ALTER COLUMN data DROP NOT NULL;
+
+UPDATE email_outbox
+SET data = NULL
+WHERE status = 'dead'
+AND EXISTS (SELECT 1 FROM jsonb_object_keys(data) AS key WHERE key LIKE '%Token');
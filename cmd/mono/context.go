@@ -8,7 +8,10 @@ import (
 
 type contextKey string
 
-const userContextKey = contextKey("user")
+const (
+	userContextKey  = contextKey("user")
+	actorContextKey = contextKey("actor")
+)
 
 func (a *application) contextSetUser(r *http.Request, user *database.User) *http.Request {
 	ctx := context.WithValue(r.Context(), userContextKey, user)
@@ -23,3 +26,16 @@ func (a *application) contextGetUser(r *http.Request) *database.User {
 
 	return user
 }
+
+// contextSetActor records the ID of the admin impersonating the request's user.
+func (a *application) contextSetActor(r *http.Request, actorID int64) *http.Request {
+	ctx := context.WithValue(r.Context(), actorContextKey, actorID)
+	return r.WithContext(ctx)
+}
+
+// contextGetActor returns the ID of the admin impersonating the request's user,
+// or 0 when the request is not impersonated.
+func (a *application) contextGetActor(r *http.Request) int64 {
+	actorID, _ := r.Context().Value(actorContextKey).(int64)
+	return actorID
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/jessicatarra/greenlight/internal/database"
 	"github.com/jessicatarra/greenlight/internal/validator"
 	"github.com/julienschmidt/httprouter"
 	"io"
@@ -123,3 +125,30 @@ func (a *application) readInt(qs url.Values, key string, defaultValue int, v *va
 
 	return i
 }
+
+// recordAudit stores a write action against both the authenticated user and,
+// for impersonated requests, the admin acting on their behalf. Anonymous
+// writes have no user to record against and are only logged.
+func (a *application) recordAudit(request *http.Request, action string, resource string, resourceID int64) {
+	user := a.contextGetUser(request)
+
+	if user.IsAnonymous() {
+		a.logger.Info("anonymous write", "action", action, "resource", resource, "resource_id", resourceID)
+		return
+	}
+
+	event := &database.AuditEvent{
+		UserID:     user.ID,
+		ActorID:    a.contextGetActor(request),
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+	}
+
+	// The write being audited has already happened, so the event is stored
+	// even if the client has gone away in the meantime.
+	err := a.models.Audit.Insert(context.WithoutCancel(request.Context()), event)
+	if err != nil {
+		a.logger.Error("failed to record audit event", "error", err, "action", action, "user_id", event.UserID, "actor_id", event.ActorID)
+	}
+}
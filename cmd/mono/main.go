@@ -4,9 +4,11 @@ import (
 	"database/sql"
 	"expvar"
 	pb "github.com/jessicatarra/greenlight/api/proto"
+	"github.com/jessicatarra/greenlight/internal/concurrent"
 	"github.com/jessicatarra/greenlight/internal/config"
 	"github.com/jessicatarra/greenlight/internal/database"
 	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/internal/password"
 	_auth "github.com/jessicatarra/greenlight/ms/auth"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -23,6 +25,7 @@ type application struct {
 	logger     *slog.Logger
 	models     database.Models
 	mailer     mailer.Mailer
+	jobs       *concurrent.Queue
 	wg         sync.WaitGroup
 	grpcClient pb.AuthGRPCServiceClient
 }
@@ -36,6 +39,10 @@ type application struct {
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apikey SCIMBearer
+// @in header
+// @name Authorization
+// @description The SCIM provisioning token, as "Bearer <token>".
 func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
@@ -59,28 +66,41 @@ func run(logger *slog.Logger) error {
 	}
 	defer db.Close()
 
-	initMetrics(db)
+	hashing := password.NewExecutor(cfg.Password.HashConcurrency, cfg.Password.HashQueueDepth)
+
+	initMetrics(db, hashing)
 
 	grpcConn, err := grpc.Dial(cfg.Auth.GrpcBaseURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		logger.Error("did not connect:", err)
+		logger.Error("did not connect", "error", err)
 		return err
 	}
 	defer grpcConn.Close()
 
 	grpcClient := pb.NewAuthGRPCServiceClient(grpcConn)
 
-	app := newLegacyApplication(cfg, logger, db, grpcClient)
+	transport, err := mailer.NewTransport(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	mail, err := mailer.New(transport, cfg.Smtp.From, cfg.Public.BaseURL)
+	if err != nil {
+		return err
+	}
+
+	app := newLegacyApplication(cfg, logger, db, grpcClient, mail)
 
 	monolith := NewModularMonolith(&app.wg)
 
 	monolith.AddModule(NewModule(cfg, app.routes(), app.logger))
-	monolith.AddModule(_auth.NewModule(db, cfg, &app.wg, app.logger))
+	monolith.AddModule(_auth.NewModule(db, cfg, mail, app.jobs, hashing, app.logger))
+	monolith.AddModule(app.jobs)
 
 	return monolith.Run()
 }
 
-func initMetrics(db *sql.DB) {
+func initMetrics(db *sql.DB, hashing *password.Executor) {
 	expvar.NewString("version").Set(config.Version)
 
 	expvar.Publish("goroutines", expvar.Func(func() interface{} {
@@ -91,17 +111,25 @@ func initMetrics(db *sql.DB) {
 		return db.Stats()
 	}))
 
+	expvar.Publish("password_hashing", expvar.Func(hashing.Metrics))
+
 	expvar.Publish("timestamp", expvar.Func(func() interface{} {
 		return time.Now().Unix()
 	}))
 }
 
-func newLegacyApplication(cfg config.Config, logger *slog.Logger, db *sql.DB, grpcClient pb.AuthGRPCServiceClient) *application {
+func newLegacyApplication(cfg config.Config, logger *slog.Logger, db *sql.DB, grpcClient pb.AuthGRPCServiceClient, mail mailer.Mailer) *application {
 	return &application{
 		grpcClient: grpcClient,
 		config:     cfg,
 		logger:     logger,
-		models:     database.NewModels(db),
-		mailer:     mailer.New(cfg.Smtp.Host, cfg.Smtp.Port, cfg.Smtp.Username, cfg.Smtp.Password, cfg.Smtp.From),
+		models:     database.NewModels(db, cfg.DB.QueryTimeout),
+		mailer:     mail,
+		jobs: concurrent.NewQueue(db, concurrent.QueueConfig{
+			Workers:      cfg.Jobs.Workers,
+			PollInterval: cfg.Jobs.PollInterval,
+			Visibility:   cfg.Jobs.Visibility,
+			MaxAttempts:  cfg.Jobs.MaxAttempts,
+		}, logger),
 	}
 }
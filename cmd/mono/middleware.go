@@ -1,11 +1,15 @@
 package main
 
 import (
-	"context"
 	pb "github.com/jessicatarra/greenlight/api/proto"
 	"github.com/jessicatarra/greenlight/internal/database"
 	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/middleware"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 )
@@ -16,46 +20,112 @@ func (a *application) authenticate(next http.Handler) http.Handler {
 
 		authorizationHeader := r.Header.Get("Authorization")
 
-		if authorizationHeader != "" {
-			headerParts := strings.Split(authorizationHeader, " ")
+		if authorizationHeader == "" {
+			if a.config.Sessions.Enabled {
+				w.Header().Add("Vary", "Cookie")
 
-			if len(headerParts) == 2 && headerParts[0] == "Bearer" {
-				token := headerParts[1]
-
-				grpcReq := &pb.ValidateAuthTokenRequest{
-					Token: token,
-				}
-				user, err := a.grpcClient.ValidateAuthToken(context.Background(), grpcReq)
-
-				if err != nil {
-					_errors.ServerError(w, r, err)
+				if cookie, err := r.Cookie(middleware.SessionCookieName); err == nil {
+					a.authenticateSession(w, r, cookie.Value, next)
 					return
 				}
-				if user != nil {
-					createdAt := time.Unix(user.CreatedAt.Seconds, int64(user.CreatedAt.Nanos))
-					r = a.contextSetUser(r, &database.User{
-						ID:             user.Id,
-						CreatedAt:      createdAt,
-						Name:           user.Name,
-						Email:          user.Email,
-						HashedPassword: user.HashedPassword,
-						Activated:      user.Activated,
-						Version:        int(user.Version),
-					})
-				}
 			}
+
+			r = a.contextSetUser(r, database.AnonymousUser)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		headerParts := strings.Split(authorizationHeader, " ")
+		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+			_errors.InvalidAuthenticationToken(w, r)
+			return
+		}
+
+		grpcReq := &pb.ValidateAuthTokenRequest{
+			Token: headerParts[1],
+		}
+		user, err := a.grpcClient.ValidateAuthToken(r.Context(), grpcReq)
+		if err != nil {
+			switch status.Code(err) {
+			case codes.Unauthenticated:
+				_errors.InvalidAuthenticationToken(w, r)
+			case codes.PermissionDenied:
+				_errors.AccountSuspended(w, r)
+			default:
+				_errors.ServerError(w, r, err)
+			}
+			return
 		}
 
+		r = a.contextSetAuthenticatedUser(r, user)
+
 		next.ServeHTTP(w, r)
 	})
 
 }
 
-func (a *application) requireActivatedUser(next http.HandlerFunc) http.HandlerFunc {
+// authenticateSession identifies the user by the session cookie set by the
+// auth module. Sessions that have expired or been signed out leave the
+// request anonymous.
+func (a *application) authenticateSession(w http.ResponseWriter, r *http.Request, session string, next http.Handler) {
+	grpcReq := &pb.ValidateSessionRequest{
+		Session: session,
+	}
+	user, err := a.grpcClient.ValidateSession(r.Context(), grpcReq)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			r = a.contextSetUser(r, database.AnonymousUser)
+			next.ServeHTTP(w, r)
+		case codes.PermissionDenied:
+			_errors.AccountSuspended(w, r)
+		default:
+			_errors.ServerError(w, r, err)
+		}
+		return
+	}
+
+	r = a.contextSetAuthenticatedUser(r, user)
+
+	next.ServeHTTP(w, r)
+}
+
+func (a *application) contextSetAuthenticatedUser(r *http.Request, user *pb.User) *http.Request {
+	createdAt := time.Unix(user.CreatedAt.Seconds, int64(user.CreatedAt.Nanos))
+	authenticatedUser := &database.User{
+		ID:             user.Id,
+		CreatedAt:      createdAt,
+		Name:           user.Name,
+		Email:          user.Email,
+		HashedPassword: user.HashedPassword,
+		Activated:      user.Activated,
+		Version:        int(user.Version),
+	}
+
+	if user.PermissionsEmbedded {
+		authenticatedUser.Permissions = append([]string{}, user.Permissions...)
+	}
+
+	r = a.contextSetUser(r, authenticatedUser)
+
+	if user.ActorId != 0 {
+		r = a.contextSetActor(r, user.ActorId)
+		a.logger.Info("impersonated request", slog.Group("properties",
+			"user_id", user.Id,
+			"actor_id", user.ActorId,
+			"request_method", r.Method,
+			"request_url_request_uri", r.URL.RequestURI()),
+		)
+	}
+
+	return r
+}
+
+func (a *application) requireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authenticatedUser := a.contextGetUser(r)
 
-		if authenticatedUser == nil {
+		if authenticatedUser.IsAnonymous() {
 			_errors.AuthenticationRequired(w, r)
 			return
 		}
@@ -64,26 +134,65 @@ func (a *application) requireActivatedUser(next http.HandlerFunc) http.HandlerFu
 	})
 }
 
+func (a *application) requireActivatedUser(next http.HandlerFunc) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticatedUser := a.contextGetUser(r)
+
+		if !authenticatedUser.Activated {
+			_errors.InactiveAccount(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return a.requireAuthenticatedUser(fn)
+}
+
+// requirePermission lets anonymous requests through when code is one of the
+// configured anonymous permissions; everyone else must be an activated user
+// holding the permission, checked against the token's embedded permission
+// codes when it carries them.
 func (a *application) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
 	fn := func(writer http.ResponseWriter, request *http.Request) {
 		user := a.contextGetUser(request)
 
+		if user.Permissions != nil {
+			if !slices.Contains(user.Permissions, code) {
+				_errors.NotPermitted(writer, request)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+			return
+		}
+
 		grpcReq := &pb.UserPermissionRequest{
 			Code:   code,
 			UserId: user.ID,
 		}
-		_, err := a.grpcClient.UserPermission(context.Background(), grpcReq)
+		_, err := a.grpcClient.UserPermission(request.Context(), grpcReq)
 		if err != nil {
-			//if errors.Is(err, domain.ErrPermissionNotIncluded) {
-			//	_errors.NotPermitted(writer, request)
-			//	return
-			//}
-			_errors.ServerError(writer, request, err)
+			switch status.Code(err) {
+			case codes.PermissionDenied:
+				_errors.NotPermitted(writer, request)
+			default:
+				_errors.ServerError(writer, request, err)
+			}
 			return
 		}
 
 		next.ServeHTTP(writer, request)
 	}
 
-	return a.requireActivatedUser(fn)
+	activated := a.requireActivatedUser(fn)
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if a.contextGetUser(request).IsAnonymous() && slices.Contains(a.config.Anonymous.Permissions, code) {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		activated.ServeHTTP(writer, request)
+	}
 }
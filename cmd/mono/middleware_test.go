@@ -0,0 +1,188 @@
+//go:build auth
+// +build auth
+
+package main
+
+import (
+	"context"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	pb "github.com/jessicatarra/greenlight/api/proto"
+	"github.com/jessicatarra/greenlight/internal/config"
+	"github.com/jessicatarra/greenlight/internal/database"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeAuthClient answers for the auth module with user or err, recording
+// the context of the last call.
+type fakeAuthClient struct {
+	user *pb.User
+	err  error
+	ctx  context.Context
+}
+
+func (f *fakeAuthClient) ValidateAuthToken(ctx context.Context, in *pb.ValidateAuthTokenRequest, opts ...grpc.CallOption) (*pb.User, error) {
+	f.ctx = ctx
+	return f.user, f.err
+}
+
+func (f *fakeAuthClient) UserPermission(ctx context.Context, in *pb.UserPermissionRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	f.ctx = ctx
+	return &empty.Empty{}, f.err
+}
+
+func (f *fakeAuthClient) ValidateSession(ctx context.Context, in *pb.ValidateSessionRequest, opts ...grpc.CallOption) (*pb.User, error) {
+	f.ctx = ctx
+	return f.user, f.err
+}
+
+func newTestApplication(client pb.AuthGRPCServiceClient) *application {
+	var cfg config.Config
+
+	return &application{
+		config:     cfg,
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		grpcClient: client,
+	}
+}
+
+func TestApplication_Authenticate(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{name: "suspended", err: status.Error(codes.PermissionDenied, "account suspended"), code: http.StatusForbidden},
+		{name: "stale", err: status.Error(codes.Unauthenticated, "stale token"), code: http.StatusUnauthorized},
+		{name: "internal error", err: status.Error(codes.Internal, "connection refused"), code: http.StatusInternalServerError},
+		{name: "auth module unavailable", err: status.Error(codes.Unavailable, "connection refused"), code: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			app := newTestApplication(&fakeAuthClient{err: tt.err})
+			req := httptest.NewRequest(http.MethodGet, "/v1/movies", nil)
+			req.Header.Set("Authorization", "Bearer token")
+			resRec := httptest.NewRecorder()
+
+			// Act
+			app.authenticate(http.NotFoundHandler()).ServeHTTP(resRec, req)
+
+			// Assert
+			assert.Equal(t, tt.code, resRec.Code)
+		})
+	}
+
+	t.Run("authenticated user", func(t *testing.T) {
+		// Arrange
+		app := newTestApplication(&fakeAuthClient{user: &pb.User{Id: 1, Activated: true, CreatedAt: &timestamp.Timestamp{}}})
+		req := httptest.NewRequest(http.MethodGet, "/v1/movies", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		resRec := httptest.NewRecorder()
+
+		var user *database.User
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = app.contextGetUser(r)
+		})
+
+		// Act
+		app.authenticate(next).ServeHTTP(resRec, req)
+
+		// Assert
+		assert.Equal(t, int64(1), user.ID)
+		assert.True(t, user.Activated)
+	})
+
+	t.Run("request cancellation reaches the auth module", func(t *testing.T) {
+		// Arrange
+		client := &fakeAuthClient{err: status.Error(codes.Canceled, "context canceled")}
+		app := newTestApplication(client)
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "/v1/movies", nil).WithContext(ctx)
+		req.Header.Set("Authorization", "Bearer token")
+		resRec := httptest.NewRecorder()
+
+		cancel()
+
+		// Act
+		app.authenticate(http.NotFoundHandler()).ServeHTTP(resRec, req)
+
+		// Assert
+		assert.ErrorIs(t, client.ctx.Err(), context.Canceled)
+	})
+
+	t.Run("anonymous user", func(t *testing.T) {
+		// Arrange
+		app := newTestApplication(&fakeAuthClient{})
+		req := httptest.NewRequest(http.MethodGet, "/v1/movies", nil)
+		resRec := httptest.NewRecorder()
+
+		var user *database.User
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = app.contextGetUser(r)
+		})
+
+		// Act
+		app.authenticate(next).ServeHTTP(resRec, req)
+
+		// Assert
+		assert.True(t, user.IsAnonymous())
+	})
+}
+
+func TestApplication_RequirePermission(t *testing.T) {
+	t.Run("internal error", func(t *testing.T) {
+		// Arrange
+		app := newTestApplication(&fakeAuthClient{err: status.Error(codes.Internal, "connection refused")})
+		req := httptest.NewRequest(http.MethodPost, "/v1/movies", nil)
+		req = app.contextSetUser(req, &database.User{ID: 1, Activated: true})
+		resRec := httptest.NewRecorder()
+
+		// Act
+		app.requirePermission("movies:write", http.NotFound)(resRec, req)
+
+		// Assert
+		assert.Equal(t, http.StatusInternalServerError, resRec.Code)
+	})
+
+	t.Run("request cancellation reaches the auth module", func(t *testing.T) {
+		// Arrange
+		client := &fakeAuthClient{err: status.Error(codes.Canceled, "context canceled")}
+		app := newTestApplication(client)
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodPost, "/v1/movies", nil).WithContext(ctx)
+		req = app.contextSetUser(req, &database.User{ID: 1, Activated: true})
+		resRec := httptest.NewRecorder()
+
+		cancel()
+
+		// Act
+		app.requirePermission("movies:write", http.NotFound)(resRec, req)
+
+		// Assert
+		assert.ErrorIs(t, client.ctx.Err(), context.Canceled)
+	})
+
+	t.Run("not permitted", func(t *testing.T) {
+		// Arrange
+		app := newTestApplication(&fakeAuthClient{err: status.Error(codes.PermissionDenied, "permission not included")})
+		req := httptest.NewRequest(http.MethodPost, "/v1/movies", nil)
+		req = app.contextSetUser(req, &database.User{ID: 1, Activated: true})
+		resRec := httptest.NewRecorder()
+
+		// Act
+		app.requirePermission("movies:write", http.NotFound)(resRec, req)
+
+		// Assert
+		assert.Equal(t, http.StatusForbidden, resRec.Code)
+	})
+}
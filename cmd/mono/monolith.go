@@ -55,16 +55,34 @@ func (mm *ModularMonolith) Run() error {
 	}
 
 	fmt.Println("All modules stopped. Exiting...")
-	mm.waitAll()
 	return nil
 }
 
+// StopAllModules shuts the modules down side by side, so that they share
+// the same grace period, and waits for them and their goroutines to finish.
+// Each module gets a context of its own, as Shutdown cancels the one it is
+// given when it returns.
 func (mm *ModularMonolith) StopAllModules() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	var shutdowns sync.WaitGroup
+
 	for _, module := range mm.Modules {
-		module.Shutdown(ctx, cancel)
+		shutdowns.Add(1)
+		go func(m Module) {
+			defer shutdowns.Done()
+			moduleCtx, moduleCancel := context.WithCancel(ctx)
+			m.Shutdown(moduleCtx, moduleCancel)
+		}(module)
+	}
+
+	shutdowns.Wait()
+
+	select {
+	case <-mm.waitAll():
+	case <-ctx.Done():
+		fmt.Println("Timed out waiting for modules to stop")
 	}
 }
 
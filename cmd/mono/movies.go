@@ -57,12 +57,14 @@ func (a *application) createMovieHandler(writer http.ResponseWriter, request *ht
 		return
 	}
 
-	err = a.models.Movies.Insert(movie)
+	err = a.models.Movies.Insert(request.Context(), movie)
 	if err != nil {
 		_errors.ServerError(writer, request, err)
 		return
 	}
 
+	a.recordAudit(request, "create", "movies", movie.ID)
+
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/movies/%d", movie.ID))
 
@@ -88,7 +90,7 @@ func (a *application) showMovieHandler(writer http.ResponseWriter, request *http
 		return
 	}
 
-	movie, err := a.models.Movies.Get(id)
+	movie, err := a.models.Movies.Get(request.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, database.ErrRecordNotFound):
@@ -122,7 +124,7 @@ func (a *application) updateMovieHandler(writer http.ResponseWriter, request *ht
 		return
 	}
 
-	movie, err := a.models.Movies.Get(id)
+	movie, err := a.models.Movies.Get(request.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, database.ErrRecordNotFound):
@@ -163,7 +165,7 @@ func (a *application) updateMovieHandler(writer http.ResponseWriter, request *ht
 		return
 	}
 
-	err = a.models.Movies.Update(movie)
+	err = a.models.Movies.Update(request.Context(), movie)
 	if err != nil {
 		switch {
 		case errors.Is(err, database.ErrEditConflict):
@@ -174,6 +176,8 @@ func (a *application) updateMovieHandler(writer http.ResponseWriter, request *ht
 		return
 	}
 
+	a.recordAudit(request, "update", "movies", movie.ID)
+
 	err = a.writeJSON(writer, http.StatusOK, envelope{"movie": movie}, nil)
 	if err != nil {
 		_errors.ServerError(writer, request, err)
@@ -196,7 +200,7 @@ func (a *application) deleteMovieHandler(writer http.ResponseWriter, request *ht
 		return
 	}
 
-	err = a.models.Movies.Delete(id)
+	err = a.models.Movies.Delete(request.Context(), id)
 	if err != nil {
 		switch {
 		case errors.Is(err, database.ErrRecordNotFound):
@@ -207,6 +211,8 @@ func (a *application) deleteMovieHandler(writer http.ResponseWriter, request *ht
 		return
 	}
 
+	a.recordAudit(request, "delete", "movies", id)
+
 	err = a.writeJSON(writer, http.StatusOK, envelope{"message": "movie successfully deleted"}, nil)
 	if err != nil {
 		_errors.ServerError(writer, request, err)
@@ -252,7 +258,7 @@ func (a *application) listMoviesHandler(writer http.ResponseWriter, request *htt
 		return
 	}
 
-	movies, metadata, err := a.models.Movies.GetAll(input.Title, input.Genres, input.Filters)
+	movies, metadata, err := a.models.Movies.GetAll(request.Context(), input.Title, input.Genres, input.Filters)
 	if err != nil {
 		_errors.ServerError(writer, request, err)
 		return
@@ -31,5 +31,5 @@ func (a *application) routes() http.Handler {
 
 	m := middleware.NewSharedMiddleware(&a.config, a.logger)
 
-	return m.RecoverPanic(m.RateLimit(m.EnableCORS(a.authenticate(m.LogRequest(router)))))
+	return m.RecoverPanic(m.RateLimit(m.EnableCORS(m.CSRF(a.authenticate(m.LogRequest(router))))))
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/jessicatarra/greenlight/internal/config"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"sync"
@@ -14,7 +15,10 @@ import (
 
 type module struct {
 	server *http.Server
-	logger *slog.Logger
+	// cancelRequests cancels the context of every request the server is
+	// still handling, and so any query they are waiting on.
+	cancelRequests context.CancelFunc
+	logger         *slog.Logger
 }
 
 func (m module) Start(wg *sync.WaitGroup) {
@@ -37,6 +41,8 @@ func (m module) Shutdown(ctx context.Context, cancel func()) {
 	defer cancel()
 
 	err := m.server.Shutdown(ctx)
+	// Requests still running once the grace period is over are abandoned.
+	m.cancelRequests()
 	if err != nil {
 		return
 	}
@@ -49,13 +55,16 @@ const (
 )
 
 func NewModule(cfg config.Config, routes http.Handler, logger *slog.Logger) *module {
+	base, cancelRequests := context.WithCancel(context.Background())
+
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
 		Handler:      routes,
 		IdleTimeout:  defaultIdleTimeout,
 		ReadTimeout:  defaultReadTimeout,
 		WriteTimeout: defaultWriteTimeout,
+		BaseContext:  func(net.Listener) context.Context { return base },
 	}
 
-	return &module{server: srv, logger: logger}
+	return &module{server: srv, cancelRequests: cancelRequests, logger: logger}
 }
@@ -21,14 +21,53 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/movies": {
+        "/admin/bulk-emails": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Sends a bulk email template, such as the announcement of a new release, to every user matching a filter in the background. Only the templates made for bulk sends can be used, not those of emails such as password resets. By default it goes to the activated users who are not suspended. The progress can be followed on the bulk email returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Send bulk email",
+                "parameters": [
+                    {
+                        "description": "Template and user filter",
+                        "name": "bulk_email",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.CreateBulkEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/domain.BulkEmail"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/bulk-emails/{id}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Fetch a list of movies with server-side pagination",
+                "description": "Shows the progress of a bulk email: how many users it has been sent to and how many it failed for.",
                 "consumes": [
                     "application/json"
                 ],
@@ -36,25 +75,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Movies"
+                    "Admin"
                 ],
-                "summary": "List movies with pagination",
+                "summary": "Show bulk email",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Movie title",
-                        "name": "title",
-                        "in": "query"
-                    },
+                        "type": "integer",
+                        "description": "Bulk email ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.BulkEmail"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/bulk-emails/{id}/failures": {
+            "get": {
+                "security": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Movie genres",
-                        "name": "genres",
-                        "in": "query"
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Pages through the users a bulk email could not be sent to, with the reason.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List bulk email failures",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Bulk email ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "integer",
@@ -64,7 +131,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "Number of movies per page",
+                        "description": "Number of failures per page",
                         "name": "page_size",
                         "in": "query"
                     },
@@ -77,23 +144,25 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Movie list",
+                        "description": "Failure list",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/database.Movie"
+                                "$ref": "#/definitions/domain.BulkEmailFailure"
                             }
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/bulk-emails/{id}/resume": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a new movie",
+                "description": "Queues a bulk email that has not been completed to be sent again from the user it stopped at, for when its job gave up.",
                 "consumes": [
                     "application/json"
                 ],
@@ -101,38 +170,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Movies"
+                    "Admin"
                 ],
-                "summary": "Create a movie",
+                "summary": "Resume bulk email",
                 "parameters": [
                     {
-                        "description": "Request body",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.createMovieRequest"
-                        }
+                        "type": "integer",
+                        "description": "Bulk email ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Movie created",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/database.Movie"
+                            "$ref": "#/definitions/domain.BulkEmail"
                         }
                     }
                 }
             }
         },
-        "/movies/{id}": {
+        "/admin/outbox": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Retrieve a movie by its ID",
+                "description": "Pages through the email outbox, optionally filtered by delivery status. The template data, which may hold tokens, is never shown.",
                 "consumes": [
                     "application/json"
                 ],
@@ -140,34 +207,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Movies"
+                    "Admin"
                 ],
-                "summary": "Get a movie by ID",
+                "summary": "List outbound emails",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Delivery status (pending, sent or dead)",
+                        "name": "status",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Movie ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of emails per page",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Movie details",
+                        "description": "Email list",
                         "schema": {
-                            "$ref": "#/definitions/database.Movie"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.OutboxEmail"
+                            }
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/admin/outbox/{id}/replay": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update an existing movie",
+                "description": "Queues a dead email for delivery again with a fresh set of attempts. Dead emails that held tokens are redacted and cannot be replayed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -175,43 +264,36 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Movies"
+                    "Admin"
                 ],
-                "summary": "Update a movie by ID",
+                "summary": "Replay outbound email",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Movie ID",
+                        "description": "Email ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Request body",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.updateMovieRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Movie updated",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.Movie"
+                            "$ref": "#/definitions/domain.OutboxEmail"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/users": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a movie by its ID",
+                "description": "Pages through users, optionally filtered by email, activation state, suspension state and signup date",
                 "consumes": [
                     "application/json"
                 ],
@@ -219,28 +301,80 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Movies"
+                    "Admin"
                 ],
-                "summary": "Delete a movie by ID",
+                "summary": "List users",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email (partial match)",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Activation state",
+                        "name": "activated",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Suspension state",
+                        "name": "suspended",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signed up on or after this date (YYYY-MM-DD)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signed up before this date (YYYY-MM-DD)",
+                        "name": "created_before",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Movie ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of users per page",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "User list",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.User"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/tokens/authentication": {
+        "/admin/users/{id}/impersonate": {
             "post": {
-                "description": "Creates an authentication token for a user",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Issues a short-lived authentication token for a user. The token carries an act claim identifying the admin it was issued to, and stops working once that admin loses the users:admin permission.",
                 "consumes": [
                     "application/json"
                 ],
@@ -248,18 +382,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Admin"
                 ],
-                "summary": "Create authentication token",
+                "summary": "Impersonate user",
                 "parameters": [
                     {
-                        "description": "Request body",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/domain.CreateAuthTokenRequest"
-                        }
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -272,9 +404,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
+        "/admin/users/{id}/reactivate": {
             "post": {
-                "description": "Registers a new user.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lifts the suspension of a user account",
                 "consumes": [
                     "application/json"
                 ],
@@ -282,23 +419,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Register User",
+                "summary": "Reactivate user",
                 "parameters": [
                     {
-                        "description": "User registration data",
-                        "name": "name",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/domain.CreateUserRequest"
-                        }
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/domain.User"
                         }
@@ -306,41 +441,2106 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/activated": {
-            "put": {
-                "description": "Activates a user account using a token that was previously sent when successfully register a new user",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
+        "/admin/users/{id}/suspend": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Suspends a user account. Suspended users cannot log in and their authentication tokens are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Activate User",
+                "summary": "Suspend user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/dev/emails": {
+            "get": {
+                "description": "Lists the email templates and the locales they have been translated to, for previewing. Only served in development.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Development"
+                ],
+                "summary": "List email templates",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/dev/emails/{template}": {
+            "get": {
+                "description": "Renders an email template with sample data, as it would be sent. Only served in development.",
+                "produces": [
+                    "text/html",
+                    "text/plain"
+                ],
+                "tags": [
+                    "Development"
+                ],
+                "summary": "Preview email",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Token for user activation",
-                        "name": "token",
-                        "in": "query",
+                        "description": "Template file name",
+                        "name": "template",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale to render the email in",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Part to render (html or text)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/dev/inbox": {
+            "get": {
+                "description": "Lists the emails captured by the memory mail transport, newest first, so that the tokens they carry can be copied. Only served in development.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Development"
+                ],
+                "summary": "Development inbox",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/device": {
+            "get": {
+                "description": "Renders the page where a signed-in user enters the code shown by a TV or console app and approves or denies its sign-in",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Device sign-in page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code shown by the device",
+                        "name": "user_code",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "post": {
+                "description": "Signs the device showing the user code in to the signed-in account, or refuses it",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Approve or deny a device sign-in",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Code shown by the device",
+                        "name": "user_code",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "approve or deny",
+                        "name": "action",
+                        "in": "formData",
                         "required": true
                     }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/domain.User"
-                        }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/invitations": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Invites a new user by email. The invitation token is mailed to the recipient and can be redeemed once on registration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Create invitation",
+                "parameters": [
+                    {
+                        "description": "Invitation data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.CreateInvitationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Invitation"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Fetch a list of movies with server-side pagination",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "List movies with pagination",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Movie title",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Movie genres",
+                        "name": "genres",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of movies per page",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Movie list",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Movie"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new movie",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "Create a movie",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.createMovieRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Movie created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Movie"
+                        }
+                    }
+                }
+            }
+        },
+        "/movies/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Retrieve a movie by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "Get a movie by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Movie ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Movie details",
+                        "schema": {
+                            "$ref": "#/definitions/database.Movie"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update an existing movie",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "Update a movie by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Movie ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.updateMovieRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Movie updated",
+                        "schema": {
+                            "$ref": "#/definitions/database.Movie"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a movie by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Movies"
+                ],
+                "summary": "Delete a movie by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Movie ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/oauth/device_authorization": {
+            "post": {
+                "description": "Issues a device code and a user code to a TV or console app, following RFC 8628. The app shows the user code and verification URI, then polls the token endpoint with the device code.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Start a device sign-in",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Identifier of the app",
+                        "name": "client_id",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Device authorization",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/token": {
+            "post": {
+                "description": "Exchanges an approved device code for an authentication token, following RFC 8628. Until the user answers, the response is an authorization_pending error; polling faster than the interval yields slow_down and lengthens the interval by five seconds.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Poll for a device token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "urn:ietf:params:oauth:grant-type:device_code",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Device code from the device authorization",
+                        "name": "device_code",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Identifier of the app",
+                        "name": "client_id",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Authentication token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Groups": {
+            "get": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "description": "Lists the permissions as SCIM groups, optionally narrowed by a SCIM filter",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "List SCIM groups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SCIM filter, e.g. displayName eq \\",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based index of the first result",
+                        "name": "startIndex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set to members to leave out group members",
+                        "name": "excludedAttributes",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "description": "Groups are the service's permissions, which provisioning clients cannot create or delete",
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Create or delete SCIM group",
+                "responses": {
+                    "501": {
+                        "description": "Not Implemented"
+                    }
+                }
+            }
+        },
+        "/scim/v2/Groups/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Get SCIM group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Permission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set to members to leave out group members",
+                        "name": "excludedAttributes",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/infrastructure_http.scimGroup"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "description": "Replaces the members of a group. The displayName of a group is its permission code and cannot be changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Replace SCIM group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Permission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Group resource",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/infrastructure_http.scimGroup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/infrastructure_http.scimGroup"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "description": "Applies SCIM PATCH operations to the members of a group",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Update SCIM group",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Permission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "PATCH operations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/scim.PatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/infrastructure_http.scimGroup"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/ResourceTypes": {
+            "get": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "description": "Lists the SCIM resource types served",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "SCIM resource types",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ListResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/ServiceProviderConfig": {
+            "get": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "description": "Describes the SCIM features supported by the service",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "SCIM service provider configuration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Users": {
+            "get": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "description": "Pages through users as SCIM resources, optionally narrowed by a SCIM filter on id, userName, emails, displayName, name.formatted, active or meta.created",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "List SCIM users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SCIM filter, e.g. userName eq \\",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based index of the first result",
+                        "name": "startIndex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page",
+                        "name": "count",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/scim.ListResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "description": "Creates a user on behalf of an identity provider. The account is activated straight away unless active is false, and no welcome email is sent. Without a password the user can only sign in by magic link or passkey.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Provision SCIM user",
+                "parameters": [
+                    {
+                        "description": "User resource",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/infrastructure_http.scimUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/infrastructure_http.scimUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Get SCIM user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/infrastructure_http.scimUser"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Replace SCIM user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User resource",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/infrastructure_http.scimUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/infrastructure_http.scimUser"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "description": "Deletes a user along with their tokens, devices and passkeys",
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Deprovision SCIM user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "SCIMBearer": []
+                    }
+                ],
+                "description": "Applies SCIM PATCH operations to a user. Attributes the service does not store, such as externalId, are accepted and ignored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SCIM"
+                ],
+                "summary": "Update SCIM user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "PATCH operations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/scim.PatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/infrastructure_http.scimUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions": {
+            "post": {
+                "description": "Checks the user's credentials and starts a server-side session held in an HttpOnly cookie. State-changing requests authenticated by the cookie must send the returned CSRF token, which is also set in the greenlight_csrf cookie, in the X-CSRF-Token header. Only available when cookie sessions are enabled.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Sign in with a session cookie",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.CreateAuthTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Signed-in user and CSRF token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Ends the session held in the session cookie and clears the session cookies. Requires the X-CSRF-Token header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Sign out of a cookie session",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/sessions/revoke": {
+            "get": {
+                "description": "Renders the page linked from the new sign-in email. Sessions are only revoked when the page's form is submitted, so that link scanners fetching the URL have no effect.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Sign out everywhere landing page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the new sign-in email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "post": {
+                "description": "Revokes every authentication token of the user the link was sent to and forgets their known devices",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Sign out everywhere",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the new sign-in email",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/tokens/authentication": {
+            "post": {
+                "description": "Creates an authentication token for a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Create authentication token",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.CreateAuthTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Authentication token",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Token"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/introspect": {
+            "post": {
+                "description": "Reports whether a token issued by the auth module is active, following RFC 7662. Callers authenticate with client credentials (HTTP Basic) or an API key (X-Api-Key header).",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Introspect token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token to introspect",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Hint about the type of the token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Introspection"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/magic-link": {
+            "post": {
+                "description": "Emails a short-lived, single-use login token to the user. The response is the same whether or not the email address is registered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Request magic link",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.CreateMagicLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/magic-link/exchange": {
+            "post": {
+                "description": "Exchanges a magic link token for an authentication token, activating the account if needed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Exchange magic link",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.ExchangeMagicLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Authentication token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/passkey": {
+            "post": {
+                "description": "Verifies the assertion made by the authenticator and exchanges it for an authentication token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Finish passkey sign-in",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.FinishPasskeyLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Authentication token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/passkey/challenge": {
+            "post": {
+                "description": "Returns the options to pass to navigator.credentials.get for signing in with one of the account's passkeys. The same options are returned whether or not the account exists or has passkeys, and signing in with them fails when it does not.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Begin passkey sign-in",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.BeginPasskeyLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webauthn.CredentialRequestOptions"
+                        }
+                    }
+                }
+            }
+        },
+        "/tokens/password-reset": {
+            "post": {
+                "description": "Emails a single-use password reset token to the user. The response is the same whether or not the email address is registered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Request password reset",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.CreatePasswordResetTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "post": {
+                "description": "Registers a new user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Register User",
+                "parameters": [
+                    {
+                        "description": "User registration data",
+                        "name": "name",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/activated": {
+            "get": {
+                "description": "Renders the page linked from the welcome email. Activation itself happens when the page's form is submitted, so that link scanners fetching the URL do not activate the account.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Activation landing page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token for user activation",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            },
+            "put": {
+                "description": "Activates a user account using a token that was previously sent when successfully register a new user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Activate User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token for user activation",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Activates a user account from the landing page form and renders the outcome",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Confirm activation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token for user activation",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/users/me/passkeys": {
+            "post": {
+                "description": "Verifies the credential created by the authenticator and registers it as a passkey of the signed-in account",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Finish passkey registration",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.FinishPasskeyRegistrationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Passkey"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/passkeys/challenge": {
+            "post": {
+                "description": "Returns the options to pass to navigator.credentials.create for registering a passkey on the signed-in account",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Begin passkey registration",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/webauthn.CredentialCreationOptions"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/password": {
+            "put": {
+                "description": "Replaces the password of the signed-in user. Rejected passwords come back with their strength score and improvement hints.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Change password",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/password": {
+            "put": {
+                "description": "Sets a new password using a password reset token. Rejected passwords come back with their strength score and improvement hints.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "database.Movie": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "runtime": {
+                    "type": "string",
+                    "example": "0"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.BeginPasskeyLoginRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.BulkEmail": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "description": "CompletedAt is when every user has been emailed, or nil until then.",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "filter": {
+                    "$ref": "#/definitions/domain.UserFilter"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "sent": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "template": {
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Total is the number of users matching the filter when the bulk email\nwas created. Users who register while it is being sent are emailed\ntoo, so Sent and Failed may add up to more.",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.BulkEmailFailure": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.ChangePasswordRequest": {
+            "type": "object",
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.CreateAuthTokenRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.CreateBulkEmailRequest": {
+            "type": "object",
+            "properties": {
+                "filter": {
+                    "description": "Filter selects the users to email. It defaults to the activated users\nwho are not suspended.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.UserFilter"
+                        }
+                    ]
+                },
+                "template": {
+                    "description": "Template is the name of one of the bulk email templates.",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.CreateInvitationRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "expiry": {
+                    "type": "string"
+                },
+                "locale": {
+                    "description": "Locale is the language of the invitation email, by default the one\nthe admin's Accept-Language header asks for.",
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "domain.CreateMagicLinkRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.CreatePasswordResetTokenRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.CreateUserRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "invitation_token": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ExchangeMagicLinkRequest": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.FinishPasskeyLoginRequest": {
+            "type": "object",
+            "properties": {
+                "credential": {
+                    "$ref": "#/definitions/webauthn.AssertionResponse"
+                }
+            }
+        },
+        "domain.FinishPasskeyRegistrationRequest": {
+            "type": "object",
+            "properties": {
+                "credential": {
+                    "$ref": "#/definitions/webauthn.AttestationResponse"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Introspection": {
+            "type": "object",
+            "properties": {
+                "act": {
+                    "$ref": "#/definitions/domain.IntrospectionActor"
+                },
+                "active": {
+                    "type": "boolean"
+                },
+                "exp": {
+                    "type": "integer"
+                },
+                "iat": {
+                    "type": "integer"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "sub": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.IntrospectionActor": {
+            "type": "object",
+            "properties": {
+                "sub": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Invitation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expiry": {
+                    "type": "string"
+                },
+                "invited_by": {
+                    "type": "integer"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "domain.OutboxEmail": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "locale": {
+                    "description": "Locale picks the translation of the template the email is sent in.",
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "redacted": {
+                    "description": "Redacted reports that Data was discarded, so the email cannot be\nreplayed; the user has to ask for a new token instead.",
+                    "type": "boolean"
+                },
+                "sent_at": {
+                    "description": "SentAt is when the email was sent, or nil if it has not been.",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "template": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Passkey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "transports": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "domain.ResetPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Token": {
+            "type": "object",
+            "properties": {
+                "expiry": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.User": {
+            "type": "object",
+            "properties": {
+                "activated": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "locale": {
+                    "description": "Locale is the language emails are sent to the user in.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "suspended": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.UserFilter": {
+            "type": "object",
+            "properties": {
+                "activated": {
+                    "type": "boolean"
+                },
+                "created_after": {
+                    "type": "string"
+                },
+                "created_before": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "suspended": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "infrastructure_http.scimEmail": {
+            "type": "object",
+            "properties": {
+                "primary": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "infrastructure_http.scimGroup": {
+            "type": "object",
+            "properties": {
+                "displayName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/infrastructure_http.scimMember"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/scim.Meta"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "infrastructure_http.scimMember": {
+            "type": "object",
+            "properties": {
+                "$ref": {
+                    "type": "string"
+                },
+                "display": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "infrastructure_http.scimName": {
+            "type": "object",
+            "properties": {
+                "familyName": {
+                    "type": "string"
+                },
+                "formatted": {
+                    "type": "string"
+                },
+                "givenName": {
+                    "type": "string"
+                }
+            }
+        },
+        "infrastructure_http.scimUser": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "displayName": {
+                    "type": "string"
+                },
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/infrastructure_http.scimEmail"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "meta": {
+                    "$ref": "#/definitions/scim.Meta"
+                },
+                "name": {
+                    "$ref": "#/definitions/infrastructure_http.scimName"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
+                },
+                "userName": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
-        "database.Movie": {
+        },
+        "main.createMovieRequest": {
             "type": "object",
             "properties": {
                 "genres": {
@@ -349,117 +2549,329 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
-                "id": {
+                "runtime": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "year": {
                     "type": "integer"
+                }
+            }
+        },
+        "main.updateMovieRequest": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
                 "runtime": {
-                    "type": "string",
-                    "example": "0"
+                    "type": "integer"
                 },
                 "title": {
                     "type": "string"
                 },
-                "version": {
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "scim.ListResponse": {
+            "type": "object",
+            "properties": {
+                "Resources": {},
+                "itemsPerPage": {
                     "type": "integer"
                 },
-                "year": {
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "startIndex": {
+                    "type": "integer"
+                },
+                "totalResults": {
                     "type": "integer"
                 }
             }
         },
-        "domain.CreateAuthTokenRequest": {
+        "scim.Meta": {
             "type": "object",
             "properties": {
-                "email": {
+                "created": {
                     "type": "string"
                 },
-                "password": {
+                "lastModified": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "resourceType": {
+                    "type": "string"
+                },
+                "version": {
                     "type": "string"
                 }
             }
         },
-        "domain.CreateUserRequest": {
+        "scim.PatchOperation": {
             "type": "object",
             "properties": {
-                "email": {
+                "op": {
                     "type": "string"
                 },
-                "name": {
+                "path": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string"
+                "value": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
                 }
             }
         },
-        "domain.Token": {
+        "scim.PatchRequest": {
             "type": "object",
             "properties": {
-                "expiry": {
+                "Operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scim.PatchOperation"
+                    }
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "webauthn.AssertionResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "string"
                 },
-                "token": {
+                "rawId": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "response": {
+                    "type": "object",
+                    "properties": {
+                        "authenticatorData": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        },
+                        "clientDataJSON": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        },
+                        "signature": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        },
+                        "userHandle": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                },
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "domain.User": {
+        "webauthn.AttestationResponse": {
             "type": "object",
             "properties": {
-                "activated": {
-                    "type": "boolean"
+                "id": {
+                    "type": "string"
                 },
-                "created_at": {
+                "rawId": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "response": {
+                    "type": "object",
+                    "properties": {
+                        "attestationObject": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        },
+                        "clientDataJSON": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        },
+                        "transports": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "webauthn.AuthenticatorSelection": {
+            "type": "object",
+            "properties": {
+                "residentKey": {
                     "type": "string"
                 },
-                "email": {
+                "userVerification": {
+                    "type": "string"
+                }
+            }
+        },
+        "webauthn.CredentialCreationOptions": {
+            "type": "object",
+            "properties": {
+                "attestation": {
                     "type": "string"
                 },
-                "id": {
+                "authenticatorSelection": {
+                    "$ref": "#/definitions/webauthn.AuthenticatorSelection"
+                },
+                "challenge": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "excludeCredentials": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webauthn.CredentialDescriptor"
+                    }
+                },
+                "pubKeyCredParams": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/webauthn.CredentialParameter"
+                    }
+                },
+                "rp": {
+                    "$ref": "#/definitions/webauthn.RelyingPartyEntity"
+                },
+                "timeout": {
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "user": {
+                    "$ref": "#/definitions/webauthn.User"
                 }
             }
         },
-        "main.createMovieRequest": {
+        "webauthn.CredentialDescriptor": {
             "type": "object",
             "properties": {
-                "genres": {
+                "id": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "transports": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "runtime": {
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "webauthn.CredentialParameter": {
+            "type": "object",
+            "properties": {
+                "alg": {
                     "type": "integer"
                 },
-                "title": {
+                "type": {
                     "type": "string"
-                },
-                "year": {
-                    "type": "integer"
                 }
             }
         },
-        "main.updateMovieRequest": {
+        "webauthn.CredentialRequestOptions": {
             "type": "object",
             "properties": {
-                "genres": {
+                "allowCredentials": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/webauthn.CredentialDescriptor"
                     }
                 },
-                "runtime": {
-                    "type": "integer"
+                "challenge": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
                 },
-                "title": {
+                "rpId": {
                     "type": "string"
                 },
-                "year": {
+                "timeout": {
                     "type": "integer"
+                },
+                "userVerification": {
+                    "type": "string"
+                }
+            }
+        },
+        "webauthn.RelyingPartyEntity": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "webauthn.User": {
+            "type": "object",
+            "properties": {
+                "displayName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "name": {
+                    "type": "string"
                 }
             }
         }
@@ -469,6 +2881,12 @@ const docTemplate = `{
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
+        },
+        "SCIMBearer": {
+            "description": "The SCIM provisioning token, as \"Bearer \u003ctoken\u003e\".",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
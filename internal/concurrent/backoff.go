@@ -0,0 +1,17 @@
+package concurrent
+
+import "time"
+
+// Backoff returns the time to wait after the given number of failed
+// attempts, doubling from min up to max.
+func Backoff(attempts int, min, max time.Duration) time.Duration {
+	backoff := min
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+
+	return backoff
+}
@@ -0,0 +1,17 @@
+//go:build auth
+// +build auth
+
+package concurrent
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, Backoff(1, 10*time.Second, time.Hour))
+	assert.Equal(t, 20*time.Second, Backoff(2, 10*time.Second, time.Hour))
+	assert.Equal(t, 80*time.Second, Backoff(4, 10*time.Second, time.Hour))
+	assert.Equal(t, time.Hour, Backoff(20, 10*time.Second, time.Hour))
+}
@@ -0,0 +1,367 @@
+package concurrent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	JobPending   = "pending"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+)
+
+const (
+	defaultQueueWorkers      = 2
+	defaultQueuePollInterval = time.Second
+	defaultQueueVisibility   = 5 * time.Minute
+	defaultQueueMaxAttempts  = 10
+	defaultQueueQueryTimeout = 3 * time.Second
+
+	jobMinBackoff = 10 * time.Second
+	jobMaxBackoff = time.Hour
+)
+
+// errJobReclaimed is returned when recording the outcome of a job that
+// another worker has claimed since.
+var errJobReclaimed = errors.New("job was claimed by another worker")
+
+// Job is a unit of work stored in the jobs table. Its payload is decoded
+// into the type its handler was registered with.
+type Job struct {
+	ID        int64
+	Kind      string
+	Payload   json.RawMessage
+	Attempts  int
+	CreatedAt time.Time
+
+	// claimedUntil is the run_at the job was claimed with. The outcome of
+	// the job is only recorded while it still holds, so that a worker whose
+	// job outlived its visibility timeout cannot overwrite the outcome of
+	// the worker that claimed it next.
+	claimedUntil time.Time
+}
+
+// DBTX is the part of *sql.DB and *sql.Tx that Enqueue uses, so that a job
+// can be enqueued in the same transaction as the change it follows from.
+type DBTX interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Enqueue stores a job of kind with payload encoded as JSON, to be run by
+// whichever instance claims it first.
+func Enqueue(ctx context.Context, db DBTX, kind string, payload any) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+        INSERT INTO jobs (kind, payload)
+        VALUES ($1, $2)
+        RETURNING id`
+
+	var id int64
+	err = db.QueryRowContext(ctx, query, kind, data).Scan(&id)
+	return id, err
+}
+
+type QueueConfig struct {
+	// Workers is the number of jobs run at once by this instance.
+	Workers int
+	// PollInterval is how long an idle worker waits before looking for
+	// jobs again.
+	PollInterval time.Duration
+	// Visibility is how long a claimed job is hidden from other workers.
+	// A job still running by then is cancelled, and one whose worker died
+	// is claimed again once it runs out.
+	Visibility time.Duration
+	// MaxAttempts is the number of times a failing job is run before it is
+	// marked dead.
+	MaxAttempts int
+}
+
+// Queue runs jobs from the jobs table on a fixed number of workers. Any
+// number of instances can share the table: each job is claimed by one worker
+// at a time, and retried with exponential backoff when its handler fails
+// until it runs out of attempts and is dead-lettered.
+//
+// Queue has the Start and Shutdown methods of a module of the monolith, so
+// that its workers stop with the rest of the process.
+type Queue struct {
+	db       *sql.DB
+	cfg      QueueConfig
+	logger   *slog.Logger
+	handlers map[string]func(ctx context.Context, job Job) error
+
+	// stop is closed when workers should stop claiming jobs, and
+	// cancelJobs cancels the jobs still running when the grace period of
+	// a shutdown runs out.
+	stop       chan struct{}
+	stopOnce   sync.Once
+	jobs       context.Context
+	cancelJobs context.CancelFunc
+	workers    sync.WaitGroup
+}
+
+func NewQueue(db *sql.DB, cfg QueueConfig, logger *slog.Logger) *Queue {
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultQueueWorkers
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultQueuePollInterval
+	}
+	if cfg.Visibility <= 0 {
+		cfg.Visibility = defaultQueueVisibility
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultQueueMaxAttempts
+	}
+
+	jobs, cancelJobs := context.WithCancel(context.Background())
+
+	return &Queue{
+		db:         db,
+		cfg:        cfg,
+		logger:     logger,
+		handlers:   make(map[string]func(ctx context.Context, job Job) error),
+		stop:       make(chan struct{}),
+		jobs:       jobs,
+		cancelJobs: cancelJobs,
+	}
+}
+
+// Register makes q run jobs of kind with handle, passing it their payload
+// decoded into a T. A worker only claims jobs of the kinds registered on its
+// own queue, so handlers must be registered before Start.
+func Register[T any](q *Queue, kind string, handle func(ctx context.Context, job Job, payload T) error) {
+	q.handlers[kind] = func(ctx context.Context, job Job) error {
+		var payload T
+
+		err := json.Unmarshal(job.Payload, &payload)
+		if err != nil {
+			return fmt.Errorf("decode payload: %w", err)
+		}
+
+		return handle(ctx, job, payload)
+	}
+}
+
+// Enqueue stores a job of kind with payload in the jobs table of q.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any) (int64, error) {
+	return Enqueue(ctx, q.db, kind, payload)
+}
+
+func (q *Queue) Start(wg *sync.WaitGroup) {
+	if len(q.handlers) == 0 {
+		return
+	}
+
+	q.logger.Info("Starting job queue", "workers", q.cfg.Workers)
+
+	for i := 0; i < q.cfg.Workers; i++ {
+		wg.Add(1)
+		q.workers.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer q.workers.Done()
+			q.work()
+		}()
+	}
+}
+
+// Shutdown stops the workers from claiming more jobs and waits for the jobs
+// they are running to finish. Jobs still running once ctx is done are
+// cancelled, and run again once their visibility timeout is over.
+func (q *Queue) Shutdown(ctx context.Context, cancel func()) {
+	defer cancel()
+
+	q.stopOnce.Do(func() { close(q.stop) })
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		q.logger.Warn("cancelling running jobs")
+		q.cancelJobs()
+		<-done
+	}
+
+	q.logger.Info("Stopped job queue")
+}
+
+func (q *Queue) work() {
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		job, err := q.claim()
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			q.logger.Error("failed to claim job", "error", err)
+		}
+
+		if job == nil {
+			select {
+			case <-q.stop:
+				return
+			case <-time.After(q.cfg.PollInterval):
+			}
+			continue
+		}
+
+		q.run(job)
+	}
+}
+
+// claim takes the job that has been due the longest, counting an attempt and
+// pushing its run_at past the visibility timeout so that no other worker
+// takes it meanwhile. It returns sql.ErrNoRows when no job is due.
+func (q *Queue) claim() (*Job, error) {
+	query := `
+        UPDATE jobs
+        SET attempts = attempts + 1, run_at = $1
+        WHERE id = (
+            SELECT id
+            FROM jobs
+            WHERE status = $2
+            AND run_at <= $3
+            AND kind = ANY($4)
+            ORDER BY run_at, id
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, kind, payload, attempts, created_at, run_at`
+
+	kinds := make([]string, 0, len(q.handlers))
+	for kind := range q.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	now := time.Now()
+	args := []interface{}{now.Add(q.cfg.Visibility), JobPending, now, pq.Array(kinds)}
+
+	ctx, cancel := context.WithTimeout(q.jobs, defaultQueueQueryTimeout)
+	defer cancel()
+
+	var job Job
+
+	err := q.db.QueryRowContext(ctx, query, args...).Scan(&job.ID, &job.Kind, &job.Payload, &job.Attempts, &job.CreatedAt, &job.claimedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (q *Queue) run(job *Job) {
+	// A job may not outlive its visibility timeout, or another worker would
+	// claim it while it is still running.
+	ctx, cancel := context.WithTimeout(q.jobs, q.cfg.Visibility)
+	defer cancel()
+
+	started := time.Now()
+	err := q.handle(ctx, job)
+
+	// The outcome is recorded even when the job was cancelled by a
+	// shutdown, so that it is retried rather than left claimed.
+	ctx, cancel = context.WithTimeout(context.WithoutCancel(q.jobs), defaultQueueQueryTimeout)
+	defer cancel()
+
+	if err == nil {
+		q.logger.Debug("job succeeded", "id", job.ID, "kind", job.Kind, "duration", time.Since(started))
+		err = q.finish(ctx, job)
+	} else if job.Attempts >= q.cfg.MaxAttempts {
+		q.logger.Error("job dead", "id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+		err = q.bury(ctx, job, err.Error())
+	} else {
+		runAt := time.Now().Add(Backoff(job.Attempts, jobMinBackoff, jobMaxBackoff))
+		q.logger.Warn("job failed", "id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "run_at", runAt, "error", err)
+		err = q.retry(ctx, job, err.Error(), runAt)
+	}
+
+	if errors.Is(err, errJobReclaimed) {
+		q.logger.Warn("discarded job outcome", "id", job.ID, "kind", job.Kind, "error", err)
+	} else if err != nil {
+		q.logger.Error("failed to record job outcome", "id", job.ID, "kind", job.Kind, "error", err)
+	}
+}
+
+// handle runs the handler of job, turning a panic into an error so that one
+// bad job cannot take the worker down.
+func (q *Queue) handle(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	handler, found := q.handlers[job.Kind]
+	if !found {
+		return fmt.Errorf("no handler for job kind %q", job.Kind)
+	}
+
+	return handler(ctx, *job)
+}
+
+func (q *Queue) finish(ctx context.Context, job *Job) error {
+	query := `
+        UPDATE jobs
+        SET status = $1, finished_at = $2, last_error = ''
+        WHERE id = $3 AND run_at = $4`
+
+	return q.update(ctx, query, JobSucceeded, time.Now(), job.ID, job.claimedUntil)
+}
+
+func (q *Queue) retry(ctx context.Context, job *Job, lastError string, runAt time.Time) error {
+	query := `
+        UPDATE jobs
+        SET run_at = $1, last_error = $2
+        WHERE id = $3 AND run_at = $4`
+
+	return q.update(ctx, query, runAt, lastError, job.ID, job.claimedUntil)
+}
+
+func (q *Queue) bury(ctx context.Context, job *Job, lastError string) error {
+	query := `
+        UPDATE jobs
+        SET status = $1, finished_at = $2, last_error = $3
+        WHERE id = $4 AND run_at = $5`
+
+	return q.update(ctx, query, JobDead, time.Now(), lastError, job.ID, job.claimedUntil)
+}
+
+// update records the outcome of a job, returning errJobReclaimed when the
+// job is no longer claimed by this worker.
+func (q *Queue) update(ctx context.Context, query string, args ...interface{}) error {
+	result, err := q.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errJobReclaimed
+	}
+
+	return nil
+}
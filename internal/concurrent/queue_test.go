@@ -0,0 +1,228 @@
+//go:build auth
+// +build auth
+
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+var jobColumns = []string{"id", "kind", "payload", "attempts", "created_at", "run_at"}
+
+// claimedUntil is the run_at of the jobs claimed in tests.
+var claimedUntil = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+type welcomePayload struct {
+	UserID int64 `json:"user_id"`
+}
+
+func newTestQueue(t *testing.T) (*Queue, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	queue := NewQueue(db, QueueConfig{Workers: 1, PollInterval: time.Hour, MaxAttempts: 3}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	return queue, mock
+}
+
+func expectClaim(mock sqlmock.Sqlmock, attempts int) {
+	mock.ExpectQuery("UPDATE jobs SET attempts = attempts \\+ 1(.+)FOR UPDATE SKIP LOCKED").
+		WithArgs(sqlmock.AnyArg(), JobPending, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(jobColumns).
+			AddRow(int64(7), "send_welcome", []byte(`{"user_id":42}`), attempts, time.Now(), claimedUntil))
+}
+
+func TestEnqueue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// Arrange
+	mock.ExpectQuery("INSERT INTO jobs").
+		WithArgs("send_welcome", []byte(`{"user_id":42}`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+
+	// Act
+	id, err := Enqueue(context.Background(), db, "send_welcome", welcomePayload{UserID: 42})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueue_Run(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		queue, mock := newTestQueue(t)
+
+		var received welcomePayload
+		Register(queue, "send_welcome", func(ctx context.Context, job Job, payload welcomePayload) error {
+			received = payload
+			return nil
+		})
+
+		expectClaim(mock, 1)
+		mock.ExpectExec("UPDATE jobs SET status = \\$1, finished_at = \\$2(.+)WHERE id = \\$3 AND run_at = \\$4").
+			WithArgs(JobSucceeded, sqlmock.AnyArg(), int64(7), claimedUntil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		job, err := queue.claim()
+		assert.NoError(t, err)
+		queue.run(job)
+
+		// Assert
+		assert.Equal(t, int64(42), received.UserID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - retried later", func(t *testing.T) {
+		// Arrange
+		queue, mock := newTestQueue(t)
+
+		Register(queue, "send_welcome", func(ctx context.Context, job Job, payload welcomePayload) error {
+			return errors.New("connection refused")
+		})
+
+		expectClaim(mock, 2)
+		mock.ExpectExec("UPDATE jobs SET run_at = \\$1, last_error = \\$2").
+			WithArgs(sqlmock.AnyArg(), "connection refused", int64(7), claimedUntil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		job, err := queue.claim()
+		assert.NoError(t, err)
+		queue.run(job)
+
+		// Assert
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - out of attempts", func(t *testing.T) {
+		// Arrange
+		queue, mock := newTestQueue(t)
+
+		Register(queue, "send_welcome", func(ctx context.Context, job Job, payload welcomePayload) error {
+			panic("nil map")
+		})
+
+		expectClaim(mock, 3)
+		mock.ExpectExec("UPDATE jobs SET status = \\$1, finished_at = \\$2, last_error = \\$3").
+			WithArgs(JobDead, sqlmock.AnyArg(), "panic: nil map", int64(7), claimedUntil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		job, err := queue.claim()
+		assert.NoError(t, err)
+		queue.run(job)
+
+		// Assert
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Reclaimed by another worker", func(t *testing.T) {
+		// Arrange
+		queue, mock := newTestQueue(t)
+
+		Register(queue, "send_welcome", func(ctx context.Context, job Job, payload welcomePayload) error {
+			return nil
+		})
+
+		expectClaim(mock, 1)
+		mock.ExpectExec("UPDATE jobs SET status = \\$1, finished_at = \\$2").
+			WithArgs(JobSucceeded, sqlmock.AnyArg(), int64(7), claimedUntil).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Act
+		job, err := queue.claim()
+		assert.NoError(t, err)
+		err = queue.finish(context.Background(), job)
+
+		// Assert
+		assert.ErrorIs(t, err, errJobReclaimed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestQueue_Shutdown(t *testing.T) {
+	t.Run("Waits for running jobs", func(t *testing.T) {
+		// Arrange
+		queue, mock := newTestQueue(t)
+
+		started := make(chan struct{})
+		release := make(chan struct{})
+		Register(queue, "send_welcome", func(ctx context.Context, job Job, payload welcomePayload) error {
+			close(started)
+			<-release
+			return nil
+		})
+
+		expectClaim(mock, 1)
+		mock.ExpectExec("UPDATE jobs SET status = \\$1").
+			WithArgs(JobSucceeded, sqlmock.AnyArg(), int64(7), claimedUntil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		var wg sync.WaitGroup
+		queue.Start(&wg)
+		<-started
+
+		// Act
+		stopped := make(chan struct{})
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			queue.Shutdown(ctx, cancel)
+			close(stopped)
+		}()
+
+		// Assert
+		select {
+		case <-stopped:
+			t.Fatal("Shutdown returned while a job was running")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		<-stopped
+		wg.Wait()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Cancels jobs after the grace period", func(t *testing.T) {
+		// Arrange
+		queue, mock := newTestQueue(t)
+
+		started := make(chan struct{})
+		Register(queue, "send_welcome", func(ctx context.Context, job Job, payload welcomePayload) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		expectClaim(mock, 1)
+		mock.ExpectExec("UPDATE jobs SET run_at = \\$1, last_error = \\$2").
+			WithArgs(sqlmock.AnyArg(), context.Canceled.Error(), int64(7), claimedUntil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		var wg sync.WaitGroup
+		queue.Start(&wg)
+		<-started
+
+		// Act
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		queue.Shutdown(ctx, cancel)
+
+		// Assert
+		wg.Wait()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
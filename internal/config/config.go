@@ -1,9 +1,13 @@
 package config
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"github.com/jessicatarra/greenlight/internal/password"
+	"os"
 	"strings"
+	"time"
 )
 
 var (
@@ -18,6 +22,7 @@ type Config struct {
 		MaxOpenConns int
 		MaxIdleConns int
 		MaxIdleTime  string
+		QueryTimeout time.Duration
 	}
 	Smtp struct {
 		Host     string
@@ -26,6 +31,28 @@ type Config struct {
 		Password string
 		From     string
 	}
+	Mail struct {
+		// Transport is how emails leave the application: smtp, maildir,
+		// memory or log.
+		Transport   string
+		MaildirPath string
+	}
+	Outbox struct {
+		PollInterval time.Duration
+		BatchSize    int
+		MaxAttempts  int
+	}
+	BulkEmail struct {
+		// Rate is the number of bulk emails sent a second.
+		Rate      float64
+		BatchSize int
+	}
+	Jobs struct {
+		Workers      int
+		PollInterval time.Duration
+		Visibility   time.Duration
+		MaxAttempts  int
+	}
 	Cors struct {
 		TrustedOrigins []string
 	}
@@ -38,6 +65,65 @@ type Config struct {
 		GrpcServerPort int
 		HttpPort       int
 	}
+	Signup struct {
+		InvitationOnly  bool
+		AntiEnumeration bool
+	}
+	Anonymous struct {
+		Permissions []string
+	}
+	Tokens struct {
+		AuthenticationTTL  time.Duration
+		ActivationTTL      time.Duration
+		MagicLinkTTL       time.Duration
+		InvitationTTL      time.Duration
+		ImpersonationTTL   time.Duration
+		PasswordResetTTL   time.Duration
+		RevokeSessionsTTL  time.Duration
+		WebAuthnTTL        time.Duration
+		DeviceCodeTTL      time.Duration
+		DevicePollInterval time.Duration
+		EmbedPermissions   bool
+	}
+	Introspection struct {
+		Clients map[string]string
+		APIKeys []string
+	}
+	Public struct {
+		BaseURL string
+	}
+	Password struct {
+		MinLength int
+		MaxLength int
+		MinScore  int
+		// Peppers maps key IDs to the secrets passwords are peppered with;
+		// CurrentPepper is the key ID new hashes use.
+		Peppers       map[string][]byte
+		CurrentPepper string
+		// HashConcurrency and HashQueueDepth bound the bcrypt work done at
+		// once and the work allowed to wait for it.
+		HashConcurrency int
+		HashQueueDepth  int
+	}
+	Devices struct {
+		Sensitivity    string
+		ClientIPHeader string
+	}
+	WebAuthn struct {
+		RPID             string
+		RPName           string
+		Origins          []string
+		UserVerification string
+	}
+	SCIM struct {
+		Tokens []string
+	}
+	Sessions struct {
+		Enabled      bool
+		TTL          time.Duration
+		CookieDomain string
+		SameSite     string
+	}
 }
 
 func Init() (cfg Config, err error) {
@@ -47,6 +133,7 @@ func Init() (cfg Config, err error) {
 	flag.IntVar(&cfg.DB.MaxOpenConns, "db-max-open-conns", 25, "PostgreSQL max open connections")
 	flag.IntVar(&cfg.DB.MaxIdleConns, "db-max-idle-conns", 25, "PostgreSQL max idle connections")
 	flag.StringVar(&cfg.DB.MaxIdleTime, "db-max-idle-time", "15m", "PostgreSQL max connection idle time")
+	flag.DurationVar(&cfg.DB.QueryTimeout, "db-query-timeout", 0, "Upper bound on a single PostgreSQL query (0 for the package default)")
 
 	flag.StringVar(&cfg.Smtp.Host, "smtp-host", "example.smtp.host", "SMTP host")
 	flag.IntVar(&cfg.Smtp.Port, "smtp-port", 25, "SMTP port")
@@ -54,6 +141,30 @@ func Init() (cfg Config, err error) {
 	flag.StringVar(&cfg.Smtp.Password, "smtp-password", "example_password", "SMTP password")
 	flag.StringVar(&cfg.Smtp.From, "smtp-sender", "Example Name <no-reply@example.org>", "SMTP sender")
 
+	cfg.Mail.Transport = "smtp"
+	flag.Func("mail-transport", "How emails are delivered (smtp|maildir|memory|log, default smtp); memory also serves a development inbox page", func(val string) error {
+		switch val {
+		case "smtp", "maildir", "memory", "log":
+			cfg.Mail.Transport = val
+			return nil
+		default:
+			return fmt.Errorf("invalid mail transport %q", val)
+		}
+	})
+	flag.StringVar(&cfg.Mail.MaildirPath, "mail-maildir-path", "tmp/maildir", "Maildir emails are written to by the maildir transport")
+
+	flag.DurationVar(&cfg.Outbox.PollInterval, "outbox-poll-interval", 2*time.Second, "Time between checks for outbound emails that are due")
+	flag.IntVar(&cfg.Outbox.BatchSize, "outbox-batch-size", 20, "Maximum number of outbound emails sent per check")
+	flag.IntVar(&cfg.Outbox.MaxAttempts, "outbox-max-attempts", 8, "Attempts at sending an email before it is marked dead")
+
+	flag.Float64Var(&cfg.BulkEmail.Rate, "bulk-email-rate", 10, "Maximum number of bulk emails sent a second")
+	flag.IntVar(&cfg.BulkEmail.BatchSize, "bulk-email-batch-size", 100, "Number of recipients of a bulk email loaded at a time")
+
+	flag.IntVar(&cfg.Jobs.Workers, "jobs-workers", 2, "Number of background jobs run at once")
+	flag.DurationVar(&cfg.Jobs.PollInterval, "jobs-poll-interval", time.Second, "Time between checks for background jobs that are due")
+	flag.DurationVar(&cfg.Jobs.Visibility, "jobs-visibility-timeout", 5*time.Minute, "Time a claimed background job may run before it is retried")
+	flag.IntVar(&cfg.Jobs.MaxAttempts, "jobs-max-attempts", 10, "Attempts at running a background job before it is marked dead")
+
 	flag.Func("cors-trusted-origins", "Trusted CORS origins (space separated)", func(val string) error {
 		cfg.Cors.TrustedOrigins = strings.Fields(val)
 		return nil
@@ -64,14 +175,135 @@ func Init() (cfg Config, err error) {
 	flag.StringVar(&cfg.Auth.HttpBaseURL, "base-url", "http://localhost:8082", "base URL for the application")
 	flag.StringVar(&cfg.Auth.GrpcBaseURL, "auth-grpc-client-base-url", "localhost:50051", "GRPC client")
 
+	flag.StringVar(&cfg.Public.BaseURL, "public-base-url", "http://localhost:8082", "Public URL of the auth module, used for links in emails")
+
 	flag.IntVar(&cfg.Auth.HttpPort, "auth-http-port", 8082, "port to listen on for HTTP requests for auth module")
 
 	flag.IntVar(&cfg.Auth.GrpcServerPort, "auth-grpc-port", 50051, "port to listen on for GRPC methods for auth module")
 
+	flag.BoolVar(&cfg.Signup.InvitationOnly, "signup-invitation-only", false, "Require an invitation token to register new users")
+	flag.BoolVar(&cfg.Signup.AntiEnumeration, "anti-enumeration", false, "Answer logins and registrations alike whether or not the email address is registered")
+
+	flag.DurationVar(&cfg.Tokens.AuthenticationTTL, "token-authentication-ttl", 24*time.Hour, "Lifetime of authentication tokens")
+	flag.DurationVar(&cfg.Tokens.ActivationTTL, "token-activation-ttl", 3*24*time.Hour, "Lifetime of account activation tokens")
+	flag.DurationVar(&cfg.Tokens.MagicLinkTTL, "token-magic-link-ttl", 15*time.Minute, "Lifetime of magic-link login tokens")
+	flag.DurationVar(&cfg.Tokens.InvitationTTL, "token-invitation-ttl", 7*24*time.Hour, "Default lifetime of invitation tokens")
+	flag.DurationVar(&cfg.Tokens.ImpersonationTTL, "token-impersonation-ttl", 15*time.Minute, "Lifetime of admin impersonation tokens")
+	flag.DurationVar(&cfg.Tokens.PasswordResetTTL, "token-password-reset-ttl", 45*time.Minute, "Lifetime of password reset tokens")
+	flag.DurationVar(&cfg.Tokens.RevokeSessionsTTL, "token-revoke-sessions-ttl", 7*24*time.Hour, "Lifetime of the sign-out-everywhere link in new sign-in emails")
+	flag.DurationVar(&cfg.Tokens.WebAuthnTTL, "token-webauthn-ttl", 5*time.Minute, "Time allowed to complete a passkey registration or sign-in")
+	flag.DurationVar(&cfg.Tokens.DeviceCodeTTL, "token-device-code-ttl", 10*time.Minute, "Time allowed to approve a device sign-in with its user code")
+	flag.DurationVar(&cfg.Tokens.DevicePollInterval, "token-device-poll-interval", 5*time.Second, "Minimum time devices must wait between polls for their token")
+	flag.BoolVar(&cfg.Tokens.EmbedPermissions, "jwt-embed-permissions", false, "Embed permission codes and activation state in authentication tokens")
+
+	flag.IntVar(&cfg.Password.MinLength, "password-min-length", 8, "Minimum password length in bytes")
+	flag.IntVar(&cfg.Password.MaxLength, "password-max-length", 72, "Maximum password length in bytes (at most 72)")
+	flag.IntVar(&cfg.Password.MinScore, "password-min-score", 0, "Minimum estimated password strength, from 0 (any) to 4 (very strong)")
+	flag.IntVar(&cfg.Password.HashConcurrency, "password-hash-concurrency", 0, "Maximum number of passwords hashed at once (defaults to the number of CPUs)")
+	flag.IntVar(&cfg.Password.HashQueueDepth, "password-hash-queue-depth", 64, "Maximum number of passwords waiting to be hashed before requests are turned away")
+	flag.Func("password-pepper-files", "Files holding secret peppers for password hashes (space separated key_id=path pairs, the first one is used for new hashes)", func(val string) error {
+		cfg.Password.Peppers = make(map[string][]byte)
+		cfg.Password.CurrentPepper = ""
+		for _, pair := range strings.Fields(val) {
+			id, path, found := strings.Cut(pair, "=")
+			if !found || id == "" || path == "" {
+				return fmt.Errorf("invalid password pepper file %q", pair)
+			}
+			pepper, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			pepper = bytes.TrimSpace(pepper)
+			err = password.ValidatePepper(id, pepper)
+			if err != nil {
+				return err
+			}
+			cfg.Password.Peppers[id] = pepper
+			if cfg.Password.CurrentPepper == "" {
+				cfg.Password.CurrentPepper = id
+			}
+		}
+		return nil
+	})
+
+	flag.Func("new-device-sensitivity", "How different a sign-in must look to trigger a new device email (off|low|medium|high, default off in development and medium elsewhere)", func(val string) error {
+		switch val {
+		case "off", "low", "medium", "high":
+			cfg.Devices.Sensitivity = val
+			return nil
+		default:
+			return fmt.Errorf("invalid new device sensitivity %q", val)
+		}
+	})
+	flag.StringVar(&cfg.Devices.ClientIPHeader, "client-ip-header", "", "Request header set by a trusted proxy with the client IP address, e.g. Fly-Client-IP")
+
+	flag.StringVar(&cfg.WebAuthn.RPID, "webauthn-rp-id", "", "WebAuthn relying party ID (defaults to the host of -public-base-url)")
+	flag.StringVar(&cfg.WebAuthn.RPName, "webauthn-rp-name", "Greenlight", "Name shown by authenticators when registering a passkey")
+	flag.Func("webauthn-origins", "Origins allowed to use passkeys (space separated, defaults to the origin of -public-base-url)", func(val string) error {
+		cfg.WebAuthn.Origins = strings.Fields(val)
+		return nil
+	})
+	flag.Func("webauthn-user-verification", "Whether passkeys must verify the user with a PIN or biometric (required|preferred|discouraged)", func(val string) error {
+		switch val {
+		case "required", "preferred", "discouraged":
+			cfg.WebAuthn.UserVerification = val
+			return nil
+		default:
+			return fmt.Errorf("invalid webauthn user verification %q", val)
+		}
+	})
+
+	flag.Func("anonymous-permissions", "Permissions granted to unauthenticated requests (space separated)", func(val string) error {
+		cfg.Anonymous.Permissions = strings.Fields(val)
+		return nil
+	})
+
+	flag.Func("introspection-clients", "Client credentials allowed to introspect tokens (space separated client_id:client_secret pairs)", func(val string) error {
+		cfg.Introspection.Clients = make(map[string]string)
+		for _, pair := range strings.Fields(val) {
+			id, secret, found := strings.Cut(pair, ":")
+			if !found || id == "" || secret == "" {
+				return fmt.Errorf("invalid introspection client %q", pair)
+			}
+			cfg.Introspection.Clients[id] = secret
+		}
+		return nil
+	})
+
+	flag.Func("introspection-api-keys", "API keys allowed to introspect tokens (space separated)", func(val string) error {
+		cfg.Introspection.APIKeys = strings.Fields(val)
+		return nil
+	})
+
+	flag.Func("scim-tokens", "Bearer tokens accepted from SCIM provisioning clients (space separated)", func(val string) error {
+		cfg.SCIM.Tokens = strings.Fields(val)
+		return nil
+	})
+
+	flag.BoolVar(&cfg.Sessions.Enabled, "session-cookies", false, "Let browsers sign in with an HttpOnly session cookie instead of a bearer token")
+	flag.DurationVar(&cfg.Sessions.TTL, "session-ttl", 24*time.Hour, "Lifetime of cookie sessions")
+	flag.StringVar(&cfg.Sessions.CookieDomain, "session-cookie-domain", "", "Domain attribute of the session cookies, to share them with sibling hosts")
+	flag.Func("session-same-site", "SameSite attribute of the session cookies (lax|strict, default lax)", func(val string) error {
+		switch val {
+		case "lax", "strict":
+			cfg.Sessions.SameSite = val
+			return nil
+		default:
+			return fmt.Errorf("invalid session same site %q", val)
+		}
+	})
+
 	displayVersion := flag.Bool("version", false, "Display version and exit")
 
 	flag.Parse()
 
+	if cfg.Devices.Sensitivity == "" {
+		cfg.Devices.Sensitivity = "medium"
+		if cfg.Env == "development" {
+			cfg.Devices.Sensitivity = "off"
+		}
+	}
+
 	if *displayVersion {
 		fmt.Printf("Version:\t%s\n", Version)
 	}
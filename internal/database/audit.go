@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// AuditEvent records an action of UserID on a resource, taken by ActorID
+// when an admin was impersonating them.
+type AuditEvent struct {
+	ID         int64     `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UserID     int64     `json:"user_id"`
+	ActorID    int64     `json:"actor_id,omitempty"`
+	Action     string    `json:"action"`
+	Resource   string    `json:"resource"`
+	ResourceID int64     `json:"resource_id"`
+}
+
+type AuditModel struct {
+	DB      *sql.DB
+	Timeout time.Duration
+}
+
+func (m AuditModel) Insert(ctx context.Context, event *AuditEvent) error {
+	query := `
+        INSERT INTO audit_events (user_id, actor_id, action, resource, resource_id)
+        VALUES ($1, NULLIF($2::bigint, 0), $3, $4, $5)
+        RETURNING id, created_at`
+
+	args := []interface{}{event.UserID, event.ActorID, event.Action, event.Resource, event.ResourceID}
+
+	ctx, cancel := context.WithTimeout(ctx, m.Timeout)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&event.ID, &event.CreatedAt)
+}
@@ -1,25 +1,13 @@
 package database
 
 import (
+	"github.com/jessicatarra/greenlight/internal/utils/filters"
 	"github.com/jessicatarra/greenlight/internal/validator"
-	"math"
-	"strings"
 )
 
-type Metadata struct {
-	CurrentPage  int `json:"current_page,omitempty"`
-	PageSize     int `json:"page_size,omitempty"`
-	FirstPage    int `json:"first_page,omitempty"`
-	LastPage     int `json:"last_page,omitempty"`
-	TotalRecords int `json:"total_records,omitempty"`
-}
+type Metadata = filters.Metadata
 
-type Filters struct {
-	Page         int
-	PageSize     int
-	Sort         string
-	SortSafelist []string
-}
+type Filters = filters.Filters
 
 func ValidateFilters(v *validator.Validator, f Filters) {
 	// Check that the page and page_size parameters contain sensible values.
@@ -32,41 +20,6 @@ func ValidateFilters(v *validator.Validator, f Filters) {
 	v.Check(validator.In(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
 }
 
-func (f Filters) sortColumn() string {
-	for _, safeValue := range f.SortSafelist {
-		if f.Sort == safeValue {
-			return strings.TrimPrefix(f.Sort, "-")
-		}
-	}
-
-	panic("unsafe sort parameter: " + f.Sort)
-}
-
-func (f Filters) sortDirection() string {
-	if strings.HasPrefix(f.Sort, "-") {
-		return "DESC"
-	}
-
-	return "ASC"
-}
-
-func (f Filters) limit() int {
-	return f.PageSize
-}
-
-func (f Filters) offset() int {
-	return (f.Page - 1) * f.PageSize
-}
 func calculateMetadata(totalRecords, page, pageSize int) Metadata {
-	if totalRecords == 0 {
-		return Metadata{}
-	}
-
-	return Metadata{
-		CurrentPage:  page,
-		PageSize:     pageSize,
-		FirstPage:    1,
-		LastPage:     int(math.Ceil(float64(totalRecords) / float64(pageSize))),
-		TotalRecords: totalRecords,
-	}
+	return filters.CalculateMetadata(totalRecords, page, pageSize)
 }
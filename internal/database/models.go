@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"errors"
+	"time"
 )
 
 var (
@@ -12,10 +13,18 @@ var (
 
 type Models struct {
 	Movies MovieModel
+	Audit  AuditModel
 }
 
-func NewModels(db *sql.DB) Models {
+// NewModels returns the models backed by db. Each query is given at most
+// timeout to run, or defaultTimeout when timeout is not positive.
+func NewModels(db *sql.DB, timeout time.Duration) Models {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
 	return Models{
-		Movies: MovieModel{DB: db},
+		Movies: MovieModel{DB: db, Timeout: timeout},
+		Audit:  AuditModel{DB: db, Timeout: timeout},
 	}
 }
@@ -12,4 +12,13 @@ type User struct {
 	HashedPassword string    `json:"-"`
 	Activated      bool      `json:"activated"`
 	Version        int       `json:"-"`
+	// Permissions holds the permission codes embedded in the authentication
+	// token, or nil when the token carries none.
+	Permissions []string `json:"-"`
+}
+
+var AnonymousUser = &User{}
+
+func (u *User) IsAnonymous() bool {
+	return u == AnonymousUser
 }
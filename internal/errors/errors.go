@@ -5,9 +5,12 @@ import (
 	"github.com/jessicatarra/greenlight/internal/response"
 	"github.com/jessicatarra/greenlight/internal/utils/validator"
 	"log/slog"
+	"math"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func ReportError(err error) {
@@ -54,6 +57,16 @@ func RateLimitExceeded(w http.ResponseWriter, r *http.Request) {
 	errorMessage(w, r, http.StatusTooManyRequests, message, nil)
 }
 
+// ServiceUnavailable tells the client the server is too busy to handle the
+// request and that it may retry after retryAfter.
+func ServiceUnavailable(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	headers := make(http.Header)
+	headers.Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+
+	message := "the server is too busy to process your request, please try again later"
+	errorMessage(w, r, http.StatusServiceUnavailable, message, headers)
+}
+
 func NotFound(w http.ResponseWriter, r *http.Request) {
 	message := "The requested resource could not be found"
 	errorMessage(w, r, http.StatusNotFound, message, nil)
@@ -82,6 +95,21 @@ func InvalidAuthenticationToken(w http.ResponseWriter, r *http.Request) {
 	errorMessage(w, r, http.StatusUnauthorized, "Invalid authentication token", headers)
 }
 
+func InvalidClientCredentials(w http.ResponseWriter, r *http.Request) {
+	headers := make(http.Header)
+	headers.Set("WWW-Authenticate", `Basic realm="introspection"`)
+
+	errorMessage(w, r, http.StatusUnauthorized, "Invalid or missing client credentials", headers)
+}
+
+func InvalidCSRFToken(w http.ResponseWriter, r *http.Request) {
+	errorMessage(w, r, http.StatusForbidden, "Invalid or missing CSRF token", nil)
+}
+
+func InvalidCredentials(w http.ResponseWriter, r *http.Request) {
+	errorMessage(w, r, http.StatusUnauthorized, "Invalid authentication credentials", nil)
+}
+
 func AuthenticationRequired(w http.ResponseWriter, r *http.Request) {
 	errorMessage(w, r, http.StatusUnauthorized, "You must be authenticated to access this resource", nil)
 }
@@ -91,6 +119,11 @@ func InactiveAccount(writer http.ResponseWriter, request *http.Request) {
 	errorMessage(writer, request, http.StatusForbidden, message, nil)
 }
 
+func AccountSuspended(writer http.ResponseWriter, request *http.Request) {
+	message := "your user account has been suspended"
+	errorMessage(writer, request, http.StatusForbidden, message, nil)
+}
+
 func NotPermitted(writer http.ResponseWriter, request *http.Request) {
 	message := "your user account doesn't have the necessary permissions to access this resource"
 	errorMessage(writer, request, http.StatusForbidden, message, nil)
@@ -105,6 +138,10 @@ func EditConflict(writer http.ResponseWriter, request *http.Request) {
 	errorMessage(writer, request, http.StatusConflict, message, nil)
 }
 
+func Conflict(w http.ResponseWriter, r *http.Request, err error) {
+	errorMessage(w, r, http.StatusConflict, err.Error(), nil)
+}
+
 func legacyErrorMessage(writer http.ResponseWriter, request *http.Request, status int, message interface{}) {
 	type envelope map[string]interface{}
 
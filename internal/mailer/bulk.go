@@ -0,0 +1,157 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+)
+
+// ErrUnavailable is returned by Bulk.Send when the mail server cannot be
+// reached, as opposed to a failure to send to one recipient.
+var ErrUnavailable = errors.New("mail server unavailable")
+
+// Bulk sends emails one after another over one connection to the mail
+// server, at most rate a second. It is not safe for concurrent use, and
+// must be closed once done with.
+type Bulk struct {
+	mailer   Mailer
+	interval time.Duration
+	next     time.Time
+	batch    Batch
+	// reused is set once a message has gone through batch, after which a
+	// failed send may be down to the server having dropped the connection.
+	reused bool
+}
+
+// Bulk returns a Bulk sending at most rate emails a second, or as fast as
+// the mail server takes them when rate is zero.
+func (m Mailer) Bulk(rate float64) *Bulk {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+
+	return &Bulk{mailer: m, interval: interval}
+}
+
+// BulkData returns the data a template sent in bulk is rendered with for the
+// user with userID, name and email.
+func BulkData(userID int64, name, email string) map[string]interface{} {
+	return map[string]interface{}{
+		"userID": userID,
+		"name":   name,
+		"email":  email,
+	}
+}
+
+// Send renders the bulk template templateFile in locale with data and emails
+// it to recipient, first waiting for its turn under the rate of b. Only the
+// templates listed by BulkTemplates can be sent. It returns the error of ctx
+// if ctx is done before then, and one wrapping ErrUnavailable if the mail
+// server cannot be reached.
+func (b *Bulk) Send(ctx context.Context, recipient, templateFile, locale string, data map[string]interface{}) error {
+	if path.Base(templateFile) != templateFile {
+		return fmt.Errorf("%w: %s", ErrTemplateNotFound, templateFile)
+	}
+
+	msg, err := b.mailer.Render(path.Join(bulkDir, templateFile), locale, data)
+	if err != nil {
+		return err
+	}
+
+	msg.To = recipient
+
+	err = b.wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = b.deliver(msg)
+	if err != nil && b.reused {
+		// Servers close idle connections and cap the messages sent over
+		// one, so the send is tried once more on a fresh connection.
+		b.Close()
+		err = b.deliver(msg)
+	}
+	if err != nil {
+		b.Close()
+	}
+
+	return err
+}
+
+// Close closes the connection to the mail server, if open.
+func (b *Bulk) Close() error {
+	if b.batch == nil {
+		return nil
+	}
+
+	err := b.batch.Close()
+	b.batch = nil
+
+	return err
+}
+
+func (b *Bulk) wait(ctx context.Context) error {
+	now := time.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+
+	timer := time.NewTimer(b.next.Sub(now))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	b.next = b.next.Add(b.interval)
+
+	return nil
+}
+
+func (b *Bulk) deliver(msg Message) error {
+	if b.batch == nil {
+		batch, err := b.open()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		b.batch = batch
+		b.reused = false
+	}
+
+	err := b.batch.Send(msg)
+	if err != nil {
+		return err
+	}
+
+	b.reused = true
+
+	return nil
+}
+
+func (b *Bulk) open() (Batch, error) {
+	if batcher, ok := b.mailer.transport.(Batcher); ok {
+		return batcher.Open()
+	}
+
+	return transportBatch{transport: b.mailer.transport}, nil
+}
+
+// transportBatch sends the messages of a batch one by one through a
+// transport with no connection to keep open.
+type transportBatch struct {
+	transport Transport
+}
+
+func (b transportBatch) Send(msg Message) error {
+	return b.transport.Send(msg)
+}
+
+func (b transportBatch) Close() error {
+	return nil
+}
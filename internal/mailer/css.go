@@ -0,0 +1,208 @@
+package mailer
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	styleBlockRX = regexp.MustCompile(`(?is)<style[^>]*>(.*?)</style>`)
+	cssCommentRX = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	startTagRX   = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9]*)((?:\s[^<>]*?)?)(\s*/?)>`)
+	attributeRX  = regexp.MustCompile(`([a-zA-Z-]+)\s*=\s*"([^"]*)"`)
+	styleAttrRX  = regexp.MustCompile(`(?i)\sstyle\s*=\s*"[^"]*"`)
+	selectorRX   = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9]*)?((?:[.#][a-zA-Z0-9_-]+)*)$`)
+	simpleRX     = regexp.MustCompile(`[.#][a-zA-Z0-9_-]+`)
+)
+
+// cssRule is a rule with a simple selector: an optional tag name followed by
+// any number of class and ID selectors, as in "p", ".note" or "a#activate".
+type cssRule struct {
+	tag          string
+	id           string
+	classes      []string
+	declarations string
+	specificity  int
+}
+
+// inlineCSS copies the declarations of the rules in the <style> blocks of
+// document to the style attribute of the elements they match, since many
+// email clients drop style sheets. Declarations already inline win over the
+// style sheet, and rules that cannot be inlined, such as those with
+// combinators, pseudo-classes or in media queries, are left in the style
+// sheet for the clients that do support it.
+func inlineCSS(document string) string {
+	var rules []cssRule
+	for _, block := range styleBlockRX.FindAllStringSubmatch(document, -1) {
+		rules = append(rules, parseCSS(block[1])...)
+	}
+
+	if len(rules) == 0 {
+		return document
+	}
+
+	// Later rules override earlier ones of the same specificity, as in the
+	// cascade.
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].specificity < rules[j].specificity
+	})
+
+	start := strings.Index(strings.ToLower(document), "<body")
+	if start < 0 {
+		start = 0
+	}
+
+	body := startTagRX.ReplaceAllStringFunc(document[start:], func(tag string) string {
+		parts := startTagRX.FindStringSubmatch(tag)
+		name, attrs, end := strings.ToLower(parts[1]), parts[2], parts[3]
+
+		var id, style string
+		var classes []string
+		hasStyle := false
+		for _, attr := range attributeRX.FindAllStringSubmatch(attrs, -1) {
+			switch strings.ToLower(attr[1]) {
+			case "id":
+				id = attr[2]
+			case "class":
+				classes = strings.Fields(attr[2])
+			case "style":
+				style = attr[2]
+				hasStyle = true
+			}
+		}
+
+		var declarations []string
+		for _, rule := range rules {
+			if rule.matches(name, id, classes) {
+				declarations = append(declarations, rule.declarations)
+			}
+		}
+
+		if len(declarations) == 0 {
+			return tag
+		}
+
+		if style != "" {
+			declarations = append(declarations, html.UnescapeString(style))
+		}
+
+		styleAttr := `style="` + html.EscapeString(strings.Join(declarations, " ")) + `"`
+
+		if hasStyle {
+			attrs = styleAttrRX.ReplaceAllLiteralString(attrs, " "+styleAttr)
+		} else {
+			attrs += " " + styleAttr
+		}
+
+		return "<" + parts[1] + attrs + end + ">"
+	})
+
+	return document[:start] + body
+}
+
+// parseCSS returns the rules of a style sheet that can be inlined, skipping
+// at-rules such as @media along with the rules nested in them.
+func parseCSS(css string) []cssRule {
+	css = cssCommentRX.ReplaceAllString(css, "")
+
+	var rules []cssRule
+
+	for css = strings.TrimSpace(css); css != ""; css = strings.TrimSpace(css) {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			break
+		}
+
+		prelude := strings.TrimSpace(css[:open])
+
+		// Find the brace closing the block, counting nested blocks.
+		depth, end := 0, -1
+		for i := open; i < len(css) && end < 0; i++ {
+			switch css[i] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+
+		block := strings.Join(strings.Fields(css[open+1:end]), " ")
+		css = css[end+1:]
+
+		if strings.HasPrefix(prelude, "@") || block == "" {
+			continue
+		}
+
+		if !strings.HasSuffix(block, ";") {
+			block += ";"
+		}
+
+		for _, selector := range strings.Split(prelude, ",") {
+			rule, ok := parseSelector(strings.TrimSpace(selector))
+			if !ok {
+				continue
+			}
+			rule.declarations = block
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules
+}
+
+func parseSelector(selector string) (cssRule, bool) {
+	parts := selectorRX.FindStringSubmatch(selector)
+	if parts == nil || selector == "" {
+		return cssRule{}, false
+	}
+
+	rule := cssRule{tag: strings.ToLower(parts[1])}
+	if rule.tag != "" {
+		rule.specificity = 1
+	}
+
+	for _, simple := range simpleRX.FindAllString(parts[2], -1) {
+		switch simple[0] {
+		case '#':
+			rule.id = simple[1:]
+			rule.specificity += 100
+		case '.':
+			rule.classes = append(rule.classes, simple[1:])
+			rule.specificity += 10
+		}
+	}
+
+	return rule, true
+}
+
+func (r cssRule) matches(tag, id string, classes []string) bool {
+	if r.tag != "" && r.tag != tag {
+		return false
+	}
+	if r.id != "" && r.id != id {
+		return false
+	}
+
+	for _, class := range r.classes {
+		found := false
+		for _, c := range classes {
+			if c == class {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,38 @@
+//go:build auth
+// +build auth
+
+package mailer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInlineCSS(t *testing.T) {
+	// Arrange
+	document := `<html><head><style>
+/* Shared */
+p { color: #333; margin: 0 }
+.note, #footer { font-size: 12px; }
+p.note { color: #999; }
+a:hover { color: red; }
+@media (max-width: 600px) { p { margin: 8px; } }
+</style></head>
+<body><p>Hello</p><p class="note lead">Fine print</p><div id="footer" style="color: blue">Bye</div><br/></body></html>`
+
+	// Act
+	actual := inlineCSS(document)
+
+	// Assert
+	assert.Contains(t, actual, `<p style="color: #333; margin: 0;">Hello</p>`)
+	assert.Contains(t, actual, `<p class="note lead" style="color: #333; margin: 0; font-size: 12px; color: #999;">Fine print</p>`)
+	assert.Contains(t, actual, `<div id="footer" style="font-size: 12px; color: blue">Bye</div>`)
+	assert.Contains(t, actual, `<br/>`)
+	assert.Contains(t, actual, `@media (max-width: 600px)`)
+}
+
+func TestInlineCSS_NoStyleSheet(t *testing.T) {
+	document := `<body><p>Hello</p></body>`
+
+	assert.Equal(t, document, inlineCSS(document))
+}
@@ -0,0 +1,191 @@
+package mailer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jessicatarra/greenlight/assets"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLocale is the locale of the templates at the root of the emails
+// directory, which every other locale falls back to.
+const DefaultLocale = "en"
+
+// NormalizeLocale returns tag in the form used for template directories: a
+// lower-case language, optionally followed by a hyphen and an upper-case
+// region, as in "es" or "pt-BR".
+func NormalizeLocale(tag string) string {
+	language, region, _ := strings.Cut(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"), "-")
+	language = strings.ToLower(language)
+	if region == "" {
+		return language
+	}
+
+	return language + "-" + strings.ToUpper(region)
+}
+
+// localeChain returns the locales to look for a template in, most specific
+// first: "pt-BR" falls back to "pt" and then to the default locale.
+func localeChain(locale string) []string {
+	locale = NormalizeLocale(locale)
+
+	var chain []string
+	if locale != "" && locale != DefaultLocale {
+		chain = append(chain, locale)
+		if language, _, found := strings.Cut(locale, "-"); found && language != DefaultLocale {
+			chain = append(chain, language)
+		}
+	}
+
+	return append(chain, DefaultLocale)
+}
+
+var localeRX = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+
+// SupportedLocale reports whether emails have been translated to locale.
+func SupportedLocale(locale string) bool {
+	if locale == DefaultLocale {
+		return true
+	}
+	if !localeRX.MatchString(locale) {
+		return false
+	}
+
+	info, err := fs.Stat(assets.EmbeddedFiles, path.Join("emails", locale))
+	return err == nil && info.IsDir()
+}
+
+// MatchLocale picks the supported locale that best matches an
+// Accept-Language header, or DefaultLocale when none does.
+func MatchLocale(acceptLanguage string) string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	var preferences []preference
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			var err error
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil || quality <= 0 {
+				continue
+			}
+		}
+
+		preferences = append(preferences, preference{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+
+	for _, p := range preferences {
+		// The default locale ends every chain, but should only win when it
+		// was asked for, not as the fallback of a less preferred tag.
+		for _, locale := range localeChain(p.tag) {
+			if locale != DefaultLocale && SupportedLocale(locale) {
+				return locale
+			}
+		}
+
+		if language, _, _ := strings.Cut(NormalizeLocale(p.tag), "-"); language == DefaultLocale {
+			return DefaultLocale
+		}
+	}
+
+	return DefaultLocale
+}
+
+// catalog holds the translations of the phrases shared by email templates,
+// read from messages.json next to the templates of each locale.
+type catalog struct {
+	messages []map[string]string
+}
+
+func loadCatalog(fsys fs.FS, chain []string) (*catalog, error) {
+	c := &catalog{}
+
+	for _, locale := range chain {
+		file := path.Join(localeDir(locale), "messages.json")
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		var messages map[string]string
+		err = json.Unmarshal(data, &messages)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		c.messages = append(c.messages, messages)
+	}
+
+	return c, nil
+}
+
+// translate returns the translation of key in the most specific locale that
+// has one, formatted with args. Keys with no translation at all are returned
+// as they are, so that a missing one shows up in the email.
+func (c *catalog) translate(key string, args ...interface{}) string {
+	for _, messages := range c.messages {
+		if message, found := messages[key]; found {
+			if len(args) == 0 {
+				return message
+			}
+			return fmt.Sprintf(message, args...)
+		}
+	}
+
+	return key
+}
+
+// date formats a time, or a time encoded as an RFC 3339 string as it comes
+// back from the outbox, with the date layout of the locale. Anything else is
+// returned unchanged.
+func (c *catalog) date(value interface{}) string {
+	var t time.Time
+
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return v
+		}
+		t = parsed
+	default:
+		return fmt.Sprint(value)
+	}
+
+	return t.Format(c.translate("dateLayout"))
+}
+
+func localeDir(locale string) string {
+	if locale == DefaultLocale {
+		return "emails"
+	}
+
+	return path.Join("emails", locale)
+}
@@ -0,0 +1,108 @@
+//go:build auth
+// +build auth
+
+package mailer
+
+import (
+	"github.com/jessicatarra/greenlight/assets"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	assert.Equal(t, "es", NormalizeLocale("ES"))
+	assert.Equal(t, "pt-BR", NormalizeLocale("pt_br"))
+	assert.Equal(t, "en-GB", NormalizeLocale(" en-gb "))
+}
+
+func TestMatchLocale(t *testing.T) {
+	for header, expected := range map[string]string{
+		"":                               DefaultLocale,
+		"es":                             "es",
+		"es-AR,es;q=0.9":                 "es",
+		"fr-CH, fr;q=0.9, es;q=0.8":      "es",
+		"en-US,en;q=0.9,es;q=0.8":        DefaultLocale,
+		"es;q=0.2, en;q=0.8":             DefaultLocale,
+		"es;q=0, de":                     DefaultLocale,
+		"*":                              DefaultLocale,
+		"../../etc, es;q=0.5":            "es",
+		"de-DE;q=0.9, es-MX;q=malformed": DefaultLocale,
+	} {
+		assert.Equal(t, expected, MatchLocale(header), header)
+	}
+}
+
+func TestMailer_SendLocalized(t *testing.T) {
+	expiry := time.Date(2026, time.March, 5, 14, 30, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		locale  string
+		subject string
+		body    string
+	}{
+		{locale: "es", subject: "¡Te damos la bienvenida a Greenlight!", body: "caduca el 05/03/2026 14:30:00 UTC"},
+		{locale: "es-MX", subject: "¡Te damos la bienvenida a Greenlight!", body: "El equipo de Greenlight"},
+		{locale: "fr", subject: "Welcome to Greenlight!", body: "expire on Thu, 05 Mar 2026 14:30:00 UTC"},
+		{locale: "", subject: "Welcome to Greenlight!", body: "The Greenlight Team"},
+	} {
+		t.Run(tc.locale, func(t *testing.T) {
+			// Arrange
+			transport := NewMemoryTransport(1)
+			m, err := New(transport, "no-reply@example.org", "http://localhost:8082")
+			assert.NoError(t, err)
+
+			// Act
+			// The expiry comes back from the outbox as an RFC 3339 string.
+			err = m.Send("john@example.com", "user_welcome.gohtml", tc.locale, map[string]interface{}{
+				"activationToken": "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU",
+				"userID":          1,
+				"expiry":          expiry.Format(time.RFC3339),
+			})
+
+			// Assert
+			assert.NoError(t, err)
+			msg := transport.Messages()[0]
+			assert.Equal(t, tc.subject, msg.Subject)
+			assert.Contains(t, msg.PlainBody, tc.body)
+			assert.Contains(t, msg.HTMLBody, tc.body)
+		})
+	}
+}
+
+// Every template in every locale must render without leaving a translation
+// key untranslated.
+func TestTemplatesTranslated(t *testing.T) {
+	locales := []string{DefaultLocale}
+	entries, err := fs.ReadDir(assets.EmbeddedFiles, "emails")
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		if entry.IsDir() && SupportedLocale(entry.Name()) {
+			locales = append(locales, entry.Name())
+		}
+	}
+
+	keys := []string{"greeting", "greetingName", "thanks", "team", "dateLayout"}
+
+	for _, locale := range locales {
+		templates, err := fs.Glob(assets.EmbeddedFiles, path.Join(localeDir(locale), "*.gohtml"))
+		assert.NoError(t, err)
+		bulkTemplates, err := fs.Glob(assets.EmbeddedFiles, path.Join(localeDir(locale), bulkDir, "*.gohtml"))
+		assert.NoError(t, err)
+
+		for _, file := range append(templates, bulkTemplates...) {
+			m, err := New(NewMemoryTransport(1), "no-reply@example.org", "http://localhost:8082")
+			assert.NoError(t, err)
+
+			msg, err := m.Preview(strings.TrimPrefix(file, localeDir(locale)+"/"), locale)
+			assert.NoError(t, err, file)
+
+			for _, key := range keys {
+				assert.False(t, strings.Contains(msg.PlainBody, key), "%s: untranslated %q", file, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package mailer
+
+import "log/slog"
+
+// LogTransport logs messages instead of sending them.
+type LogTransport struct {
+	logger *slog.Logger
+}
+
+func NewLogTransport(logger *slog.Logger) *LogTransport {
+	return &LogTransport{logger: logger}
+}
+
+func (t *LogTransport) Send(msg Message) error {
+	t.logger.Info("email", "to", msg.To, "from", msg.From, "subject", msg.Subject, "body", msg.PlainBody)
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// MaildirTransport writes every message as a file to the new folder of a
+// maildir, where any mail client that reads maildirs can open it.
+type MaildirTransport struct {
+	dir string
+	seq atomic.Uint64
+}
+
+func NewMaildirTransport(dir string) (*MaildirTransport, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		err := os.MkdirAll(filepath.Join(dir, sub), 0o755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &MaildirTransport{dir: dir}, nil
+}
+
+func (t *MaildirTransport) Send(msg Message) error {
+	// Messages are written to tmp and then moved to new, so that readers
+	// never see one half written.
+	name := fmt.Sprintf("%d.%d_%d.greenlight.eml", time.Now().Unix(), os.Getpid(), t.seq.Add(1))
+	tmp := filepath.Join(t.dir, "tmp", name)
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	_, err = newMailMessage(msg).WriteTo(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(t.dir, "new", name))
+}
@@ -2,62 +2,291 @@ package mailer
 
 import (
 	"bytes"
-	"github.com/go-mail/mail/v2"
+	"errors"
+	"fmt"
 	"github.com/jessicatarra/greenlight/assets"
 	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
 	"time"
 )
 
+// ErrTemplateNotFound is returned when rendering a template that does not
+// exist.
+var ErrTemplateNotFound = errors.New("email template not found")
+
+// The blocks shared by every email template, such as the style sheet.
+const partials = "emails/partials/*.gohtml"
+
+// The directory, in that of each locale, of the templates that may be sent in
+// bulk. These only receive the data of BulkData.
+const bulkDir = "bulk"
+
+// Every email template must define these blocks.
+var templateBlocks = []string{"subject", "plainBody", "htmlBody"}
+
+// Message is a rendered email, ready to be handed to a Transport.
+type Message struct {
+	To        string
+	From      string
+	Subject   string
+	PlainBody string
+	HTMLBody  string
+	Date      time.Time
+}
+
 type Mailer struct {
-	dialer *mail.Dialer
-	sender string
+	transport Transport
+	// templates holds every email template, parsed with the helpers of its
+	// locale and keyed by its path in the embedded files.
+	templates map[string]*template.Template
+	sender    string
+	baseURL   string
 }
 
-func New(host string, port int, username, password, sender string) Mailer {
-	dialer := mail.NewDialer(host, port, username, password)
-	dialer.Timeout = 5 * time.Second
+// New parses and checks every email template in every locale, so that a
+// broken template stops the process from starting rather than failing the
+// first email sent with it.
+func New(transport Transport, sender, baseURL string) (Mailer, error) {
+	templates, err := parseTemplates(assets.EmbeddedFiles)
+	if err != nil {
+		return Mailer{}, err
+	}
 
 	return Mailer{
-		dialer: dialer,
-		sender: sender,
-	}
+		transport: transport,
+		templates: templates,
+		sender:    sender,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+	}, nil
 }
 
-func (m Mailer) Send(recipient, templateFile string, data interface{}) error {
-	tmpl, err := template.New("email").ParseFS(assets.EmbeddedFiles, "emails/"+templateFile)
+// Send renders templateFile in locale with data and emails it to recipient.
+// The template is looked up in the directory of the locale, then of its
+// language and finally of DefaultLocale, so that untranslated emails still go
+// out in English. Every template also receives the public base URL as
+// "baseURL" for building links, and the t and date functions to translate
+// shared phrases and format dates in the locale of the template.
+func (m Mailer) Send(recipient, templateFile, locale string, data map[string]interface{}) error {
+	msg, err := m.Render(templateFile, locale, data)
 	if err != nil {
 		return err
 	}
 
+	msg.To = recipient
+
+	return m.transport.Send(msg)
+}
+
+// Render renders templateFile in locale with data, without sending it. The
+// CSS of the HTML part is inlined.
+func (m Mailer) Render(templateFile, locale string, data map[string]interface{}) (Message, error) {
+	templateData := map[string]interface{}{"baseURL": m.baseURL}
+	for key, value := range data {
+		templateData[key] = value
+	}
+
+	tmpl, err := m.lookup(templateFile, locale)
+	if err != nil {
+		return Message{}, err
+	}
+
 	subject := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(subject, "subject", data)
+	err = tmpl.ExecuteTemplate(subject, "subject", templateData)
 	if err != nil {
-		return err
+		return Message{}, err
 	}
 
 	plainBody := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(plainBody, "plainBody", data)
+	err = tmpl.ExecuteTemplate(plainBody, "plainBody", templateData)
 	if err != nil {
-		return err
+		return Message{}, err
 	}
 
 	htmlBody := new(bytes.Buffer)
-	err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", data)
+	err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", templateData)
 	if err != nil {
-		return err
+		return Message{}, err
 	}
 
-	msg := mail.NewMessage()
-	msg.SetHeader("To", recipient)
-	msg.SetHeader("From", m.sender)
-	msg.SetHeader("Subject", subject.String())
-	msg.SetBody("text/plain", plainBody.String())
-	msg.AddAlternative("text/html", htmlBody.String())
+	return Message{
+		From:      m.sender,
+		Subject:   subject.String(),
+		PlainBody: plainBody.String(),
+		HTMLBody:  inlineCSS(htmlBody.String()),
+		Date:      time.Now(),
+	}, nil
+}
 
-	err = m.dialer.DialAndSend(msg)
+// Preview renders templateFile in locale with made-up data, for checking how
+// an email looks without going through the flow that sends it.
+func (m Mailer) Preview(templateFile, locale string) (Message, error) {
+	now := time.Now()
+
+	msg, err := m.Render(templateFile, locale, map[string]interface{}{
+		"userID":              1,
+		"name":                "Jane Doe",
+		"email":               "jane@example.com",
+		"activationToken":     previewToken,
+		"invitationToken":     previewToken,
+		"magicLinkToken":      previewToken,
+		"passwordResetToken":  previewToken,
+		"revokeSessionsToken": previewToken,
+		"expiry":              now.Add(72 * time.Hour),
+		"expiryMinutes":       15,
+		"signedInAt":          now,
+		"userAgent":           "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0",
+		"ipPrefix":            "203.0.113.0/24",
+	})
 	if err != nil {
-		return err
+		return Message{}, err
+	}
+
+	msg.To = "jane@example.com"
+
+	return msg, nil
+}
+
+const previewToken = "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU"
+
+// Templates returns the names of the email templates, sorted.
+func (m Mailer) Templates() []string {
+	return m.templatesIn(localeDir(DefaultLocale))
+}
+
+// BulkTemplates returns the names of the email templates that may be sent in
+// bulk, sorted.
+func (m Mailer) BulkTemplates() []string {
+	return m.templatesIn(path.Join(localeDir(DefaultLocale), bulkDir))
+}
+
+func (m Mailer) templatesIn(dir string) []string {
+	var names []string
+	for file := range m.templates {
+		if path.Dir(file) == dir {
+			names = append(names, path.Base(file))
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Locales returns the locales emails have been translated to, sorted.
+func (m Mailer) Locales() []string {
+	seen := make(map[string]bool)
+	for file := range m.templates {
+		seen[templateLocale(file)] = true
+	}
+
+	var locales []string
+	for locale := range seen {
+		locales = append(locales, locale)
+	}
+
+	sort.Strings(locales)
+
+	return locales
+}
+
+// Inbox returns the transport of m if it keeps messages in memory, or nil.
+func (m Mailer) Inbox() *MemoryTransport {
+	inbox, _ := m.transport.(*MemoryTransport)
+	return inbox
+}
+
+func (m Mailer) lookup(templateFile, locale string) (*template.Template, error) {
+	for _, l := range localeChain(locale) {
+		if tmpl, found := m.templates[path.Join(localeDir(l), templateFile)]; found {
+			return tmpl, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, templateFile)
+}
+
+// templateLocale returns the locale of the template at file.
+func templateLocale(file string) string {
+	dir, _, found := strings.Cut(strings.TrimPrefix(file, localeDir(DefaultLocale)+"/"), "/")
+	if found && localeRX.MatchString(dir) {
+		return dir
+	}
+
+	return DefaultLocale
+}
+
+// parseTemplates parses the templates of every locale in fsys with the
+// helpers of that locale, checking that each one defines all the blocks an
+// email needs. A template using data it is not given fails to render rather
+// than printing "<no value>", so the bulk templates are also rendered with
+// the data of BulkData, to catch any that needs more.
+func parseTemplates(fsys fs.FS) (map[string]*template.Template, error) {
+	locales := []string{DefaultLocale}
+
+	entries, err := fs.ReadDir(fsys, localeDir(DefaultLocale))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && localeRX.MatchString(entry.Name()) {
+			locales = append(locales, entry.Name())
+		}
+	}
+
+	templates := make(map[string]*template.Template)
+
+	for _, locale := range locales {
+		c, err := loadCatalog(fsys, localeChain(locale))
+		if err != nil {
+			return nil, err
+		}
+
+		funcs := template.FuncMap{
+			"t":    c.translate,
+			"date": c.date,
+		}
+
+		files, err := fs.Glob(fsys, path.Join(localeDir(locale), "*.gohtml"))
+		if err != nil {
+			return nil, err
+		}
+
+		bulkFiles, err := fs.Glob(fsys, path.Join(localeDir(locale), bulkDir, "*.gohtml"))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range append(files, bulkFiles...) {
+			tmpl, err := template.New("email").Option("missingkey=error").Funcs(funcs).ParseFS(fsys, partials, file)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, block := range templateBlocks {
+				if tmpl.Lookup(block) == nil {
+					return nil, fmt.Errorf("%s: missing %q block", file, block)
+				}
+			}
+
+			if path.Base(path.Dir(file)) == bulkDir {
+				data := BulkData(1, "Jane Doe", "jane@example.com")
+				data["baseURL"] = ""
+
+				for _, block := range templateBlocks {
+					err = tmpl.ExecuteTemplate(io.Discard, block, data)
+					if err != nil {
+						return nil, fmt.Errorf("%s: %w", file, err)
+					}
+				}
+			}
+
+			templates[file] = tmpl
+		}
 	}
 
-	return nil
+	return templates, nil
 }
@@ -0,0 +1,316 @@
+//go:build auth
+// +build auth
+
+package mailer
+
+import (
+	"context"
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/config"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMailer_Send(t *testing.T) {
+	// Arrange
+	transport := NewMemoryTransport(10)
+	m, err := New(transport, "Greenlight <no-reply@example.org>", "http://localhost:8082/")
+	assert.NoError(t, err)
+
+	// Act
+	err = m.Send("john@example.com", "user_magic_link.gohtml", "", map[string]interface{}{
+		"magicLinkToken": "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU",
+		"expiryMinutes":  15,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	messages := transport.Messages()
+	assert.Len(t, messages, 1)
+	assert.Equal(t, "john@example.com", messages[0].To)
+	assert.Equal(t, "Greenlight <no-reply@example.org>", messages[0].From)
+	assert.Equal(t, "Your Greenlight login link", messages[0].Subject)
+	assert.Contains(t, messages[0].PlainBody, "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU")
+	assert.Contains(t, messages[0].HTMLBody, `<p style="margin: 0 0 16px;">`)
+	assert.False(t, messages[0].Date.IsZero())
+}
+
+func TestMailer_Preview(t *testing.T) {
+	// Arrange
+	m, err := New(NewMemoryTransport(1), "no-reply@example.org", "http://localhost:8082")
+	assert.NoError(t, err)
+
+	for _, locale := range m.Locales() {
+		for _, template := range m.Templates() {
+			// Act
+			msg, err := m.Preview(template, locale)
+
+			// Assert
+			assert.NoError(t, err, template)
+			assert.NotEmpty(t, msg.Subject, template)
+			assert.NotContains(t, msg.PlainBody, "<no value>", template)
+			assert.NotContains(t, msg.HTMLBody, "<no value>", template)
+		}
+	}
+
+	_, err = m.Preview("missing.gohtml", DefaultLocale)
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestParseTemplates_MissingBlock(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"emails/partials/styles.gohtml": {Data: []byte(`{{define "styles"}}{{end}}`)},
+		"emails/user_welcome.gohtml":    {Data: []byte(`{{define "subject"}}Welcome{{end}}{{define "plainBody"}}Hi{{end}}`)},
+	}
+
+	// Act
+	_, err := parseTemplates(fsys)
+
+	// Assert
+	assert.EqualError(t, err, `emails/user_welcome.gohtml: missing "htmlBody" block`)
+}
+
+func TestParseTemplates_BulkTemplateMissingData(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"emails/partials/styles.gohtml": {Data: []byte(`{{define "styles"}}{{end}}`)},
+		"emails/bulk/reset.gohtml":      {Data: []byte(`{{define "subject"}}Reset{{end}}{{define "plainBody"}}{{.passwordResetToken}}{{end}}{{define "htmlBody"}}{{.name}}{{end}}`)},
+	}
+
+	// Act
+	_, err := parseTemplates(fsys)
+
+	// Assert
+	assert.ErrorContains(t, err, "emails/bulk/reset.gohtml")
+	assert.ErrorContains(t, err, `map has no entry for key "passwordResetToken"`)
+}
+
+func TestMailer_BulkTemplates(t *testing.T) {
+	// Arrange
+	m, err := New(NewMemoryTransport(1), "no-reply@example.org", "http://localhost:8082")
+	assert.NoError(t, err)
+
+	// Act
+	templates := m.BulkTemplates()
+
+	// Assert
+	assert.Contains(t, templates, "release_announcement.gohtml")
+	assert.NotContains(t, templates, "token_password_reset.gohtml")
+	assert.NotContains(t, m.Templates(), "release_announcement.gohtml")
+	assert.NotContains(t, m.Locales(), bulkDir)
+}
+
+func TestMailer_Render_MissingData(t *testing.T) {
+	// Arrange
+	m, err := New(NewMemoryTransport(1), "no-reply@example.org", "http://localhost:8082")
+	assert.NoError(t, err)
+
+	// Act
+	_, err = m.Render("token_password_reset.gohtml", DefaultLocale, map[string]interface{}{"expiryMinutes": 15})
+
+	// Assert
+	assert.ErrorContains(t, err, `map has no entry for key "passwordResetToken"`)
+}
+
+func TestMemoryTransport(t *testing.T) {
+	// Arrange
+	transport := NewMemoryTransport(2)
+
+	// Act
+	for _, subject := range []string{"first", "second", "third"} {
+		assert.NoError(t, transport.Send(Message{Subject: subject}))
+	}
+
+	// Assert
+	messages := transport.Messages()
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "third", messages[0].Subject)
+	assert.Equal(t, "second", messages[1].Subject)
+}
+
+func TestMaildirTransport(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	transport, err := NewMaildirTransport(dir)
+	assert.NoError(t, err)
+
+	// Act
+	err = transport.Send(Message{To: "john@example.com", From: "no-reply@example.org", Subject: "Welcome", PlainBody: "Hi John", HTMLBody: "<p>Hi John</p>"})
+
+	// Assert
+	assert.NoError(t, err)
+	files, err := os.ReadDir(filepath.Join(dir, "new"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	contents, err := os.ReadFile(filepath.Join(dir, "new", files[0].Name()))
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(contents), "Subject: Welcome"))
+	tmp, err := os.ReadDir(filepath.Join(dir, "tmp"))
+	assert.NoError(t, err)
+	assert.Empty(t, tmp)
+}
+
+func TestNewTransport(t *testing.T) {
+	var cfg config.Config
+	logger := slog.Default()
+
+	for transport, expected := range map[string]Transport{
+		TransportSMTP:   &SMTPTransport{},
+		TransportMemory: &MemoryTransport{},
+		TransportLog:    &LogTransport{},
+	} {
+		cfg.Mail.Transport = transport
+		actual, err := NewTransport(cfg, logger)
+		assert.NoError(t, err)
+		assert.IsType(t, expected, actual)
+	}
+
+	cfg.Mail.Transport = "pigeon"
+	_, err := NewTransport(cfg, logger)
+	assert.Error(t, err)
+}
+
+// batchTransport keeps the messages sent through its batches, and fails the
+// sends listed in failures, counted from one across batches.
+type batchTransport struct {
+	MemoryTransport
+	opens    int
+	sends    int
+	failures map[int]bool
+	openErr  error
+}
+
+func (t *batchTransport) Open() (Batch, error) {
+	if t.openErr != nil {
+		return nil, t.openErr
+	}
+	t.opens++
+	return transportBatch{transport: t}, nil
+}
+
+func (t *batchTransport) Send(msg Message) error {
+	t.sends++
+	if t.failures[t.sends] {
+		return errors.New("connection reset by peer")
+	}
+	return t.MemoryTransport.Send(msg)
+}
+
+func TestBulk_Send(t *testing.T) {
+	data := BulkData(11, "John", "john@example.com")
+
+	t.Run("reuses the connection", func(t *testing.T) {
+		// Arrange
+		transport := &batchTransport{MemoryTransport: MemoryTransport{capacity: 10}}
+		m, err := New(transport, "no-reply@example.org", "http://localhost:8082")
+		assert.NoError(t, err)
+		bulk := m.Bulk(0)
+
+		// Act
+		for _, recipient := range []string{"john@example.com", "jane@example.com", "joe@example.com"} {
+			assert.NoError(t, bulk.Send(context.Background(), recipient, "release_announcement.gohtml", "", data))
+		}
+		assert.NoError(t, bulk.Close())
+
+		// Assert
+		assert.Equal(t, 1, transport.opens)
+		assert.Len(t, transport.Messages(), 3)
+		assert.Equal(t, "joe@example.com", transport.Messages()[0].To)
+	})
+
+	t.Run("reconnects once the connection drops", func(t *testing.T) {
+		// Arrange
+		transport := &batchTransport{MemoryTransport: MemoryTransport{capacity: 10}, failures: map[int]bool{2: true}}
+		m, err := New(transport, "no-reply@example.org", "http://localhost:8082")
+		assert.NoError(t, err)
+		bulk := m.Bulk(0)
+		defer bulk.Close()
+
+		// Act
+		err1 := bulk.Send(context.Background(), "john@example.com", "release_announcement.gohtml", "", data)
+		err2 := bulk.Send(context.Background(), "jane@example.com", "release_announcement.gohtml", "", data)
+
+		// Assert
+		assert.NoError(t, err1)
+		assert.NoError(t, err2)
+		assert.Equal(t, 2, transport.opens)
+		assert.Len(t, transport.Messages(), 2)
+	})
+
+	t.Run("paces the sends", func(t *testing.T) {
+		// Arrange
+		transport := &batchTransport{MemoryTransport: MemoryTransport{capacity: 10}}
+		m, err := New(transport, "no-reply@example.org", "http://localhost:8082")
+		assert.NoError(t, err)
+		bulk := m.Bulk(50)
+		defer bulk.Close()
+		started := time.Now()
+
+		// Act
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, bulk.Send(context.Background(), "john@example.com", "release_announcement.gohtml", "", data))
+		}
+
+		// Assert
+		assert.GreaterOrEqual(t, time.Since(started), 40*time.Millisecond)
+	})
+
+	t.Run("stops when ctx is done", func(t *testing.T) {
+		// Arrange
+		transport := &batchTransport{MemoryTransport: MemoryTransport{capacity: 10}}
+		m, err := New(transport, "no-reply@example.org", "http://localhost:8082")
+		assert.NoError(t, err)
+		bulk := m.Bulk(0.1)
+		defer bulk.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		// Act
+		err1 := bulk.Send(ctx, "john@example.com", "release_announcement.gohtml", "", data)
+		err2 := bulk.Send(ctx, "jane@example.com", "release_announcement.gohtml", "", data)
+
+		// Assert
+		assert.NoError(t, err1)
+		assert.ErrorIs(t, err2, context.DeadlineExceeded)
+		assert.Len(t, transport.Messages(), 1)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		// Arrange
+		transport := &batchTransport{openErr: errors.New("connection refused")}
+		m, err := New(transport, "no-reply@example.org", "http://localhost:8082")
+		assert.NoError(t, err)
+		bulk := m.Bulk(0)
+
+		// Act
+		err = bulk.Send(context.Background(), "john@example.com", "release_announcement.gohtml", "", data)
+
+		// Assert
+		assert.ErrorIs(t, err, ErrUnavailable)
+	})
+
+	t.Run("only sends bulk templates", func(t *testing.T) {
+		// Arrange
+		transport := &batchTransport{MemoryTransport: MemoryTransport{capacity: 10}}
+		m, err := New(transport, "no-reply@example.org", "http://localhost:8082")
+		assert.NoError(t, err)
+		bulk := m.Bulk(0)
+		defer bulk.Close()
+
+		// Act
+		err1 := bulk.Send(context.Background(), "john@example.com", "token_password_reset.gohtml", "", data)
+		err2 := bulk.Send(context.Background(), "john@example.com", "../token_password_reset.gohtml", "", data)
+
+		// Assert
+		assert.ErrorIs(t, err1, ErrTemplateNotFound)
+		assert.ErrorIs(t, err2, ErrTemplateNotFound)
+		assert.Empty(t, transport.Messages())
+	})
+}
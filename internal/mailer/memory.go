@@ -0,0 +1,42 @@
+package mailer
+
+import "sync"
+
+// MemoryTransport keeps the most recent messages in memory instead of
+// sending them, for tests and the development inbox.
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages []Message
+	capacity int
+}
+
+// NewMemoryTransport returns a transport that keeps up to capacity messages,
+// dropping the oldest ones first.
+func NewMemoryTransport(capacity int) *MemoryTransport {
+	return &MemoryTransport{capacity: capacity}
+}
+
+func (t *MemoryTransport) Send(msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.messages = append(t.messages, msg)
+	if len(t.messages) > t.capacity {
+		t.messages = t.messages[len(t.messages)-t.capacity:]
+	}
+
+	return nil
+}
+
+// Messages returns the messages kept, newest first.
+func (t *MemoryTransport) Messages() []Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	messages := make([]Message, len(t.messages))
+	for i, msg := range t.messages {
+		messages[len(t.messages)-1-i] = msg
+	}
+
+	return messages
+}
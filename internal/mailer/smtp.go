@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"github.com/go-mail/mail/v2"
+	"time"
+)
+
+type SMTPTransport struct {
+	dialer *mail.Dialer
+}
+
+func NewSMTPTransport(host string, port int, username, password string) *SMTPTransport {
+	dialer := mail.NewDialer(host, port, username, password)
+	dialer.Timeout = 5 * time.Second
+
+	return &SMTPTransport{dialer: dialer}
+}
+
+func (t *SMTPTransport) Send(msg Message) error {
+	return t.dialer.DialAndSend(newMailMessage(msg))
+}
+
+// Open dials the SMTP server once for all the messages sent through the
+// returned batch.
+func (t *SMTPTransport) Open() (Batch, error) {
+	sender, err := t.dialer.Dial()
+	if err != nil {
+		return nil, err
+	}
+
+	return smtpBatch{sender: sender}, nil
+}
+
+type smtpBatch struct {
+	sender mail.SendCloser
+}
+
+func (b smtpBatch) Send(msg Message) error {
+	return mail.Send(b.sender, newMailMessage(msg))
+}
+
+func (b smtpBatch) Close() error {
+	return b.sender.Close()
+}
+
+func newMailMessage(msg Message) *mail.Message {
+	m := mail.NewMessage()
+	m.SetHeader("To", msg.To)
+	m.SetHeader("From", msg.From)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetDateHeader("Date", msg.Date)
+	m.SetBody("text/plain", msg.PlainBody)
+	m.AddAlternative("text/html", msg.HTMLBody)
+
+	return m
+}
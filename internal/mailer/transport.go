@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"fmt"
+	"github.com/jessicatarra/greenlight/internal/config"
+	"log/slog"
+)
+
+const (
+	TransportSMTP    = "smtp"
+	TransportMaildir = "maildir"
+	TransportMemory  = "memory"
+	TransportLog     = "log"
+)
+
+const defaultInboxCapacity = 100
+
+// Transport delivers rendered messages. The SMTP transport sends them for
+// real; the others keep them on the machine for development and tests.
+type Transport interface {
+	Send(msg Message) error
+}
+
+// Batch sends messages over a connection it keeps open until closed.
+type Batch interface {
+	Send(msg Message) error
+	Close() error
+}
+
+// Batcher is implemented by transports that can send several messages over
+// one connection, so that bulk sends do not dial once per message.
+type Batcher interface {
+	Open() (Batch, error)
+}
+
+// NewTransport returns the transport selected by cfg.Mail.Transport.
+func NewTransport(cfg config.Config, logger *slog.Logger) (Transport, error) {
+	switch cfg.Mail.Transport {
+	case TransportSMTP, "":
+		return NewSMTPTransport(cfg.Smtp.Host, cfg.Smtp.Port, cfg.Smtp.Username, cfg.Smtp.Password), nil
+	case TransportMaildir:
+		return NewMaildirTransport(cfg.Mail.MaildirPath)
+	case TransportMemory:
+		return NewMemoryTransport(defaultInboxCapacity), nil
+	case TransportLog:
+		return NewLogTransport(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", cfg.Mail.Transport)
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"github.com/jessicatarra/greenlight/internal/config"
 	"github.com/jessicatarra/greenlight/internal/errors"
@@ -9,10 +10,20 @@ import (
 	"net/http"
 )
 
+// Names of the cookies set when a browser signs in with a session, and of
+// the header and form field that must echo the CSRF cookie.
+const (
+	SessionCookieName = "greenlight_session"
+	CSRFCookieName    = "greenlight_csrf"
+	CSRFHeaderName    = "X-CSRF-Token"
+	CSRFFormField     = "csrf_token"
+)
+
 type Middleware interface {
 	RecoverPanic(next http.Handler) http.Handler
 	RateLimit(next http.Handler) http.Handler
 	EnableCORS(next http.Handler) http.Handler
+	CSRF(next http.Handler) http.Handler
 	LogRequest(next http.Handler) http.Handler
 }
 
@@ -67,10 +78,15 @@ func (m *middleware) EnableCORS(next http.Handler) http.Handler {
 				if origin == m.cfg.Cors.TrustedOrigins[i] {
 					writer.Header().Set("Access-Control-Allow-Origin", origin)
 
+					// Let trusted origins send the session cookies.
+					if m.cfg.Sessions.Enabled {
+						writer.Header().Set("Access-Control-Allow-Credentials", "true")
+					}
+
 					if request.Method == http.MethodOptions && request.Header.Get("Access-Control-Request-Method") != "" {
 
 						writer.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
-						writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+						writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+CSRFHeaderName)
 
 						writer.WriteHeader(http.StatusOK)
 						return
@@ -83,6 +99,44 @@ func (m *middleware) EnableCORS(next http.Handler) http.Handler {
 	})
 }
 
+// CSRF guards cookie sessions with the double-submit pattern. A request that
+// changes state and carries the session cookie, but no Authorization header,
+// must repeat the value of the CSRF cookie in the X-CSRF-Token header, or in
+// the csrf_token field of a submitted form. Another site can make a browser
+// send the cookies but cannot read them to fill in the header.
+func (m *middleware) CSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		if _, err := request.Cookie(SessionCookieName); err != nil || request.Header.Get("Authorization") != "" {
+			next.ServeHTTP(writer, request)
+			return
+		}
+
+		cookie, err := request.Cookie(CSRFCookieName)
+		if err != nil || cookie.Value == "" {
+			errors.InvalidCSRFToken(writer, request)
+			return
+		}
+
+		token := request.Header.Get(CSRFHeaderName)
+		if token == "" {
+			token = request.PostFormValue(CSRFFormField)
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(cookie.Value)) != 1 {
+			errors.InvalidCSRFToken(writer, request)
+			return
+		}
+
+		next.ServeHTTP(writer, request)
+	})
+}
+
 func (m *middleware) LogRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 		m.logger.Info("request", slog.Group("properties",
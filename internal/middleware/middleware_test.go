@@ -0,0 +1,75 @@
+//go:build auth
+// +build auth
+
+package middleware
+
+import (
+	"github.com/jessicatarra/greenlight/internal/config"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware_CSRF(t *testing.T) {
+	m := NewSharedMiddleware(&config.Config{}, slog.Default())
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		session        bool
+		csrfCookie     string
+		csrfHeader     string
+		csrfFormField  string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "safe method", method: http.MethodGet, session: true, expectedStatus: http.StatusOK},
+		{name: "no session cookie", method: http.MethodPost, expectedStatus: http.StatusOK},
+		{name: "bearer token", method: http.MethodPost, session: true, authorization: "Bearer token", expectedStatus: http.StatusOK},
+		{name: "matching header", method: http.MethodPatch, session: true, csrfCookie: "abc", csrfHeader: "abc", expectedStatus: http.StatusOK},
+		{name: "matching form field", method: http.MethodPost, session: true, csrfCookie: "abc", csrfFormField: "abc", expectedStatus: http.StatusOK},
+		{name: "missing header", method: http.MethodDelete, session: true, csrfCookie: "abc", expectedStatus: http.StatusForbidden},
+		{name: "missing cookie", method: http.MethodPost, session: true, csrfHeader: "abc", expectedStatus: http.StatusForbidden},
+		{name: "mismatched header", method: http.MethodPut, session: true, csrfCookie: "abc", csrfHeader: "abd", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			form := url.Values{}
+			if tc.csrfFormField != "" {
+				form.Set(CSRFFormField, tc.csrfFormField)
+			}
+			req := httptest.NewRequest(tc.method, "/v1/movies", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.session {
+				req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: "session"})
+			}
+			if tc.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tc.csrfCookie})
+			}
+			if tc.csrfHeader != "" {
+				req.Header.Set(CSRFHeaderName, tc.csrfHeader)
+			}
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			resRec := httptest.NewRecorder()
+
+			// Act
+			m.CSRF(next).ServeHTTP(resRec, req)
+
+			// Assert
+			if resRec.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, resRec.Code)
+			}
+		})
+	}
+}
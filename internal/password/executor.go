@@ -0,0 +1,102 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSaturated is returned by Executor.Do when as many hashes are already
+// waiting as the queue holds.
+var ErrSaturated = errors.New("password hashing queue is full")
+
+// Executor runs bcrypt hashes and comparisons on a bounded number of
+// goroutines, so that a burst of logins cannot take every CPU from the rest
+// of the process. Work beyond the concurrency limit waits in a queue of
+// bounded depth and is turned away once the queue is full.
+type Executor struct {
+	// admitted holds a token for every hash running or waiting to run, and
+	// running one for every hash running.
+	admitted chan struct{}
+	running  chan struct{}
+
+	hashes       atomic.Int64
+	rejected     atomic.Int64
+	cancelled    atomic.Int64
+	queueWait    atomic.Int64
+	hashDuration atomic.Int64
+	maxQueueWait atomic.Int64
+}
+
+// NewExecutor returns an Executor that runs up to concurrency hashes at once,
+// or one per CPU when concurrency is not positive, and lets up to queueDepth
+// more wait for their turn.
+func NewExecutor(concurrency, queueDepth int) *Executor {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	if queueDepth < 0 {
+		queueDepth = 0
+	}
+
+	return &Executor{
+		admitted: make(chan struct{}, concurrency+queueDepth),
+		running:  make(chan struct{}, concurrency),
+	}
+}
+
+// Do runs fn once a slot is free. It returns ErrSaturated without running fn
+// when the queue is full, and ctx.Err() when ctx is done before fn gets a
+// slot, so that cancelled requests leave the queue.
+func (e *Executor) Do(ctx context.Context, fn func()) error {
+	select {
+	case e.admitted <- struct{}{}:
+	default:
+		e.rejected.Add(1)
+		return ErrSaturated
+	}
+	defer func() { <-e.admitted }()
+
+	queued := time.Now()
+
+	select {
+	case e.running <- struct{}{}:
+	case <-ctx.Done():
+		e.cancelled.Add(1)
+		return ctx.Err()
+	}
+	defer func() { <-e.running }()
+
+	wait := time.Since(queued)
+	e.queueWait.Add(int64(wait))
+	for {
+		max := e.maxQueueWait.Load()
+		if int64(wait) <= max || e.maxQueueWait.CompareAndSwap(max, int64(wait)) {
+			break
+		}
+	}
+
+	started := time.Now()
+	fn()
+	e.hashDuration.Add(int64(time.Since(started)))
+	e.hashes.Add(1)
+
+	return nil
+}
+
+// Metrics returns counters suitable for publishing with expvar. Durations
+// are totals in milliseconds; divide by hashes for the average.
+func (e *Executor) Metrics() any {
+	return map[string]int64{
+		"running":                int64(len(e.running)),
+		"queued":                 int64(len(e.admitted) - len(e.running)),
+		"hashes":                 e.hashes.Load(),
+		"rejected":               e.rejected.Load(),
+		"cancelled":              e.cancelled.Load(),
+		"queue_wait_total_ms":    time.Duration(e.queueWait.Load()).Milliseconds(),
+		"queue_wait_max_ms":      time.Duration(e.maxQueueWait.Load()).Milliseconds(),
+		"hash_duration_total_ms": time.Duration(e.hashDuration.Load()).Milliseconds(),
+	}
+}
@@ -0,0 +1,89 @@
+//go:build auth
+// +build auth
+
+package password
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// occupy runs a task on executor that blocks until the returned function is
+// called, and waits for it to start.
+func occupy(t *testing.T, executor *Executor) func() {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+
+	go func() {
+		done <- executor.Do(context.Background(), func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+
+	return func() {
+		close(release)
+		assert.NoError(t, <-done)
+	}
+}
+
+func TestExecutor_Do(t *testing.T) {
+	t.Run("runs the task", func(t *testing.T) {
+		executor := NewExecutor(1, 0)
+		ran := false
+
+		err := executor.Do(context.Background(), func() { ran = true })
+
+		assert.NoError(t, err)
+		assert.True(t, ran)
+		assert.Equal(t, int64(1), executor.Metrics().(map[string]int64)["hashes"])
+	})
+
+	t.Run("saturated", func(t *testing.T) {
+		executor := NewExecutor(1, 0)
+		release := occupy(t, executor)
+		defer release()
+
+		err := executor.Do(context.Background(), func() { t.Error("task ran on a saturated executor") })
+
+		assert.ErrorIs(t, err, ErrSaturated)
+		assert.Equal(t, int64(1), executor.Metrics().(map[string]int64)["rejected"])
+	})
+
+	t.Run("queued until a slot is free", func(t *testing.T) {
+		executor := NewExecutor(1, 1)
+		release := occupy(t, executor)
+
+		done := make(chan error)
+		go func() {
+			done <- executor.Do(context.Background(), func() {})
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, int64(1), executor.Metrics().(map[string]int64)["queued"])
+
+		release()
+		assert.NoError(t, <-done)
+		assert.Equal(t, int64(2), executor.Metrics().(map[string]int64)["hashes"])
+	})
+
+	t.Run("cancelled while queued", func(t *testing.T) {
+		executor := NewExecutor(1, 1)
+		release := occupy(t, executor)
+		defer release()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := executor.Do(ctx, func() { t.Error("cancelled task ran") })
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, int64(0), executor.Metrics().(map[string]int64)["queued"])
+		assert.Equal(t, int64(1), executor.Metrics().(map[string]int64)["cancelled"])
+	})
+}
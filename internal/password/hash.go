@@ -28,3 +28,14 @@ func Matches(plaintextPassword, hashedPassword string) (bool, error) {
 
 	return true, nil
 }
+
+// dummyHash is a hash of a random password nobody knows, made with the same
+// cost as Hash.
+const dummyHash = "$2a$12$lQDLnKE2BHNciitzqdmpAOnYjJiGAp3EjIKxDzB0KXceLsODXLfvC"
+
+// MatchesNothing compares plaintextPassword against a hash no password
+// matches. Running it when there is no stored hash to check makes a failed
+// login take as long for an unknown account as for a wrong password.
+func MatchesNothing(plaintextPassword string) {
+	_, _ = Matches(plaintextPassword, dummyHash)
+}
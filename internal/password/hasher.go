@@ -0,0 +1,155 @@
+package password
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// pepperedPrefix marks a hash made from a peppered password. The key ID of
+// the pepper follows it, then the bcrypt hash itself, so a stored hash looks
+// like "$pepper$2026-01$2a$12$...".
+const pepperedPrefix = "$pepper$"
+
+// MinPepperLength is the shortest pepper, in bytes, a Hasher accepts.
+const MinPepperLength = 32
+
+var ErrUnknownPepper = errors.New("password hashed with an unknown pepper")
+
+// Hasher hashes passwords with bcrypt after running them through HMAC-SHA256
+// keyed with a secret pepper, so that a leaked table of hashes cannot be
+// cracked without the pepper too. Several peppers can be known at once while
+// rotating: hashes record the ID of their pepper, new hashes always use the
+// current one and NeedsRehash tells which stored hashes are out of date.
+//
+// A Hasher without a current pepper hashes plainly, like Hash. The bcrypt
+// work runs on its Executor, if it has one, or else on the calling goroutine.
+type Hasher struct {
+	executor  *Executor
+	currentID string
+	peppers   map[string][]byte
+}
+
+// NewHasher returns a Hasher that peppers new hashes with peppers[currentID].
+// An empty currentID disables peppering of new hashes, while hashes made
+// with any of peppers can still be checked. Each pepper should have passed
+// ValidatePepper.
+func NewHasher(executor *Executor, currentID string, peppers map[string][]byte) *Hasher {
+	return &Hasher{executor: executor, currentID: currentID, peppers: peppers}
+}
+
+// ValidatePepper checks that a pepper is long enough to be a secret and that
+// its key ID can be stored in a hash.
+func ValidatePepper(id string, pepper []byte) error {
+	if id == "" || strings.Contains(id, "$") {
+		return fmt.Errorf("invalid pepper key ID %q", id)
+	}
+	if len(pepper) < MinPepperLength {
+		return fmt.Errorf("pepper %q must be at least %d bytes long", id, MinPepperLength)
+	}
+
+	return nil
+}
+
+func (h *Hasher) Hash(ctx context.Context, plaintextPassword string) (string, error) {
+	var (
+		hashedPassword string
+		hashErr        error
+	)
+
+	id := h.currentID
+	if id != "" {
+		plaintextPassword = h.pepper(id, plaintextPassword)
+	}
+
+	err := h.do(ctx, func() { hashedPassword, hashErr = Hash(plaintextPassword) })
+	if err != nil {
+		return "", err
+	}
+	if hashErr != nil {
+		return "", hashErr
+	}
+
+	if id == "" {
+		return hashedPassword, nil
+	}
+
+	return pepperedPrefix + id + hashedPassword, nil
+}
+
+// Matches reports whether plaintextPassword is the password hashedPassword
+// was made from, whether or not it was peppered.
+func (h *Hasher) Matches(ctx context.Context, plaintextPassword, hashedPassword string) (bool, error) {
+	var (
+		matches  bool
+		matchErr error
+	)
+
+	id, hashedPassword, peppered := splitPepperedHash(hashedPassword)
+	if peppered {
+		if _, found := h.peppers[id]; !found {
+			return false, fmt.Errorf("%w: %q", ErrUnknownPepper, id)
+		}
+		plaintextPassword = h.pepper(id, plaintextPassword)
+	}
+
+	err := h.do(ctx, func() { matches, matchErr = Matches(plaintextPassword, hashedPassword) })
+	if err != nil {
+		return false, err
+	}
+
+	return matches, matchErr
+}
+
+// MatchesNothing is the package function MatchesNothing run on the
+// Executor, so that it queues like a real comparison.
+func (h *Hasher) MatchesNothing(ctx context.Context, plaintextPassword string) error {
+	return h.do(ctx, func() { MatchesNothing(plaintextPassword) })
+}
+
+// NeedsRehash reports whether hashedPassword was made without the current
+// pepper. Checking a password against it is the time to replace it.
+func (h *Hasher) NeedsRehash(hashedPassword string) bool {
+	id, _, _ := splitPepperedHash(hashedPassword)
+	return id != h.currentID
+}
+
+// do runs fn on the Executor. The error returned is the Executor's; fn
+// passes its results out through the variables it closes over.
+func (h *Hasher) do(ctx context.Context, fn func()) error {
+	if h.executor == nil {
+		fn()
+		return nil
+	}
+
+	return h.executor.Do(ctx, fn)
+}
+
+// pepper returns the HMAC of plaintextPassword keyed with the pepper id,
+// base64 encoded since bcrypt only looks at the first 72 bytes and stops at
+// a zero byte in some implementations.
+func (h *Hasher) pepper(id string, plaintextPassword string) string {
+	mac := hmac.New(sha256.New, h.peppers[id])
+	mac.Write([]byte(plaintextPassword))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// splitPepperedHash returns the pepper key ID and bcrypt hash of a peppered
+// hash. Any other hash is returned unchanged with an empty key ID.
+func splitPepperedHash(hashedPassword string) (id string, bcryptHash string, peppered bool) {
+	rest, found := strings.CutPrefix(hashedPassword, pepperedPrefix)
+	if !found {
+		return "", hashedPassword, false
+	}
+
+	i := strings.IndexByte(rest, '$')
+	if i <= 0 {
+		return "", hashedPassword, false
+	}
+
+	return rest[:i], rest[i:], true
+}
@@ -0,0 +1,87 @@
+//go:build auth
+// +build auth
+
+package password
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	oldPepper = []byte("0123456789abcdef0123456789abcdef")
+	newPepper = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func TestHasher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("peppered hash", func(t *testing.T) {
+		hasher := NewHasher(nil, "2026-01", map[string][]byte{"2026-01": newPepper})
+
+		hashedPassword, err := hasher.Hash(ctx, "correct horse")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hashedPassword, "$pepper$2026-01$2a$12$"), hashedPassword)
+		assert.False(t, hasher.NeedsRehash(hashedPassword))
+
+		matches, err := hasher.Matches(ctx, "correct horse", hashedPassword)
+		assert.NoError(t, err)
+		assert.True(t, matches)
+
+		matches, err = hasher.Matches(ctx, "battery staple", hashedPassword)
+		assert.NoError(t, err)
+		assert.False(t, matches)
+
+		// Without the pepper the bcrypt hash on its own is useless.
+		matches, err = Matches("correct horse", strings.TrimPrefix(hashedPassword, "$pepper$2026-01"))
+		assert.NoError(t, err)
+		assert.False(t, matches)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		previous := NewHasher(nil, "2025-07", map[string][]byte{"2025-07": oldPepper})
+		hashedPassword, err := previous.Hash(ctx, "correct horse")
+		assert.NoError(t, err)
+
+		hasher := NewHasher(nil, "2026-01", map[string][]byte{"2025-07": oldPepper, "2026-01": newPepper})
+
+		matches, err := hasher.Matches(ctx, "correct horse", hashedPassword)
+		assert.NoError(t, err)
+		assert.True(t, matches)
+		assert.True(t, hasher.NeedsRehash(hashedPassword))
+	})
+
+	t.Run("unpeppered hash", func(t *testing.T) {
+		hashedPassword, err := Hash("correct horse")
+		assert.NoError(t, err)
+
+		hasher := NewHasher(nil, "2026-01", map[string][]byte{"2026-01": newPepper})
+
+		matches, err := hasher.Matches(ctx, "correct horse", hashedPassword)
+		assert.NoError(t, err)
+		assert.True(t, matches)
+		assert.True(t, hasher.NeedsRehash(hashedPassword))
+		assert.False(t, NewHasher(nil, "", nil).NeedsRehash(hashedPassword))
+	})
+
+	t.Run("unknown pepper", func(t *testing.T) {
+		hashedPassword, err := NewHasher(nil, "2025-07", map[string][]byte{"2025-07": oldPepper}).Hash(ctx, "correct horse")
+		assert.NoError(t, err)
+
+		hasher := NewHasher(nil, "2026-01", map[string][]byte{"2026-01": newPepper})
+
+		matches, err := hasher.Matches(ctx, "correct horse", hashedPassword)
+		assert.ErrorIs(t, err, ErrUnknownPepper)
+		assert.False(t, matches)
+	})
+}
+
+func TestValidatePepper(t *testing.T) {
+	assert.NoError(t, ValidatePepper("2026-01", newPepper))
+	assert.Error(t, ValidatePepper("", newPepper))
+	assert.Error(t, ValidatePepper("2026$01", newPepper))
+	assert.Error(t, ValidatePepper("2026-01", []byte("too short")))
+}
@@ -0,0 +1,444 @@
+package password
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	patternDictionary = "dictionary"
+	patternSpatial    = "spatial"
+	patternRepeat     = "repeat"
+	patternSequence   = "sequence"
+	patternBruteforce = "bruteforce"
+
+	maxSequenceDelta = 5
+	maxL33tSubs      = 64
+)
+
+// match is a substring of a password, runes i through j inclusive, that
+// follows a guessable pattern. Only the fields relevant to the pattern are
+// set.
+type match struct {
+	pattern string
+	i, j    int
+	token   string
+	guesses float64
+
+	rank      int
+	userInput bool
+	reversed  bool
+	sub       map[rune]rune
+
+	turns        int
+	shiftedCount int
+
+	baseToken   string
+	baseGuesses float64
+	repeatCount int
+
+	ascending bool
+}
+
+type rankedDictionary map[string]int
+
+func newRankedDictionary(words []string) rankedDictionary {
+	dict := make(rankedDictionary, len(words))
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if _, exists := dict[word]; !exists {
+			dict[word] = i + 1
+		}
+	}
+
+	return dict
+}
+
+var commonPasswordRanks = newRankedDictionary(CommonPasswords)
+
+var l33tTable = map[rune][]rune{
+	'4': {'a'},
+	'@': {'a'},
+	'8': {'b'},
+	'(': {'c'},
+	'{': {'c'},
+	'[': {'c'},
+	'<': {'c'},
+	'3': {'e'},
+	'6': {'g'},
+	'9': {'g'},
+	'1': {'i', 'l'},
+	'!': {'i'},
+	'|': {'i', 'l'},
+	'7': {'l', 't'},
+	'0': {'o'},
+	'$': {'s'},
+	'5': {'s'},
+	'+': {'t'},
+	'%': {'x'},
+	'2': {'z'},
+}
+
+// keyboardGraph maps every key on a US QWERTY keyboard, in both its shifted
+// and unshifted form, to its neighbours. Neighbours are listed clockwise
+// starting from the key on the left, with an empty string where the layout
+// has no key.
+type keyboardGraph map[rune][6]string
+
+var qwertyRows = [][]string{
+	{"1!", "2@", "3#", "4$", "5%", "6^", "7&", "8*", "9(", "0)", "-_", "=+"},
+	{"qQ", "wW", "eE", "rR", "tT", "yY", "uU", "iI", "oO", "pP", "[{", "]}", "\\|"},
+	{"aA", "sS", "dD", "fF", "gG", "hH", "jJ", "kK", "lL", ";:", "'\""},
+	{"zZ", "xX", "cC", "vV", "bB", "nN", "mM", ",<", ".>", "/?"},
+}
+
+func newKeyboardGraph(rows [][]string) keyboardGraph {
+	key := func(row, col int) string {
+		if row < 0 || row >= len(rows) || col < 0 || col >= len(rows[row]) {
+			return ""
+		}
+		return rows[row][col]
+	}
+
+	graph := make(keyboardGraph)
+	for row := range rows {
+		for col, k := range rows[row] {
+			// Each row is offset half a key to the right of the row above,
+			// so the keys above sit at col and col+1 and those below at
+			// col-1 and col.
+			neighbours := [6]string{
+				key(row, col-1),
+				key(row-1, col),
+				key(row-1, col+1),
+				key(row, col+1),
+				key(row+1, col),
+				key(row+1, col-1),
+			}
+			for _, r := range k {
+				graph[r] = neighbours
+			}
+		}
+	}
+
+	return graph
+}
+
+var qwerty = newKeyboardGraph(qwertyRows)
+
+// startingPositions returns the number of distinct keys in the graph.
+func (g keyboardGraph) startingPositions() float64 {
+	return float64(len(g) / 2)
+}
+
+// averageDegree returns the average number of neighbours per key.
+func (g keyboardGraph) averageDegree() float64 {
+	var total float64
+	for _, neighbours := range g {
+		for _, n := range neighbours {
+			if n != "" {
+				total++
+			}
+		}
+	}
+
+	return total / float64(len(g))
+}
+
+// shifted reports whether r is typed with the shift key held.
+func shifted(r rune) bool {
+	for _, row := range qwertyRows {
+		for _, k := range row {
+			if []rune(k)[1] == r {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matcher finds every guessable pattern in a password.
+type matcher struct {
+	userInputs rankedDictionary
+}
+
+func (m matcher) omnimatch(password []rune) []match {
+	var matches []match
+
+	matches = append(matches, m.dictionaryMatches(password)...)
+	matches = append(matches, m.reverseDictionaryMatches(password)...)
+	matches = append(matches, m.l33tMatches(password)...)
+	matches = append(matches, spatialMatches(password)...)
+	matches = append(matches, m.repeatMatches(password)...)
+	matches = append(matches, sequenceMatches(password)...)
+
+	return matches
+}
+
+func (m matcher) dictionaryMatches(password []rune) []match {
+	matches := dictionaryMatches(password, commonPasswordRanks, false)
+	matches = append(matches, dictionaryMatches(password, m.userInputs, true)...)
+
+	return matches
+}
+
+func dictionaryMatches(password []rune, dict rankedDictionary, userInput bool) []match {
+	lower := make([]rune, len(password))
+	for i, r := range password {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var matches []match
+	for i := range lower {
+		for j := i; j < len(lower); j++ {
+			rank, ok := dict[string(lower[i:j+1])]
+			if !ok {
+				continue
+			}
+
+			matches = append(matches, match{
+				pattern:   patternDictionary,
+				i:         i,
+				j:         j,
+				token:     string(password[i : j+1]),
+				rank:      rank,
+				userInput: userInput,
+			})
+		}
+	}
+
+	return matches
+}
+
+func (m matcher) reverseDictionaryMatches(password []rune) []match {
+	n := len(password)
+
+	reversed := make([]rune, n)
+	for i, r := range password {
+		reversed[n-1-i] = r
+	}
+
+	matches := m.dictionaryMatches(reversed)
+	for k := range matches {
+		i, j := n-1-matches[k].j, n-1-matches[k].i
+		matches[k].i, matches[k].j = i, j
+		matches[k].token = string(password[i : j+1])
+		matches[k].reversed = true
+	}
+
+	return matches
+}
+
+func (m matcher) l33tMatches(password []rune) []match {
+	var matches []match
+
+	for _, sub := range l33tSubs(password) {
+		translated := make([]rune, len(password))
+		for i, r := range password {
+			if letter, ok := sub[r]; ok {
+				r = letter
+			}
+			translated[i] = r
+		}
+
+		for _, candidate := range m.dictionaryMatches(translated) {
+			token := password[candidate.i : candidate.j+1]
+			if len(token) < 2 {
+				continue
+			}
+
+			used := make(map[rune]rune)
+			for _, r := range token {
+				if letter, ok := sub[r]; ok {
+					used[r] = letter
+				}
+			}
+			if len(used) == 0 {
+				continue
+			}
+
+			candidate.token = string(token)
+			candidate.sub = used
+			matches = append(matches, candidate)
+		}
+	}
+
+	return matches
+}
+
+// l33tSubs lists the ways the l33t characters in password can be read back
+// as letters, capped at maxL33tSubs combinations.
+func l33tSubs(password []rune) []map[rune]rune {
+	var present []rune
+	seen := make(map[rune]bool)
+	for _, r := range password {
+		if _, ok := l33tTable[r]; ok && !seen[r] {
+			seen[r] = true
+			present = append(present, r)
+		}
+	}
+
+	if len(present) == 0 {
+		return nil
+	}
+
+	subs := []map[rune]rune{{}}
+	for _, r := range present {
+		var next []map[rune]rune
+		for _, sub := range subs {
+			for _, letter := range l33tTable[r] {
+				if len(next) == maxL33tSubs {
+					break
+				}
+
+				extended := make(map[rune]rune, len(sub)+1)
+				for k, v := range sub {
+					extended[k] = v
+				}
+				extended[r] = letter
+				next = append(next, extended)
+			}
+		}
+		subs = next
+	}
+
+	return subs
+}
+
+func spatialMatches(password []rune) []match {
+	var matches []match
+
+	for i := 0; i < len(password)-1; {
+		j := i + 1
+		lastDirection := -1
+		turns := 0
+		shiftedCount := 0
+		if shifted(password[i]) {
+			shiftedCount++
+		}
+
+		for ; j < len(password); j++ {
+			neighbours, ok := qwerty[password[j-1]]
+			if !ok {
+				break
+			}
+
+			direction := -1
+			for d, n := range neighbours {
+				if n == "" {
+					continue
+				}
+				if k := strings.IndexRune(n, password[j]); k != -1 {
+					direction = d
+					if k == 1 {
+						shiftedCount++
+					}
+					break
+				}
+			}
+			if direction == -1 {
+				break
+			}
+
+			if direction != lastDirection {
+				turns++
+				lastDirection = direction
+			}
+		}
+
+		if j-i > 2 {
+			matches = append(matches, match{
+				pattern:      patternSpatial,
+				i:            i,
+				j:            j - 1,
+				token:        string(password[i:j]),
+				turns:        turns,
+				shiftedCount: shiftedCount,
+			})
+		}
+
+		i = j
+	}
+
+	return matches
+}
+
+func (m matcher) repeatMatches(password []rune) []match {
+	var matches []match
+
+	for i := 0; i < len(password); {
+		span, baseLen, count := 0, 0, 0
+		for b := 1; i+2*b <= len(password); b++ {
+			base := string(password[i : i+b])
+
+			c := 1
+			for i+(c+1)*b <= len(password) && string(password[i+c*b:i+(c+1)*b]) == base {
+				c++
+			}
+
+			if c >= 2 && c*b > span {
+				span, baseLen, count = c*b, b, c
+			}
+		}
+
+		if span == 0 {
+			i++
+			continue
+		}
+
+		base := password[i : i+baseLen]
+		matches = append(matches, match{
+			pattern:     patternRepeat,
+			i:           i,
+			j:           i + span - 1,
+			token:       string(password[i : i+span]),
+			baseToken:   string(base),
+			baseGuesses: m.mostGuessableSequence(base).guesses,
+			repeatCount: count,
+		})
+
+		i += span
+	}
+
+	return matches
+}
+
+func sequenceMatches(password []rune) []match {
+	if len(password) < 2 {
+		return nil
+	}
+
+	var matches []match
+	update := func(i, j, delta int) {
+		abs := delta
+		if abs < 0 {
+			abs = -abs
+		}
+
+		if (j-i > 1 || abs == 1) && abs > 0 && abs <= maxSequenceDelta {
+			matches = append(matches, match{
+				pattern:   patternSequence,
+				i:         i,
+				j:         j,
+				token:     string(password[i : j+1]),
+				ascending: delta > 0,
+			})
+		}
+	}
+
+	i := 0
+	lastDelta := int(password[1] - password[0])
+	for k := 2; k < len(password); k++ {
+		delta := int(password[k] - password[k-1])
+		if delta == lastDelta {
+			continue
+		}
+
+		update(i, k-1, lastDelta)
+		i = k - 1
+		lastDelta = delta
+	}
+	update(i, len(password)-1, lastDelta)
+
+	return matches
+}
@@ -0,0 +1,120 @@
+package password
+
+import "unicode/utf8"
+
+// maxEstimatedLength is how many bytes of a password Estimate looks at. Its
+// cost grows quickly with the length of the password, and bcrypt ignores
+// anything past 72 bytes anyway.
+const maxEstimatedLength = 72
+
+// Rule inspects a candidate password together with its estimated strength and
+// returns a message explaining why it is unacceptable, or an empty string when
+// it passes.
+type Rule func(plaintext string, strength Strength) string
+
+// Policy is an ordered list of rules a password must satisfy. The first
+// failing rule decides the message reported to the user.
+type Policy struct {
+	// limits are checked before the strength of the password is estimated,
+	// with a zero Strength, so that a password of any length is turned down
+	// cheaply.
+	limits []Rule
+	rules  []Rule
+}
+
+func NewPolicy(rules ...Rule) *Policy {
+	return &Policy{rules: rules}
+}
+
+// NewStandardPolicy returns the policy used for registration, reset and
+// password change: a password is required, its length in bytes must lie
+// between minLength and maxLength, it must not be one of CommonPasswords and
+// its estimated strength must be at least minScore.
+func NewStandardPolicy(minLength, maxLength, minScore int) *Policy {
+	return &Policy{
+		limits: []Rule{Required(), MinLength(minLength), MaxLength(maxLength)},
+		rules:  []Rule{NotCommon(), MinScore(minScore)},
+	}
+}
+
+// Check estimates the strength of plaintext, penalising any of userInputs it
+// contains, and returns it together with the message of the first rule it
+// fails. The message is empty when the password is acceptable. Only the first
+// 72 bytes of plaintext are estimated.
+func (p *Policy) Check(plaintext string, userInputs ...string) (Strength, string) {
+	for _, rule := range p.limits {
+		if message := rule(plaintext, Strength{}); message != "" {
+			return Strength{}, message
+		}
+	}
+
+	strength := Estimate(truncate(plaintext, maxEstimatedLength), userInputs...)
+
+	for _, rule := range p.rules {
+		if message := rule(plaintext, strength); message != "" {
+			return strength, message
+		}
+	}
+
+	return strength, ""
+}
+
+func Required() Rule {
+	return func(plaintext string, _ Strength) string {
+		if plaintext == "" {
+			return "Password is required"
+		}
+		return ""
+	}
+}
+
+func MinLength(n int) Rule {
+	return func(plaintext string, _ Strength) string {
+		if len(plaintext) < n {
+			return "Password is too short"
+		}
+		return ""
+	}
+}
+
+// MaxLength limits the length of plaintext in bytes. bcrypt ignores anything
+// past 72 bytes, so n should not be larger than that.
+func MaxLength(n int) Rule {
+	return func(plaintext string, _ Strength) string {
+		if len(plaintext) > n {
+			return "Password is too long"
+		}
+		return ""
+	}
+}
+
+func NotCommon() Rule {
+	return func(plaintext string, _ Strength) string {
+		if _, found := commonPasswordRanks[plaintext]; found {
+			return "Password is too common"
+		}
+		return ""
+	}
+}
+
+func MinScore(n int) Rule {
+	return func(_ string, strength Strength) string {
+		if strength.Score < n {
+			return "Password is too weak"
+		}
+		return ""
+	}
+}
+
+// truncate returns at most the first n bytes of s, without splitting a rune.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
@@ -0,0 +1,481 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	bruteforceCardinality           = 10
+	minGuessesSingleChar            = 10
+	minGuessesMultiChar             = 50
+	minGuessesBeforeGrowingSequence = 10000
+	scoreDelta                      = 5
+)
+
+const (
+	hintAddWord                      = "Add another word or two. Uncommon words are better."
+	warningUserInput                 = "Passwords that contain your name or email address are easy to guess."
+	suggestionAvoidUserInput         = "Avoid using your name or email address."
+	suggestionCapitalization         = "Capitalization doesn't help very much."
+	suggestionAllUppercase           = "All-uppercase is almost as easy to guess as all-lowercase."
+	suggestionReversed               = "Reversed words aren't much harder to guess."
+	suggestionPredictableSubstitutes = "Predictable substitutions like '@' instead of 'a' don't help very much."
+)
+
+// Strength is an estimate of how hard a password is to guess, in the style of
+// zxcvbn. Score ranges from 0 (trivially guessable) to 4 (very unguessable).
+// Warning and Hints explain what makes a weak password weak and how to
+// improve it; both are empty for passwords scoring 3 or more.
+type Strength struct {
+	Score   int      `json:"score"`
+	Guesses float64  `json:"-"`
+	Warning string   `json:"warning,omitempty"`
+	Hints   []string `json:"hints,omitempty"`
+}
+
+// Estimate scores plaintext by finding the sequence of dictionary words,
+// keyboard patterns, repeats, sequences and bruteforce segments that is
+// cheapest for an attacker to guess. The userInputs, such as the user's name
+// and email address, are treated as a dictionary of their own so passwords
+// built from them score poorly; email addresses contribute their local part.
+func Estimate(plaintext string, userInputs ...string) Strength {
+	m := matcher{userInputs: newRankedDictionary(expandUserInputs(userInputs))}
+
+	result := m.mostGuessableSequence([]rune(plaintext))
+	score := guessesToScore(result.guesses)
+	warning, hints := feedback(score, result.sequence)
+
+	return Strength{
+		Score:   score,
+		Guesses: result.guesses,
+		Warning: warning,
+		Hints:   hints,
+	}
+}
+
+func expandUserInputs(inputs []string) []string {
+	var words []string
+
+	for _, input := range inputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		if local, _, found := strings.Cut(input, "@"); found {
+			input = local
+		}
+		if input == "" {
+			continue
+		}
+
+		words = append(words, input)
+
+		fields := strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if joined := strings.Join(fields, ""); joined != input && joined != "" {
+			words = append(words, joined)
+		}
+		for _, field := range fields {
+			if len([]rune(field)) >= 3 && field != input {
+				words = append(words, field)
+			}
+		}
+	}
+
+	return words
+}
+
+type guessResult struct {
+	guesses  float64
+	sequence []match
+}
+
+// mostGuessableSequence finds the sequence of non-overlapping matches covering
+// password that minimises the total number of guesses. As in zxcvbn, a
+// sequence of l matches costs l! times the product of their guesses, plus a
+// penalty that grows with l, and gaps between matches are filled with
+// bruteforce segments.
+func (m matcher) mostGuessableSequence(password []rune) guessResult {
+	n := len(password)
+	if n == 0 {
+		return guessResult{guesses: 1}
+	}
+
+	byEnd := make([][]match, n)
+	for _, mt := range m.omnimatch(password) {
+		byEnd[mt.j] = append(byEnd[mt.j], mt)
+	}
+
+	type step struct {
+		match   match
+		product float64
+		guesses float64
+	}
+
+	optimal := make([]map[int]step, n)
+	for k := range optimal {
+		optimal[k] = make(map[int]step)
+	}
+
+	update := func(mt match, l int) {
+		k := mt.j
+
+		mt.guesses = estimateGuesses(mt, n)
+		product := mt.guesses
+		if l > 1 {
+			product *= optimal[mt.i-1][l-1].product
+		}
+		guesses := factorial(l)*product + math.Pow(minGuessesBeforeGrowingSequence, float64(l-1))
+
+		for competingL, competing := range optimal[k] {
+			if competingL <= l && competing.guesses <= guesses {
+				return
+			}
+		}
+
+		optimal[k][l] = step{match: mt, product: product, guesses: guesses}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, mt := range byEnd[k] {
+			if mt.i == 0 {
+				update(mt, 1)
+				continue
+			}
+			for l := range optimal[mt.i-1] {
+				update(mt, l+1)
+			}
+		}
+
+		update(bruteforceMatch(password, 0, k), 1)
+		for i := 1; i <= k; i++ {
+			bf := bruteforceMatch(password, i, k)
+			for l, s := range optimal[i-1] {
+				if s.match.pattern == patternBruteforce {
+					continue
+				}
+				update(bf, l+1)
+			}
+		}
+	}
+
+	bestL := 0
+	bestGuesses := math.Inf(1)
+	for l, s := range optimal[n-1] {
+		if s.guesses < bestGuesses || (s.guesses == bestGuesses && l < bestL) {
+			bestL, bestGuesses = l, s.guesses
+		}
+	}
+
+	sequence := make([]match, bestL)
+	for k, l := n-1, bestL; k >= 0; l-- {
+		s := optimal[k][l]
+		sequence[l-1] = s.match
+		k = s.match.i - 1
+	}
+
+	return guessResult{guesses: bestGuesses, sequence: sequence}
+}
+
+func bruteforceMatch(password []rune, i, j int) match {
+	return match{
+		pattern: patternBruteforce,
+		i:       i,
+		j:       j,
+		token:   string(password[i : j+1]),
+	}
+}
+
+func estimateGuesses(mt match, passwordLen int) float64 {
+	tokenLen := len([]rune(mt.token))
+
+	minGuesses := 1.0
+	if tokenLen < passwordLen {
+		if tokenLen == 1 {
+			minGuesses = minGuessesSingleChar
+		} else {
+			minGuesses = minGuessesMultiChar
+		}
+	}
+
+	var guesses float64
+	switch mt.pattern {
+	case patternDictionary:
+		guesses = dictionaryGuesses(mt)
+	case patternSpatial:
+		guesses = spatialGuesses(mt)
+	case patternRepeat:
+		guesses = mt.baseGuesses * float64(mt.repeatCount)
+	case patternSequence:
+		guesses = sequenceGuesses(mt)
+	default:
+		guesses = bruteforceGuesses(tokenLen)
+	}
+
+	return math.Max(guesses, minGuesses)
+}
+
+func dictionaryGuesses(mt match) float64 {
+	guesses := float64(mt.rank) * uppercaseVariations(mt.token) * l33tVariations(mt)
+	if mt.reversed {
+		guesses *= 2
+	}
+
+	return guesses
+}
+
+func bruteforceGuesses(tokenLen int) float64 {
+	guesses := math.Pow(bruteforceCardinality, float64(tokenLen))
+	if math.IsInf(guesses, 1) {
+		guesses = math.MaxFloat64
+	}
+
+	minGuesses := float64(minGuessesMultiChar + 1)
+	if tokenLen == 1 {
+		minGuesses = minGuessesSingleChar + 1
+	}
+
+	return math.Max(guesses, minGuesses)
+}
+
+func spatialGuesses(mt match) float64 {
+	s := qwerty.startingPositions()
+	d := qwerty.averageDegree()
+	tokenLen := len([]rune(mt.token))
+
+	var guesses float64
+	for i := 2; i <= tokenLen; i++ {
+		possibleTurns := min(mt.turns, i-1)
+		for j := 1; j <= possibleTurns; j++ {
+			guesses += nCk(i-1, j-1) * s * math.Pow(d, float64(j))
+		}
+	}
+
+	if mt.shiftedCount > 0 {
+		shiftedCount := mt.shiftedCount
+		unshiftedCount := tokenLen - shiftedCount
+		if unshiftedCount == 0 {
+			guesses *= 2
+		} else {
+			var variations float64
+			for i := 1; i <= min(shiftedCount, unshiftedCount); i++ {
+				variations += nCk(shiftedCount+unshiftedCount, i)
+			}
+			guesses *= variations
+		}
+	}
+
+	return guesses
+}
+
+func sequenceGuesses(mt match) float64 {
+	first := []rune(mt.token)[0]
+
+	var base float64
+	switch {
+	case strings.ContainsRune("aAzZ019", first):
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+
+	if !mt.ascending {
+		base *= 2
+	}
+
+	return base * float64(len([]rune(mt.token)))
+}
+
+func uppercaseVariations(token string) float64 {
+	runes := []rune(token)
+
+	var upper, lower int
+	for _, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	if upper == 0 {
+		return 1
+	}
+
+	startUpper := unicode.IsUpper(runes[0]) && upper == 1
+	endUpper := unicode.IsUpper(runes[len(runes)-1]) && upper == 1
+	if startUpper || endUpper || lower == 0 {
+		return 2
+	}
+
+	var variations float64
+	for i := 1; i <= min(upper, lower); i++ {
+		variations += nCk(upper+lower, i)
+	}
+
+	return variations
+}
+
+func l33tVariations(mt match) float64 {
+	variations := 1.0
+	token := []rune(strings.ToLower(mt.token))
+
+	for subbed, letter := range mt.sub {
+		var subbedCount, letterCount int
+		for _, r := range token {
+			switch r {
+			case subbed:
+				subbedCount++
+			case letter:
+				letterCount++
+			}
+		}
+
+		if subbedCount == 0 || letterCount == 0 {
+			variations *= 2
+			continue
+		}
+
+		var possibilities float64
+		for i := 1; i <= min(subbedCount, letterCount); i++ {
+			possibilities += nCk(subbedCount+letterCount, i)
+		}
+		variations *= possibilities
+	}
+
+	return variations
+}
+
+func guessesToScore(guesses float64) int {
+	switch {
+	case guesses < 1e3+scoreDelta:
+		return 0
+	case guesses < 1e6+scoreDelta:
+		return 1
+	case guesses < 1e8+scoreDelta:
+		return 2
+	case guesses < 1e10+scoreDelta:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func feedback(score int, sequence []match) (string, []string) {
+	if len(sequence) == 0 {
+		return "", []string{
+			"Use a few words, avoid common phrases.",
+			"No need for symbols, digits, or uppercase letters.",
+		}
+	}
+
+	if score > 2 {
+		return "", nil
+	}
+
+	longest := sequence[0]
+	for _, mt := range sequence[1:] {
+		if len([]rune(mt.token)) > len([]rune(longest.token)) {
+			longest = mt
+		}
+	}
+
+	warning, hints := matchFeedback(longest, len(sequence) == 1)
+
+	return warning, append([]string{hintAddWord}, hints...)
+}
+
+func matchFeedback(mt match, soleMatch bool) (string, []string) {
+	switch mt.pattern {
+	case patternDictionary:
+		return dictionaryFeedback(mt, soleMatch)
+	case patternSpatial:
+		warning := "Short keyboard patterns are easy to guess."
+		if mt.turns == 1 {
+			warning = "Straight rows of keys are easy to guess."
+		}
+		return warning, []string{"Use a longer keyboard pattern with more turns."}
+	case patternRepeat:
+		warning := `Repeats like "abcabcabc" are only slightly harder to guess than "abc".`
+		if len([]rune(mt.baseToken)) == 1 {
+			warning = `Repeats like "aaa" are easy to guess.`
+		}
+		return warning, []string{"Avoid repeated words and characters."}
+	case patternSequence:
+		return "Sequences like abc or 6543 are easy to guess.", []string{"Avoid sequences."}
+	default:
+		return "", nil
+	}
+}
+
+func dictionaryFeedback(mt match, soleMatch bool) (string, []string) {
+	var warning string
+	switch {
+	case mt.userInput:
+		warning = warningUserInput
+	case soleMatch && mt.sub == nil && !mt.reversed:
+		switch {
+		case mt.rank <= 10:
+			warning = "This is a top-10 common password."
+		case mt.rank <= 100:
+			warning = "This is a top-100 common password."
+		default:
+			warning = "This is a very common password."
+		}
+	case math.Log10(mt.guesses) <= 4:
+		warning = "This is similar to a commonly used password."
+	}
+
+	var hints []string
+	if mt.userInput {
+		hints = append(hints, suggestionAvoidUserInput)
+	}
+
+	runes := []rune(mt.token)
+	switch {
+	case unicode.IsUpper(runes[0]) && strings.ToUpper(mt.token) != mt.token:
+		hints = append(hints, suggestionCapitalization)
+	case strings.ToUpper(mt.token) == mt.token && strings.ToLower(mt.token) != mt.token:
+		hints = append(hints, suggestionAllUppercase)
+	}
+
+	if mt.reversed && len(runes) >= 4 {
+		hints = append(hints, suggestionReversed)
+	}
+
+	if mt.sub != nil {
+		hints = append(hints, suggestionPredictableSubstitutes)
+	}
+
+	return warning, hints
+}
+
+func nCk(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+	if k == 0 {
+		return 1
+	}
+
+	r := 1.0
+	for d := 1; d <= k; d++ {
+		r *= float64(n)
+		r /= float64(d)
+		n--
+	}
+
+	return r
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+
+	return f
+}
@@ -0,0 +1,139 @@
+//go:build auth
+// +build auth
+
+package password
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		minScore int
+		maxScore int
+		warning  string
+	}{
+		{name: "empty", password: "", maxScore: 0},
+		{name: "top-10 common password", password: "password", maxScore: 0, warning: "This is a top-10 common password."},
+		{name: "l33t common password", password: "p@ssw0rd", maxScore: 0},
+		{name: "reversed common password", password: "drowssap", maxScore: 0},
+		{name: "keyboard row", password: "qwertyuiop", maxScore: 1, warning: "Straight rows of keys are easy to guess."},
+		{name: "keyboard pattern with turns", password: "zxcvfr43", maxScore: 1},
+		{name: "repeated character", password: "aaaaaaaaaa", maxScore: 0, warning: `Repeats like "aaa" are easy to guess.`},
+		{name: "repeated word", password: "abcxyzabcxyzabcxyz", maxScore: 2},
+		{name: "sequence", password: "abcdefghij", maxScore: 0, warning: "Sequences like abc or 6543 are easy to guess."},
+		{name: "descending digits", password: "98765432", maxScore: 0},
+		{name: "random", password: "kx8#Qv!2Lm9z", minScore: 4, maxScore: 4},
+		{name: "passphrase", password: "correcthorsebatterystaple", minScore: 4, maxScore: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strength := Estimate(tt.password)
+
+			assert.GreaterOrEqual(t, strength.Score, tt.minScore)
+			assert.LessOrEqual(t, strength.Score, tt.maxScore)
+			if strength.Score <= 2 {
+				assert.NotEmpty(t, strength.Hints)
+			} else {
+				assert.Empty(t, strength.Hints)
+			}
+			if tt.warning != "" {
+				assert.Equal(t, tt.warning, strength.Warning)
+			}
+		})
+	}
+}
+
+func TestEstimateUserInputs(t *testing.T) {
+	without := Estimate("JohnSmith1987")
+	with := Estimate("JohnSmith1987", "John Smith", "john.smith@example.com")
+
+	assert.Less(t, with.Guesses, without.Guesses)
+	assert.Less(t, with.Score, without.Score)
+	assert.Equal(t, warningUserInput, with.Warning)
+	assert.Contains(t, with.Hints, suggestionAvoidUserInput)
+
+	emailLocalPart := Estimate("johnsmith!", "john.smith@example.com")
+	assert.Less(t, emailLocalPart.Score, Estimate("johnsmith!").Score)
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy := NewStandardPolicy(8, 72, 3)
+
+	tests := []struct {
+		name     string
+		password string
+		message  string
+	}{
+		{name: "missing", password: "", message: "Password is required"},
+		{name: "too short", password: "kx8#Qv", message: "Password is too short"},
+		{name: "too long", password: string(make([]byte, 73)), message: "Password is too long"},
+		{name: "too common", password: "football", message: "Password is too common"},
+		{name: "too weak", password: "janedoe1", message: "Password is too weak"},
+		{name: "contains name", password: "johnsmith!!", message: "Password is too weak"},
+		{name: "acceptable", password: "violet kettle drums at noon", message: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strength, message := policy.Check(tt.password, "John Smith", "john@example.com")
+
+			assert.Equal(t, tt.message, message)
+			if message == "" {
+				assert.GreaterOrEqual(t, strength.Score, 3)
+			}
+		})
+	}
+
+	t.Run("custom rule", func(t *testing.T) {
+		noSpaces := func(plaintext string, _ Strength) string {
+			for _, r := range plaintext {
+				if r == ' ' {
+					return "Password must not contain spaces"
+				}
+			}
+			return ""
+		}
+		custom := NewPolicy(Required(), noSpaces)
+
+		_, message := custom.Check("violet kettle drums at noon")
+
+		assert.Equal(t, "Password must not contain spaces", message)
+	})
+
+	t.Run("very long password", func(t *testing.T) {
+		plaintext := strings.Repeat("kx8#Qv!2Lm9z", 1<<16)
+
+		started := time.Now()
+		strength, message := policy.Check(plaintext, "John Smith", "john@example.com")
+
+		assert.Equal(t, "Password is too long", message)
+		assert.Equal(t, Strength{}, strength)
+		assert.Less(t, time.Since(started), time.Second)
+	})
+
+	t.Run("very long password without a length limit", func(t *testing.T) {
+		custom := NewPolicy(MinScore(3))
+		plaintext := strings.Repeat("é", 1<<16)
+
+		started := time.Now()
+		_, message := custom.Check(plaintext)
+
+		assert.Equal(t, "Password is too weak", message)
+		assert.Less(t, time.Since(started), time.Second)
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 72))
+	assert.Equal(t, "ab", truncate("abcd", 2))
+	// "é" is two bytes long and is not split.
+	assert.Equal(t, "a", truncate("aé", 2))
+}
@@ -0,0 +1,30 @@
+package response
+
+import (
+	"bytes"
+	"github.com/jessicatarra/greenlight/assets"
+	"html/template"
+	"net/http"
+)
+
+// HTML renders the page template html/<page> inside the html/base.gohtml
+// layout. The page is rendered to a buffer first so that template errors can
+// still be reported with a proper status code.
+func HTML(w http.ResponseWriter, status int, page string, data any) error {
+	tmpl, err := template.New("page").ParseFS(assets.EmbeddedFiles, "html/base.gohtml", "html/"+page)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	err = tmpl.ExecuteTemplate(buf, "base", data)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package scim
+
+import (
+	"strings"
+)
+
+// Matches evaluates expr against a resource in its JSON form, as produced by
+// decoding the resource into a map[string]any. Attribute names and string
+// values compare case insensitively; a multi-valued attribute matches when
+// any of its values does.
+func Matches(expr Expression, resource map[string]any) bool {
+	switch e := expr.(type) {
+	case *LogicalExpression:
+		if e.Operator == "and" {
+			return Matches(e.Left, resource) && Matches(e.Right, resource)
+		}
+		return Matches(e.Left, resource) || Matches(e.Right, resource)
+	case *NotExpression:
+		return !Matches(e.Expression, resource)
+	case *ValuePathExpression:
+		for _, element := range elements(lookup(resource, e.Path.Name)) {
+			if m, ok := element.(map[string]any); ok && Matches(e.Filter, m) {
+				return true
+			}
+		}
+		return false
+	case *AttributeExpression:
+		values := resolve(resource, e.Path)
+		if e.Operator == OperatorPresent {
+			for _, v := range values {
+				if present(v) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(values) == 0 {
+			values = []any{nil}
+		}
+		for _, v := range values {
+			if compare(v, e.Operator, e.Value) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return false
+}
+
+func lookup(resource map[string]any, name string) any {
+	for key, value := range resource {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return nil
+}
+
+func elements(value any) []any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
+
+// resolve returns every value path refers to, flattening multi-valued
+// attributes so that "emails.value" yields one entry per email.
+func resolve(resource map[string]any, path AttributePath) []any {
+	values := elements(lookup(resource, path.Name))
+	if path.SubAttribute == "" {
+		return values
+	}
+
+	var subValues []any
+	for _, v := range values {
+		if m, ok := v.(map[string]any); ok {
+			subValues = append(subValues, elements(lookup(m, path.SubAttribute))...)
+		}
+	}
+	return subValues
+}
+
+func present(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+func compare(actual any, operator string, expected any) bool {
+	switch want := expected.(type) {
+	case nil:
+		switch operator {
+		case OperatorEqual:
+			return actual == nil
+		case OperatorNotEqual:
+			return actual != nil
+		}
+	case bool:
+		got, ok := actual.(bool)
+		switch operator {
+		case OperatorEqual:
+			return ok && got == want
+		case OperatorNotEqual:
+			return !ok || got != want
+		}
+	case float64:
+		got, ok := actual.(float64)
+		if !ok {
+			return operator == OperatorNotEqual
+		}
+		switch operator {
+		case OperatorEqual:
+			return got == want
+		case OperatorNotEqual:
+			return got != want
+		case OperatorGreaterThan:
+			return got > want
+		case OperatorGreaterOrEqual:
+			return got >= want
+		case OperatorLessThan:
+			return got < want
+		case OperatorLessOrEqual:
+			return got <= want
+		}
+	case string:
+		got, ok := actual.(string)
+		if !ok {
+			return operator == OperatorNotEqual
+		}
+		got, want = strings.ToLower(got), strings.ToLower(want)
+		switch operator {
+		case OperatorEqual:
+			return got == want
+		case OperatorNotEqual:
+			return got != want
+		case OperatorContains:
+			return strings.Contains(got, want)
+		case OperatorStartsWith:
+			return strings.HasPrefix(got, want)
+		case OperatorEndsWith:
+			return strings.HasSuffix(got, want)
+		case OperatorGreaterThan:
+			return got > want
+		case OperatorGreaterOrEqual:
+			return got >= want
+		case OperatorLessThan:
+			return got < want
+		case OperatorLessOrEqual:
+			return got <= want
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,407 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidFilter wraps every reason a filter or attribute path is rejected.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+func invalidFilter(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidFilter, fmt.Sprintf(format, args...))
+}
+
+// Comparison operators of RFC 7644 section 3.4.2.2.
+const (
+	OperatorEqual          = "eq"
+	OperatorNotEqual       = "ne"
+	OperatorContains       = "co"
+	OperatorStartsWith     = "sw"
+	OperatorEndsWith       = "ew"
+	OperatorPresent        = "pr"
+	OperatorGreaterThan    = "gt"
+	OperatorGreaterOrEqual = "ge"
+	OperatorLessThan       = "lt"
+	OperatorLessOrEqual    = "le"
+)
+
+var comparisonOperators = map[string]bool{
+	OperatorEqual:          true,
+	OperatorNotEqual:       true,
+	OperatorContains:       true,
+	OperatorStartsWith:     true,
+	OperatorEndsWith:       true,
+	OperatorGreaterThan:    true,
+	OperatorGreaterOrEqual: true,
+	OperatorLessThan:       true,
+	OperatorLessOrEqual:    true,
+}
+
+// maxFilterDepth bounds the nesting of parsed filters.
+const maxFilterDepth = 32
+
+// Expression is a parsed filter: one of *AttributeExpression,
+// *LogicalExpression, *NotExpression or *ValuePathExpression.
+type Expression interface {
+	expression()
+}
+
+// AttributePath names an attribute, optionally qualified by its schema URN
+// and narrowed to a sub-attribute, e.g. "name.givenName".
+type AttributePath struct {
+	URN          string
+	Name         string
+	SubAttribute string
+}
+
+// Is reports whether the path refers to name, given as "attribute" or
+// "attribute.subAttribute". Attribute names are case insensitive.
+func (p AttributePath) Is(name string) bool {
+	attribute, sub, _ := strings.Cut(name, ".")
+	return strings.EqualFold(p.Name, attribute) && strings.EqualFold(p.SubAttribute, sub)
+}
+
+func (p AttributePath) String() string {
+	s := p.Name
+	if p.SubAttribute != "" {
+		s += "." + p.SubAttribute
+	}
+	if p.URN != "" {
+		s = p.URN + ":" + s
+	}
+	return s
+}
+
+// AttributeExpression compares an attribute with Value, which is a string,
+// float64, bool or nil. Value is unused for the "pr" operator.
+type AttributeExpression struct {
+	Path     AttributePath
+	Operator string
+	Value    any
+}
+
+// LogicalExpression combines two filters with "and" or "or".
+type LogicalExpression struct {
+	Operator string
+	Left     Expression
+	Right    Expression
+}
+
+type NotExpression struct {
+	Expression Expression
+}
+
+// ValuePathExpression matches when an element of the multi-valued attribute
+// Path satisfies Filter, e.g. emails[type eq "work"].
+type ValuePathExpression struct {
+	Path   AttributePath
+	Filter Expression
+}
+
+func (*AttributeExpression) expression() {}
+func (*LogicalExpression) expression()   {}
+func (*NotExpression) expression()       {}
+func (*ValuePathExpression) expression() {}
+
+// ParseFilter parses the value of a filter query parameter.
+func ParseFilter(filter string) (Expression, error) {
+	p, err := newParser(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.done() {
+		return nil, invalidFilter("unexpected %q", p.peek().text)
+	}
+
+	return expr, nil
+}
+
+// ParseAttributePath parses a plain attribute path such as
+// "urn:ietf:params:scim:schemas:core:2.0:User:name.givenName".
+func ParseAttributePath(path string) (AttributePath, error) {
+	var p AttributePath
+
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		i := strings.LastIndex(path, ":")
+		p.URN, path = path[:i], path[i+1:]
+	}
+
+	p.Name, p.SubAttribute, _ = strings.Cut(path, ".")
+
+	if !validAttributeName(p.Name) || (p.SubAttribute != "" && !validAttributeName(p.SubAttribute)) {
+		return AttributePath{}, invalidFilter("invalid attribute path %q", path)
+	}
+
+	return p, nil
+}
+
+// validAttributeName checks ATTRNAME of RFC 7644: ALPHA *(nameChar), where
+// nameChar is "-", "_", DIGIT or ALPHA. "$ref" is allowed as well.
+func validAttributeName(name string) bool {
+	if name == "$ref" {
+		return true
+	}
+	if name == "" || !isAlpha(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		c := name[i]
+		if !isAlpha(c) && !(c >= '0' && c <= '9') && c != '-' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func newParser(s string) (*parser, error) {
+	var tokens []token
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpenParen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenCloseParen, text: ")"})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{kind: tokenOpenBracket, text: "["})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{kind: tokenCloseBracket, text: "]"})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, invalidFilter("unterminated string")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(s[i:end+1]), &value); err != nil {
+				return nil, invalidFilter("invalid string %s", s[i:end+1])
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value})
+			i = end + 1
+		default:
+			end := i
+			for end < len(s) && !strings.ContainsRune(" \t\n\r()[]\"", rune(s[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: s[i:end]})
+			i = end
+		}
+	}
+
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{kind: -1, text: "end of filter"}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) peekKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	if t := p.next(); t.kind != kind {
+		return invalidFilter("expected %q, got %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr(depth int) (Expression, error) {
+	if depth > maxFilterDepth {
+		return nil, invalidFilter("filter nested too deeply")
+	}
+
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekKeyword("or") {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalExpression{Operator: "or", Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (Expression, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekKeyword("and") {
+		p.next()
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalExpression{Operator: "and", Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary(depth int) (Expression, error) {
+	if p.peekKeyword("not") {
+		p.next()
+		if err := p.expect(tokenOpenParen, "("); err != nil {
+			return nil, err
+		}
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseParen, ")"); err != nil {
+			return nil, err
+		}
+		return &NotExpression{Expression: expr}, nil
+	}
+
+	if p.peek().kind == tokenOpenParen {
+		p.next()
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseParen, ")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+
+	return p.parseAttribute(depth)
+}
+
+func (p *parser) parseAttribute(depth int) (Expression, error) {
+	t := p.next()
+	if t.kind != tokenWord {
+		return nil, invalidFilter("expected attribute path, got %q", t.text)
+	}
+
+	path, err := ParseAttributePath(t.text)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind == tokenOpenBracket {
+		if path.SubAttribute != "" {
+			return nil, invalidFilter("unexpected [ after %q", t.text)
+		}
+		p.next()
+		filter, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseBracket, "]"); err != nil {
+			return nil, err
+		}
+		return &ValuePathExpression{Path: path, Filter: filter}, nil
+	}
+
+	op := p.next()
+	operator := strings.ToLower(op.text)
+
+	if op.kind == tokenWord && operator == OperatorPresent {
+		return &AttributeExpression{Path: path, Operator: OperatorPresent}, nil
+	}
+
+	if op.kind != tokenWord || !comparisonOperators[operator] {
+		return nil, invalidFilter("unknown operator %q", op.text)
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AttributeExpression{Path: path, Operator: operator, Value: value}, nil
+}
+
+func (p *parser) parseValue() (any, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenWord:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		var number float64
+		if err := json.Unmarshal([]byte(t.text), &number); err == nil {
+			return number, nil
+		}
+	}
+
+	return nil, invalidFilter("invalid comparison value %q", t.text)
+}
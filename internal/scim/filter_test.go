@@ -0,0 +1,253 @@
+//go:build auth
+// +build auth
+
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   Expression
+	}{
+		{
+			name:   "equality",
+			filter: `userName eq "bjensen@example.com"`,
+			want:   &AttributeExpression{Path: AttributePath{Name: "userName"}, Operator: "eq", Value: "bjensen@example.com"},
+		},
+		{
+			name:   "operator is case insensitive",
+			filter: `userName EQ "bjensen"`,
+			want:   &AttributeExpression{Path: AttributePath{Name: "userName"}, Operator: "eq", Value: "bjensen"},
+		},
+		{
+			name:   "present",
+			filter: `title pr`,
+			want:   &AttributeExpression{Path: AttributePath{Name: "title"}, Operator: "pr"},
+		},
+		{
+			name:   "sub-attribute and boolean",
+			filter: `name.familyName co "O'Malley" and active eq true`,
+			want: &LogicalExpression{
+				Operator: "and",
+				Left:     &AttributeExpression{Path: AttributePath{Name: "name", SubAttribute: "familyName"}, Operator: "co", Value: "O'Malley"},
+				Right:    &AttributeExpression{Path: AttributePath{Name: "active"}, Operator: "eq", Value: true},
+			},
+		},
+		{
+			name:   "schema qualified",
+			filter: `urn:ietf:params:scim:schemas:core:2.0:User:userName sw "J"`,
+			want:   &AttributeExpression{Path: AttributePath{URN: SchemaUser, Name: "userName"}, Operator: "sw", Value: "J"},
+		},
+		{
+			name:   "and binds tighter than or",
+			filter: `a eq 1 or b eq 2 and c eq null`,
+			want: &LogicalExpression{
+				Operator: "or",
+				Left:     &AttributeExpression{Path: AttributePath{Name: "a"}, Operator: "eq", Value: 1.0},
+				Right: &LogicalExpression{
+					Operator: "and",
+					Left:     &AttributeExpression{Path: AttributePath{Name: "b"}, Operator: "eq", Value: 2.0},
+					Right:    &AttributeExpression{Path: AttributePath{Name: "c"}, Operator: "eq", Value: nil},
+				},
+			},
+		},
+		{
+			name:   "not and grouping",
+			filter: `not (a eq "x" or b eq "y")`,
+			want: &NotExpression{Expression: &LogicalExpression{
+				Operator: "or",
+				Left:     &AttributeExpression{Path: AttributePath{Name: "a"}, Operator: "eq", Value: "x"},
+				Right:    &AttributeExpression{Path: AttributePath{Name: "b"}, Operator: "eq", Value: "y"},
+			}},
+		},
+		{
+			name:   "value path",
+			filter: `emails[type eq "work" and value co "@example.com"]`,
+			want: &ValuePathExpression{
+				Path: AttributePath{Name: "emails"},
+				Filter: &LogicalExpression{
+					Operator: "and",
+					Left:     &AttributeExpression{Path: AttributePath{Name: "type"}, Operator: "eq", Value: "work"},
+					Right:    &AttributeExpression{Path: AttributePath{Name: "value"}, Operator: "co", Value: "@example.com"},
+				},
+			},
+		},
+		{
+			name:   "escaped string",
+			filter: `displayName eq "say \"hi\""`,
+			want:   &AttributeExpression{Path: AttributePath{Name: "displayName"}, Operator: "eq", Value: `say "hi"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	filters := []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName like "x"`,
+		`userName eq "unterminated`,
+		`userName eq bare`,
+		`(userName eq "x"`,
+		`userName eq "x")`,
+		`emails[type eq "work"`,
+		`1name eq "x"`,
+		`not userName eq "x"`,
+		`userName eq "x" and`,
+	}
+
+	for _, filter := range filters {
+		t.Run(filter, func(t *testing.T) {
+			_, err := ParseFilter(filter)
+			if !errors.Is(err, ErrInvalidFilter) {
+				t.Errorf("expected ErrInvalidFilter, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	var resource map[string]any
+	err := json.Unmarshal([]byte(`{
+		"userName": "BJensen@example.com",
+		"active": true,
+		"name": {"familyName": "Jensen"},
+		"emails": [
+			{"value": "bjensen@example.com", "type": "work"},
+			{"value": "babs@home.example", "type": "home"}
+		],
+		"members": []
+	}`), &resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{`username eq "bjensen@example.com"`, true},
+		{`userName ne "bjensen@example.com"`, false},
+		{`userName sw "bj"`, true},
+		{`userName ew ".com"`, true},
+		{`name.familyName co "ens"`, true},
+		{`active eq false`, false},
+		{`emails.value eq "babs@home.example"`, true},
+		{`emails[type eq "work" and value ew "home.example"]`, false},
+		{`emails[type eq "home" and value ew "home.example"]`, true},
+		{`title pr`, false},
+		{`members pr`, false},
+		{`userName pr and not (active eq false)`, true},
+		{`title eq null`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			expr, err := ParseFilter(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Matches(expr, resource); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath(`members[value eq "42"].display`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Path{
+		Attribute:    AttributePath{Name: "members"},
+		Filter:       &AttributeExpression{Path: AttributePath{Name: "value"}, Operator: "eq", Value: "42"},
+		SubAttribute: "display",
+	}
+	if !reflect.DeepEqual(path, want) {
+		t.Errorf("got %#v, want %#v", path, want)
+	}
+
+	for _, invalid := range []string{``, `members[`, `members]`, `name.givenName[value eq "x"]`, `members[value eq "x"] extra`} {
+		if _, err := ParsePath(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestPatchRequest_Validate(t *testing.T) {
+	t.Run("normalises op", func(t *testing.T) {
+		r := PatchRequest{
+			Schemas:    []string{SchemaPatchOp},
+			Operations: []PatchOperation{{Op: "Replace", Value: json.RawMessage(`{"active":false}`)}},
+		}
+		if err := r.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if r.Operations[0].Op != PatchReplace {
+			t.Errorf("got op %q", r.Operations[0].Op)
+		}
+	})
+
+	invalid := []PatchRequest{
+		{Operations: []PatchOperation{{Op: "add", Value: json.RawMessage(`1`)}}},
+		{Schemas: []string{SchemaPatchOp}},
+		{Schemas: []string{SchemaPatchOp}, Operations: []PatchOperation{{Op: "move", Path: "x"}}},
+		{Schemas: []string{SchemaPatchOp}, Operations: []PatchOperation{{Op: "remove"}}},
+		{Schemas: []string{SchemaPatchOp}, Operations: []PatchOperation{{Op: "add", Path: "x"}}},
+	}
+	for i, r := range invalid {
+		err := r.Validate()
+		if AsError(err) == nil || AsError(err).StatusCode() != 400 {
+			t.Errorf("case %d: expected a 400 SCIM error, got %v", i, err)
+		}
+	}
+}
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    Page
+		wantErr bool
+	}{
+		{query: "", want: Page{StartIndex: 1, Count: 100}},
+		{query: "startIndex=11&count=5", want: Page{StartIndex: 11, Count: 5}},
+		{query: "startIndex=0&count=-3", want: Page{StartIndex: 1, Count: 0}},
+		{query: "count=5000", want: Page{StartIndex: 1, Count: 200}},
+		{query: "count=ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			got, err := ParsePage(query, 100, 200)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// PATCH operations of RFC 7644 section 3.5.2.
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+)
+
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Path is the target of a PATCH operation, e.g. "members",
+// "name.givenName" or `emails[type eq "work"].value`.
+type Path struct {
+	Attribute    AttributePath
+	Filter       Expression
+	SubAttribute string
+}
+
+// Validate checks the request envelope and normalises each op to lower case,
+// since some provisioning clients send "Replace" or "Add".
+func (r *PatchRequest) Validate() error {
+	if !hasSchema(r.Schemas, SchemaPatchOp) {
+		return NewError(http.StatusBadRequest, ErrorInvalidSyntax, "Request must use the PatchOp schema")
+	}
+
+	if len(r.Operations) == 0 {
+		return NewError(http.StatusBadRequest, ErrorInvalidSyntax, "Request must contain at least one operation")
+	}
+
+	for i := range r.Operations {
+		op := &r.Operations[i]
+		op.Op = strings.ToLower(op.Op)
+
+		switch op.Op {
+		case PatchAdd, PatchReplace:
+			if len(op.Value) == 0 {
+				return NewError(http.StatusBadRequest, ErrorInvalidValue, "Operation "+op.Op+" requires a value")
+			}
+		case PatchRemove:
+			if op.Path == "" {
+				return NewError(http.StatusBadRequest, ErrorNoTarget, "Operation remove requires a path")
+			}
+		default:
+			return NewError(http.StatusBadRequest, ErrorInvalidSyntax, "Unknown operation "+op.Op)
+		}
+	}
+
+	return nil
+}
+
+// ParsePath parses the path of a PATCH operation.
+func ParsePath(path string) (*Path, error) {
+	p, err := newParser(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.next()
+	if t.kind != tokenWord {
+		return nil, invalidFilter("invalid path %q", path)
+	}
+
+	attribute, err := ParseAttributePath(t.text)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Path{Attribute: attribute}
+
+	if p.peek().kind == tokenOpenBracket {
+		if attribute.SubAttribute != "" {
+			return nil, invalidFilter("invalid path %q", path)
+		}
+		p.next()
+		result.Filter, err = p.parseOr(1)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokenCloseBracket, "]"); err != nil {
+			return nil, err
+		}
+		if t := p.peek(); t.kind == tokenWord && strings.HasPrefix(t.text, ".") {
+			p.next()
+			result.SubAttribute = t.text[1:]
+			if !validAttributeName(result.SubAttribute) {
+				return nil, invalidFilter("invalid path %q", path)
+			}
+		}
+	}
+
+	if !p.done() {
+		return nil, invalidFilter("invalid path %q", path)
+	}
+
+	return result, nil
+}
+
+func hasSchema(schemas []string, schema string) bool {
+	for _, s := range schemas {
+		if strings.EqualFold(s, schema) {
+			return true
+		}
+	}
+	return false
+}
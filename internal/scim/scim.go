@@ -0,0 +1,187 @@
+// Package scim implements the protocol pieces of SCIM 2.0 (RFC 7643 and
+// RFC 7644) that provisioning endpoints share: message schemas, the filter
+// language, PATCH operations, pagination and error responses. Mapping
+// resources onto the domain is left to the service exposing them.
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ContentType = "application/scim+json"
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// Values of the scimType member of error responses.
+const (
+	ErrorInvalidFilter = "invalidFilter"
+	ErrorInvalidSyntax = "invalidSyntax"
+	ErrorInvalidPath   = "invalidPath"
+	ErrorInvalidValue  = "invalidValue"
+	ErrorNoTarget      = "noTarget"
+	ErrorMutability    = "mutability"
+	ErrorUniqueness    = "uniqueness"
+	ErrorTooMany       = "tooMany"
+)
+
+// Error is the body of a SCIM error response. It doubles as a Go error so
+// that validation failures can carry their status and scimType upwards.
+type Error struct {
+	Schemas []string `json:"schemas"`
+	Status  string   `json:"status"`
+	Type    string   `json:"scimType,omitempty"`
+	Detail  string   `json:"detail,omitempty"`
+}
+
+func NewError(status int, scimType, detail string) *Error {
+	return &Error{
+		Schemas: []string{SchemaError},
+		Status:  strconv.Itoa(status),
+		Type:    scimType,
+		Detail:  detail,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("scim %s (%s): %s", e.Status, e.Type, e.Detail)
+	}
+	return fmt.Sprintf("scim %s: %s", e.Status, e.Detail)
+}
+
+// StatusCode returns the HTTP status of the error.
+func (e *Error) StatusCode() int {
+	status, err := strconv.Atoi(e.Status)
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+// AsError converts err into an *Error, mapping filter errors to 400
+// invalidFilter. It returns nil for any other error.
+func AsError(err error) *Error {
+	var scimErr *Error
+	switch {
+	case errors.As(err, &scimErr):
+		return scimErr
+	case errors.Is(err, ErrInvalidFilter):
+		return NewError(http.StatusBadRequest, ErrorInvalidFilter, err.Error())
+	default:
+		return nil
+	}
+}
+
+// WriteJSON writes data with the SCIM media type.
+func WriteJSON(w http.ResponseWriter, status int, data any, headers http.Header) error {
+	js, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	js = append(js, '\n')
+
+	for key, value := range headers {
+		w.Header()[key] = value
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(status)
+	w.Write(js)
+
+	return nil
+}
+
+// WriteError writes err as a SCIM error response.
+func WriteError(w http.ResponseWriter, err *Error) error {
+	return WriteJSON(w, err.StatusCode(), err, nil)
+}
+
+type Meta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+	Version      string     `json:"version,omitempty"`
+}
+
+type ListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    any      `json:"Resources"`
+}
+
+func NewListResponse(resources any, totalResults int, page Page, itemsPerPage int) *ListResponse {
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: totalResults,
+		StartIndex:   page.StartIndex,
+		ItemsPerPage: itemsPerPage,
+		Resources:    resources,
+	}
+}
+
+// Page is a 1-based window over a result set, as requested by the
+// startIndex and count query parameters.
+type Page struct {
+	StartIndex int
+	Count      int
+}
+
+// Offset returns the number of results to skip.
+func (p Page) Offset() int {
+	return p.StartIndex - 1
+}
+
+// ParsePage reads startIndex and count from query. Values below 1 for
+// startIndex are treated as 1 and negative counts as 0, as RFC 7644 asks;
+// count defaults to defaultCount and is capped at maxCount.
+func ParsePage(query url.Values, defaultCount, maxCount int) (Page, error) {
+	page := Page{StartIndex: 1, Count: defaultCount}
+
+	if s := query.Get("startIndex"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return Page{}, NewError(http.StatusBadRequest, ErrorInvalidValue, "startIndex must be an integer")
+		}
+		page.StartIndex = max(i, 1)
+	}
+
+	if s := query.Get("count"); s != "" {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return Page{}, NewError(http.StatusBadRequest, ErrorInvalidValue, "count must be an integer")
+		}
+		page.Count = min(max(i, 0), maxCount)
+	}
+
+	return page, nil
+}
+
+// ExcludesAttribute reports whether the excludedAttributes query parameter
+// names attribute.
+func ExcludesAttribute(query url.Values, attribute string) bool {
+	for _, a := range strings.Split(query.Get("excludedAttributes"), ",") {
+		if path, err := ParseAttributePath(strings.TrimSpace(a)); err == nil && path.Is(attribute) {
+			return true
+		}
+	}
+	return false
+}
@@ -7,12 +7,15 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 )
 
 type Helpers interface {
 	ReadIDParam(request *http.Request) (int64, error)
 	ReadString(qs url.Values, key string, defaultValue string) string
 	ReadInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int
+	ReadBool(qs url.Values, key string, v *validator.Validator) *bool
+	ReadTime(qs url.Values, key string, layout string, v *validator.Validator) time.Time
 }
 
 type helpers struct{}
@@ -57,3 +60,35 @@ func (h *helpers) ReadInt(qs url.Values, key string, defaultValue int, v *valida
 
 	return i
 }
+
+func (h *helpers) ReadBool(qs url.Values, key string, v *validator.Validator) *bool {
+	s := qs.Get(key)
+
+	if s == "" {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		v.AddFieldError(key, "must be a boolean value")
+		return nil
+	}
+
+	return &b
+}
+
+func (h *helpers) ReadTime(qs url.Values, key string, layout string, v *validator.Validator) time.Time {
+	s := qs.Get(key)
+
+	if s == "" {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		v.AddFieldError(key, "must be a date in the format "+layout)
+		return time.Time{}
+	}
+
+	return t
+}
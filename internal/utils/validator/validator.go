@@ -1,8 +1,9 @@
 package validator
 
 type Validator struct {
-	Errors      []string          `json:",omitempty"`
-	FieldErrors map[string]string `json:",omitempty"`
+	Errors       []string          `json:",omitempty"`
+	FieldErrors  map[string]string `json:",omitempty"`
+	FieldDetails map[string]any    `json:",omitempty"`
 }
 
 func (v *Validator) HasErrors() bool {
@@ -27,6 +28,16 @@ func (v *Validator) AddFieldError(key, message string) {
 	}
 }
 
+// AddFieldDetail attaches extra information about a field's error, such as
+// hints on how to fix it, to the validation response.
+func (v *Validator) AddFieldDetail(key string, detail any) {
+	if v.FieldDetails == nil {
+		v.FieldDetails = map[string]any{}
+	}
+
+	v.FieldDetails[key] = detail
+}
+
 func (v *Validator) Check(ok bool, message string) {
 	if !ok {
 		v.AddError(message)
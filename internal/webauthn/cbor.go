@@ -0,0 +1,148 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// maxCBORDepth bounds the nesting of decoded CBOR values. Attestation
+// objects and COSE keys are at most three levels deep.
+const maxCBORDepth = 16
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+// decodeCBOR decodes the first CBOR data item in data and returns it together
+// with the bytes that follow it. Only the subset of CBOR used by WebAuthn is
+// supported: integers come back as int64, byte strings as []byte, text strings
+// as string, arrays as []any and maps as map[any]any keyed by int64 or string.
+// Indefinite lengths, tags and floating point values are rejected.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if depth > maxCBORDepth {
+		return nil, nil, errors.New("cbor: nesting too deep")
+	}
+
+	if len(data) == 0 {
+		return nil, nil, errCBORTruncated
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+	data = data[1:]
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		default:
+			return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", info)
+		}
+	}
+
+	arg, data, err := decodeCBORArgument(info, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return int64(arg), data, nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return -1 - int64(arg), data, nil
+	case 2, 3:
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBORTruncated
+		}
+		value := data[:arg]
+		if major == 3 {
+			return string(value), data[arg:], nil
+		}
+		return append([]byte{}, value...), data[arg:], nil
+	case 4:
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBORTruncated
+		}
+		items := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var item any
+			item, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, data, nil
+	case 5:
+		if arg > uint64(len(data)) {
+			return nil, nil, errCBORTruncated
+		}
+		entries := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			var key, value any
+			key, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("cbor: unsupported map key type %T", key)
+			}
+			if _, found := entries[key]; found {
+				return nil, nil, fmt.Errorf("cbor: duplicate map key %v", key)
+			}
+			value, data, err = decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			entries[key] = value
+		}
+		return entries, data, nil
+	default:
+		return nil, nil, fmt.Errorf("cbor: unsupported major type %d", major)
+	}
+}
+
+func decodeCBORArgument(info byte, data []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), data, nil
+	case info == 24:
+		if len(data) < 1 {
+			return 0, nil, errCBORTruncated
+		}
+		return uint64(data[0]), data[1:], nil
+	case info == 25:
+		if len(data) < 2 {
+			return 0, nil, errCBORTruncated
+		}
+		return uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26:
+		if len(data) < 4 {
+			return 0, nil, errCBORTruncated
+		}
+		return uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27:
+		if len(data) < 8 {
+			return 0, nil, errCBORTruncated
+		}
+		return binary.BigEndian.Uint64(data), data[8:], nil
+	default:
+		return 0, nil, fmt.Errorf("cbor: unsupported additional information %d", info)
+	}
+}
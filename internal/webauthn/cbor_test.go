@@ -0,0 +1,56 @@
+//go:build auth
+// +build auth
+
+package webauthn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    any
+		rest    []byte
+		wantErr bool
+	}{
+		{name: "small integer", data: []byte{0x17}, want: int64(23)},
+		{name: "one byte integer", data: []byte{0x18, 0xff}, want: int64(255)},
+		{name: "negative integer", data: []byte{0x26}, want: int64(-7)},
+		{name: "two byte negative integer", data: []byte{0x39, 0x01, 0x00}, want: int64(-257)},
+		{name: "byte string with trailing data", data: []byte{0x42, 0x01, 0x02, 0xff}, want: []byte{1, 2}, rest: []byte{0xff}},
+		{name: "text string", data: []byte{0x63, 'f', 'm', 't'}, want: "fmt"},
+		{name: "array", data: []byte{0x82, 0x01, 0xf5}, want: []any{int64(1), true}},
+		{name: "map", data: []byte{0xa2, 0x01, 0x02, 0x20, 0x40}, want: map[any]any{int64(1): int64(2), int64(-1): []byte{}}},
+		{name: "truncated byte string", data: []byte{0x45, 0x01}, wantErr: true},
+		{name: "indefinite length", data: []byte{0x5f}, wantErr: true},
+		{name: "duplicate map key", data: []byte{0xa2, 0x01, 0x01, 0x01, 0x02}, wantErr: true},
+		{name: "float", data: []byte{0xf9, 0x3c, 0x00}, wantErr: true},
+		{name: "tag", data: []byte{0xc0, 0x01}, wantErr: true},
+		{name: "empty", data: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rest, err := decodeCBOR(tt.data)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeCBOR() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCBOR() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeCBOR() = %#v, want %#v", got, tt.want)
+			}
+			if len(rest) != len(tt.rest) {
+				t.Errorf("rest = %x, want %x", rest, tt.rest)
+			}
+		})
+	}
+}
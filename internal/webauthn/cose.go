@@ -0,0 +1,114 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers accepted for credential keys, in order of
+// preference.
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms is offered to authenticators as pubKeyCredParams.
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+const (
+	coseKeyType  int64 = 1
+	coseKeyAlg   int64 = 3
+	coseCurve    int64 = -1
+	coseX        int64 = -2
+	coseY        int64 = -3
+	coseModulus  int64 = -1
+	coseExponent int64 = -2
+
+	coseKeyTypeOKP int64 = 1
+	coseKeyTypeEC2 int64 = 2
+	coseKeyTypeRSA int64 = 3
+
+	coseCurveP256    int64 = 1
+	coseCurveEd25519 int64 = 6
+)
+
+// publicKey is a credential public key decoded from its COSE_Key form.
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key and returns it with any bytes that follow.
+func parsePublicKey(data []byte) (*publicKey, []byte, error) {
+	value, rest, err := decodeCBOR(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, ok := value.(map[any]any)
+	if !ok {
+		return nil, nil, errors.New("cose: key is not a map")
+	}
+
+	kty, _ := entries[coseKeyType].(int64)
+	alg, _ := entries[coseKeyAlg].(int64)
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgES256:
+		curve, _ := entries[coseCurve].(int64)
+		x, _ := entries[coseX].([]byte)
+		y, _ := entries[coseY].([]byte)
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, nil, errors.New("cose: invalid P-256 key")
+		}
+		uncompressed := append(append([]byte{4}, x...), y...)
+		if _, err := ecdh.P256().NewPublicKey(uncompressed); err != nil {
+			return nil, nil, fmt.Errorf("cose: invalid P-256 key: %w", err)
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return &publicKey{alg: alg, key: key}, rest, nil
+	case kty == coseKeyTypeOKP && alg == AlgEdDSA:
+		curve, _ := entries[coseCurve].(int64)
+		x, _ := entries[coseX].([]byte)
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, nil, errors.New("cose: invalid Ed25519 key")
+		}
+		return &publicKey{alg: alg, key: ed25519.PublicKey(x)}, rest, nil
+	case kty == coseKeyTypeRSA && alg == AlgRS256:
+		n, _ := entries[coseModulus].([]byte)
+		e, _ := entries[coseExponent].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, nil, errors.New("cose: invalid RSA key")
+		}
+		exponent := new(big.Int).SetBytes(e)
+		return &publicKey{alg: alg, key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}}, rest, nil
+	default:
+		return nil, nil, fmt.Errorf("cose: unsupported key type %d with algorithm %d", kty, alg)
+	}
+}
+
+// verify checks signature over message with the algorithm the key was
+// registered for.
+func (p *publicKey) verify(message, signature []byte) bool {
+	switch key := p.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, message, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(message)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
@@ -0,0 +1,511 @@
+// Package webauthn implements the relying party side of the WebAuthn
+// registration and authentication ceremonies
+// (https://www.w3.org/TR/webauthn-2/). Attestation is not used to decide
+// which authenticators to trust: "none" and "packed" statements are
+// accepted, and a packed statement only has to be signed consistently.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+	flagExtensions   = 0x80
+)
+
+// ErrInvalidResponse wraps every reason a ceremony response is rejected.
+var ErrInvalidResponse = errors.New("invalid webauthn response")
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidResponse, fmt.Sprintf(format, args...))
+}
+
+// URLEncodedBase64 is binary data that travels in JSON as unpadded base64url,
+// the encoding browsers use for WebAuthn buffers.
+type URLEncodedBase64 []byte
+
+func (b URLEncodedBase64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *URLEncodedBase64) UnmarshalJSON(data []byte) error {
+	var encoded string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(trimPadding(encoded))
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+	return nil
+}
+
+func trimPadding(s string) string {
+	for len(s) > 0 && s[len(s)-1] == '=' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// User identifies the account a credential is created for. ID is the user
+// handle stored on the authenticator; it must not contain personal data.
+type User struct {
+	ID          URLEncodedBase64 `json:"id"`
+	Name        string           `json:"name"`
+	DisplayName string           `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string           `json:"type"`
+	ID         URLEncodedBase64 `json:"id"`
+	Transports []string         `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey,omitempty"`
+	UserVerification string `json:"userVerification,omitempty"`
+}
+
+// CredentialCreationOptions is passed, after decoding its buffers, to
+// navigator.credentials.create({publicKey: options}).
+type CredentialCreationOptions struct {
+	RelyingParty           RelyingPartyEntity     `json:"rp"`
+	User                   User                   `json:"user"`
+	Challenge              URLEncodedBase64       `json:"challenge"`
+	Parameters             []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// CredentialRequestOptions is passed, after decoding its buffers, to
+// navigator.credentials.get({publicKey: options}).
+type CredentialRequestOptions struct {
+	Challenge        URLEncodedBase64       `json:"challenge"`
+	Timeout          int64                  `json:"timeout,omitempty"`
+	RelyingPartyID   string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification,omitempty"`
+}
+
+// AttestationResponse is the PublicKeyCredential returned by
+// navigator.credentials.create, with its buffers base64url encoded.
+type AttestationResponse struct {
+	ID       string           `json:"id"`
+	RawID    URLEncodedBase64 `json:"rawId"`
+	Type     string           `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+		AttestationObject URLEncodedBase64 `json:"attestationObject"`
+		Transports        []string         `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the PublicKeyCredential returned by
+// navigator.credentials.get, with its buffers base64url encoded.
+type AssertionResponse struct {
+	ID       string           `json:"id"`
+	RawID    URLEncodedBase64 `json:"rawId"`
+	Type     string           `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+		AuthenticatorData URLEncodedBase64 `json:"authenticatorData"`
+		Signature         URLEncodedBase64 `json:"signature"`
+		UserHandle        URLEncodedBase64 `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// Challenge returns the challenge the authenticator signed, so the caller
+// can look up the ceremony it belongs to before verifying the response.
+func (r *AttestationResponse) Challenge() ([]byte, error) {
+	return challengeOf(r.Response.ClientDataJSON)
+}
+
+// Challenge returns the challenge the authenticator signed, so the caller
+// can look up the ceremony it belongs to before verifying the response.
+func (r *AssertionResponse) Challenge() ([]byte, error) {
+	return challengeOf(r.Response.ClientDataJSON)
+}
+
+// Credential is a verified public key credential as it should be stored.
+type Credential struct {
+	ID         []byte
+	PublicKey  []byte
+	SignCount  uint32
+	Transports []string
+	AAGUID     []byte
+}
+
+// RelyingParty verifies ceremonies for one relying party ID, accepting
+// responses from any of origins.
+type RelyingParty struct {
+	id               string
+	name             string
+	origins          []string
+	userVerification string
+	timeout          time.Duration
+}
+
+func New(id, name string, origins []string, userVerification string, timeout time.Duration) *RelyingParty {
+	if userVerification == "" {
+		userVerification = UserVerificationPreferred
+	}
+
+	return &RelyingParty{
+		id:               id,
+		name:             name,
+		origins:          origins,
+		userVerification: userVerification,
+		timeout:          timeout,
+	}
+}
+
+// CreationOptions returns the options for registering a new credential for
+// user. Credentials in exclude are already registered and will be refused by
+// authenticators holding them.
+func (rp *RelyingParty) CreationOptions(challenge []byte, user User, exclude []CredentialDescriptor) *CredentialCreationOptions {
+	parameters := make([]CredentialParameter, 0, len(SupportedAlgorithms))
+	for _, alg := range SupportedAlgorithms {
+		parameters = append(parameters, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+
+	return &CredentialCreationOptions{
+		RelyingParty:       RelyingPartyEntity{ID: rp.id, Name: rp.name},
+		User:               user,
+		Challenge:          challenge,
+		Parameters:         parameters,
+		Timeout:            rp.timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: rp.userVerification,
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions returns the options for signing in with one of allow.
+func (rp *RelyingParty) RequestOptions(challenge []byte, allow []CredentialDescriptor) *CredentialRequestOptions {
+	return &CredentialRequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.timeout.Milliseconds(),
+		RelyingPartyID:   rp.id,
+		AllowCredentials: allow,
+		UserVerification: rp.userVerification,
+	}
+}
+
+// FinishRegistration verifies an attestation response to the given challenge
+// and returns the credential it creates.
+func (rp *RelyingParty) FinishRegistration(response *AttestationResponse, challenge []byte) (*Credential, error) {
+	if response.Type != "public-key" {
+		return nil, invalid("unexpected credential type %q", response.Type)
+	}
+
+	err := rp.verifyClientData(response.Response.ClientDataJSON, "webauthn.create", challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	value, _, err := decodeCBOR(response.Response.AttestationObject)
+	if err != nil {
+		return nil, invalid("malformed attestation object: %v", err)
+	}
+
+	object, ok := value.(map[any]any)
+	if !ok {
+		return nil, invalid("malformed attestation object")
+	}
+
+	format, _ := object["fmt"].(string)
+	statement, _ := object["attStmt"].(map[any]any)
+	rawAuthData, _ := object["authData"].([]byte)
+	if statement == nil {
+		return nil, invalid("missing attestation statement")
+	}
+
+	authData, err := rp.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if authData.credential == nil {
+		return nil, invalid("attested credential data missing")
+	}
+
+	if !bytes.Equal(authData.credentialID, response.RawID) {
+		return nil, invalid("credential ID does not match")
+	}
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)
+
+	switch format {
+	case "none":
+		if len(statement) != 0 {
+			return nil, invalid("unexpected statement for none attestation")
+		}
+	case "packed":
+		err = verifyPackedAttestation(statement, signed, authData.credential)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, invalid("unsupported attestation format %q", format)
+	}
+
+	return &Credential{
+		ID:         authData.credentialID,
+		PublicKey:  authData.credentialKey,
+		SignCount:  authData.signCount,
+		Transports: response.Response.Transports,
+		AAGUID:     authData.aaguid,
+	}, nil
+}
+
+// FinishLogin verifies an assertion response to the given challenge made with
+// credential, and returns the authenticator's new signature counter. The
+// counter must be stored so a cloned authenticator can be detected.
+func (rp *RelyingParty) FinishLogin(response *AssertionResponse, challenge []byte, credential *Credential) (uint32, error) {
+	if response.Type != "public-key" {
+		return 0, invalid("unexpected credential type %q", response.Type)
+	}
+
+	if !bytes.Equal(response.RawID, credential.ID) {
+		return 0, invalid("credential ID does not match")
+	}
+
+	err := rp.verifyClientData(response.Response.ClientDataJSON, "webauthn.get", challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	authData, err := rp.parseAuthenticatorData(response.Response.AuthenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	key, _, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), response.Response.AuthenticatorData...), clientDataHash[:]...)
+
+	if !key.verify(signed, response.Response.Signature) {
+		return 0, invalid("signature verification failed")
+	}
+
+	// Authenticators that keep a counter must increase it on every use; a
+	// counter that goes backwards means the private key has been copied.
+	if (authData.signCount != 0 || credential.SignCount != 0) && authData.signCount <= credential.SignCount {
+		return 0, invalid("signature counter did not increase")
+	}
+
+	return authData.signCount, nil
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+func challengeOf(clientDataJSON []byte) ([]byte, error) {
+	var data clientData
+
+	err := json.Unmarshal(clientDataJSON, &data)
+	if err != nil {
+		return nil, invalid("malformed client data: %v", err)
+	}
+
+	challenge, err := base64.RawURLEncoding.DecodeString(trimPadding(data.Challenge))
+	if err != nil {
+		return nil, invalid("malformed challenge: %v", err)
+	}
+
+	return challenge, nil
+}
+
+func (rp *RelyingParty) verifyClientData(clientDataJSON []byte, ceremony string, challenge []byte) error {
+	var data clientData
+
+	err := json.Unmarshal(clientDataJSON, &data)
+	if err != nil {
+		return invalid("malformed client data: %v", err)
+	}
+
+	if data.Type != ceremony {
+		return invalid("unexpected client data type %q", data.Type)
+	}
+
+	signedChallenge, err := base64.RawURLEncoding.DecodeString(trimPadding(data.Challenge))
+	if err != nil || subtle.ConstantTimeCompare(signedChallenge, challenge) != 1 {
+		return invalid("challenge does not match")
+	}
+
+	for _, origin := range rp.origins {
+		if data.Origin == origin {
+			return nil
+		}
+	}
+
+	return invalid("unexpected origin %q", data.Origin)
+}
+
+type authenticatorData struct {
+	flags         byte
+	signCount     uint32
+	aaguid        []byte
+	credentialID  []byte
+	credentialKey []byte
+	credential    *publicKey
+}
+
+func (rp *RelyingParty) parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, invalid("authenticator data too short")
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rp.id))
+	if subtle.ConstantTimeCompare(data[:32], rpIDHash[:]) != 1 {
+		return nil, invalid("relying party ID does not match")
+	}
+
+	authData := &authenticatorData{
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	if authData.flags&flagUserPresent == 0 {
+		return nil, invalid("user not present")
+	}
+
+	if rp.userVerification == UserVerificationRequired && authData.flags&flagUserVerified == 0 {
+		return nil, invalid("user not verified")
+	}
+
+	rest := data[37:]
+
+	if authData.flags&flagAttested != 0 {
+		if len(rest) < 18 {
+			return nil, invalid("attested credential data too short")
+		}
+
+		authData.aaguid = append([]byte(nil), rest[:16]...)
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+
+		if idLength == 0 || idLength > 1023 || len(rest) < idLength {
+			return nil, invalid("invalid credential ID length")
+		}
+
+		authData.credentialID = append([]byte(nil), rest[:idLength]...)
+		rest = rest[idLength:]
+
+		key, after, err := parsePublicKey(rest)
+		if err != nil {
+			return nil, invalid("%v", err)
+		}
+
+		authData.credential = key
+		authData.credentialKey = append([]byte(nil), rest[:len(rest)-len(after)]...)
+		rest = after
+	}
+
+	if authData.flags&flagExtensions != 0 {
+		var err error
+		_, rest, err = decodeCBOR(rest)
+		if err != nil {
+			return nil, invalid("malformed extensions: %v", err)
+		}
+	}
+
+	if len(rest) != 0 {
+		return nil, invalid("trailing bytes in authenticator data")
+	}
+
+	return authData, nil
+}
+
+func verifyPackedAttestation(statement map[any]any, signed []byte, credential *publicKey) error {
+	alg, _ := statement["alg"].(int64)
+	signature, _ := statement["sig"].([]byte)
+	if len(signature) == 0 {
+		return invalid("packed attestation without signature")
+	}
+
+	chain, found := statement["x5c"].([]any)
+	if !found {
+		// Self attestation is signed with the credential key itself.
+		if alg != credential.alg {
+			return invalid("attestation algorithm does not match credential")
+		}
+		if !credential.verify(signed, signature) {
+			return invalid("attestation signature verification failed")
+		}
+		return nil
+	}
+
+	if len(chain) == 0 {
+		return invalid("empty attestation certificate chain")
+	}
+
+	der, _ := chain[0].([]byte)
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return invalid("malformed attestation certificate: %v", err)
+	}
+
+	var algorithm x509.SignatureAlgorithm
+	switch alg {
+	case AlgES256:
+		algorithm = x509.ECDSAWithSHA256
+	case AlgEdDSA:
+		algorithm = x509.PureEd25519
+	case AlgRS256:
+		algorithm = x509.SHA256WithRSA
+	default:
+		return invalid("unsupported attestation algorithm %d", alg)
+	}
+
+	err = certificate.CheckSignature(algorithm, signed, signature)
+	if err != nil {
+		return invalid("attestation signature verification failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+//go:build auth
+// +build auth
+
+package webauthn_test
+
+import (
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/webauthn"
+	"github.com/jessicatarra/greenlight/internal/webauthn/webauthntest"
+	"testing"
+	"time"
+)
+
+const (
+	rpID   = "auth.example.com"
+	origin = "https://auth.example.com"
+)
+
+var challenge = []byte("GQRPVONORIEUPDJ6V4RTDIVSTQ")
+
+func register(t *testing.T, rp *webauthn.RelyingParty, authenticator *webauthntest.Authenticator) *webauthn.Credential {
+	t.Helper()
+
+	options := rp.CreationOptions(challenge, webauthn.User{ID: []byte{0, 0, 0, 0, 0, 0, 0, 1}, Name: "john@example.com"}, nil)
+
+	response, err := authenticator.Create(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	credential, err := rp.FinishRegistration(response, challenge)
+	if err != nil {
+		t.Fatalf("FinishRegistration() error = %v", err)
+	}
+
+	return credential
+}
+
+func login(rp *webauthn.RelyingParty, authenticator *webauthntest.Authenticator, credential *webauthn.Credential) (uint32, error) {
+	options := rp.RequestOptions(challenge, []webauthn.CredentialDescriptor{{Type: "public-key", ID: credential.ID}})
+
+	response, err := authenticator.Get(options)
+	if err != nil {
+		return 0, err
+	}
+
+	return rp.FinishLogin(response, challenge, credential)
+}
+
+func TestRelyingParty_Ceremonies(t *testing.T) {
+	rp := webauthn.New(rpID, "Greenlight", []string{origin}, webauthn.UserVerificationRequired, time.Minute)
+
+	t.Run("register and sign in", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+		credential := register(t, rp, authenticator)
+
+		if len(credential.ID) == 0 || len(credential.PublicKey) == 0 {
+			t.Fatalf("incomplete credential: %+v", credential)
+		}
+
+		for want := uint32(1); want <= 2; want++ {
+			signCount, err := login(rp, authenticator, credential)
+			if err != nil {
+				t.Fatalf("FinishLogin() error = %v", err)
+			}
+			if signCount != want {
+				t.Errorf("signCount = %d, want %d", signCount, want)
+			}
+			credential.SignCount = signCount
+		}
+	})
+
+	t.Run("challenge read from response", func(t *testing.T) {
+		options := rp.CreationOptions(challenge, webauthn.User{ID: []byte{1}, Name: "john@example.com"}, nil)
+		response, err := webauthntest.New(origin).Create(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := response.Challenge()
+		if err != nil || string(got) != string(challenge) {
+			t.Errorf("Challenge() = %q, %v", got, err)
+		}
+	})
+
+	t.Run("wrong origin", func(t *testing.T) {
+		options := rp.CreationOptions(challenge, webauthn.User{ID: []byte{1}, Name: "john@example.com"}, nil)
+		response, err := webauthntest.New("https://evil.example.com").Create(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = rp.FinishRegistration(response, challenge)
+		if !errors.Is(err, webauthn.ErrInvalidResponse) {
+			t.Errorf("FinishRegistration() error = %v, want ErrInvalidResponse", err)
+		}
+	})
+
+	t.Run("wrong challenge", func(t *testing.T) {
+		options := rp.CreationOptions(challenge, webauthn.User{ID: []byte{1}, Name: "john@example.com"}, nil)
+		response, err := webauthntest.New(origin).Create(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = rp.FinishRegistration(response, []byte("ZZZZVONORIEUPDJ6V4RTDIVSTQ"))
+		if !errors.Is(err, webauthn.ErrInvalidResponse) {
+			t.Errorf("FinishRegistration() error = %v, want ErrInvalidResponse", err)
+		}
+	})
+
+	t.Run("credential for another relying party", func(t *testing.T) {
+		other := webauthn.New("evil.example.com", "Evil", []string{origin}, webauthn.UserVerificationPreferred, time.Minute)
+		authenticator := webauthntest.New(origin)
+		credential := register(t, other, authenticator)
+
+		_, err := login(rp, authenticator, credential)
+		if err == nil {
+			t.Error("expected sign-in to fail")
+		}
+	})
+
+	t.Run("user verification required", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+		authenticator.SkipUserVerification = true
+
+		options := rp.CreationOptions(challenge, webauthn.User{ID: []byte{1}, Name: "john@example.com"}, nil)
+		response, err := authenticator.Create(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = rp.FinishRegistration(response, challenge)
+		if !errors.Is(err, webauthn.ErrInvalidResponse) {
+			t.Errorf("FinishRegistration() error = %v, want ErrInvalidResponse", err)
+		}
+	})
+
+	t.Run("tampered signature", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+		credential := register(t, rp, authenticator)
+
+		options := rp.RequestOptions(challenge, []webauthn.CredentialDescriptor{{Type: "public-key", ID: credential.ID}})
+		response, err := authenticator.Get(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Response.Signature[len(response.Response.Signature)-1] ^= 0xff
+
+		_, err = rp.FinishLogin(response, challenge, credential)
+		if !errors.Is(err, webauthn.ErrInvalidResponse) {
+			t.Errorf("FinishLogin() error = %v, want ErrInvalidResponse", err)
+		}
+	})
+
+	t.Run("cloned authenticator", func(t *testing.T) {
+		authenticator := webauthntest.New(origin)
+		credential := register(t, rp, authenticator)
+		clone := authenticator.Clone()
+
+		signCount, err := login(rp, authenticator, credential)
+		if err != nil {
+			t.Fatal(err)
+		}
+		credential.SignCount = signCount
+
+		_, err = login(rp, clone, credential)
+		if !errors.Is(err, webauthn.ErrInvalidResponse) {
+			t.Errorf("FinishLogin() error = %v, want ErrInvalidResponse", err)
+		}
+	})
+}
@@ -0,0 +1,262 @@
+// Package webauthntest provides a software authenticator for exercising
+// WebAuthn ceremonies end to end in tests.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/webauthn"
+	"sort"
+)
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+// Authenticator is a platform authenticator that keeps ES256 keys in memory.
+// It plays the part of both the browser and the authenticator: it builds the
+// client data for Origin and answers with "none" attestation.
+type Authenticator struct {
+	Origin string
+	// SkipUserVerification makes the authenticator report that it checked
+	// user presence only.
+	SkipUserVerification bool
+
+	credentials []*credential
+}
+
+type credential struct {
+	id         []byte
+	rpID       string
+	userHandle []byte
+	key        *ecdsa.PrivateKey
+	signCount  uint32
+}
+
+func New(origin string) *Authenticator {
+	return &Authenticator{Origin: origin}
+}
+
+// Clone returns an authenticator holding copies of the same private keys and
+// counters, as an attacker who extracted them would.
+func (a *Authenticator) Clone() *Authenticator {
+	clone := *a
+	clone.credentials = nil
+	for _, c := range a.credentials {
+		copied := *c
+		clone.credentials = append(clone.credentials, &copied)
+	}
+	return &clone
+}
+
+// Create performs navigator.credentials.create with options.
+func (a *Authenticator) Create(options *webauthn.CredentialCreationOptions) (*webauthn.AttestationResponse, error) {
+	supported := false
+	for _, parameter := range options.Parameters {
+		if parameter.Type == "public-key" && parameter.Alg == webauthn.AlgES256 {
+			supported = true
+		}
+	}
+	if !supported {
+		return nil, errors.New("webauthntest: ES256 not offered")
+	}
+
+	for _, excluded := range options.ExcludeCredentials {
+		if a.find(options.RelyingParty.ID, excluded.ID) != nil {
+			return nil, errors.New("webauthntest: credential already registered")
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 32)
+	_, err = rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &credential{id: id, rpID: options.RelyingParty.ID, userHandle: options.User.ID, key: key}
+	a.credentials = append(a.credentials, c)
+
+	authData := a.authenticatorData(c, flagAttested)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(id)))
+	authData = append(authData, id...)
+	authData = append(authData, encodeCOSEKey(&key.PublicKey)...)
+
+	attestationObject := encodeCBOR(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": authData,
+	})
+
+	response := &webauthn.AttestationResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(id),
+		RawID: id,
+		Type:  "public-key",
+	}
+	response.Response.ClientDataJSON = a.clientData("webauthn.create", options.Challenge)
+	response.Response.AttestationObject = attestationObject
+	response.Response.Transports = []string{"internal"}
+
+	return response, nil
+}
+
+// Get performs navigator.credentials.get with options.
+func (a *Authenticator) Get(options *webauthn.CredentialRequestOptions) (*webauthn.AssertionResponse, error) {
+	var c *credential
+	if len(options.AllowCredentials) == 0 {
+		for _, candidate := range a.credentials {
+			if candidate.rpID == options.RelyingPartyID {
+				c = candidate
+				break
+			}
+		}
+	}
+	for _, allowed := range options.AllowCredentials {
+		if c = a.find(options.RelyingPartyID, allowed.ID); c != nil {
+			break
+		}
+	}
+	if c == nil {
+		return nil, errors.New("webauthntest: no matching credential")
+	}
+
+	c.signCount++
+
+	authData := a.authenticatorData(c, 0)
+	clientData := a.clientData("webauthn.get", options.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, c.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	response := &webauthn.AssertionResponse{
+		ID:    base64.RawURLEncoding.EncodeToString(c.id),
+		RawID: c.id,
+		Type:  "public-key",
+	}
+	response.Response.ClientDataJSON = clientData
+	response.Response.AuthenticatorData = authData
+	response.Response.Signature = signature
+	response.Response.UserHandle = c.userHandle
+
+	return response, nil
+}
+
+func (a *Authenticator) find(rpID string, id []byte) *credential {
+	for _, c := range a.credentials {
+		if c.rpID == rpID && string(c.id) == string(id) {
+			return c
+		}
+	}
+	return nil
+}
+
+func (a *Authenticator) authenticatorData(c *credential, flags byte) []byte {
+	flags |= flagUserPresent
+	if !a.SkipUserVerification {
+		flags |= flagUserVerified
+	}
+
+	rpIDHash := sha256.Sum256([]byte(c.rpID))
+
+	data := append([]byte(nil), rpIDHash[:]...)
+	data = append(data, flags)
+	return binary.BigEndian.AppendUint32(data, c.signCount)
+}
+
+func (a *Authenticator) clientData(ceremony string, challenge []byte) []byte {
+	data, _ := json.Marshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	return data
+}
+
+func encodeCOSEKey(key *ecdsa.PublicKey) []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+
+	return encodeCBOR(map[int64]any{
+		1:  int64(2),
+		3:  webauthn.AlgES256,
+		-1: int64(1),
+		-2: x,
+		-3: y,
+	})
+}
+
+// encodeCBOR encodes the handful of types the authenticator produces.
+func encodeCBOR(value any) []byte {
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		out := cborHead(5, uint64(len(v)))
+		for _, key := range keys {
+			out = append(out, encodeCBOR(key)...)
+			out = append(out, encodeCBOR(v[key])...)
+		}
+		return out
+	case map[int64]any:
+		keys := make([]int64, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		out := cborHead(5, uint64(len(v)))
+		for _, key := range keys {
+			out = append(out, encodeCBOR(key)...)
+			out = append(out, encodeCBOR(v[key])...)
+		}
+		return out
+	default:
+		panic("webauthntest: cannot encode value")
+	}
+}
+
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, arg)
+	}
+}
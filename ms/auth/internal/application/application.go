@@ -1,163 +1,1463 @@
 package application
 
 import (
-	"github.com/jessicatarra/greenlight/internal/concurrent"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/jessicatarra/greenlight/internal/config"
 	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/internal/webauthn"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/infrastructure/repositories"
 	"github.com/pascaldekloe/jwt"
+	"net"
+	"net/url"
 	"strconv"
-	"sync"
+	"strings"
 	"time"
 )
 
+const (
+	defaultAuthenticationTTL  = 24 * time.Hour
+	defaultActivationTTL      = 3 * 24 * time.Hour
+	defaultMagicLinkTTL       = 15 * time.Minute
+	defaultInvitationTTL      = 7 * 24 * time.Hour
+	defaultImpersonationTTL   = 15 * time.Minute
+	defaultPasswordResetTTL   = 45 * time.Minute
+	defaultRevokeSessionsTTL  = 7 * 24 * time.Hour
+	defaultWebAuthnTTL        = 5 * time.Minute
+	defaultSessionTTL         = 24 * time.Hour
+	defaultDeviceCodeTTL      = 10 * time.Minute
+	defaultDevicePollInterval = 5 * time.Second
+)
+
+// impersonationPermission is the permission an admin needs to impersonate
+// users, checked again each time an impersonation token is used.
+const impersonationPermission = "users:admin"
+
 type appl struct {
-	userRepo       domain.UserRepository
-	tokenRepo      domain.TokenRepository
-	permissionRepo domain.PermissionRepository
-	concurrent     concurrent.Resource
-	mailer         mailer.Mailer
-	cfg            config.Config
+	userRepo                domain.UserRepository
+	tokenRepo               domain.TokenRepository
+	permissionRepo          domain.PermissionRepository
+	invitationRepo          domain.InvitationRepository
+	auditRepo               domain.AuditRepository
+	deviceRepo              domain.DeviceRepository
+	passkeyRepo             domain.PasskeyRepository
+	deviceAuthorizationRepo domain.DeviceAuthorizationRepository
+	outboxRepo              domain.OutboxRepository
+	bulkEmailRepo           domain.BulkEmailRepository
+	jobs                    domain.JobQueue
+	unitOfWork              domain.UnitOfWork
+	relyingParty            *webauthn.RelyingParty
+	cfg                     config.Config
+}
+
+// Deps are the repositories and services the use cases work through. Those
+// left nil must not be needed by the use cases called.
+type Deps struct {
+	Users                domain.UserRepository
+	Tokens               domain.TokenRepository
+	Permissions          domain.PermissionRepository
+	Invitations          domain.InvitationRepository
+	Audit                domain.AuditRepository
+	Devices              domain.DeviceRepository
+	Passkeys             domain.PasskeyRepository
+	DeviceAuthorizations domain.DeviceAuthorizationRepository
+	Outbox               domain.OutboxRepository
+	BulkEmails           domain.BulkEmailRepository
+	Jobs                 domain.JobQueue
+	UnitOfWork           domain.UnitOfWork
+}
+
+func NewAppl(deps Deps, cfg config.Config) domain.Appl {
+	if cfg.Tokens.AuthenticationTTL == 0 {
+		cfg.Tokens.AuthenticationTTL = defaultAuthenticationTTL
+	}
+	if cfg.Tokens.ActivationTTL == 0 {
+		cfg.Tokens.ActivationTTL = defaultActivationTTL
+	}
+	if cfg.Tokens.MagicLinkTTL == 0 {
+		cfg.Tokens.MagicLinkTTL = defaultMagicLinkTTL
+	}
+	if cfg.Tokens.InvitationTTL == 0 {
+		cfg.Tokens.InvitationTTL = defaultInvitationTTL
+	}
+	if cfg.Tokens.ImpersonationTTL == 0 {
+		cfg.Tokens.ImpersonationTTL = defaultImpersonationTTL
+	}
+	if cfg.Tokens.PasswordResetTTL == 0 {
+		cfg.Tokens.PasswordResetTTL = defaultPasswordResetTTL
+	}
+	if cfg.Tokens.RevokeSessionsTTL == 0 {
+		cfg.Tokens.RevokeSessionsTTL = defaultRevokeSessionsTTL
+	}
+	if cfg.Tokens.WebAuthnTTL == 0 {
+		cfg.Tokens.WebAuthnTTL = defaultWebAuthnTTL
+	}
+	if cfg.Tokens.DeviceCodeTTL == 0 {
+		cfg.Tokens.DeviceCodeTTL = defaultDeviceCodeTTL
+	}
+	if cfg.Tokens.DevicePollInterval == 0 {
+		cfg.Tokens.DevicePollInterval = defaultDevicePollInterval
+	}
+	if cfg.Sessions.TTL == 0 {
+		cfg.Sessions.TTL = defaultSessionTTL
+	}
+
+	// Passkeys are bound to the public host of the auth module unless
+	// configured otherwise.
+	if base, err := url.Parse(cfg.Public.BaseURL); err == nil && base.Host != "" {
+		if cfg.WebAuthn.RPID == "" {
+			cfg.WebAuthn.RPID = base.Hostname()
+		}
+		if len(cfg.WebAuthn.Origins) == 0 {
+			cfg.WebAuthn.Origins = []string{base.Scheme + "://" + base.Host}
+		}
+	}
+
+	return &appl{
+		userRepo:                deps.Users,
+		tokenRepo:               deps.Tokens,
+		permissionRepo:          deps.Permissions,
+		invitationRepo:          deps.Invitations,
+		auditRepo:               deps.Audit,
+		deviceRepo:              deps.Devices,
+		passkeyRepo:             deps.Passkeys,
+		deviceAuthorizationRepo: deps.DeviceAuthorizations,
+		outboxRepo:              deps.Outbox,
+		bulkEmailRepo:           deps.BulkEmails,
+		jobs:                    deps.Jobs,
+		unitOfWork:              deps.UnitOfWork,
+		relyingParty:            webauthn.New(cfg.WebAuthn.RPID, cfg.WebAuthn.RPName, cfg.WebAuthn.Origins, cfg.WebAuthn.UserVerification, cfg.Tokens.WebAuthnTTL),
+		cfg:                     cfg,
+	}
+}
+
+func (a *appl) CreateUseCase(ctx context.Context, input *domain.CreateUserRequest, hashedPassword string) (*domain.User, error) {
+	invitation, err := a.invitationForSignup(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &domain.User{Name: input.Name, Email: input.Email, Activated: invitation != nil, Locale: input.Locale}
+	if user.Locale == "" {
+		user.Locale = mailer.DefaultLocale
+	}
+
+	err = a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		err := repos.Users.InsertNewUser(ctx, user, hashedPassword)
+		if err != nil {
+			return err
+		}
+
+		codes := []string{"movies:read"}
+		if invitation != nil {
+			codes = append(codes, invitation.Permissions...)
+		}
+
+		err = repos.Permissions.AddForUser(ctx, user.ID, codes...)
+		if err != nil {
+			return err
+		}
+
+		if invitation != nil {
+			return repos.Invitations.DeleteAllForEmail(ctx, invitation.Email)
+		}
+
+		token, err := repos.Tokens.New(ctx, user.ID, a.cfg.Tokens.ActivationTTL, repositories.ScopeActivation)
+		if err != nil {
+			return err
+		}
+
+		return repos.Outbox.Insert(ctx, &domain.OutboxEmail{
+			Recipient: user.Email,
+			Locale:    user.Locale,
+			Template:  "user_welcome.gohtml",
+			Data: map[string]interface{}{
+				"activationToken": token.Plaintext,
+				"userID":          user.ID,
+				"expiry":          token.Expiry,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// NotifyRegistrationAttemptUseCase tells the owner of email that someone
+// tried to register it again, instead of telling whoever tried that the
+// address is taken.
+func (a *appl) NotifyRegistrationAttemptUseCase(ctx context.Context, email string) error {
+	user, err := a.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	return a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		return repos.Outbox.Insert(ctx, &domain.OutboxEmail{
+			Recipient: user.Email,
+			Locale:    user.Locale,
+			Template:  "user_registration_attempt.gohtml",
+			Data: map[string]interface{}{
+				"name": user.Name,
+			},
+		})
+	})
+}
+
+func (a *appl) ActivateUseCase(ctx context.Context, tokenPlainText string) (*domain.User, error) {
+	user, err := a.userRepo.GetForToken(ctx, repositories.ScopeActivation, tokenPlainText)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Activated = true
+
+	err = a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		err := repos.Users.UpdateUser(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return repos.Tokens.DeleteAllForUser(ctx, repositories.ScopeActivation, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (a *appl) GetByEmailUseCase(ctx context.Context, email string) (*domain.User, error) {
+	existingUser, err := a.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	return existingUser, nil
+}
+
+func (a *appl) CreateAuthTokenUseCase(ctx context.Context, userID int64) ([]byte, error) {
+	return a.signAuthToken(ctx, userID, a.cfg.Tokens.AuthenticationTTL, 0)
+}
+
+func (a *appl) ValidateAuthTokenUseCase(ctx context.Context, token string) (*domain.User, error) {
+	claims, err := jwt.HMACCheck([]byte(token), []byte(a.cfg.Jwt.Secret))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidClaims, err)
+	}
+
+	if !claims.Valid(time.Now()) {
+		return nil, domain.ErrInvalidClaims
+	}
+
+	if claims.Issuer != a.cfg.Auth.HttpBaseURL {
+		return nil, domain.ErrInvalidClaims
+	}
+
+	if !claims.AcceptAudience(a.cfg.Auth.HttpBaseURL) {
+		return nil, domain.ErrInvalidClaims
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, domain.ErrInvalidClaims
+	}
+
+	actorID, err := actorFromClaims(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.userRepo.GetUserById(ctx, int64(userID))
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Suspended {
+		return nil, domain.ErrAccountSuspended
+	}
+
+	if issuedBefore(claims, user.SessionsRevokedAt) {
+		return nil, domain.ErrRevokedToken
+	}
+
+	err = permissionsFromClaims(claims, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if actorID != 0 {
+		actor, err := a.userRepo.GetUserById(ctx, actorID)
+		if err != nil {
+			return nil, err
+		}
+
+		if actor.Suspended {
+			return nil, domain.ErrAccountSuspended
+		}
+
+		if issuedBefore(claims, actor.SessionsRevokedAt) {
+			return nil, domain.ErrRevokedToken
+		}
+
+		// The token only stands while its admin may still impersonate, so
+		// taking the permission away also ends the tokens already issued.
+		permissions, err := a.permissionRepo.GetAllForUser(ctx, actor.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !permissions.Include(impersonationPermission) {
+			return nil, domain.ErrRevokedToken
+		}
+
+		user.ActorID = actor.ID
+	}
+
+	return user, nil
+}
+
+// CreateSessionUseCase starts a cookie session for a user who has signed in.
+// The session is a token row, so it ends when it expires, when the user signs
+// out, or when they sign out everywhere.
+func (a *appl) CreateSessionUseCase(ctx context.Context, userID int64) (*domain.Token, error) {
+	return a.tokenRepo.New(ctx, userID, a.cfg.Sessions.TTL, repositories.ScopeSession)
+}
+
+func (a *appl) ValidateSessionUseCase(ctx context.Context, tokenPlaintext string) (*domain.User, error) {
+	user, err := a.userRepo.GetForToken(ctx, repositories.ScopeSession, tokenPlaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Suspended {
+		return nil, domain.ErrAccountSuspended
+	}
+
+	return user, nil
+}
+
+func (a *appl) DeleteSessionUseCase(ctx context.Context, tokenPlaintext string) error {
+	_, err := a.tokenRepo.Consume(ctx, repositories.ScopeSession, tokenPlaintext)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+func (a *appl) UserPermissionUseCase(ctx context.Context, code string, userID int64) error {
+	permissions, err := a.permissionRepo.GetAllForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !permissions.Include(code) {
+		return domain.ErrPermissionNotIncluded
+	}
+
+	return nil
+}
+
+func (a *appl) CreateInvitationUseCase(ctx context.Context, input *domain.CreateInvitationRequest, invitedBy int64) (*domain.Invitation, error) {
+	ttl := a.cfg.Tokens.InvitationTTL
+	if !input.Expiry.IsZero() {
+		ttl = time.Until(input.Expiry)
+	}
+
+	var invitation *domain.Invitation
+
+	err := a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		var err error
+
+		invitation, err = repos.Invitations.New(ctx, input.Email, input.Permissions, invitedBy, ttl)
+		if err != nil {
+			return err
+		}
+
+		return repos.Outbox.Insert(ctx, &domain.OutboxEmail{
+			Recipient: invitation.Email,
+			Locale:    input.Locale,
+			Template:  "user_invitation.gohtml",
+			Data: map[string]interface{}{
+				"invitationToken": invitation.Plaintext,
+				"email":           invitation.Email,
+				"expiry":          invitation.Expiry,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (a *appl) CreateMagicLinkUseCase(ctx context.Context, email string) error {
+	user, err := a.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	return a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		err := repos.Tokens.DeleteAllForUser(ctx, repositories.ScopeMagicLink, user.ID)
+		if err != nil {
+			return err
+		}
+
+		token, err := repos.Tokens.New(ctx, user.ID, a.cfg.Tokens.MagicLinkTTL, repositories.ScopeMagicLink)
+		if err != nil {
+			return err
+		}
+
+		return repos.Outbox.Insert(ctx, &domain.OutboxEmail{
+			Recipient: user.Email,
+			Locale:    user.Locale,
+			Template:  "user_magic_link.gohtml",
+			Data: map[string]interface{}{
+				"magicLinkToken": token.Plaintext,
+				"expiryMinutes":  int(a.cfg.Tokens.MagicLinkTTL.Minutes()),
+			},
+		})
+	})
+}
+
+func (a *appl) ExchangeMagicLinkUseCase(ctx context.Context, tokenPlaintext string) ([]byte, error) {
+	userID, err := a.tokenRepo.Consume(ctx, repositories.ScopeMagicLink, tokenPlaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Suspended {
+		return nil, domain.ErrAccountSuspended
+	}
+
+	if !user.Activated {
+		user.Activated = true
+
+		err = a.userRepo.UpdateUser(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		err = a.tokenRepo.DeleteAllForUser(ctx, repositories.ScopeActivation, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a.CreateAuthTokenUseCase(ctx, user.ID)
+}
+
+// CreatePasswordResetTokenUseCase emails a password reset token to the owner
+// of email. Unknown, unactivated and suspended accounts are silently skipped
+// so the response does not reveal which addresses are registered.
+func (a *appl) CreatePasswordResetTokenUseCase(ctx context.Context, email string) error {
+	user, err := a.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if !user.Activated || user.Suspended {
+		return nil
+	}
+
+	return a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		err := repos.Tokens.DeleteAllForUser(ctx, repositories.ScopePasswordReset, user.ID)
+		if err != nil {
+			return err
+		}
+
+		token, err := repos.Tokens.New(ctx, user.ID, a.cfg.Tokens.PasswordResetTTL, repositories.ScopePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		return repos.Outbox.Insert(ctx, &domain.OutboxEmail{
+			Recipient: user.Email,
+			Locale:    user.Locale,
+			Template:  "token_password_reset.gohtml",
+			Data: map[string]interface{}{
+				"passwordResetToken": token.Plaintext,
+				"expiryMinutes":      int(a.cfg.Tokens.PasswordResetTTL.Minutes()),
+			},
+		})
+	})
+}
+
+func (a *appl) GetByPasswordResetTokenUseCase(ctx context.Context, tokenPlaintext string) (*domain.User, error) {
+	return a.userRepo.GetForToken(ctx, repositories.ScopePasswordReset, tokenPlaintext)
+}
+
+func (a *appl) ResetPasswordUseCase(ctx context.Context, user *domain.User, hashedPassword string) error {
+	user.HashedPassword = hashedPassword
+
+	return a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		err := repos.Users.UpdateUser(ctx, user)
+		if err != nil {
+			return err
+		}
+
+		return repos.Tokens.DeleteAllForUser(ctx, repositories.ScopePasswordReset, user.ID)
+	})
+}
+
+// ChangePasswordUseCase replaces the password of a signed-in user. Any
+// outstanding reset tokens are revoked since the user evidently knows their
+// password.
+func (a *appl) ChangePasswordUseCase(ctx context.Context, user *domain.User, hashedPassword string) error {
+	return a.ResetPasswordUseCase(ctx, user, hashedPassword)
+}
+
+// RehashPasswordUseCase stores a new hash of a user's unchanged password,
+// such as one made with a newer pepper. Unlike a password change it leaves
+// the user's tokens alone.
+func (a *appl) RehashPasswordUseCase(ctx context.Context, user *domain.User, hashedPassword string) error {
+	user.HashedPassword = hashedPassword
+
+	return a.userRepo.UpdateUser(ctx, user)
+}
+
+// RecordSignInUseCase remembers the device a user signed in from and, when it
+// is not one they have used before, emails them a link to sign out everywhere.
+// The first device a user signs in from is remembered without an email. The
+// device and the email are recorded together, and the email is only sent by
+// the sign-in that recorded the device, so that concurrent sign-ins from a
+// new device report it once.
+func (a *appl) RecordSignInUseCase(ctx context.Context, user *domain.User, ip net.IP, userAgent string) error {
+	sensitivity := a.cfg.Devices.Sensitivity
+	if sensitivity == "" || sensitivity == domain.DeviceSensitivityOff {
+		return nil
+	}
+
+	device := domain.NewDevice(user.ID, ip, userAgent, sensitivity)
+
+	return a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		known, err := repos.Devices.Get(ctx, user.ID, device.Fingerprint)
+		if err == nil {
+			return repos.Devices.Touch(ctx, known)
+		}
+		if !errors.Is(err, domain.ErrRecordNotFound) {
+			return err
+		}
+
+		count, err := repos.Devices.CountForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		created, err := repos.Devices.Insert(ctx, device)
+		if err != nil {
+			return err
+		}
+
+		if count == 0 || !created {
+			return nil
+		}
+
+		token, err := repos.Tokens.New(ctx, user.ID, a.cfg.Tokens.RevokeSessionsTTL, repositories.ScopeRevokeSessions)
+		if err != nil {
+			return err
+		}
+
+		return repos.Outbox.Insert(ctx, &domain.OutboxEmail{
+			Recipient: user.Email,
+			Locale:    user.Locale,
+			Template:  "user_new_sign_in.gohtml",
+			Data: map[string]interface{}{
+				"name":                user.Name,
+				"ipPrefix":            device.IPPrefix,
+				"userAgent":           device.UserAgent,
+				"signedInAt":          device.CreatedAt,
+				"revokeSessionsToken": token.Plaintext,
+			},
+		})
+	})
+}
+
+// RevokeSessionsUseCase signs the owner of a revoke-sessions token out
+// everywhere: authentication tokens issued so far stop validating, cookie
+// sessions, pending magic links and other revoke links are deleted, and every
+// known device is forgotten so the next sign-in from each of them is reported
+// again.
+func (a *appl) RevokeSessionsUseCase(ctx context.Context, tokenPlaintext string) (*domain.User, error) {
+	userID, err := a.tokenRepo.Consume(ctx, repositories.ScopeRevokeSessions, tokenPlaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.userRepo.RevokeSessions(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, scope := range []string{repositories.ScopeMagicLink, repositories.ScopeRevokeSessions, repositories.ScopeSession} {
+		err = a.tokenRepo.DeleteAllForUser(ctx, scope, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = a.deviceRepo.DeleteAllForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.auditRepo.Insert(ctx, &domain.AuditEvent{
+		UserID:     userID,
+		Action:     "revoke_sessions",
+		Resource:   "users",
+		ResourceID: userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a.userRepo.GetUserById(ctx, userID)
+}
+
+// BeginPasskeyRegistrationUseCase starts registering a passkey for user. The
+// challenge is stored as a token so only the latest ceremony can complete.
+func (a *appl) BeginPasskeyRegistrationUseCase(ctx context.Context, user *domain.User) (*webauthn.CredentialCreationOptions, error) {
+	passkeys, err := a.passkeyRepo.GetAllForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		exclude = append(exclude, passkey.Descriptor())
+	}
+
+	err = a.tokenRepo.DeleteAllForUser(ctx, repositories.ScopeWebAuthnRegistration, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := a.tokenRepo.New(ctx, user.ID, a.cfg.Tokens.WebAuthnTTL, repositories.ScopeWebAuthnRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := webauthn.User{ID: domain.UserHandle(user.ID), Name: user.Email, DisplayName: user.Name}
+
+	return a.relyingParty.CreationOptions([]byte(token.Plaintext), owner, exclude), nil
+}
+
+// FinishPasskeyRegistrationUseCase verifies the authenticator's response to
+// the challenge issued to user and stores the new passkey. A challenge that
+// is unknown, expired or was issued to someone else yields
+// domain.ErrRecordNotFound.
+func (a *appl) FinishPasskeyRegistrationUseCase(ctx context.Context, user *domain.User, name string, response *webauthn.AttestationResponse) (*domain.Passkey, error) {
+	challenge, err := response.Challenge()
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := a.tokenRepo.Consume(ctx, repositories.ScopeWebAuthnRegistration, string(challenge))
+	if err != nil {
+		return nil, err
+	}
+
+	if userID != user.ID {
+		return nil, domain.ErrRecordNotFound
+	}
+
+	credential, err := a.relyingParty.FinishRegistration(response, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey := &domain.Passkey{
+		UserID:       user.ID,
+		CredentialID: credential.ID,
+		PublicKey:    credential.PublicKey,
+		SignCount:    credential.SignCount,
+		Transports:   credential.Transports,
+		AAGUID:       credential.AAGUID,
+		Name:         name,
+	}
+
+	err = a.passkeyRepo.Insert(ctx, passkey)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.auditRepo.Insert(ctx, &domain.AuditEvent{
+		UserID:     user.ID,
+		Action:     "register_passkey",
+		Resource:   "webauthn_credentials",
+		ResourceID: passkey.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return passkey, nil
+}
+
+// BeginPasskeyLoginUseCase starts a passkey sign-in for the user registered
+// with email. The options list no credentials, leaving the authenticator to
+// offer its passkeys for the relying party. When there is no such user or
+// they have no passkeys, the challenge is made up and never stored, so that
+// the options do not tell whether email has passkeys.
+func (a *appl) BeginPasskeyLoginUseCase(ctx context.Context, email string) (*webauthn.CredentialRequestOptions, error) {
+	user, err := a.userRepo.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var passkeys []*domain.Passkey
+	if user != nil {
+		passkeys, err = a.passkeyRepo.GetAllForUser(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var token *domain.Token
+	if len(passkeys) == 0 {
+		token, err = domain.NewToken().GenerateToken(0, a.cfg.Tokens.WebAuthnTTL, repositories.ScopeWebAuthnLogin)
+	} else {
+		token, err = a.tokenRepo.New(ctx, user.ID, a.cfg.Tokens.WebAuthnTTL, repositories.ScopeWebAuthnLogin)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return a.relyingParty.RequestOptions([]byte(token.Plaintext), nil), nil
+}
+
+// FinishPasskeyLoginUseCase verifies the authenticator's response to a sign-in
+// challenge and returns the user it authenticates. Responses to unknown or
+// expired challenges, or made with a passkey of another user, yield
+// domain.ErrRecordNotFound.
+func (a *appl) FinishPasskeyLoginUseCase(ctx context.Context, response *webauthn.AssertionResponse) (*domain.User, error) {
+	challenge, err := response.Challenge()
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := a.tokenRepo.Consume(ctx, repositories.ScopeWebAuthnLogin, string(challenge))
+	if err != nil {
+		return nil, err
+	}
+
+	passkey, err := a.passkeyRepo.GetByCredentialID(ctx, response.RawID)
+	if err != nil {
+		return nil, err
+	}
+
+	if passkey.UserID != userID {
+		return nil, domain.ErrRecordNotFound
+	}
+
+	if len(response.Response.UserHandle) != 0 && !bytes.Equal(response.Response.UserHandle, domain.UserHandle(userID)) {
+		return nil, domain.ErrRecordNotFound
+	}
+
+	signCount, err := a.relyingParty.FinishLogin(response, challenge, passkey.Credential())
+	if err != nil {
+		return nil, err
+	}
+
+	passkey.SignCount = signCount
+
+	err = a.passkeyRepo.UpdateSignCount(ctx, passkey)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Suspended {
+		return nil, domain.ErrAccountSuspended
+	}
+
+	return user, nil
 }
 
-func NewAppl(userRepo domain.UserRepository, tokenRepo domain.TokenRepository, permissionRepo domain.PermissionRepository, wg *sync.WaitGroup, cfg config.Config) domain.Appl {
-	return &appl{
-		userRepo:       userRepo,
-		tokenRepo:      tokenRepo,
-		permissionRepo: permissionRepo,
-		concurrent:     concurrent.NewBackgroundTask(wg),
-		mailer:         mailer.New(cfg.Smtp.Host, cfg.Smtp.Port, cfg.Smtp.Username, cfg.Smtp.Password, cfg.Smtp.From),
-		cfg:            cfg,
+// CreateDeviceAuthorizationUseCase starts an OAuth device authorization grant
+// for the client a living-room app identifies itself as.
+func (a *appl) CreateDeviceAuthorizationUseCase(ctx context.Context, clientID string) (*domain.DeviceAuthorization, error) {
+	// Expired authorizations are cleared here so their user codes can be
+	// handed out again.
+	err := a.deviceAuthorizationRepo.DeleteExpired(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.deviceAuthorizationRepo.New(ctx, clientID, a.cfg.Tokens.DeviceCodeTTL, a.cfg.Tokens.DevicePollInterval)
+}
+
+// VerifyDeviceAuthorizationUseCase records user's answer to the device that
+// showed them userCode. Once approved, the device's next poll receives an
+// authentication token for user.
+func (a *appl) VerifyDeviceAuthorizationUseCase(ctx context.Context, userCode string, user *domain.User, approved bool) error {
+	authorization, err := a.deviceAuthorizationRepo.GetByUserCode(ctx, userCode)
+	if err != nil {
+		return err
+	}
+
+	authorization.UserID = user.ID
+	authorization.Status = domain.DeviceAuthorizationDenied
+	if approved {
+		authorization.Status = domain.DeviceAuthorizationApproved
+	}
+
+	err = a.deviceAuthorizationRepo.Answer(ctx, authorization)
+	if err != nil {
+		return err
+	}
+
+	if !approved {
+		return nil
+	}
+
+	return a.auditRepo.Insert(ctx, &domain.AuditEvent{
+		UserID:     user.ID,
+		Action:     "approve_device",
+		Resource:   "device_authorizations",
+		ResourceID: authorization.ID,
+	})
+}
+
+// ExchangeDeviceCodeUseCase answers a device polling for the outcome of its
+// authorization, following RFC 8628. Until the user has answered it returns
+// domain.ErrAuthorizationPending, or domain.ErrSlowDown when the device polls
+// sooner than its interval allows, which also lengthens the interval by five
+// seconds. A denied authorization yields domain.ErrAccessDenied and an
+// expired one domain.ErrExpiredToken. Device codes that are unknown, already
+// redeemed or were issued to another client yield domain.ErrRecordNotFound.
+func (a *appl) ExchangeDeviceCodeUseCase(ctx context.Context, deviceCode string, clientID string) ([]byte, error) {
+	authorization, err := a.deviceAuthorizationRepo.GetByDeviceCode(ctx, deviceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if authorization.ClientID != clientID {
+		return nil, domain.ErrRecordNotFound
+	}
+
+	now := time.Now()
+
+	if !now.Before(authorization.Expiry) {
+		return nil, domain.ErrExpiredToken
+	}
+
+	switch authorization.Status {
+	case domain.DeviceAuthorizationApproved:
+		err = a.deviceAuthorizationRepo.Delete(ctx, authorization.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := a.userRepo.GetUserById(ctx, authorization.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		if user.Suspended {
+			return nil, domain.ErrAccountSuspended
+		}
+
+		return a.CreateAuthTokenUseCase(ctx, user.ID)
+	case domain.DeviceAuthorizationDenied:
+		err = a.deviceAuthorizationRepo.Delete(ctx, authorization.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, domain.ErrAccessDenied
+	}
+
+	pollErr := domain.ErrAuthorizationPending
+	if !authorization.LastPolledAt.IsZero() && now.Sub(authorization.LastPolledAt) < authorization.Interval {
+		authorization.Interval += 5 * time.Second
+		pollErr = domain.ErrSlowDown
+	}
+
+	authorization.LastPolledAt = now
+
+	err = a.deviceAuthorizationRepo.RecordPoll(ctx, authorization)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, err
 	}
+
+	// When the user answered since the authorization was read, the device
+	// learns the outcome on its next poll.
+	return nil, pollErr
+}
+
+func (a *appl) ListUsersUseCase(ctx context.Context, filter domain.UserFilter, filters domain.Filters) ([]*domain.User, domain.Metadata, error) {
+	return a.userRepo.GetAll(ctx, filter, filters)
 }
 
-func (a *appl) CreateUseCase(input *domain.CreateUserRequest, hashedPassword string) (*domain.User, error) {
-	user := &domain.User{Name: input.Name, Email: input.Email, Activated: false}
+func (a *appl) SuspendUserUseCase(ctx context.Context, userID int64) (*domain.User, error) {
+	var user *domain.User
+
+	err := a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		var err error
 
-	err := a.userRepo.InsertNewUser(user, hashedPassword)
+		user, err = setSuspended(ctx, repos.Users, userID, true)
+		if err != nil {
+			return err
+		}
 
+		return repos.Tokens.DeleteAllForUser(ctx, repositories.ScopeMagicLink, user.ID)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	err = a.permissionRepo.AddForUser(user.ID, "movies:read")
+	return user, nil
+}
+
+func (a *appl) ReactivateUserUseCase(ctx context.Context, userID int64) (*domain.User, error) {
+	return setSuspended(ctx, a.userRepo, userID, false)
+}
+
+func setSuspended(ctx context.Context, userRepo domain.UserRepository, userID int64, suspended bool) (*domain.User, error) {
+	user, err := userRepo.GetUserById(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	token, err := a.tokenRepo.New(user.ID, 3*24*time.Hour, repositories.ScopeActivation)
+	if user.Suspended == suspended {
+		return user, nil
+	}
+
+	user.Suspended = suspended
+
+	err = userRepo.UpdateUser(ctx, user)
 	if err != nil {
 		return nil, err
 	}
 
-	fn := func() error {
-		data := map[string]interface{}{
-			"activationToken": token.Plaintext,
-			"userID":          user.ID,
+	return user, nil
+}
+
+func (a *appl) SearchUsersUseCase(ctx context.Context, query domain.UserQuery, offset, limit int) ([]*domain.User, int, error) {
+	return a.userRepo.Search(ctx, query, offset, limit)
+}
+
+func (a *appl) GetUserUseCase(ctx context.Context, id int64) (*domain.User, error) {
+	return a.userRepo.GetUserById(ctx, id)
+}
+
+// ProvisionUserUseCase creates a user on behalf of a SCIM provisioning client.
+// The identity provider owns the account's lifecycle, so no activation email
+// is sent and the activation state is taken as given.
+func (a *appl) ProvisionUserUseCase(ctx context.Context, user *domain.User, hashedPassword string) error {
+	if user.Locale == "" {
+		user.Locale = mailer.DefaultLocale
+	}
+
+	return a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		err := repos.Users.InsertNewUser(ctx, user, hashedPassword)
+		if err != nil {
+			return err
+		}
+
+		err = repos.Permissions.AddForUser(ctx, user.ID, "movies:read")
+		if err != nil {
+			return err
+		}
+
+		// New rows are never suspended, so a user provisioned in that state
+		// needs a second write.
+		if user.Suspended {
+			return repos.Users.UpdateUser(ctx, user)
 		}
-		//print(token.Plaintext)
 
-		err = a.mailer.Send(user.Email, "user_welcome.gohtml", data)
+		return nil
+	})
+}
+
+// UpdateProvisionedUserUseCase stores changes made by a SCIM provisioning
+// client. Suspending a user this way revokes pending magic links, as
+// SuspendUserUseCase does.
+func (a *appl) UpdateProvisionedUserUseCase(ctx context.Context, user *domain.User) error {
+	return a.unitOfWork.Do(ctx, func(repos domain.Repositories) error {
+		err := repos.Users.UpdateUser(ctx, user)
 		if err != nil {
 			return err
 		}
+
+		if user.Suspended {
+			return repos.Tokens.DeleteAllForUser(ctx, repositories.ScopeMagicLink, user.ID)
+		}
+
 		return nil
+	})
+}
+
+func (a *appl) DeprovisionUserUseCase(ctx context.Context, id int64) error {
+	return a.userRepo.DeleteUser(ctx, id)
+}
+
+func (a *appl) ListGroupsUseCase(ctx context.Context, withMembers bool) ([]*domain.Group, error) {
+	permissions, err := a.permissionRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	a.concurrent.BackgroundTask(fn)
+	groups := make([]*domain.Group, 0, len(permissions))
+
+	for _, permission := range permissions {
+		group, err := a.group(ctx, permission, withMembers)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
 
-	return user, err
+	return groups, nil
 }
 
-func (a *appl) ActivateUseCase(tokenPlainText string) (*domain.User, error) {
-	user, err := a.userRepo.GetForToken(repositories.ScopeActivation, tokenPlainText)
+func (a *appl) GetGroupUseCase(ctx context.Context, id int64, withMembers bool) (*domain.Group, error) {
+	permission, err := a.permissionRepo.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	user.Activated = true
+	return a.group(ctx, permission, withMembers)
+}
 
-	err = a.userRepo.UpdateUser(user)
+// SetGroupMembersUseCase makes userIDs the exact set of users holding the
+// permission. It returns ErrRecordNotFound if the permission or any of the
+// users being added does not exist.
+func (a *appl) SetGroupMembersUseCase(ctx context.Context, id int64, userIDs []int64) (*domain.Group, error) {
+	permission, err := a.permissionRepo.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	err = a.tokenRepo.DeleteAllForUser(repositories.ScopeActivation, user.ID)
+	current, err := a.permissionRepo.GetUsers(ctx, permission.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return user, err
+	wanted := make(map[int64]bool, len(userIDs))
+	for _, userID := range userIDs {
+		wanted[userID] = true
+	}
+
+	var remove []int64
+	for _, user := range current {
+		if wanted[user.ID] {
+			delete(wanted, user.ID)
+		} else {
+			remove = append(remove, user.ID)
+		}
+	}
+
+	var add []int64
+	for _, userID := range userIDs {
+		if !wanted[userID] {
+			continue
+		}
+		delete(wanted, userID)
+
+		_, err := a.userRepo.GetUserById(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		add = append(add, userID)
+	}
+
+	if len(add) > 0 {
+		err = a.permissionRepo.AddUsers(ctx, permission.ID, add...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(remove) > 0 {
+		err = a.permissionRepo.RemoveUsers(ctx, permission.ID, remove...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a.group(ctx, permission, true)
+}
+
+func (a *appl) ListOutboxEmailsUseCase(ctx context.Context, status string, filters domain.Filters) ([]*domain.OutboxEmail, domain.Metadata, error) {
+	return a.outboxRepo.GetAll(ctx, status, filters)
+}
+
+// ReplayOutboxEmailUseCase queues a dead email for delivery again, for when
+// whatever made it fail has been fixed. Redacted emails cannot be replayed.
+func (a *appl) ReplayOutboxEmailUseCase(ctx context.Context, id int64) (*domain.OutboxEmail, error) {
+	return a.outboxRepo.Replay(ctx, id)
 }
 
-func (a *appl) GetByEmailUseCase(email string) (*domain.User, error) {
-	existingUser, err := a.userRepo.GetUserByEmail(email)
+// CreateBulkEmailUseCase records a bulk email and queues a job to send it.
+func (a *appl) CreateBulkEmailUseCase(ctx context.Context, input *domain.CreateBulkEmailRequest, createdBy int64) (*domain.BulkEmail, error) {
+	bulk := &domain.BulkEmail{
+		Template:  input.Template,
+		Filter:    input.Filter,
+		CreatedBy: createdBy,
+	}
+
+	err := a.bulkEmailRepo.Insert(ctx, bulk)
 	if err != nil {
 		return nil, err
 	}
 
-	return existingUser, nil
+	_, err = a.jobs.Enqueue(ctx, domain.BulkEmailJobKind, domain.BulkEmailJob{BulkEmailID: bulk.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	return bulk, nil
 }
 
-func (a *appl) CreateAuthTokenUseCase(userID int64) ([]byte, error) {
-	var claims jwt.Claims
-	claims.Subject = strconv.FormatInt(userID, 10)
-	claims.Issued = jwt.NewNumericTime(time.Now())
-	claims.NotBefore = jwt.NewNumericTime(time.Now())
-	claims.Expires = jwt.NewNumericTime(time.Now().Add(24 * time.Hour))
-	claims.Issuer = a.cfg.Auth.HttpBaseURL
-	claims.Audiences = []string{a.cfg.Auth.HttpBaseURL}
+func (a *appl) GetBulkEmailUseCase(ctx context.Context, id int64) (*domain.BulkEmail, error) {
+	return a.bulkEmailRepo.Get(ctx, id)
+}
 
-	jwtBytes, err := claims.HMACSign(jwt.HS256, []byte(a.cfg.Jwt.Secret))
+func (a *appl) ListBulkEmailFailuresUseCase(ctx context.Context, id int64, filters domain.Filters) ([]*domain.BulkEmailFailure, domain.Metadata, error) {
+	_, err := a.bulkEmailRepo.Get(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, domain.Metadata{}, err
 	}
 
-	return jwtBytes, nil
+	return a.bulkEmailRepo.GetFailures(ctx, id, filters)
 }
 
-func (a *appl) ValidateAuthTokenUseCase(token string) (*domain.User, error) {
-	claims, err := jwt.HMACCheck([]byte(token), []byte(a.cfg.Jwt.Secret))
+// ResumeBulkEmailUseCase queues another job to send a bulk email that has
+// not been completed, for when the one sending it gave up. A bulk email that
+// is still being sent is left to its job.
+func (a *appl) ResumeBulkEmailUseCase(ctx context.Context, id int64) (*domain.BulkEmail, error) {
+	bulk, err := a.bulkEmailRepo.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	if !claims.Valid(time.Now()) {
-		return nil, err
+	if bulk.Status == domain.BulkEmailCompleted {
+		return nil, domain.ErrBulkEmailCompleted
 	}
 
-	if claims.Issuer != a.cfg.Auth.HttpBaseURL {
+	_, err = a.jobs.Enqueue(ctx, domain.BulkEmailJobKind, domain.BulkEmailJob{BulkEmailID: bulk.ID})
+	if err != nil {
 		return nil, err
+	}
 
+	return bulk, nil
+}
+
+func (a *appl) group(ctx context.Context, permission *domain.Permission, withMembers bool) (*domain.Group, error) {
+	group := &domain.Group{Permission: *permission}
+
+	if withMembers {
+		members, err := a.permissionRepo.GetUsers(ctx, permission.ID)
+		if err != nil {
+			return nil, err
+		}
+		group.Members = members
 	}
 
-	if !claims.AcceptAudience(a.cfg.Auth.HttpBaseURL) {
+	return group, nil
+}
+
+func (a *appl) ImpersonateUseCase(ctx context.Context, userID int64, actorID int64) ([]byte, error) {
+	user, err := a.userRepo.GetUserById(ctx, userID)
+	if err != nil {
 		return nil, err
+	}
 
+	if user.Suspended {
+		return nil, domain.ErrAccountSuspended
 	}
 
-	userID, err := strconv.Atoi(claims.Subject)
+	jwtBytes, err := a.signAuthToken(ctx, user.ID, a.cfg.Tokens.ImpersonationTTL, actorID)
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := a.userRepo.GetUserById(int64(userID))
+	err = a.auditRepo.Insert(ctx, &domain.AuditEvent{
+		UserID:     user.ID,
+		ActorID:    actorID,
+		Action:     "impersonate",
+		Resource:   "users",
+		ResourceID: user.ID,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return user, nil
+	return jwtBytes, nil
 }
 
-func (a *appl) UserPermissionUseCase(code string, userID int64) error {
-	permissions, err := a.permissionRepo.GetAllForUser(userID)
+// IntrospectTokenUseCase describes any token the module issues in RFC 7662
+// terms. Tokens that are unknown, expired or belong to a suspended account are
+// reported as inactive rather than as errors.
+func (a *appl) IntrospectTokenUseCase(ctx context.Context, tokenPlaintext string) (*domain.Introspection, error) {
+	if strings.Count(tokenPlaintext, ".") == 2 {
+		return a.introspectAuthToken(ctx, tokenPlaintext)
+	}
+
+	token, err := a.tokenRepo.Get(ctx, tokenPlaintext)
 	if err != nil {
-		return err
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			return a.introspectInvitation(ctx, tokenPlaintext)
+		default:
+			return nil, err
+		}
 	}
-	if !permissions.Include(code) {
-		return domain.ErrPermissionNotIncluded
+
+	user, err := a.userRepo.GetUserById(ctx, token.UserID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			return &domain.Introspection{Active: false}, nil
+		default:
+			return nil, err
+		}
+	}
+
+	if user.Suspended {
+		return &domain.Introspection{Active: false}, nil
+	}
+
+	return &domain.Introspection{
+		Active:   true,
+		Scope:    token.Scope,
+		Username: user.Email,
+		Exp:      token.Expiry.Unix(),
+		Sub:      strconv.FormatInt(user.ID, 10),
+	}, nil
+}
+
+func (a *appl) introspectAuthToken(ctx context.Context, tokenPlaintext string) (*domain.Introspection, error) {
+	claims, err := jwt.HMACCheck([]byte(tokenPlaintext), []byte(a.cfg.Jwt.Secret))
+	if err != nil {
+		return &domain.Introspection{Active: false}, nil
+	}
+
+	user, err := a.ValidateAuthTokenUseCase(ctx, tokenPlaintext)
+	if err != nil {
+		switch {
+		case domain.IsInvalidToken(err), errors.Is(err, domain.ErrAccountSuspended):
+			return &domain.Introspection{Active: false}, nil
+		default:
+			return nil, err
+		}
+	}
+
+	permissions := user.Permissions
+	if permissions == nil {
+		permissions, err = a.permissionRepo.GetAllForUser(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	introspection := &domain.Introspection{
+		Active:   true,
+		Scope:    strings.Join(permissions, " "),
+		Username: user.Email,
+		Exp:      claims.Expires.Time().Unix(),
+		Iat:      claims.Issued.Time().Unix(),
+		Sub:      claims.Subject,
+	}
+
+	if user.ActorID != 0 {
+		introspection.Act = &domain.IntrospectionActor{Sub: strconv.FormatInt(user.ActorID, 10)}
+	}
+
+	return introspection, nil
+}
+
+func (a *appl) introspectInvitation(ctx context.Context, tokenPlaintext string) (*domain.Introspection, error) {
+	invitation, err := a.invitationRepo.GetForToken(ctx, tokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			return &domain.Introspection{Active: false}, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return &domain.Introspection{
+		Active:   true,
+		Scope:    repositories.ScopeInvitation,
+		Username: invitation.Email,
+		Exp:      invitation.Expiry.Unix(),
+		Iat:      invitation.CreatedAt.Unix(),
+	}, nil
+}
+
+// signAuthToken issues a JWT for userID. A non-zero actorID adds an RFC 8693
+// "act" claim naming the admin the token was issued to. When permission
+// embedding is enabled the token also carries the user's permission codes and
+// activation state, stamped with the permissions version they were read at.
+func (a *appl) signAuthToken(ctx context.Context, userID int64, ttl time.Duration, actorID int64) ([]byte, error) {
+	var claims jwt.Claims
+	claims.Subject = strconv.FormatInt(userID, 10)
+	claims.Issued = jwt.NewNumericTime(time.Now())
+	claims.NotBefore = jwt.NewNumericTime(time.Now())
+	claims.Expires = jwt.NewNumericTime(time.Now().Add(ttl))
+	claims.Issuer = a.cfg.Auth.HttpBaseURL
+	claims.Audiences = []string{a.cfg.Auth.HttpBaseURL}
+
+	set := map[string]interface{}{}
+
+	if actorID != 0 {
+		set["act"] = map[string]interface{}{"sub": strconv.FormatInt(actorID, 10)}
+	}
+
+	if a.cfg.Tokens.EmbedPermissions {
+		user, err := a.userRepo.GetUserById(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions, err := a.permissionRepo.GetAllForUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if permissions == nil {
+			permissions = domain.Permissions{}
+		}
+
+		set["activated"] = user.Activated
+		set["permissions"] = permissions
+		set["permissions_version"] = user.PermissionsVersion
+	}
+
+	if len(set) > 0 {
+		claims.Set = set
+	}
+
+	jwtBytes, err := claims.HMACSign(jwt.HS256, []byte(a.cfg.Jwt.Secret))
+	if err != nil {
+		return nil, err
+	}
+
+	return jwtBytes, nil
+}
+
+// issuedBefore reports whether the token was issued before sessions were
+// revoked at revokedAt. The issue time only has whole seconds, so revokedAt
+// is compared to the second too, or a token issued in the same second but
+// after the revocation would be rejected.
+func issuedBefore(claims *jwt.Claims, revokedAt time.Time) bool {
+	return claims.Issued.Time().Before(revokedAt.Truncate(time.Second))
+}
+
+// actorFromClaims returns the user ID from the "act" claim, or 0 when the
+// token was not issued for impersonation.
+func actorFromClaims(claims *jwt.Claims) (int64, error) {
+	act, ok := claims.Set["act"]
+	if !ok {
+		return 0, nil
+	}
+
+	actor, ok := act.(map[string]interface{})
+	if !ok {
+		return 0, domain.ErrInvalidClaims
+	}
+
+	sub, ok := actor["sub"].(string)
+	if !ok {
+		return 0, domain.ErrInvalidClaims
+	}
+
+	actorID, err := strconv.ParseInt(sub, 10, 64)
+	if err != nil || actorID < 1 {
+		return 0, domain.ErrInvalidClaims
+	}
+
+	return actorID, nil
+}
+
+// permissionsFromClaims copies the permission codes embedded in the token onto
+// user. Tokens issued before the user's permissions last changed are rejected
+// with ErrStaleToken.
+func permissionsFromClaims(claims *jwt.Claims, user *domain.User) error {
+	version, ok := claims.Set["permissions_version"].(float64)
+	if !ok {
+		return nil
+	}
+
+	if int(version) != user.PermissionsVersion {
+		return domain.ErrStaleToken
+	}
+
+	codes, ok := claims.Set["permissions"].([]interface{})
+	if !ok {
+		return domain.ErrInvalidClaims
+	}
+
+	permissions := make(domain.Permissions, 0, len(codes))
+	for _, code := range codes {
+		c, ok := code.(string)
+		if !ok {
+			return domain.ErrInvalidClaims
+		}
+		permissions = append(permissions, c)
 	}
 
+	user.Permissions = permissions
+
 	return nil
 }
+
+// invitationForSignup returns the invitation a registration is redeeming, or
+// nil when the request carries none and open registration is allowed.
+func (a *appl) invitationForSignup(ctx context.Context, input *domain.CreateUserRequest) (*domain.Invitation, error) {
+	if input.InvitationToken == "" {
+		if a.cfg.Signup.InvitationOnly {
+			return nil, domain.ErrInvitationRequired
+		}
+		return nil, nil
+	}
+
+	invitation, err := a.invitationRepo.GetForToken(ctx, input.InvitationToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			return nil, domain.ErrInvalidInvitation
+		default:
+			return nil, err
+		}
+	}
+
+	if !strings.EqualFold(invitation.Email, input.Email) {
+		return nil, domain.ErrInvalidInvitation
+	}
+
+	return invitation, nil
+}
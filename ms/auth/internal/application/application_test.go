@@ -4,25 +4,34 @@
 package application
 
 import (
+	"context"
 	"errors"
 	"github.com/jessicatarra/greenlight/internal/config"
 	"github.com/jessicatarra/greenlight/internal/password"
+	"github.com/jessicatarra/greenlight/internal/webauthn"
+	"github.com/jessicatarra/greenlight/internal/webauthn/webauthntest"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/infrastructure/repositories"
 	"github.com/pascaldekloe/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"net"
 	"strconv"
-	"sync"
 	"testing"
+	"time"
 )
 
-func Init() (mocks.UserRepository, mocks.TokenRepository, mocks.PermissionRepository, config.Config, sync.WaitGroup) {
+func Init() (mocks.UserRepository, mocks.TokenRepository, mocks.PermissionRepository, mocks.InvitationRepository, mocks.AuditRepository, mocks.DeviceRepository, mocks.PasskeyRepository, mocks.DeviceAuthorizationRepository, mocks.OutboxRepository, config.Config) {
 	userRepo := mocks.UserRepository{}
 	tokenRepo := mocks.TokenRepository{}
 	permissionRepo := mocks.PermissionRepository{}
-	wg := sync.WaitGroup{}
+	invitationRepo := mocks.InvitationRepository{}
+	auditRepo := mocks.AuditRepository{}
+	deviceRepo := mocks.DeviceRepository{}
+	passkeyRepo := mocks.PasskeyRepository{}
+	deviceAuthorizationRepo := mocks.DeviceAuthorizationRepository{}
+	outboxRepo := mocks.OutboxRepository{}
 	cfg := config.Config{
 		Jwt: struct {
 			Secret string
@@ -54,46 +63,87 @@ func Init() (mocks.UserRepository, mocks.TokenRepository, mocks.PermissionReposi
 			HttpPort:       8082,
 		},
 	}
-	return userRepo, tokenRepo, permissionRepo, cfg, wg
+	return userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg
+}
+
+// inlineUnitOfWork hands use cases the repository mocks directly, since
+// there is no transaction to take part in.
+type inlineUnitOfWork domain.Repositories
+
+func newInlineUnitOfWork(userRepo domain.UserRepository, tokenRepo domain.TokenRepository, permissionRepo domain.PermissionRepository, invitationRepo domain.InvitationRepository, outboxRepo domain.OutboxRepository, deviceRepo domain.DeviceRepository) domain.UnitOfWork {
+	return inlineUnitOfWork{Users: userRepo, Tokens: tokenRepo, Permissions: permissionRepo, Invitations: invitationRepo, Outbox: outboxRepo, Devices: deviceRepo}
+}
+
+func (u inlineUnitOfWork) Do(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	return fn(domain.Repositories(u))
 }
 
 func TestAppl_CreateUseCase(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		// Initialize the repositories mock
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
 
 		// CreateUseCase the application instance with the repositories mock
-		app := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		// Prepare the input for the CreateUseCase function
 		input := domain.CreateUserRequest{
 			Name:     "John Doe",
 			Email:    "john@example.com",
 			Password: "password123",
+			Locale:   "es",
 		}
 
 		hashedPassword, _ := password.Hash(input.Password)
 
 		// Set up the success step
-		userRepo.On("InsertNewUser", mock.AnythingOfType("*domain.User"), mock.AnythingOfType("string")).Return(nil)
-		permissionRepo.On("AddForUser", mock.AnythingOfTypeArgument("int64"), "movies:read").Return(nil)
-		tokenRepo.On("New", mock.Anything, mock.AnythingOfType("time.Duration"), mock.IsType("string")).Return(nil, nil)
+		userRepo.On("InsertNewUser", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+			return user.Locale == "es"
+		}), mock.AnythingOfType("string")).Return(nil)
+		permissionRepo.On("AddForUser", mock.Anything, mock.AnythingOfTypeArgument("int64"), "movies:read").Return(nil)
+		tokenRepo.On("New", mock.Anything, mock.Anything, mock.AnythingOfType("time.Duration"), mock.IsType("string")).Return(&domain.Token{Plaintext: "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU", Expiry: time.Now().Add(time.Hour)}, nil)
+		outboxRepo.On("Insert", mock.Anything, mock.MatchedBy(func(email *domain.OutboxEmail) bool {
+			return email.Recipient == "john@example.com" && email.Template == "user_welcome.gohtml" && email.Locale == "es" && email.Data["activationToken"] == "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU"
+		})).Return(nil)
 
 		// Call the CreateUseCase function
-		user, err := app.CreateUseCase(&input, hashedPassword)
+		user, err := app.CreateUseCase(context.Background(), &input, hashedPassword)
 
 		// Assert the results
 		assert.NotNil(t, user)
 		assert.NoError(t, err)
+		outboxRepo.AssertExpectations(t)
 	})
 
 	t.Run("Error", func(t *testing.T) {
 		// Initialize the repositories mock
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
 
 		// CreateUseCase the application instance with the repositories mock
-		app := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		// Prepare the input for the CreateUseCase function
 		input := domain.CreateUserRequest{
@@ -104,11 +154,11 @@ func TestAppl_CreateUseCase(t *testing.T) {
 		hashedPassword, _ := password.Hash(input.Password)
 
 		// Set up the error step
-		userRepo.On("InsertNewUser", mock.AnythingOfType("*domain.User"), mock.AnythingOfType("string")).Return(errors.New("failed to insert user"))
-		tokenRepo.On("New", mock.Anything, mock.AnythingOfType("time.Duration"), mock.IsType("string")).Return(nil, nil)
+		userRepo.On("InsertNewUser", mock.Anything, mock.AnythingOfType("*domain.User"), mock.AnythingOfType("string")).Return(errors.New("failed to insert user"))
+		tokenRepo.On("New", mock.Anything, mock.Anything, mock.AnythingOfType("time.Duration"), mock.IsType("string")).Return(nil, nil)
 
 		// Call the CreateUseCase function again
-		user, err := app.CreateUseCase(&input, hashedPassword)
+		user, err := app.CreateUseCase(context.Background(), &input, hashedPassword)
 
 		// Assert the error step
 		assert.Nil(t, user)
@@ -117,13 +167,235 @@ func TestAppl_CreateUseCase(t *testing.T) {
 
 }
 
+func TestAppl_CreateUseCaseWithInvitation(t *testing.T) {
+
+	t.Run("Success", func(t *testing.T) {
+		// Initialize the repositories mock
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Signup.InvitationOnly = true
+
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		input := domain.CreateUserRequest{
+			Name:            "John Doe",
+			Email:           "john@example.com",
+			Password:        "password123",
+			InvitationToken: "GQRPVONORIEUPDJ6V4RTDIVSTQ",
+		}
+		invitation := &domain.Invitation{
+			Email:       "John@Example.com",
+			Permissions: domain.Permissions{"movies:write"},
+		}
+
+		invitationRepo.On("GetForToken", mock.Anything, input.InvitationToken).Return(invitation, nil)
+		userRepo.On("InsertNewUser", mock.Anything, mock.AnythingOfType("*domain.User"), "hashed").Return(nil)
+		permissionRepo.On("AddForUser", mock.Anything, mock.AnythingOfType("int64"), "movies:read", "movies:write").Return(nil)
+		invitationRepo.On("DeleteAllForEmail", mock.Anything, invitation.Email).Return(nil)
+
+		// Call the CreateUseCase function
+		user, err := app.CreateUseCase(context.Background(), &input, "hashed")
+
+		// Assert the results
+		assert.NoError(t, err)
+		assert.True(t, user.Activated)
+		tokenRepo.AssertNotCalled(t, "New", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		invitationRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - invitation required", func(t *testing.T) {
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Signup.InvitationOnly = true
+
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		input := domain.CreateUserRequest{
+			Name:     "John Doe",
+			Email:    "john@example.com",
+			Password: "password123",
+		}
+
+		user, err := app.CreateUseCase(context.Background(), &input, "hashed")
+
+		assert.Nil(t, user)
+		assert.ErrorIs(t, err, domain.ErrInvitationRequired)
+		userRepo.AssertNotCalled(t, "InsertNewUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - invitation not found", func(t *testing.T) {
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		input := domain.CreateUserRequest{
+			Name:            "John Doe",
+			Email:           "john@example.com",
+			Password:        "password123",
+			InvitationToken: "GQRPVONORIEUPDJ6V4RTDIVSTQ",
+		}
+
+		invitationRepo.On("GetForToken", mock.Anything, input.InvitationToken).Return(nil, domain.ErrRecordNotFound)
+
+		user, err := app.CreateUseCase(context.Background(), &input, "hashed")
+
+		assert.Nil(t, user)
+		assert.ErrorIs(t, err, domain.ErrInvalidInvitation)
+	})
+
+	t.Run("Error - invitation for another email", func(t *testing.T) {
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		input := domain.CreateUserRequest{
+			Name:            "John Doe",
+			Email:           "john@example.com",
+			Password:        "password123",
+			InvitationToken: "GQRPVONORIEUPDJ6V4RTDIVSTQ",
+		}
+
+		invitationRepo.On("GetForToken", mock.Anything, input.InvitationToken).Return(&domain.Invitation{Email: "sarah@example.com"}, nil)
+
+		user, err := app.CreateUseCase(context.Background(), &input, "hashed")
+
+		assert.Nil(t, user)
+		assert.ErrorIs(t, err, domain.ErrInvalidInvitation)
+	})
+}
+
+func TestAppl_CreateInvitationUseCase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		input := domain.CreateInvitationRequest{
+			Email:       "sarah@example.com",
+			Permissions: []string{"movies:write"},
+			Locale:      "es",
+		}
+		expectedInvitation := &domain.Invitation{
+			Plaintext: "GQRPVONORIEUPDJ6V4RTDIVSTQ",
+			Email:     input.Email,
+		}
+
+		invitationRepo.On("New", mock.Anything, input.Email, domain.Permissions{"movies:write"}, int64(1), 7*24*time.Hour).Return(expectedInvitation, nil)
+		outboxRepo.On("Insert", mock.Anything, mock.MatchedBy(func(email *domain.OutboxEmail) bool {
+			return email.Recipient == "sarah@example.com" && email.Template == "user_invitation.gohtml" && email.Locale == "es"
+		})).Return(nil)
+
+		// Act
+		invitation, err := appl.CreateInvitationUseCase(context.Background(), &input, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expectedInvitation, invitation)
+	})
+
+	t.Run("Error - database", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		input := domain.CreateInvitationRequest{
+			Email:  "sarah@example.com",
+			Expiry: time.Now().Add(time.Hour),
+		}
+
+		invitationRepo.On("New", mock.Anything, input.Email, mock.Anything, int64(1), mock.AnythingOfType("time.Duration")).Return(nil, errors.New("error"))
+
+		// Act
+		invitation, err := appl.CreateInvitationUseCase(context.Background(), &input, 1)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, invitation)
+	})
+}
+
 func TestAppl_GetByEmailUseCase(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		// Initialize the repositories mock
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
 
 		// CreateUseCase the application instance with the repositories mock
-		app := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		// Prepare the input for the CreateUseCase function
 		input := domain.CreateUserRequest{
@@ -132,11 +404,11 @@ func TestAppl_GetByEmailUseCase(t *testing.T) {
 			Password: "password123",
 		}
 
-		userRepo.On("GetUserByEmail", mock.AnythingOfType("string")).Return(nil, errors.New("record not found"))
-		tokenRepo.On("New", mock.Anything, mock.AnythingOfType("time.Duration"), mock.IsType("string")).Return(nil, nil)
+		userRepo.On("GetUserByEmail", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("record not found"))
+		tokenRepo.On("New", mock.Anything, mock.Anything, mock.AnythingOfType("time.Duration"), mock.IsType("string")).Return(nil, nil)
 
 		// Call the CreateUseCase function
-		user, err := app.GetByEmailUseCase(input.Email)
+		user, err := app.GetByEmailUseCase(context.Background(), input.Email)
 
 		// Assert the results
 		assert.Error(t, err)
@@ -145,10 +417,21 @@ func TestAppl_GetByEmailUseCase(t *testing.T) {
 
 	t.Run("error", func(t *testing.T) {
 		// Initialize the repositories mock
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
 
 		// CreateUseCase the application instance with the repositories mock
-		app := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		// Prepare the input for the CreateUseCase function
 		input := domain.CreateUserRequest{
@@ -158,11 +441,11 @@ func TestAppl_GetByEmailUseCase(t *testing.T) {
 		}
 
 		// Set up the error step
-		userRepo.On("GetUserByEmail", mock.AnythingOfType("string")).Return(nil, errors.New("database error"))
-		tokenRepo.On("New", mock.Anything, mock.AnythingOfType("time.Duration"), mock.IsType("string")).Return(nil, nil)
+		userRepo.On("GetUserByEmail", mock.Anything, mock.AnythingOfType("string")).Return(nil, errors.New("database error"))
+		tokenRepo.On("New", mock.Anything, mock.Anything, mock.AnythingOfType("time.Duration"), mock.IsType("string")).Return(nil, nil)
 
 		// Call the GetByEmailUseCase function again
-		user, err := app.GetByEmailUseCase(input.Email)
+		user, err := app.GetByEmailUseCase(context.Background(), input.Email)
 
 		// Assert the error step
 		assert.Nil(t, user)
@@ -170,14 +453,107 @@ func TestAppl_GetByEmailUseCase(t *testing.T) {
 	})
 }
 
+func TestAppl_NotifyRegistrationAttemptUseCase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Name: "John Doe", Email: "john@example.com"}
+
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		outboxRepo.On("Insert", mock.Anything, mock.MatchedBy(func(email *domain.OutboxEmail) bool {
+			return email.Recipient == "john@example.com" && email.Template == "user_registration_attempt.gohtml"
+		})).Return(nil)
+
+		// Act
+		err := appl.NotifyRegistrationAttemptUseCase(context.Background(), user.Email)
+
+		// Assert
+		assert.NoError(t, err)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - unknown email", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		userRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		err := appl.NotifyRegistrationAttemptUseCase(context.Background(), "nobody@example.com")
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("Error - database", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		userRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(nil, errors.New("database error"))
+
+		// Act
+		err := appl.NotifyRegistrationAttemptUseCase(context.Background(), "john@example.com")
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
 func TestAppl_ActivateUseCase(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		// Initialize the repositories mock
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
 
 		// CreateUseCase the application instance with the repositories mock
-		app := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		// Prepare the input for the ActivateUseCase function
 		tokenPlainText := "valid_token"
@@ -189,12 +565,12 @@ func TestAppl_ActivateUseCase(t *testing.T) {
 			Email:     "john@example.com",
 			Activated: true,
 		}
-		userRepo.On("GetForToken", repositories.ScopeActivation, tokenPlainText).Return(expectedUser, nil)
-		userRepo.On("UpdateUser", expectedUser).Return(nil)
-		tokenRepo.On("DeleteAllForUser", repositories.ScopeActivation, expectedUser.ID).Return(nil)
+		userRepo.On("GetForToken", mock.Anything, repositories.ScopeActivation, tokenPlainText).Return(expectedUser, nil)
+		userRepo.On("UpdateUser", mock.Anything, expectedUser).Return(nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopeActivation, expectedUser.ID).Return(nil)
 
 		// Call the ActivateUseCase function
-		user, err := app.ActivateUseCase(tokenPlainText)
+		user, err := app.ActivateUseCase(context.Background(), tokenPlainText)
 
 		// Assert the success step
 		assert.Equal(t, expectedUser, user)
@@ -203,20 +579,31 @@ func TestAppl_ActivateUseCase(t *testing.T) {
 
 	t.Run("error - GetForToken", func(t *testing.T) {
 		// Initialize the repositories mock
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
 
 		// CreateUseCase the application instance with the repositories mock
-		app := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		// Prepare the input for the ActivateUseCase function
 		tokenPlainText := "valid_token"
 
 		// Set up the error step for GetForToken
 		expectedErr := errors.New("failed to get user for token")
-		userRepo.On("GetForToken", repositories.ScopeActivation, tokenPlainText).Return(nil, expectedErr)
+		userRepo.On("GetForToken", mock.Anything, repositories.ScopeActivation, tokenPlainText).Return(nil, expectedErr)
 
 		// Call the ActivateUseCase function again
-		user, err := app.ActivateUseCase(tokenPlainText)
+		user, err := app.ActivateUseCase(context.Background(), tokenPlainText)
 
 		// Assert the error step for GetForToken
 		assert.Nil(t, user)
@@ -225,10 +612,21 @@ func TestAppl_ActivateUseCase(t *testing.T) {
 
 	t.Run("error - UpdateUser", func(t *testing.T) {
 		// Initialize the repositories mock
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
 
 		// CreateUseCase the application instance with the repositories mock
-		app := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		// Prepare the input for the ActivateUseCase function
 		tokenPlainText := "valid_token"
@@ -241,11 +639,11 @@ func TestAppl_ActivateUseCase(t *testing.T) {
 			Activated: true,
 		}
 		expectedErr := errors.New("failed to update user")
-		userRepo.On("GetForToken", repositories.ScopeActivation, tokenPlainText).Return(expectedUser, nil)
-		userRepo.On("UpdateUser", expectedUser).Return(expectedErr)
+		userRepo.On("GetForToken", mock.Anything, repositories.ScopeActivation, tokenPlainText).Return(expectedUser, nil)
+		userRepo.On("UpdateUser", mock.Anything, expectedUser).Return(expectedErr)
 
 		// Call the ActivateUseCase function again
-		user, err := app.ActivateUseCase(tokenPlainText)
+		user, err := app.ActivateUseCase(context.Background(), tokenPlainText)
 
 		// Assert the error step for UpdateUser
 		assert.Nil(t, user)
@@ -254,10 +652,21 @@ func TestAppl_ActivateUseCase(t *testing.T) {
 
 	t.Run("error - DeleteAllForUser", func(t *testing.T) {
 		// Initialize the repositories mock
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
 
 		// CreateUseCase the application instance with the repositories mock
-		app := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		app := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		// Prepare the input for the ActivateUseCase function
 		tokenPlainText := "valid_token"
@@ -270,12 +679,12 @@ func TestAppl_ActivateUseCase(t *testing.T) {
 			Activated: true,
 		}
 		expectedErr := errors.New("failed to delete tokens")
-		userRepo.On("GetForToken", repositories.ScopeActivation, tokenPlainText).Return(expectedUser, nil)
-		userRepo.On("UpdateUser", expectedUser).Return(nil)
-		tokenRepo.On("DeleteAllForUser", repositories.ScopeActivation, expectedUser.ID).Return(expectedErr)
+		userRepo.On("GetForToken", mock.Anything, repositories.ScopeActivation, tokenPlainText).Return(expectedUser, nil)
+		userRepo.On("UpdateUser", mock.Anything, expectedUser).Return(nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopeActivation, expectedUser.ID).Return(expectedErr)
 
 		// Call the ActivateUseCase function again
-		user, err := app.ActivateUseCase(tokenPlainText)
+		user, err := app.ActivateUseCase(context.Background(), tokenPlainText)
 
 		// Assert the error step for DeleteAllForUser
 		assert.Nil(t, user)
@@ -287,8 +696,19 @@ func TestAppl_CreateAuthTokenUseCase(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		// Arrange
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
-		appl := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		expectedUserID := int64(1)
 		expectedSubject := strconv.FormatInt(expectedUserID, 10)
@@ -296,7 +716,7 @@ func TestAppl_CreateAuthTokenUseCase(t *testing.T) {
 		expectedAudience := []string{cfg.Auth.HttpBaseURL}
 
 		// Act
-		tokenBytes, err := appl.CreateAuthTokenUseCase(expectedUserID)
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), expectedUserID)
 		token, err := jwt.HMACCheck(tokenBytes, []byte(cfg.Jwt.Secret))
 
 		// Assert
@@ -321,12 +741,23 @@ func TestAppl_CreateAuthTokenUseCase(t *testing.T) {
 				HttpPort:       8082,
 			},
 		}
-		userRepo, tokenRepo, permissionRepo, _, wg := Init()
-		appl := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, _ := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 		expectedUserID := int64(1)
 
 		// Act
-		_, err := appl.CreateAuthTokenUseCase(expectedUserID)
+		_, err := appl.CreateAuthTokenUseCase(context.Background(), expectedUserID)
 
 		// Assert
 		assert.Error(t, err)
@@ -336,8 +767,19 @@ func TestAppl_CreateAuthTokenUseCase(t *testing.T) {
 func TestAppl_ValidateAuthTokenUseCase(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		// Arrange
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
-		appl := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 		expectedUserID := int64(1)
 		expectedUser := &domain.User{
 			ID:        int64(1),
@@ -345,12 +787,12 @@ func TestAppl_ValidateAuthTokenUseCase(t *testing.T) {
 			Email:     "john@example.com",
 			Activated: true,
 		}
-		userRepo.On("GetUserById", mock.AnythingOfType("int64")).Return(expectedUser, nil)
+		userRepo.On("GetUserById", mock.Anything, mock.AnythingOfType("int64")).Return(expectedUser, nil)
 
 		// Act
-		tokenBytes, err := appl.CreateAuthTokenUseCase(expectedUserID)
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), expectedUserID)
 		token := string(tokenBytes)
-		user, err := appl.ValidateAuthTokenUseCase(token)
+		user, err := appl.ValidateAuthTokenUseCase(context.Background(), token)
 
 		// Assert
 		assert.NoError(t, err)
@@ -359,7 +801,7 @@ func TestAppl_ValidateAuthTokenUseCase(t *testing.T) {
 
 	t.Run("Error - JWT Secret", func(t *testing.T) {
 		// Arrange
-		userRepo, tokenRepo, permissionRepo, _, wg := Init()
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, _ := Init()
 		cfg := config.Config{
 			Auth: struct {
 				HttpBaseURL    string
@@ -373,7 +815,18 @@ func TestAppl_ValidateAuthTokenUseCase(t *testing.T) {
 				HttpPort:       8082,
 			},
 		}
-		appl := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 		expectedUserID := int64(1)
 		expectedUser := &domain.User{
 			ID:        int64(1),
@@ -381,12 +834,12 @@ func TestAppl_ValidateAuthTokenUseCase(t *testing.T) {
 			Email:     "john@example.com",
 			Activated: true,
 		}
-		userRepo.On("GetUserById", mock.AnythingOfType("int64")).Return(expectedUser, nil)
+		userRepo.On("GetUserById", mock.Anything, mock.AnythingOfType("int64")).Return(expectedUser, nil)
 
 		// Act
-		tokenBytes, err := appl.CreateAuthTokenUseCase(expectedUserID)
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), expectedUserID)
 		token := string(tokenBytes)
-		_, err = appl.ValidateAuthTokenUseCase(token)
+		_, err = appl.ValidateAuthTokenUseCase(context.Background(), token)
 
 		// Assert
 		assert.Error(t, err)
@@ -394,71 +847,2415 @@ func TestAppl_ValidateAuthTokenUseCase(t *testing.T) {
 
 	t.Run("Error - database", func(t *testing.T) {
 		// Arrange
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
-		appl := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 		expectedUserID := int64(1)
-		userRepo.On("GetUserById", mock.AnythingOfType("int64")).Return(nil, errors.New("record not found"))
+		userRepo.On("GetUserById", mock.Anything, mock.AnythingOfType("int64")).Return(nil, errors.New("record not found"))
 
 		// Act
-		tokenBytes, err := appl.CreateAuthTokenUseCase(expectedUserID)
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), expectedUserID)
 		token := string(tokenBytes)
-		_, err = appl.ValidateAuthTokenUseCase(token)
+		_, err = appl.ValidateAuthTokenUseCase(context.Background(), token)
 
 		// Assert
 		assert.Error(t, err)
 	})
 
+	t.Run("Error - suspended user", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		expectedUser := &domain.User{ID: int64(1), Activated: true, Suspended: true}
+		userRepo.On("GetUserById", mock.Anything, expectedUser.ID).Return(expectedUser, nil)
+
+		// Act
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), expectedUser.ID)
+		assert.NoError(t, err)
+		user, err := appl.ValidateAuthTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrAccountSuspended)
+		assert.Nil(t, user)
+	})
+
+	t.Run("Error - sessions revoked after issue", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		expectedUser := &domain.User{ID: int64(1), Activated: true, SessionsRevokedAt: time.Now().Add(time.Minute)}
+		userRepo.On("GetUserById", mock.Anything, expectedUser.ID).Return(expectedUser, nil)
+
+		// Act
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), expectedUser.ID)
+		assert.NoError(t, err)
+		user, err := appl.ValidateAuthTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRevokedToken)
+		assert.Nil(t, user)
+	})
+
+	t.Run("Success - issued in the same second as the revocation", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		// Revoked just before the token is issued, which leaves out the
+		// fraction of a second its issue time is truncated to.
+		expectedUser := &domain.User{ID: int64(1), Activated: true, SessionsRevokedAt: time.Now()}
+		userRepo.On("GetUserById", mock.Anything, expectedUser.ID).Return(expectedUser, nil)
+
+		// Act
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), expectedUser.ID)
+		assert.NoError(t, err)
+		user, err := appl.ValidateAuthTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expectedUser.ID, user.ID)
+	})
 }
 
 func TestAppl_UserPermissionUseCase(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		// Arrange
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
-		appl := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		expectedUserID := int64(1)
 		code := "movie:read"
 		permissions := domain.Permissions{code}
 
-		permissionRepo.On("GetAllForUser", mock.AnythingOfType("int64")).Return(permissions, nil)
+		permissionRepo.On("GetAllForUser", mock.Anything, mock.AnythingOfType("int64")).Return(permissions, nil)
 
 		// Act
-		err := appl.UserPermissionUseCase(code, expectedUserID)
+		err := appl.UserPermissionUseCase(context.Background(), code, expectedUserID)
 
 		// Assert
 		assert.NoError(t, err)
 	})
 	t.Run("Error - database", func(t *testing.T) {
 		// Arrange
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
-		appl := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		expectedUserID := int64(1)
 		code := "movie:read"
 
-		permissionRepo.On("GetAllForUser", mock.AnythingOfType("int64")).Return(nil, errors.New("error"))
+		permissionRepo.On("GetAllForUser", mock.Anything, mock.AnythingOfType("int64")).Return(nil, errors.New("error"))
 
 		// Act
-		err := appl.UserPermissionUseCase(code, expectedUserID)
+		err := appl.UserPermissionUseCase(context.Background(), code, expectedUserID)
 
 		// Assert
 		assert.Error(t, err)
 	})
 	t.Run("Error - permission not included", func(t *testing.T) {
 		// Arrange
-		userRepo, tokenRepo, permissionRepo, cfg, wg := Init()
-		appl := NewAppl(&userRepo, &tokenRepo, &permissionRepo, &wg, cfg)
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
 
 		expectedUserID := int64(1)
 		code := "movie:read"
 		permissions := domain.Permissions{code}
 
-		permissionRepo.On("GetAllForUser", mock.AnythingOfType("int64")).Return(permissions, nil)
+		permissionRepo.On("GetAllForUser", mock.Anything, mock.AnythingOfType("int64")).Return(permissions, nil)
+
+		// Act
+		err := appl.UserPermissionUseCase(context.Background(), "movies:write", expectedUserID)
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestAppl_CreateMagicLinkUseCase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Email: "john@example.com", Locale: "es"}
+
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopeMagicLink, user.ID).Return(nil)
+		tokenRepo.On("New", mock.Anything, user.ID, 15*time.Minute, repositories.ScopeMagicLink).Return(&domain.Token{Plaintext: "GQRPVONORIEUPDJ6V4RTDIVSTQ"}, nil)
+		outboxRepo.On("Insert", mock.Anything, mock.MatchedBy(func(email *domain.OutboxEmail) bool {
+			return email.Recipient == "john@example.com" && email.Template == "user_magic_link.gohtml" && email.Locale == "es"
+		})).Return(nil)
+
+		// Act
+		err := appl.CreateMagicLinkUseCase(context.Background(), user.Email)
+
+		// Assert
+		assert.NoError(t, err)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - unknown email", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		userRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		err := appl.CreateMagicLinkUseCase(context.Background(), "nobody@example.com")
+
+		// Assert
+		assert.NoError(t, err)
+		tokenRepo.AssertNotCalled(t, "New", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - database", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		userRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(nil, errors.New("database error"))
+
+		// Act
+		err := appl.CreateMagicLinkUseCase(context.Background(), "john@example.com")
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestAppl_ExchangeMagicLinkUseCase(t *testing.T) {
+	t.Run("Success - activates user", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		tokenPlaintext := "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+		user := &domain.User{ID: 1, Email: "john@example.com"}
+
+		tokenRepo.On("Consume", mock.Anything, repositories.ScopeMagicLink, tokenPlaintext).Return(user.ID, nil)
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		userRepo.On("UpdateUser", mock.Anything, user).Return(nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopeActivation, user.ID).Return(nil)
+
+		// Act
+		tokenBytes, err := appl.ExchangeMagicLinkUseCase(context.Background(), tokenPlaintext)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, user.Activated)
+		claims, err := jwt.HMACCheck(tokenBytes, []byte(cfg.Jwt.Secret))
+		assert.NoError(t, err)
+		assert.Equal(t, strconv.FormatInt(user.ID, 10), claims.Subject)
+	})
+
+	t.Run("Error - token already used or expired", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		tokenPlaintext := "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+
+		tokenRepo.On("Consume", mock.Anything, repositories.ScopeMagicLink, tokenPlaintext).Return(int64(0), domain.ErrRecordNotFound)
+
+		// Act
+		tokenBytes, err := appl.ExchangeMagicLinkUseCase(context.Background(), tokenPlaintext)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, tokenBytes)
+		userRepo.AssertNotCalled(t, "GetUserById", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppl_CreatePasswordResetTokenUseCase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopePasswordReset, user.ID).Return(nil)
+		tokenRepo.On("New", mock.Anything, user.ID, 45*time.Minute, repositories.ScopePasswordReset).Return(&domain.Token{Plaintext: "GQRPVONORIEUPDJ6V4RTDIVSTQ"}, nil)
+		outboxRepo.On("Insert", mock.Anything, mock.MatchedBy(func(email *domain.OutboxEmail) bool {
+			return email.Recipient == "john@example.com" && email.Template == "token_password_reset.gohtml"
+		})).Return(nil)
+
+		// Act
+		err := appl.CreatePasswordResetTokenUseCase(context.Background(), user.Email)
+
+		// Assert
+		assert.NoError(t, err)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - unactivated user is skipped", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Email: "john@example.com"}
+
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+
+		// Act
+		err := appl.CreatePasswordResetTokenUseCase(context.Background(), user.Email)
+
+		// Assert
+		assert.NoError(t, err)
+		tokenRepo.AssertNotCalled(t, "New", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - unknown email", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		userRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com").Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		err := appl.CreatePasswordResetTokenUseCase(context.Background(), "nobody@example.com")
+
+		// Assert
+		assert.NoError(t, err)
+		tokenRepo.AssertNotCalled(t, "New", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - database", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		userRepo.On("GetUserByEmail", mock.Anything, "john@example.com").Return(nil, errors.New("database error"))
 
 		// Act
-		err := appl.UserPermissionUseCase("movies:write", expectedUserID)
+		err := appl.CreatePasswordResetTokenUseCase(context.Background(), "john@example.com")
 
 		// Assert
 		assert.Error(t, err)
 	})
 }
+
+func TestAppl_ResetPasswordUseCase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Email: "john@example.com", HashedPassword: "old"}
+
+		userRepo.On("UpdateUser", mock.Anything, user).Return(nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopePasswordReset, user.ID).Return(nil)
+
+		// Act
+		err := appl.ResetPasswordUseCase(context.Background(), user, "new")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "new", user.HashedPassword)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - edit conflict", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Email: "john@example.com", HashedPassword: "old"}
+
+		userRepo.On("UpdateUser", mock.Anything, user).Return(domain.ErrEditConflict)
+
+		// Act
+		err := appl.ResetPasswordUseCase(context.Background(), user, "new")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrEditConflict)
+		tokenRepo.AssertNotCalled(t, "DeleteAllForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppl_ChangePasswordUseCase(t *testing.T) {
+	// Arrange
+	userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+	appl := NewAppl(Deps{
+		Users:                &userRepo,
+		Tokens:               &tokenRepo,
+		Permissions:          &permissionRepo,
+		Invitations:          &invitationRepo,
+		Audit:                &auditRepo,
+		Devices:              &deviceRepo,
+		Passkeys:             &passkeyRepo,
+		DeviceAuthorizations: &deviceAuthorizationRepo,
+		Outbox:               &outboxRepo,
+		UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+	}, cfg)
+	user := &domain.User{ID: 1, Email: "john@example.com", HashedPassword: "old"}
+
+	userRepo.On("UpdateUser", mock.Anything, user).Return(nil)
+	tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopePasswordReset, user.ID).Return(nil)
+
+	// Act
+	err := appl.ChangePasswordUseCase(context.Background(), user, "new")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "new", user.HashedPassword)
+	tokenRepo.AssertExpectations(t)
+}
+
+func TestAppl_RehashPasswordUseCase(t *testing.T) {
+	// Arrange
+	userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+	appl := NewAppl(Deps{
+		Users:                &userRepo,
+		Tokens:               &tokenRepo,
+		Permissions:          &permissionRepo,
+		Invitations:          &invitationRepo,
+		Audit:                &auditRepo,
+		Devices:              &deviceRepo,
+		Passkeys:             &passkeyRepo,
+		DeviceAuthorizations: &deviceAuthorizationRepo,
+		Outbox:               &outboxRepo,
+		UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+	}, cfg)
+	user := &domain.User{ID: 1, HashedPassword: "old"}
+
+	userRepo.On("UpdateUser", mock.Anything, user).Return(nil)
+
+	// Act
+	err := appl.RehashPasswordUseCase(context.Background(), user, "new")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "new", user.HashedPassword)
+	tokenRepo.AssertNotCalled(t, "DeleteAllForUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAppl_ListUsersUseCase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		filter := domain.UserFilter{Email: "example.com"}
+		filters := domain.Filters{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}}
+		expectedUsers := []*domain.User{{ID: 1, Email: "john@example.com"}}
+		expectedMetadata := domain.CalculateMetadata(1, 1, 20)
+
+		userRepo.On("GetAll", mock.Anything, filter, filters).Return(expectedUsers, expectedMetadata, nil)
+
+		// Act
+		users, metadata, err := appl.ListUsersUseCase(context.Background(), filter, filters)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expectedUsers, users)
+		assert.Equal(t, expectedMetadata, metadata)
+	})
+}
+
+func TestAppl_SuspendUserUseCase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		userRepo.On("UpdateUser", mock.Anything, user).Return(nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopeMagicLink, user.ID).Return(nil)
+
+		// Act
+		result, err := appl.SuspendUserUseCase(context.Background(), user.ID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, result.Suspended)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - user not found", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		userRepo.On("GetUserById", mock.Anything, int64(2)).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		result, err := appl.SuspendUserUseCase(context.Background(), 2)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, result)
+		userRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - edit conflict", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		userRepo.On("UpdateUser", mock.Anything, user).Return(domain.ErrEditConflict)
+
+		// Act
+		result, err := appl.SuspendUserUseCase(context.Background(), user.ID)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrEditConflict)
+		assert.Nil(t, result)
+	})
+}
+
+func TestAppl_ReactivateUserUseCase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true, Suspended: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		userRepo.On("UpdateUser", mock.Anything, user).Return(nil)
+
+		// Act
+		result, err := appl.ReactivateUserUseCase(context.Background(), user.ID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, result.Suspended)
+	})
+
+	t.Run("Success - not suspended", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+
+		// Act
+		result, err := appl.ReactivateUserUseCase(context.Background(), user.ID)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, user, result)
+		userRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppl_ImpersonateUseCase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		admin := &domain.User{ID: 1, Email: "admin@example.com", Activated: true}
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		userRepo.On("GetUserById", mock.Anything, admin.ID).Return(admin, nil)
+		auditRepo.On("Insert", mock.Anything, mock.MatchedBy(func(event *domain.AuditEvent) bool {
+			return event.UserID == user.ID && event.ActorID == admin.ID && event.Action == "impersonate"
+		})).Return(nil)
+		permissionRepo.On("GetAllForUser", mock.Anything, admin.ID).Return(domain.Permissions{"users:admin"}, nil)
+
+		// Act
+		tokenBytes, err := appl.ImpersonateUseCase(context.Background(), user.ID, admin.ID)
+
+		// Assert
+		assert.NoError(t, err)
+		claims, err := jwt.HMACCheck(tokenBytes, []byte(cfg.Jwt.Secret))
+		assert.NoError(t, err)
+		assert.Equal(t, strconv.FormatInt(user.ID, 10), claims.Subject)
+		assert.WithinDuration(t, time.Now().Add(15*time.Minute), claims.Expires.Time(), time.Minute)
+		auditRepo.AssertExpectations(t)
+
+		validated, err := appl.ValidateAuthTokenUseCase(context.Background(), string(tokenBytes))
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, validated.ID)
+		assert.Equal(t, admin.ID, validated.ActorID)
+	})
+
+	t.Run("Error - suspended user", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true, Suspended: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+
+		// Act
+		tokenBytes, err := appl.ImpersonateUseCase(context.Background(), user.ID, 1)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrAccountSuspended)
+		assert.Nil(t, tokenBytes)
+		auditRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - audit insert", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		auditRepo.On("Insert", mock.Anything, mock.Anything).Return(errors.New("error"))
+
+		// Act
+		tokenBytes, err := appl.ImpersonateUseCase(context.Background(), user.ID, 1)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, tokenBytes)
+	})
+
+	t.Run("Error - suspended actor", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		admin := &domain.User{ID: 1, Email: "admin@example.com", Activated: true}
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		userRepo.On("GetUserById", mock.Anything, admin.ID).Return(admin, nil)
+		auditRepo.On("Insert", mock.Anything, mock.Anything).Return(nil)
+
+		tokenBytes, err := appl.ImpersonateUseCase(context.Background(), user.ID, admin.ID)
+		assert.NoError(t, err)
+		admin.Suspended = true
+
+		// Act
+		validated, err := appl.ValidateAuthTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrAccountSuspended)
+		assert.Nil(t, validated)
+	})
+
+	t.Run("Error - actor no longer an admin", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		admin := &domain.User{ID: 1, Email: "admin@example.com", Activated: true}
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		userRepo.On("GetUserById", mock.Anything, admin.ID).Return(admin, nil)
+		auditRepo.On("Insert", mock.Anything, mock.Anything).Return(nil)
+		permissionRepo.On("GetAllForUser", mock.Anything, admin.ID).Return(domain.Permissions{"movies:read"}, nil)
+
+		tokenBytes, err := appl.ImpersonateUseCase(context.Background(), user.ID, admin.ID)
+		assert.NoError(t, err)
+
+		// Act
+		validated, err := appl.ValidateAuthTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRevokedToken)
+		assert.Nil(t, validated)
+	})
+}
+
+func TestAppl_TokenLifetimes(t *testing.T) {
+	t.Run("Success - configured authentication TTL", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Tokens.AuthenticationTTL = 2 * time.Hour
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		// Act
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), 1)
+
+		// Assert
+		assert.NoError(t, err)
+		claims, err := jwt.HMACCheck(tokenBytes, []byte(cfg.Jwt.Secret))
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(2*time.Hour), claims.Expires.Time(), time.Minute)
+	})
+
+	t.Run("Success - configured activation TTL", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Tokens.ActivationTTL = 6 * time.Hour
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		input := &domain.CreateUserRequest{Name: "John Doe", Email: "john@example.com", Password: "password123"}
+
+		userRepo.On("InsertNewUser", mock.Anything, mock.AnythingOfType("*domain.User"), "hash").Return(nil)
+		permissionRepo.On("AddForUser", mock.Anything, mock.AnythingOfType("int64"), "movies:read").Return(nil)
+		tokenRepo.On("New", mock.Anything, mock.AnythingOfType("int64"), 6*time.Hour, repositories.ScopeActivation).Return(&domain.Token{Plaintext: "token"}, nil)
+		outboxRepo.On("Insert", mock.Anything, mock.MatchedBy(func(email *domain.OutboxEmail) bool {
+			return email.Recipient == "john@example.com" && email.Template == "user_welcome.gohtml"
+		})).Return(nil)
+
+		// Act
+		_, err := appl.CreateUseCase(context.Background(), input, "hash")
+
+		// Assert
+		assert.NoError(t, err)
+		tokenRepo.AssertExpectations(t)
+	})
+}
+
+func TestAppl_EmbeddedPermissions(t *testing.T) {
+	t.Run("Success - claims round trip", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Tokens.EmbedPermissions = true
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Email: "john@example.com", Activated: true, PermissionsVersion: 3}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		permissionRepo.On("GetAllForUser", mock.Anything, user.ID).Return(domain.Permissions{"movies:read", "movies:write"}, nil)
+
+		// Act
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), user.ID)
+		assert.NoError(t, err)
+		validated, err := appl.ValidateAuthTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.NoError(t, err)
+		claims, err := jwt.HMACCheck(tokenBytes, []byte(cfg.Jwt.Secret))
+		assert.NoError(t, err)
+		assert.Equal(t, true, claims.Set["activated"])
+		assert.Equal(t, float64(3), claims.Set["permissions_version"])
+		assert.Equal(t, domain.Permissions{"movies:read", "movies:write"}, validated.Permissions)
+	})
+
+	t.Run("Error - stale permissions", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Tokens.EmbedPermissions = true
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Email: "john@example.com", Activated: true, PermissionsVersion: 3}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		permissionRepo.On("GetAllForUser", mock.Anything, user.ID).Return(domain.Permissions{"movies:read"}, nil)
+
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), user.ID)
+		assert.NoError(t, err)
+		user.PermissionsVersion = 4
+
+		// Act
+		validated, err := appl.ValidateAuthTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrStaleToken)
+		assert.Nil(t, validated)
+	})
+
+	t.Run("Success - permissions not embedded", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+
+		// Act
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), user.ID)
+		assert.NoError(t, err)
+		validated, err := appl.ValidateAuthTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Nil(t, validated.Permissions)
+		permissionRepo.AssertNotCalled(t, "GetAllForUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppl_IntrospectTokenUseCase(t *testing.T) {
+	t.Run("Success - authentication token", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		permissionRepo.On("GetAllForUser", mock.Anything, user.ID).Return(domain.Permissions{"movies:read", "movies:write"}, nil)
+
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), user.ID)
+		assert.NoError(t, err)
+
+		// Act
+		introspection, err := appl.IntrospectTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, introspection.Active)
+		assert.Equal(t, "1", introspection.Sub)
+		assert.Equal(t, "john@example.com", introspection.Username)
+		assert.Equal(t, "movies:read movies:write", introspection.Scope)
+		assert.NotZero(t, introspection.Exp)
+		assert.NotZero(t, introspection.Iat)
+		assert.Nil(t, introspection.Act)
+	})
+
+	t.Run("Success - impersonation token carries actor", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		admin := &domain.User{ID: 1, Email: "admin@example.com", Activated: true}
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		userRepo.On("GetUserById", mock.Anything, admin.ID).Return(admin, nil)
+		auditRepo.On("Insert", mock.Anything, mock.Anything).Return(nil)
+		permissionRepo.On("GetAllForUser", mock.Anything, user.ID).Return(domain.Permissions{"movies:read"}, nil)
+		permissionRepo.On("GetAllForUser", mock.Anything, admin.ID).Return(domain.Permissions{"users:admin"}, nil)
+
+		tokenBytes, err := appl.ImpersonateUseCase(context.Background(), user.ID, admin.ID)
+		assert.NoError(t, err)
+
+		// Act
+		introspection, err := appl.IntrospectTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, introspection.Active)
+		assert.Equal(t, "2", introspection.Sub)
+		assert.Equal(t, &domain.IntrospectionActor{Sub: "1"}, introspection.Act)
+	})
+
+	t.Run("Success - forged authentication token is inactive", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		// Act
+		introspection, err := appl.IntrospectTokenUseCase(context.Background(), "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.invalid")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.Introspection{Active: false}, introspection)
+	})
+
+	t.Run("Success - suspended user is inactive", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Email: "john@example.com", Activated: true, Suspended: true}
+
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), user.ID)
+		assert.NoError(t, err)
+
+		// Act
+		introspection, err := appl.IntrospectTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, introspection.Active)
+	})
+
+	t.Run("Success - stored token", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		tokenPlaintext := "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+		expiry := time.Now().Add(time.Hour)
+		user := &domain.User{ID: 1, Email: "john@example.com"}
+
+		tokenRepo.On("Get", mock.Anything, tokenPlaintext).Return(&domain.Token{UserID: user.ID, Expiry: expiry, Scope: repositories.ScopeActivation}, nil)
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+
+		// Act
+		introspection, err := appl.IntrospectTokenUseCase(context.Background(), tokenPlaintext)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, introspection.Active)
+		assert.Equal(t, repositories.ScopeActivation, introspection.Scope)
+		assert.Equal(t, expiry.Unix(), introspection.Exp)
+		assert.Equal(t, "john@example.com", introspection.Username)
+	})
+
+	t.Run("Success - invitation token", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		tokenPlaintext := "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+		invitation := &domain.Invitation{Email: "sarah@example.com", CreatedAt: time.Now(), Expiry: time.Now().Add(time.Hour)}
+
+		tokenRepo.On("Get", mock.Anything, tokenPlaintext).Return(nil, domain.ErrRecordNotFound)
+		invitationRepo.On("GetForToken", mock.Anything, tokenPlaintext).Return(invitation, nil)
+
+		// Act
+		introspection, err := appl.IntrospectTokenUseCase(context.Background(), tokenPlaintext)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, introspection.Active)
+		assert.Equal(t, repositories.ScopeInvitation, introspection.Scope)
+		assert.Equal(t, "sarah@example.com", introspection.Username)
+		assert.Empty(t, introspection.Sub)
+	})
+
+	t.Run("Success - unknown token is inactive", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		tokenPlaintext := "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+
+		tokenRepo.On("Get", mock.Anything, tokenPlaintext).Return(nil, domain.ErrRecordNotFound)
+		invitationRepo.On("GetForToken", mock.Anything, tokenPlaintext).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		introspection, err := appl.IntrospectTokenUseCase(context.Background(), tokenPlaintext)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, introspection.Active)
+	})
+
+	t.Run("Error - database", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		tokenPlaintext := "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+
+		tokenRepo.On("Get", mock.Anything, tokenPlaintext).Return(nil, errors.New("error"))
+
+		// Act
+		introspection, err := appl.IntrospectTokenUseCase(context.Background(), tokenPlaintext)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, introspection)
+	})
+}
+
+func TestAppl_RecordSignInUseCase(t *testing.T) {
+	ip := net.ParseIP("203.0.113.7")
+	userAgent := "Mozilla/5.0 Firefox/118.0"
+
+	t.Run("Success - notifications off", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Devices.Sensitivity = domain.DeviceSensitivityOff
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		// Act
+		err := appl.RecordSignInUseCase(context.Background(), &domain.User{ID: 1}, ip, userAgent)
+
+		// Assert
+		assert.NoError(t, err)
+		deviceRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - known device", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Devices.Sensitivity = domain.DeviceSensitivityMedium
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		known := &domain.Device{ID: 5, UserID: 1}
+
+		deviceRepo.On("Get", mock.Anything, int64(1), mock.Anything).Return(known, nil)
+		deviceRepo.On("Touch", mock.Anything, known).Return(nil)
+
+		// Act
+		err := appl.RecordSignInUseCase(context.Background(), &domain.User{ID: 1}, ip, userAgent)
+
+		// Assert
+		assert.NoError(t, err)
+		deviceRepo.AssertExpectations(t)
+		tokenRepo.AssertNotCalled(t, "New", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - first device is not reported", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Devices.Sensitivity = domain.DeviceSensitivityMedium
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		deviceRepo.On("Get", mock.Anything, int64(1), mock.Anything).Return(nil, domain.ErrRecordNotFound)
+		deviceRepo.On("CountForUser", mock.Anything, int64(1)).Return(0, nil)
+		deviceRepo.On("Insert", mock.Anything, mock.AnythingOfType("*domain.Device")).Return(true, nil)
+
+		// Act
+		err := appl.RecordSignInUseCase(context.Background(), &domain.User{ID: 1}, ip, userAgent)
+
+		// Assert
+		assert.NoError(t, err)
+		deviceRepo.AssertExpectations(t)
+		tokenRepo.AssertNotCalled(t, "New", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - new device is reported", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Devices.Sensitivity = domain.DeviceSensitivityMedium
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 1, Name: "John Doe", Email: "john@example.com"}
+
+		deviceRepo.On("Get", mock.Anything, user.ID, mock.Anything).Return(nil, domain.ErrRecordNotFound)
+		deviceRepo.On("CountForUser", mock.Anything, user.ID).Return(1, nil)
+		deviceRepo.On("Insert", mock.Anything, mock.AnythingOfType("*domain.Device")).Return(true, nil)
+		tokenRepo.On("New", mock.Anything, user.ID, 7*24*time.Hour, repositories.ScopeRevokeSessions).Return(&domain.Token{Plaintext: "GQRPVONORIEUPDJ6V4RTDIVSTQ"}, nil)
+		outboxRepo.On("Insert", mock.Anything, mock.MatchedBy(func(email *domain.OutboxEmail) bool {
+			return email.Recipient == "john@example.com" && email.Template == "user_new_sign_in.gohtml"
+		})).Return(nil)
+
+		// Act
+		err := appl.RecordSignInUseCase(context.Background(), user, ip, userAgent)
+
+		// Assert
+		assert.NoError(t, err)
+		deviceRepo.AssertExpectations(t)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - new device recorded by a concurrent sign-in", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Devices.Sensitivity = domain.DeviceSensitivityMedium
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		deviceRepo.On("Get", mock.Anything, int64(1), mock.Anything).Return(nil, domain.ErrRecordNotFound)
+		deviceRepo.On("CountForUser", mock.Anything, int64(1)).Return(1, nil)
+		deviceRepo.On("Insert", mock.Anything, mock.AnythingOfType("*domain.Device")).Return(false, nil)
+
+		// Act
+		err := appl.RecordSignInUseCase(context.Background(), &domain.User{ID: 1}, ip, userAgent)
+
+		// Assert
+		assert.NoError(t, err)
+		deviceRepo.AssertExpectations(t)
+		tokenRepo.AssertNotCalled(t, "New", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		outboxRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - database", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Devices.Sensitivity = domain.DeviceSensitivityMedium
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		deviceRepo.On("Get", mock.Anything, int64(1), mock.Anything).Return(nil, errors.New("some error"))
+
+		// Act
+		err := appl.RecordSignInUseCase(context.Background(), &domain.User{ID: 1}, ip, userAgent)
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestAppl_RevokeSessionsUseCase(t *testing.T) {
+	const token = "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		expectedUser := &domain.User{ID: 1, Name: "John Doe"}
+
+		tokenRepo.On("Consume", mock.Anything, repositories.ScopeRevokeSessions, token).Return(expectedUser.ID, nil)
+		userRepo.On("RevokeSessions", mock.Anything, expectedUser.ID, mock.AnythingOfType("time.Time")).Return(nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopeMagicLink, expectedUser.ID).Return(nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopeRevokeSessions, expectedUser.ID).Return(nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopeSession, expectedUser.ID).Return(nil)
+		deviceRepo.On("DeleteAllForUser", mock.Anything, expectedUser.ID).Return(nil)
+		auditRepo.On("Insert", mock.Anything, mock.MatchedBy(func(event *domain.AuditEvent) bool {
+			return event.Action == "revoke_sessions" && event.ResourceID == expectedUser.ID
+		})).Return(nil)
+		userRepo.On("GetUserById", mock.Anything, expectedUser.ID).Return(expectedUser, nil)
+
+		// Act
+		user, err := appl.RevokeSessionsUseCase(context.Background(), token)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expectedUser, user)
+		userRepo.AssertExpectations(t)
+		tokenRepo.AssertExpectations(t)
+		deviceRepo.AssertExpectations(t)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - token already used or expired", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		tokenRepo.On("Consume", mock.Anything, repositories.ScopeRevokeSessions, token).Return(int64(0), domain.ErrRecordNotFound)
+
+		// Act
+		user, err := appl.RevokeSessionsUseCase(context.Background(), token)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, user)
+		userRepo.AssertNotCalled(t, "RevokeSessions", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppl_SessionUseCases(t *testing.T) {
+	const token = "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+
+	t.Run("Create", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Sessions.TTL = 2 * time.Hour
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		expectedToken := &domain.Token{Plaintext: token, UserID: 1, Scope: repositories.ScopeSession}
+
+		tokenRepo.On("New", mock.Anything, int64(1), 2*time.Hour, repositories.ScopeSession).Return(expectedToken, nil)
+
+		// Act
+		session, err := appl.CreateSessionUseCase(context.Background(), 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expectedToken, session)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		expectedUser := &domain.User{ID: 1, Activated: true}
+
+		userRepo.On("GetForToken", mock.Anything, repositories.ScopeSession, token).Return(expectedUser, nil)
+
+		// Act
+		user, err := appl.ValidateSessionUseCase(context.Background(), token)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expectedUser, user)
+	})
+
+	t.Run("Validate - suspended user", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		userRepo.On("GetForToken", mock.Anything, repositories.ScopeSession, token).Return(&domain.User{ID: 1, Suspended: true}, nil)
+
+		// Act
+		user, err := appl.ValidateSessionUseCase(context.Background(), token)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrAccountSuspended)
+		assert.Nil(t, user)
+	})
+
+	t.Run("Delete - already ended", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		tokenRepo.On("Consume", mock.Anything, repositories.ScopeSession, token).Return(int64(0), domain.ErrRecordNotFound)
+
+		// Act
+		err := appl.DeleteSessionUseCase(context.Background(), token)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+}
+
+func TestAppl_PasskeyCeremonies(t *testing.T) {
+	const origin = "https://auth.example.com"
+
+	newPasskeyAppl := func() (domain.Appl, *mocks.UserRepository, *mocks.TokenRepository, *mocks.PasskeyRepository, *mocks.AuditRepository) {
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		cfg.Public.BaseURL = origin
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		return appl, &userRepo, &tokenRepo, &passkeyRepo, &auditRepo
+	}
+
+	t.Run("Success - register and sign in with a software authenticator", func(t *testing.T) {
+		// Arrange
+		appl, userRepo, tokenRepo, passkeyRepo, auditRepo := newPasskeyAppl()
+		user := &domain.User{ID: 1, Name: "John Doe", Email: "john@example.com", Activated: true}
+		authenticator := webauthntest.New(origin)
+
+		var stored *domain.Passkey
+		registration := &domain.Token{Plaintext: "GQRPVONORIEUPDJ6V4RTDIVSTQ"}
+		login := &domain.Token{Plaintext: "KZ6TDK2EEQFFIE3EQTV5R3XCNU"}
+
+		passkeyRepo.On("GetAllForUser", mock.Anything, user.ID).Return(func(context.Context, int64) []*domain.Passkey {
+			if stored == nil {
+				return []*domain.Passkey{}
+			}
+			return []*domain.Passkey{stored}
+		}, nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopeWebAuthnRegistration, user.ID).Return(nil)
+		tokenRepo.On("New", mock.Anything, user.ID, 5*time.Minute, repositories.ScopeWebAuthnRegistration).Return(registration, nil)
+		tokenRepo.On("Consume", mock.Anything, repositories.ScopeWebAuthnRegistration, registration.Plaintext).Return(user.ID, nil).Once()
+		passkeyRepo.On("Insert", mock.Anything, mock.AnythingOfType("*domain.Passkey")).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*domain.Passkey)
+			stored.ID = 7
+		}).Return(nil)
+		auditRepo.On("Insert", mock.Anything, mock.IsType(&domain.AuditEvent{})).Return(nil)
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		userRepo.On("GetUserById", mock.Anything, user.ID).Return(user, nil)
+		tokenRepo.On("New", mock.Anything, user.ID, 5*time.Minute, repositories.ScopeWebAuthnLogin).Return(login, nil)
+		tokenRepo.On("Consume", mock.Anything, repositories.ScopeWebAuthnLogin, login.Plaintext).Return(user.ID, nil).Once()
+		passkeyRepo.On("GetByCredentialID", mock.Anything, mock.Anything).Return(func(context.Context, []byte) *domain.Passkey { return stored }, nil)
+		passkeyRepo.On("UpdateSignCount", mock.Anything, mock.AnythingOfType("*domain.Passkey")).Return(nil)
+
+		// Act
+		creationOptions, err := appl.BeginPasskeyRegistrationUseCase(context.Background(), user)
+		assert.NoError(t, err)
+		attestation, err := authenticator.Create(creationOptions)
+		assert.NoError(t, err)
+		passkey, err := appl.FinishPasskeyRegistrationUseCase(context.Background(), user, "Laptop", attestation)
+		assert.NoError(t, err)
+
+		requestOptions, err := appl.BeginPasskeyLoginUseCase(context.Background(), user.Email)
+		assert.NoError(t, err)
+		assertion, err := authenticator.Get(requestOptions)
+		assert.NoError(t, err)
+		signedIn, err := appl.FinishPasskeyLoginUseCase(context.Background(), assertion)
+		assert.NoError(t, err)
+
+		tokenBytes, err := appl.CreateAuthTokenUseCase(context.Background(), signedIn.ID)
+		assert.NoError(t, err)
+		validated, err := appl.ValidateAuthTokenUseCase(context.Background(), string(tokenBytes))
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "auth.example.com", creationOptions.RelyingParty.ID)
+		assert.Equal(t, "Laptop", passkey.Name)
+		assert.Equal(t, []string{"internal"}, passkey.Transports)
+		assert.Empty(t, requestOptions.AllowCredentials)
+		assert.Equal(t, uint32(1), stored.SignCount)
+		assert.Equal(t, user.ID, validated.ID)
+		auditRepo.AssertCalled(t, "Insert", mock.Anything, mock.MatchedBy(func(event *domain.AuditEvent) bool {
+			return event.Action == "register_passkey" && event.ResourceID == 7
+		}))
+	})
+
+	t.Run("Error - challenge issued to another user", func(t *testing.T) {
+		// Arrange
+		appl, _, tokenRepo, passkeyRepo, _ := newPasskeyAppl()
+		user := &domain.User{ID: 1, Email: "john@example.com"}
+		options := webauthn.New("auth.example.com", "Greenlight", []string{origin}, "", time.Minute).
+			CreationOptions([]byte("GQRPVONORIEUPDJ6V4RTDIVSTQ"), webauthn.User{ID: domain.UserHandle(user.ID)}, nil)
+		attestation, err := webauthntest.New(origin).Create(options)
+		assert.NoError(t, err)
+
+		tokenRepo.On("Consume", mock.Anything, repositories.ScopeWebAuthnRegistration, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(int64(2), nil)
+
+		// Act
+		passkey, err := appl.FinishPasskeyRegistrationUseCase(context.Background(), user, "Laptop", attestation)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, passkey)
+		passkeyRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - passkey of another user", func(t *testing.T) {
+		// Arrange
+		appl, _, tokenRepo, passkeyRepo, _ := newPasskeyAppl()
+		assertion := &webauthn.AssertionResponse{RawID: []byte{1, 2, 3}, Type: "public-key"}
+		assertion.Response.ClientDataJSON = []byte(`{"type":"webauthn.get","challenge":"R1FSUFZPTk9SSUVVUERKNlY0UlRESVZTVFE","origin":"https://auth.example.com"}`)
+
+		tokenRepo.On("Consume", mock.Anything, repositories.ScopeWebAuthnLogin, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(int64(1), nil)
+		passkeyRepo.On("GetByCredentialID", mock.Anything, []byte{1, 2, 3}).Return(&domain.Passkey{ID: 7, UserID: 2}, nil)
+
+		// Act
+		user, err := appl.FinishPasskeyLoginUseCase(context.Background(), assertion)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, user)
+		passkeyRepo.AssertNotCalled(t, "UpdateSignCount", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - no passkeys registered", func(t *testing.T) {
+		// Arrange
+		appl, userRepo, tokenRepo, passkeyRepo, _ := newPasskeyAppl()
+		user := &domain.User{ID: 1, Email: "john@example.com"}
+
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		passkeyRepo.On("GetAllForUser", mock.Anything, user.ID).Return([]*domain.Passkey{}, nil)
+
+		// Act
+		options, err := appl.BeginPasskeyLoginUseCase(context.Background(), user.Email)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, options.Challenge, 26)
+		assert.Empty(t, options.AllowCredentials)
+		tokenRepo.AssertNotCalled(t, "New", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - unknown email", func(t *testing.T) {
+		// Arrange
+		appl, userRepo, tokenRepo, passkeyRepo, _ := newPasskeyAppl()
+
+		userRepo.On("GetUserByEmail", mock.Anything, "jane@example.com").Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		options, err := appl.BeginPasskeyLoginUseCase(context.Background(), "jane@example.com")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, options.Challenge, 26)
+		assert.Empty(t, options.AllowCredentials)
+		passkeyRepo.AssertNotCalled(t, "GetAllForUser", mock.Anything, mock.Anything)
+		tokenRepo.AssertNotCalled(t, "New", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppl_ProvisionUserUseCase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{Name: "John Doe", Email: "john@example.com", Activated: true}
+
+		userRepo.On("InsertNewUser", mock.Anything, user, "somehash").Return(nil).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.User).ID = 1
+		})
+		permissionRepo.On("AddForUser", mock.Anything, int64(1), "movies:read").Return(nil)
+
+		// Act
+		err := appl.ProvisionUserUseCase(context.Background(), user, "somehash")
+
+		// Assert
+		assert.NoError(t, err)
+		userRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything)
+		tokenRepo.AssertNotCalled(t, "New", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		permissionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - provisioned inactive", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{Name: "John Doe", Email: "john@example.com", Suspended: true}
+
+		userRepo.On("InsertNewUser", mock.Anything, user, "somehash").Return(nil)
+		permissionRepo.On("AddForUser", mock.Anything, int64(0), "movies:read").Return(nil)
+		userRepo.On("UpdateUser", mock.Anything, user).Return(nil)
+
+		// Act
+		err := appl.ProvisionUserUseCase(context.Background(), user, "somehash")
+
+		// Assert
+		assert.NoError(t, err)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error - duplicate email", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{Name: "John Doe", Email: "john@example.com", Activated: true}
+
+		userRepo.On("InsertNewUser", mock.Anything, user, "somehash").Return(domain.ErrDuplicateEmail)
+
+		// Act
+		err := appl.ProvisionUserUseCase(context.Background(), user, "somehash")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrDuplicateEmail)
+		permissionRepo.AssertNotCalled(t, "AddForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppl_UpdateProvisionedUserUseCase(t *testing.T) {
+	t.Run("Success - suspended", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true, Suspended: true}
+
+		userRepo.On("UpdateUser", mock.Anything, user).Return(nil)
+		tokenRepo.On("DeleteAllForUser", mock.Anything, repositories.ScopeMagicLink, user.ID).Return(nil)
+
+		// Act
+		err := appl.UpdateProvisionedUserUseCase(context.Background(), user)
+
+		// Assert
+		assert.NoError(t, err)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - active", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 2, Email: "john@example.com", Activated: true}
+
+		userRepo.On("UpdateUser", mock.Anything, user).Return(nil)
+
+		// Act
+		err := appl.UpdateProvisionedUserUseCase(context.Background(), user)
+
+		// Assert
+		assert.NoError(t, err)
+		tokenRepo.AssertNotCalled(t, "DeleteAllForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - edit conflict", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		user := &domain.User{ID: 2, Email: "john@example.com", Suspended: true}
+
+		userRepo.On("UpdateUser", mock.Anything, user).Return(domain.ErrEditConflict)
+
+		// Act
+		err := appl.UpdateProvisionedUserUseCase(context.Background(), user)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrEditConflict)
+		tokenRepo.AssertNotCalled(t, "DeleteAllForUser", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppl_ListGroupsUseCase(t *testing.T) {
+	t.Run("Success - with members", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		members := []*domain.User{{ID: 1, Email: "john@example.com"}}
+
+		permissionRepo.On("GetAll", mock.Anything).Return([]*domain.Permission{{ID: 1, Code: "movies:read"}, {ID: 2, Code: "movies:write"}}, nil)
+		permissionRepo.On("GetUsers", mock.Anything, int64(1)).Return(members, nil)
+		permissionRepo.On("GetUsers", mock.Anything, int64(2)).Return([]*domain.User{}, nil)
+
+		// Act
+		groups, err := appl.ListGroupsUseCase(context.Background(), true)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, groups, 2)
+		assert.Equal(t, members, groups[0].Members)
+		assert.Equal(t, "movies:write", groups[1].Code)
+	})
+
+	t.Run("Success - without members", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		permissionRepo.On("GetAll", mock.Anything).Return([]*domain.Permission{{ID: 1, Code: "movies:read"}}, nil)
+
+		// Act
+		groups, err := appl.ListGroupsUseCase(context.Background(), false)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Nil(t, groups[0].Members)
+		permissionRepo.AssertNotCalled(t, "GetUsers", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppl_SetGroupMembersUseCase(t *testing.T) {
+	permission := &domain.Permission{ID: 2, Code: "movies:write"}
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		updated := []*domain.User{{ID: 1}, {ID: 3}}
+
+		permissionRepo.On("Get", mock.Anything, permission.ID).Return(permission, nil)
+		permissionRepo.On("GetUsers", mock.Anything, permission.ID).Return([]*domain.User{{ID: 1}, {ID: 2}}, nil).Once()
+		userRepo.On("GetUserById", mock.Anything, int64(3)).Return(&domain.User{ID: 3}, nil)
+		permissionRepo.On("AddUsers", mock.Anything, permission.ID, int64(3)).Return(nil)
+		permissionRepo.On("RemoveUsers", mock.Anything, permission.ID, int64(2)).Return(nil)
+		permissionRepo.On("GetUsers", mock.Anything, permission.ID).Return(updated, nil).Once()
+
+		// Act
+		group, err := appl.SetGroupMembersUseCase(context.Background(), permission.ID, []int64{3, 1, 3})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, updated, group.Members)
+		permissionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - unchanged", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		members := []*domain.User{{ID: 1}}
+
+		permissionRepo.On("Get", mock.Anything, permission.ID).Return(permission, nil)
+		permissionRepo.On("GetUsers", mock.Anything, permission.ID).Return(members, nil)
+
+		// Act
+		_, err := appl.SetGroupMembersUseCase(context.Background(), permission.ID, []int64{1})
+
+		// Assert
+		assert.NoError(t, err)
+		permissionRepo.AssertNotCalled(t, "AddUsers", mock.Anything, mock.Anything, mock.Anything)
+		permissionRepo.AssertNotCalled(t, "RemoveUsers", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - unknown user", func(t *testing.T) {
+		// Arrange
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+
+		permissionRepo.On("Get", mock.Anything, permission.ID).Return(permission, nil)
+		permissionRepo.On("GetUsers", mock.Anything, permission.ID).Return([]*domain.User{}, nil)
+		userRepo.On("GetUserById", mock.Anything, int64(9)).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		group, err := appl.SetGroupMembersUseCase(context.Background(), permission.ID, []int64{9})
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, group)
+		permissionRepo.AssertNotCalled(t, "AddUsers", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAppl_DeviceAuthorization(t *testing.T) {
+	newDeviceAppl := func() (domain.Appl, *mocks.UserRepository, *mocks.DeviceAuthorizationRepository, *mocks.AuditRepository) {
+		userRepo, tokenRepo, permissionRepo, invitationRepo, auditRepo, deviceRepo, passkeyRepo, deviceAuthorizationRepo, outboxRepo, cfg := Init()
+		appl := NewAppl(Deps{
+			Users:                &userRepo,
+			Tokens:               &tokenRepo,
+			Permissions:          &permissionRepo,
+			Invitations:          &invitationRepo,
+			Audit:                &auditRepo,
+			Devices:              &deviceRepo,
+			Passkeys:             &passkeyRepo,
+			DeviceAuthorizations: &deviceAuthorizationRepo,
+			Outbox:               &outboxRepo,
+			UnitOfWork:           newInlineUnitOfWork(&userRepo, &tokenRepo, &permissionRepo, &invitationRepo, &outboxRepo, &deviceRepo),
+		}, cfg)
+		return appl, &userRepo, &deviceAuthorizationRepo, &auditRepo
+	}
+
+	pending := func(lastPolledAt time.Time) *domain.DeviceAuthorization {
+		return &domain.DeviceAuthorization{
+			ID:           3,
+			ClientID:     "living-room",
+			Status:       domain.DeviceAuthorizationPending,
+			Interval:     5 * time.Second,
+			LastPolledAt: lastPolledAt,
+			Expiry:       time.Now().Add(10 * time.Minute),
+		}
+	}
+
+	t.Run("Success - create with configured lifetimes", func(t *testing.T) {
+		// Arrange
+		appl, _, deviceAuthorizationRepo, _ := newDeviceAppl()
+		expected := pending(time.Time{})
+
+		deviceAuthorizationRepo.On("DeleteExpired", mock.Anything).Return(nil)
+		deviceAuthorizationRepo.On("New", mock.Anything, "living-room", 10*time.Minute, 5*time.Second).Return(expected, nil)
+
+		// Act
+		authorization, err := appl.CreateDeviceAuthorizationUseCase(context.Background(), "living-room")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, authorization)
+	})
+
+	t.Run("Success - approve", func(t *testing.T) {
+		// Arrange
+		appl, _, deviceAuthorizationRepo, auditRepo := newDeviceAppl()
+		user := &domain.User{ID: 1, Activated: true}
+
+		deviceAuthorizationRepo.On("GetByUserCode", mock.Anything, "BCDF-GHJK").Return(pending(time.Time{}), nil)
+		deviceAuthorizationRepo.On("Answer", mock.Anything, mock.MatchedBy(func(authorization *domain.DeviceAuthorization) bool {
+			return authorization.UserID == user.ID && authorization.Status == domain.DeviceAuthorizationApproved
+		})).Return(nil)
+		auditRepo.On("Insert", mock.Anything, mock.MatchedBy(func(event *domain.AuditEvent) bool {
+			return event.UserID == user.ID && event.Action == "approve_device" && event.ResourceID == 3
+		})).Return(nil)
+
+		// Act
+		err := appl.VerifyDeviceAuthorizationUseCase(context.Background(), "BCDF-GHJK", user, true)
+
+		// Assert
+		assert.NoError(t, err)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success - deny", func(t *testing.T) {
+		// Arrange
+		appl, _, deviceAuthorizationRepo, auditRepo := newDeviceAppl()
+		user := &domain.User{ID: 1, Activated: true}
+
+		deviceAuthorizationRepo.On("GetByUserCode", mock.Anything, "BCDF-GHJK").Return(pending(time.Time{}), nil)
+		deviceAuthorizationRepo.On("Answer", mock.Anything, mock.MatchedBy(func(authorization *domain.DeviceAuthorization) bool {
+			return authorization.Status == domain.DeviceAuthorizationDenied
+		})).Return(nil)
+
+		// Act
+		err := appl.VerifyDeviceAuthorizationUseCase(context.Background(), "BCDF-GHJK", user, false)
+
+		// Assert
+		assert.NoError(t, err)
+		auditRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - exchange approved device code", func(t *testing.T) {
+		// Arrange
+		appl, userRepo, deviceAuthorizationRepo, _ := newDeviceAppl()
+		authorization := pending(time.Time{})
+		authorization.Status = domain.DeviceAuthorizationApproved
+		authorization.UserID = 1
+
+		deviceAuthorizationRepo.On("GetByDeviceCode", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(authorization, nil)
+		deviceAuthorizationRepo.On("Delete", mock.Anything, int64(3)).Return(nil)
+		userRepo.On("GetUserById", mock.Anything, int64(1)).Return(&domain.User{ID: 1, Activated: true}, nil)
+
+		// Act
+		jwtBytes, err := appl.ExchangeDeviceCodeUseCase(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ", "living-room")
+
+		// Assert
+		assert.NoError(t, err)
+		claims, err := jwt.HMACCheck(jwtBytes, []byte("ifTp39TukiePBVu7SY1K+l07v8l1aiP+F2Tu9BxQ34c="))
+		assert.NoError(t, err)
+		assert.Equal(t, "1", claims.Subject)
+	})
+
+	t.Run("Error - authorization pending on first poll", func(t *testing.T) {
+		// Arrange
+		appl, _, deviceAuthorizationRepo, _ := newDeviceAppl()
+
+		deviceAuthorizationRepo.On("GetByDeviceCode", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(pending(time.Time{}), nil)
+		deviceAuthorizationRepo.On("RecordPoll", mock.Anything, mock.MatchedBy(func(authorization *domain.DeviceAuthorization) bool {
+			return !authorization.LastPolledAt.IsZero() && authorization.Interval == 5*time.Second
+		})).Return(nil)
+
+		// Act
+		_, err := appl.ExchangeDeviceCodeUseCase(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ", "living-room")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrAuthorizationPending)
+	})
+
+	t.Run("Error - slow down lengthens the interval", func(t *testing.T) {
+		// Arrange
+		appl, _, deviceAuthorizationRepo, _ := newDeviceAppl()
+
+		deviceAuthorizationRepo.On("GetByDeviceCode", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(pending(time.Now().Add(-2*time.Second)), nil)
+		deviceAuthorizationRepo.On("RecordPoll", mock.Anything, mock.MatchedBy(func(authorization *domain.DeviceAuthorization) bool {
+			return authorization.Interval == 10*time.Second
+		})).Return(nil)
+
+		// Act
+		_, err := appl.ExchangeDeviceCodeUseCase(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ", "living-room")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrSlowDown)
+	})
+
+	t.Run("Error - approved while polling", func(t *testing.T) {
+		// Arrange
+		appl, _, deviceAuthorizationRepo, _ := newDeviceAppl()
+
+		deviceAuthorizationRepo.On("GetByDeviceCode", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(pending(time.Time{}), nil)
+		deviceAuthorizationRepo.On("RecordPoll", mock.Anything, mock.Anything).Return(domain.ErrRecordNotFound)
+
+		// Act
+		_, err := appl.ExchangeDeviceCodeUseCase(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ", "living-room")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrAuthorizationPending)
+	})
+
+	t.Run("Error - code answered twice", func(t *testing.T) {
+		// Arrange
+		appl, _, deviceAuthorizationRepo, auditRepo := newDeviceAppl()
+		user := &domain.User{ID: 1, Activated: true}
+
+		deviceAuthorizationRepo.On("GetByUserCode", mock.Anything, "BCDF-GHJK").Return(pending(time.Time{}), nil)
+		deviceAuthorizationRepo.On("Answer", mock.Anything, mock.Anything).Return(domain.ErrRecordNotFound)
+
+		// Act
+		err := appl.VerifyDeviceAuthorizationUseCase(context.Background(), "BCDF-GHJK", user, true)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		auditRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - denied", func(t *testing.T) {
+		// Arrange
+		appl, _, deviceAuthorizationRepo, _ := newDeviceAppl()
+		authorization := pending(time.Time{})
+		authorization.Status = domain.DeviceAuthorizationDenied
+
+		deviceAuthorizationRepo.On("GetByDeviceCode", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(authorization, nil)
+		deviceAuthorizationRepo.On("Delete", mock.Anything, int64(3)).Return(nil)
+
+		// Act
+		_, err := appl.ExchangeDeviceCodeUseCase(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ", "living-room")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrAccessDenied)
+	})
+
+	t.Run("Error - expired", func(t *testing.T) {
+		// Arrange
+		appl, _, deviceAuthorizationRepo, _ := newDeviceAppl()
+		authorization := pending(time.Time{})
+		authorization.Expiry = time.Now().Add(-time.Second)
+
+		deviceAuthorizationRepo.On("GetByDeviceCode", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(authorization, nil)
+
+		// Act
+		_, err := appl.ExchangeDeviceCodeUseCase(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ", "living-room")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrExpiredToken)
+		deviceAuthorizationRepo.AssertNotCalled(t, "RecordPoll", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Error - device code issued to another client", func(t *testing.T) {
+		// Arrange
+		appl, _, deviceAuthorizationRepo, _ := newDeviceAppl()
+
+		deviceAuthorizationRepo.On("GetByDeviceCode", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(pending(time.Time{}), nil)
+
+		// Act
+		_, err := appl.ExchangeDeviceCodeUseCase(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ", "console")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+
+	t.Run("Error - suspended user", func(t *testing.T) {
+		// Arrange
+		appl, userRepo, deviceAuthorizationRepo, _ := newDeviceAppl()
+		authorization := pending(time.Time{})
+		authorization.Status = domain.DeviceAuthorizationApproved
+		authorization.UserID = 1
+
+		deviceAuthorizationRepo.On("GetByDeviceCode", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(authorization, nil)
+		deviceAuthorizationRepo.On("Delete", mock.Anything, int64(3)).Return(nil)
+		userRepo.On("GetUserById", mock.Anything, int64(1)).Return(&domain.User{ID: 1, Activated: true, Suspended: true}, nil)
+
+		// Act
+		_, err := appl.ExchangeDeviceCodeUseCase(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ", "living-room")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrAccountSuspended)
+	})
+}
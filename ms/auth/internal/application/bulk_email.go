@@ -0,0 +1,152 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/concurrent"
+	"github.com/jessicatarra/greenlight/internal/config"
+	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultBulkEmailBatchSize = 100
+
+	// bulkEmailLease is how long a bulk email is locked by a job run with
+	// no deadline.
+	bulkEmailLease = 5 * time.Minute
+)
+
+// BulkSender sends emails one after another over a connection it keeps
+// open, at a steady pace. *mailer.Bulk is one.
+type BulkSender interface {
+	Send(ctx context.Context, recipient, templateFile, locale string, data map[string]interface{}) error
+	Close() error
+}
+
+// BulkEmailRunner runs the jobs that send bulk emails. A job sends until
+// every user has been emailed or nine tenths of its time is up, when it
+// queues another job to carry on, so that a bulk email of any size is sent
+// without a job outliving its visibility timeout.
+type BulkEmailRunner struct {
+	bulkEmails domain.BulkEmailRepository
+	jobs       domain.JobQueue
+	open       func() BulkSender
+	logger     *slog.Logger
+	batchSize  int
+}
+
+// NewBulkEmailRunner returns a runner sending through the senders open
+// returns, one for each job.
+func NewBulkEmailRunner(bulkEmails domain.BulkEmailRepository, jobs domain.JobQueue, open func() BulkSender, cfg config.Config, logger *slog.Logger) *BulkEmailRunner {
+	if cfg.BulkEmail.BatchSize <= 0 {
+		cfg.BulkEmail.BatchSize = defaultBulkEmailBatchSize
+	}
+
+	return &BulkEmailRunner{
+		bulkEmails: bulkEmails,
+		jobs:       jobs,
+		open:       open,
+		logger:     logger,
+		batchSize:  cfg.BulkEmail.BatchSize,
+	}
+}
+
+// Run sends the bulk email of a domain.BulkEmailJobKind job, starting with
+// the user after the last one it got to. A failure to send to one user is
+// recorded and the job moves on; one that leaves no email going out, such as
+// the mail server being down, fails the job so that the queue retries it
+// later.
+func (r *BulkEmailRunner) Run(ctx context.Context, job concurrent.Job, payload domain.BulkEmailJob) error {
+	started := time.Now()
+	deadline, hasDeadline := ctx.Deadline()
+	if !hasDeadline {
+		deadline = started.Add(bulkEmailLease)
+	}
+	handOffAt := started.Add(deadline.Sub(started) * 9 / 10)
+
+	bulk, err := r.bulkEmails.Acquire(ctx, payload.BulkEmailID, deadline)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			// It has been completed, or another job is sending it.
+			r.logger.Debug("bulk email not acquired", "id", payload.BulkEmailID, "job", job.ID)
+			return nil
+		}
+		return err
+	}
+
+	sender := r.open()
+	defer sender.Close()
+
+	// Progress is recorded even when ctx is done meanwhile, so that a user
+	// who was emailed is not emailed again.
+	record := context.WithoutCancel(ctx)
+
+	// Each job gets at least one email out before handing off, so that
+	// bulk emails move on however short the visibility timeout.
+	progressed := false
+
+	for {
+		users, err := r.bulkEmails.Recipients(ctx, bulk, r.batchSize)
+		if err != nil {
+			return r.stop(record, bulk, err)
+		}
+
+		for _, user := range users {
+			if progressed && time.Now().After(handOffAt) {
+				return r.handOff(record, bulk)
+			}
+
+			sendErr := sender.Send(ctx, user.Email, bulk.Template, user.Locale, mailer.BulkData(user.ID, user.Name, user.Email))
+			if sendErr != nil && (ctx.Err() != nil || errors.Is(sendErr, mailer.ErrUnavailable)) {
+				return r.stop(record, bulk, sendErr)
+			}
+
+			var sendError string
+			if sendErr != nil {
+				sendError = sendErr.Error()
+				r.logger.Warn("failed to send bulk email", "id", bulk.ID, "user_id", user.ID, "error", sendErr)
+			}
+
+			err = r.bulkEmails.RecordDelivery(record, bulk.ID, user, sendError)
+			if err != nil {
+				return r.stop(record, bulk, err)
+			}
+
+			bulk.LastUserID = user.ID
+			progressed = true
+		}
+
+		if len(users) < r.batchSize {
+			break
+		}
+	}
+
+	r.logger.Info("bulk email completed", "id", bulk.ID, "template", bulk.Template, "duration", time.Since(started))
+
+	return r.bulkEmails.Complete(record, bulk.ID)
+}
+
+// handOff unlocks bulk and queues a job to carry on sending it.
+func (r *BulkEmailRunner) handOff(ctx context.Context, bulk *domain.BulkEmail) error {
+	err := r.bulkEmails.Release(ctx, bulk.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.jobs.Enqueue(ctx, domain.BulkEmailJobKind, domain.BulkEmailJob{BulkEmailID: bulk.ID})
+	return err
+}
+
+// stop unlocks bulk, so that the retry of the job can resume it straight
+// away, and returns err.
+func (r *BulkEmailRunner) stop(ctx context.Context, bulk *domain.BulkEmail, err error) error {
+	releaseErr := r.bulkEmails.Release(ctx, bulk.ID)
+	if releaseErr != nil {
+		r.logger.Error("failed to release bulk email", "id", bulk.ID, "error", releaseErr)
+	}
+
+	return err
+}
@@ -0,0 +1,219 @@
+//go:build auth
+// +build auth
+
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jessicatarra/greenlight/internal/concurrent"
+	"github.com/jessicatarra/greenlight/internal/config"
+	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// fakeBulkSender records the emails it is asked to send, failing those to
+// the recipients in failures with their error.
+type fakeBulkSender struct {
+	sent     []string
+	failures map[string]error
+	closed   bool
+}
+
+func (f *fakeBulkSender) Send(ctx context.Context, recipient, templateFile, locale string, data map[string]interface{}) error {
+	if err := f.failures[recipient]; err != nil {
+		return err
+	}
+	f.sent = append(f.sent, recipient+" "+locale+" "+templateFile)
+	return nil
+}
+
+func (f *fakeBulkSender) Close() error {
+	f.closed = true
+	return nil
+}
+
+func newTestBulkEmailRunner(bulkEmailRepo domain.BulkEmailRepository, jobs domain.JobQueue, sender *fakeBulkSender) *BulkEmailRunner {
+	var cfg config.Config
+	cfg.BulkEmail.BatchSize = 2
+
+	return NewBulkEmailRunner(bulkEmailRepo, jobs, func() BulkSender { return sender }, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestBulkEmailRunner_Run(t *testing.T) {
+	job := concurrent.Job{ID: 7, Kind: domain.BulkEmailJobKind}
+	payload := domain.BulkEmailJob{BulkEmailID: 3}
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		bulkEmailRepo := mocks.NewBulkEmailRepository(t)
+		sender := &fakeBulkSender{failures: map[string]error{"sarah@example.com": errors.New("550 mailbox unavailable")}}
+		runner := newTestBulkEmailRunner(bulkEmailRepo, mocks.NewJobQueue(t), sender)
+
+		bulk := &domain.BulkEmail{ID: 3, Template: "release_announcement.gohtml", LastUserID: 10}
+		john := &domain.User{ID: 11, Email: "john@example.com", Locale: "en"}
+		sarah := &domain.User{ID: 12, Email: "sarah@example.com", Locale: "en"}
+		juan := &domain.User{ID: 14, Email: "juan@example.com", Locale: "es"}
+
+		bulkEmailRepo.On("Acquire", mock.Anything, int64(3), mock.Anything).Return(bulk, nil)
+		bulkEmailRepo.On("Recipients", mock.Anything, bulk, 2).Return([]*domain.User{john, sarah}, nil).Once()
+		bulkEmailRepo.On("Recipients", mock.Anything, bulk, 2).Return([]*domain.User{juan}, nil).Once()
+		bulkEmailRepo.On("RecordDelivery", mock.Anything, int64(3), john, "").Return(nil)
+		bulkEmailRepo.On("RecordDelivery", mock.Anything, int64(3), sarah, "550 mailbox unavailable").Return(nil)
+		bulkEmailRepo.On("RecordDelivery", mock.Anything, int64(3), juan, "").Return(nil)
+		bulkEmailRepo.On("Complete", mock.Anything, int64(3)).Return(nil)
+
+		// Act
+		err := runner.Run(context.Background(), job, payload)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"john@example.com en release_announcement.gohtml", "juan@example.com es release_announcement.gohtml"}, sender.sent)
+		assert.Equal(t, int64(14), bulk.LastUserID)
+		assert.True(t, sender.closed)
+	})
+
+	t.Run("Not acquired", func(t *testing.T) {
+		// Arrange
+		bulkEmailRepo := mocks.NewBulkEmailRepository(t)
+		runner := newTestBulkEmailRunner(bulkEmailRepo, mocks.NewJobQueue(t), &fakeBulkSender{})
+
+		bulkEmailRepo.On("Acquire", mock.Anything, int64(3), mock.Anything).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		err := runner.Run(context.Background(), job, payload)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("Mail server unavailable", func(t *testing.T) {
+		// Arrange
+		bulkEmailRepo := mocks.NewBulkEmailRepository(t)
+		unavailable := fmt.Errorf("%w: connection refused", mailer.ErrUnavailable)
+		sender := &fakeBulkSender{failures: map[string]error{"john@example.com": unavailable}}
+		runner := newTestBulkEmailRunner(bulkEmailRepo, mocks.NewJobQueue(t), sender)
+
+		bulk := &domain.BulkEmail{ID: 3, Template: "release_announcement.gohtml"}
+		john := &domain.User{ID: 11, Email: "john@example.com"}
+
+		bulkEmailRepo.On("Acquire", mock.Anything, int64(3), mock.Anything).Return(bulk, nil)
+		bulkEmailRepo.On("Recipients", mock.Anything, bulk, 2).Return([]*domain.User{john}, nil)
+		bulkEmailRepo.On("Release", mock.Anything, int64(3)).Return(nil)
+
+		// Act
+		err := runner.Run(context.Background(), job, payload)
+
+		// Assert
+		assert.ErrorIs(t, err, mailer.ErrUnavailable)
+		bulkEmailRepo.AssertNotCalled(t, "RecordDelivery", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Hands off before the deadline", func(t *testing.T) {
+		// Arrange
+		bulkEmailRepo := mocks.NewBulkEmailRepository(t)
+		jobs := mocks.NewJobQueue(t)
+		sender := &fakeBulkSender{}
+		runner := newTestBulkEmailRunner(bulkEmailRepo, jobs, sender)
+
+		bulk := &domain.BulkEmail{ID: 3, Template: "release_announcement.gohtml"}
+		john := &domain.User{ID: 11, Email: "john@example.com"}
+		sarah := &domain.User{ID: 12, Email: "sarah@example.com"}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		bulkEmailRepo.On("Acquire", mock.Anything, int64(3), mock.Anything).Return(bulk, nil)
+		bulkEmailRepo.On("Recipients", mock.Anything, bulk, 2).Return([]*domain.User{john, sarah}, nil)
+		// The first delivery takes up the time of the job.
+		bulkEmailRepo.On("RecordDelivery", mock.Anything, int64(3), john, "").Return(nil).After(50 * time.Millisecond)
+		bulkEmailRepo.On("Release", mock.Anything, int64(3)).Return(nil)
+		jobs.On("Enqueue", mock.Anything, domain.BulkEmailJobKind, domain.BulkEmailJob{BulkEmailID: 3}).Return(int64(8), nil)
+
+		// Act
+		err := runner.Run(ctx, job, payload)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, sender.sent, 1)
+		assert.Equal(t, int64(11), bulk.LastUserID)
+	})
+}
+
+func TestCreateBulkEmailUseCase(t *testing.T) {
+	// Arrange
+	_, _, _, _, _, _, _, _, _, cfg := Init()
+	bulkEmailRepo := mocks.NewBulkEmailRepository(t)
+	jobs := mocks.NewJobQueue(t)
+	app := NewAppl(Deps{
+		BulkEmails: bulkEmailRepo,
+		Jobs:       jobs,
+	}, cfg)
+
+	activated := true
+	input := &domain.CreateBulkEmailRequest{Template: "release_announcement.gohtml", Filter: domain.UserFilter{Activated: &activated}}
+
+	bulkEmailRepo.On("Insert", mock.Anything, mock.MatchedBy(func(bulk *domain.BulkEmail) bool {
+		return bulk.Template == input.Template && bulk.CreatedBy == 1
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.BulkEmail).ID = 3
+	}).Return(nil)
+	jobs.On("Enqueue", mock.Anything, domain.BulkEmailJobKind, domain.BulkEmailJob{BulkEmailID: 3}).Return(int64(7), nil)
+
+	// Act
+	bulk, err := app.CreateBulkEmailUseCase(context.Background(), input, 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), bulk.ID)
+}
+
+func TestResumeBulkEmailUseCase(t *testing.T) {
+	_, _, _, _, _, _, _, _, _, cfg := Init()
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		bulkEmailRepo := mocks.NewBulkEmailRepository(t)
+		jobs := mocks.NewJobQueue(t)
+		app := NewAppl(Deps{
+			BulkEmails: bulkEmailRepo,
+			Jobs:       jobs,
+		}, cfg)
+
+		bulkEmailRepo.On("Get", mock.Anything, int64(3)).Return(&domain.BulkEmail{ID: 3, Status: domain.BulkEmailSending}, nil)
+		jobs.On("Enqueue", mock.Anything, domain.BulkEmailJobKind, domain.BulkEmailJob{BulkEmailID: 3}).Return(int64(9), nil)
+
+		// Act
+		bulk, err := app.ResumeBulkEmailUseCase(context.Background(), 3)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), bulk.ID)
+	})
+
+	t.Run("Completed", func(t *testing.T) {
+		// Arrange
+		bulkEmailRepo := mocks.NewBulkEmailRepository(t)
+		app := NewAppl(Deps{
+			BulkEmails: bulkEmailRepo,
+			Jobs:       mocks.NewJobQueue(t),
+		}, cfg)
+
+		bulkEmailRepo.On("Get", mock.Anything, int64(3)).Return(&domain.BulkEmail{ID: 3, Status: domain.BulkEmailCompleted}, nil)
+
+		// Act
+		bulk, err := app.ResumeBulkEmailUseCase(context.Background(), 3)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrBulkEmailCompleted)
+		assert.Nil(t, bulk)
+	})
+}
@@ -0,0 +1,142 @@
+package application
+
+import (
+	"context"
+	"github.com/jessicatarra/greenlight/internal/concurrent"
+	"github.com/jessicatarra/greenlight/internal/config"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"log/slog"
+	"time"
+)
+
+const (
+	defaultOutboxPollInterval = 2 * time.Second
+	defaultOutboxBatchSize    = 20
+	defaultOutboxMaxAttempts  = 8
+
+	// outboxSendTimeout is how long a send is allowed to take. The SMTP
+	// transport gives up on connecting after five seconds.
+	outboxSendTimeout = 5 * time.Second
+
+	// outboxLeaseMargin is added to the time a batch takes to send, to
+	// allow for the queries around it.
+	outboxLeaseMargin = 30 * time.Second
+
+	outboxMinBackoff = 30 * time.Second
+	outboxMaxBackoff = time.Hour
+)
+
+// Sender delivers an email rendered from a template. mailer.Mailer is one.
+type Sender interface {
+	Send(recipient, templateFile, locale string, data map[string]interface{}) error
+}
+
+// OutboxDispatcher delivers the emails use cases record in the outbox. A
+// failed send is retried with exponential backoff until the email runs out
+// of attempts and is marked dead.
+type OutboxDispatcher struct {
+	outbox       domain.OutboxRepository
+	sender       Sender
+	logger       *slog.Logger
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	// lease is how long a claimed batch is hidden from other dispatchers:
+	// long enough for every email of the batch to take sendTimeout.
+	lease       time.Duration
+	sendTimeout time.Duration
+}
+
+func NewOutboxDispatcher(outbox domain.OutboxRepository, sender Sender, cfg config.Config, logger *slog.Logger) *OutboxDispatcher {
+	if cfg.Outbox.PollInterval <= 0 {
+		cfg.Outbox.PollInterval = defaultOutboxPollInterval
+	}
+	if cfg.Outbox.BatchSize <= 0 {
+		cfg.Outbox.BatchSize = defaultOutboxBatchSize
+	}
+	if cfg.Outbox.MaxAttempts <= 0 {
+		cfg.Outbox.MaxAttempts = defaultOutboxMaxAttempts
+	}
+
+	return &OutboxDispatcher{
+		outbox:       outbox,
+		sender:       sender,
+		logger:       logger,
+		pollInterval: cfg.Outbox.PollInterval,
+		batchSize:    cfg.Outbox.BatchSize,
+		maxAttempts:  cfg.Outbox.MaxAttempts,
+		lease:        time.Duration(cfg.Outbox.BatchSize)*outboxSendTimeout + outboxLeaseMargin,
+		sendTimeout:  outboxSendTimeout,
+	}
+}
+
+// Run dispatches due emails until ctx is done. A full batch is followed by
+// the next one straight away, so that a backlog drains without waiting for
+// the poll interval.
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		sent, err := d.Dispatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			d.logger.Error("failed to dispatch outbound emails", "error", err)
+		}
+
+		if sent == d.batchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch claims a batch of due emails and tries to send each of them. It
+// returns the number of emails claimed. Once ctx is done, or the lease on the
+// batch leaves too little time for another send to finish, it stops sending;
+// the emails it has claimed but not sent are retried when their lease runs
+// out. No email is still being sent when another dispatcher can claim it.
+func (d *OutboxDispatcher) Dispatch(ctx context.Context) (int, error) {
+	sendBy := time.Now().Add(d.lease - d.sendTimeout)
+
+	emails, err := d.outbox.ClaimDue(ctx, d.batchSize, d.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, email := range emails {
+		if ctx.Err() != nil || time.Now().After(sendBy) {
+			break
+		}
+
+		// The outcome of a send is recorded even if ctx is done meanwhile,
+		// so that an email that went out is not sent again.
+		err := d.deliver(context.WithoutCancel(ctx), email)
+		if err != nil {
+			return len(emails), err
+		}
+	}
+
+	return len(emails), nil
+}
+
+func (d *OutboxDispatcher) deliver(ctx context.Context, email *domain.OutboxEmail) error {
+	sendErr := d.sender.Send(email.Recipient, email.Template, email.Locale, email.Data)
+	if sendErr == nil {
+		return d.outbox.MarkSent(ctx, email.ID)
+	}
+
+	if email.Attempts >= d.maxAttempts {
+		d.logger.Error("giving up on outbound email", "id", email.ID, "template", email.Template, "attempts", email.Attempts, "error", sendErr)
+		return d.outbox.MarkDead(ctx, email.ID, sendErr.Error())
+	}
+
+	nextAttemptAt := time.Now().Add(concurrent.Backoff(email.Attempts, outboxMinBackoff, outboxMaxBackoff))
+	d.logger.Warn("failed to send outbound email", "id", email.ID, "template", email.Template, "attempts", email.Attempts, "next_attempt_at", nextAttemptAt, "error", sendErr)
+
+	return d.outbox.MarkFailed(ctx, email.ID, sendErr.Error(), nextAttemptAt)
+}
@@ -0,0 +1,160 @@
+//go:build auth
+// +build auth
+
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/config"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// fakeSender records the emails it is asked to send, taking delay over each,
+// and fails with err.
+type fakeSender struct {
+	sent  []string
+	err   error
+	delay time.Duration
+}
+
+func (f *fakeSender) Send(recipient, templateFile, locale string, data map[string]interface{}) error {
+	time.Sleep(f.delay)
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, recipient+" "+locale+" "+templateFile)
+	return nil
+}
+
+func newTestDispatcher(outboxRepo domain.OutboxRepository, sender Sender) *OutboxDispatcher {
+	var cfg config.Config
+	cfg.Outbox.MaxAttempts = 3
+
+	return NewOutboxDispatcher(outboxRepo, sender, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestOutboxDispatcher_Dispatch(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		outboxRepo := mocks.NewOutboxRepository(t)
+		sender := &fakeSender{}
+		dispatcher := newTestDispatcher(outboxRepo, sender)
+
+		emails := []*domain.OutboxEmail{
+			{ID: 1, Recipient: "john@example.com", Locale: "en", Template: "user_welcome.gohtml", Attempts: 1},
+			{ID: 2, Recipient: "sarah@example.com", Locale: "es", Template: "user_magic_link.gohtml", Attempts: 2},
+		}
+
+		outboxRepo.On("ClaimDue", mock.Anything, defaultOutboxBatchSize, dispatcher.lease).Return(emails, nil)
+		outboxRepo.On("MarkSent", mock.Anything, int64(1)).Return(nil)
+		outboxRepo.On("MarkSent", mock.Anything, int64(2)).Return(nil)
+
+		// Act
+		claimed, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 2, claimed)
+		assert.Equal(t, []string{"john@example.com en user_welcome.gohtml", "sarah@example.com es user_magic_link.gohtml"}, sender.sent)
+	})
+
+	t.Run("Failure - retried later", func(t *testing.T) {
+		// Arrange
+		outboxRepo := mocks.NewOutboxRepository(t)
+		dispatcher := newTestDispatcher(outboxRepo, &fakeSender{err: errors.New("connection refused")})
+
+		emails := []*domain.OutboxEmail{{ID: 1, Recipient: "john@example.com", Template: "user_welcome.gohtml", Attempts: 2}}
+
+		outboxRepo.On("ClaimDue", mock.Anything, defaultOutboxBatchSize, dispatcher.lease).Return(emails, nil)
+		outboxRepo.On("MarkFailed", mock.Anything, int64(1), "connection refused", mock.MatchedBy(func(nextAttemptAt time.Time) bool {
+			return nextAttemptAt.After(time.Now().Add(outboxMinBackoff))
+		})).Return(nil)
+
+		// Act
+		_, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("Failure - out of attempts", func(t *testing.T) {
+		// Arrange
+		outboxRepo := mocks.NewOutboxRepository(t)
+		dispatcher := newTestDispatcher(outboxRepo, &fakeSender{err: errors.New("mailbox unavailable")})
+
+		emails := []*domain.OutboxEmail{{ID: 1, Recipient: "john@example.com", Template: "user_welcome.gohtml", Attempts: 3}}
+
+		outboxRepo.On("ClaimDue", mock.Anything, defaultOutboxBatchSize, dispatcher.lease).Return(emails, nil)
+		outboxRepo.On("MarkDead", mock.Anything, int64(1), "mailbox unavailable").Return(nil)
+
+		// Act
+		_, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		// Arrange
+		outboxRepo := mocks.NewOutboxRepository(t)
+		sender := &fakeSender{}
+		dispatcher := newTestDispatcher(outboxRepo, sender)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		emails := []*domain.OutboxEmail{{ID: 1, Recipient: "john@example.com", Template: "user_welcome.gohtml", Attempts: 1}}
+
+		outboxRepo.On("ClaimDue", mock.Anything, defaultOutboxBatchSize, dispatcher.lease).Return(emails, nil)
+
+		// Act
+		_, err := dispatcher.Dispatch(ctx)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Empty(t, sender.sent)
+	})
+
+	t.Run("Slow batch stops before its lease runs out", func(t *testing.T) {
+		// Arrange
+		outboxRepo := mocks.NewOutboxRepository(t)
+		sender := &fakeSender{delay: 20 * time.Millisecond}
+		dispatcher := newTestDispatcher(outboxRepo, sender)
+		dispatcher.lease = 50 * time.Millisecond
+		dispatcher.sendTimeout = 20 * time.Millisecond
+
+		var emails []*domain.OutboxEmail
+		for id := int64(1); id <= 5; id++ {
+			emails = append(emails, &domain.OutboxEmail{ID: id, Recipient: "john@example.com", Template: "user_welcome.gohtml", Attempts: 1})
+		}
+
+		outboxRepo.On("ClaimDue", mock.Anything, defaultOutboxBatchSize, dispatcher.lease).Return(emails, nil)
+		outboxRepo.On("MarkSent", mock.Anything, mock.Anything).Return(nil)
+
+		// Act
+		started := time.Now()
+		claimed, err := dispatcher.Dispatch(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 5, claimed)
+		assert.Less(t, time.Since(started), dispatcher.lease)
+		assert.Less(t, len(sender.sent), 5)
+	})
+}
+
+func TestNewOutboxDispatcher(t *testing.T) {
+	dispatcher := newTestDispatcher(mocks.NewOutboxRepository(t), &fakeSender{})
+
+	// A batch in which every send takes as long as it may still finishes
+	// within its lease.
+	assert.Greater(t, dispatcher.lease, time.Duration(dispatcher.batchSize)*dispatcher.sendTimeout)
+}
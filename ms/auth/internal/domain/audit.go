@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"context"
+	"github.com/jessicatarra/greenlight/internal/database"
+)
+
+// AuditEvent is an entry of the audit log shared with the monolith.
+type AuditEvent = database.AuditEvent
+
+type AuditRepository interface {
+	Insert(ctx context.Context, event *AuditEvent) error
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"context"
+	"github.com/jessicatarra/greenlight/internal/utils/validator"
+	"time"
+)
+
+const (
+	BulkEmailPending   = "pending"
+	BulkEmailSending   = "sending"
+	BulkEmailCompleted = "completed"
+)
+
+// BulkEmailJobKind is the kind of the background jobs that send bulk emails.
+const BulkEmailJobKind = "bulk_email"
+
+// BulkEmail is an email sent from a template to every user matching a
+// filter, such as an announcement to all activated users. Users are emailed
+// in ID order and the progress is saved after each of them, so that a bulk
+// email that stops part way resumes with the next user rather than starting
+// over.
+type BulkEmail struct {
+	ID        int64      `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Template  string     `json:"template"`
+	Filter    UserFilter `json:"filter"`
+	Status    string     `json:"status"`
+	// Total is the number of users matching the filter when the bulk email
+	// was created. Users who register while it is being sent are emailed
+	// too, so Sent and Failed may add up to more.
+	Total  int `json:"total"`
+	Sent   int `json:"sent"`
+	Failed int `json:"failed"`
+	// LastUserID is the ID of the last user the email was sent to, or
+	// failed to be sent to.
+	LastUserID int64     `json:"-"`
+	CreatedBy  int64     `json:"created_by"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// CompletedAt is when every user has been emailed, or nil until then.
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// BulkEmailFailure records a user a bulk email could not be sent to.
+type BulkEmailFailure struct {
+	UserID    int64     `json:"user_id"`
+	Recipient string    `json:"recipient"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BulkEmailJob is the payload of a BulkEmailJobKind job.
+type BulkEmailJob struct {
+	BulkEmailID int64 `json:"bulk_email_id"`
+}
+
+type CreateBulkEmailRequest struct {
+	// Template is the name of one of the bulk email templates.
+	Template string `json:"template"`
+	// Filter selects the users to email. It defaults to the activated users
+	// who are not suspended.
+	Filter    UserFilter          `json:"filter"`
+	Validator validator.Validator `json:"-"`
+}
+
+type ListBulkEmailFailuresRequest struct {
+	Filters
+	Validator validator.Validator
+}
+
+// JobQueue runs work in the background, surviving restarts.
+// *concurrent.Queue is one.
+type JobQueue interface {
+	Enqueue(ctx context.Context, kind string, payload any) (int64, error)
+}
+
+type BulkEmailRepository interface {
+	// Insert stores bulk, counting the users its filter matches as its
+	// total.
+	Insert(ctx context.Context, bulk *BulkEmail) error
+	Get(ctx context.Context, id int64) (*BulkEmail, error)
+	// Acquire marks the bulk email with id as being sent by the caller until
+	// lockedUntil. It returns ErrRecordNotFound when there is no such bulk
+	// email, it has been completed or it is being sent by someone else.
+	Acquire(ctx context.Context, id int64, lockedUntil time.Time) (*BulkEmail, error)
+	Release(ctx context.Context, id int64) error
+	Complete(ctx context.Context, id int64) error
+	// Recipients returns up to limit users matching the filter of bulk that
+	// come after its last user, in ID order.
+	Recipients(ctx context.Context, bulk *BulkEmail, limit int) ([]*User, error)
+	// RecordDelivery moves the progress of the bulk email with id past user,
+	// counting the email as sent when sendError is empty and recording a
+	// failure for the user otherwise.
+	RecordDelivery(ctx context.Context, id int64, user *User, sendError string) error
+	GetFailures(ctx context.Context, id int64, filters Filters) ([]*BulkEmailFailure, Metadata, error)
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"github.com/jessicatarra/greenlight/internal/utils/validator"
+	"net"
+	"regexp"
+	"time"
+)
+
+const (
+	DeviceSensitivityOff    = "off"
+	DeviceSensitivityLow    = "low"
+	DeviceSensitivityMedium = "medium"
+	DeviceSensitivityHigh   = "high"
+)
+
+var DeviceSensitivities = []string{DeviceSensitivityOff, DeviceSensitivityLow, DeviceSensitivityMedium, DeviceSensitivityHigh}
+
+var rgxUserAgentVersion = regexp.MustCompile(`[0-9][0-9._]*`)
+
+// Device is a browser or client a user has signed in from, identified by a
+// fingerprint of its network and user agent.
+type Device struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"-"`
+	Fingerprint []byte    `json:"-"`
+	IPPrefix    string    `json:"ip_prefix"`
+	UserAgent   string    `json:"user_agent"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+type RevokeSessionsRequest struct {
+	TokenPlaintext string
+	Validator      validator.Validator
+}
+
+// NewDevice fingerprints a sign-in from ip with userAgent. Lower sensitivities
+// look at a wider network and ignore version numbers in the user agent, so
+// routine changes such as a browser update or a new DHCP lease go unnoticed:
+// low ignores the network entirely, medium uses /16 IPv4 and /32 IPv6
+// prefixes, and high uses /24 and /48 prefixes and the exact user agent.
+func NewDevice(userID int64, ip net.IP, userAgent string, sensitivity string) *Device {
+	ipv4Bits, ipv6Bits, exactUserAgent := 16, 32, false
+	switch sensitivity {
+	case DeviceSensitivityLow:
+		ipv4Bits, ipv6Bits = 0, 0
+	case DeviceSensitivityHigh:
+		ipv4Bits, ipv6Bits, exactUserAgent = 24, 48, true
+	}
+
+	prefix := ipPrefix(ip, ipv4Bits, ipv6Bits)
+
+	agent := userAgent
+	if !exactUserAgent {
+		agent = rgxUserAgentVersion.ReplaceAllString(userAgent, "")
+	}
+
+	fingerprint := sha256.Sum256([]byte(prefix + "\n" + agent))
+
+	return &Device{
+		UserID:      userID,
+		Fingerprint: fingerprint[:],
+		IPPrefix:    prefix,
+		UserAgent:   userAgent,
+	}
+}
+
+func ipPrefix(ip net.IP, ipv4Bits, ipv6Bits int) string {
+	if ip == nil {
+		return ""
+	}
+
+	if ipv4 := ip.To4(); ipv4 != nil {
+		if ipv4Bits == 0 {
+			return ""
+		}
+		mask := net.CIDRMask(ipv4Bits, 8*net.IPv4len)
+		return (&net.IPNet{IP: ipv4.Mask(mask), Mask: mask}).String()
+	}
+
+	if ipv6Bits == 0 {
+		return ""
+	}
+	mask := net.CIDRMask(ipv6Bits, 8*net.IPv6len)
+	return (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String()
+}
+
+type DeviceRepository interface {
+	// Insert records device, or only when it was last seen if the user
+	// already has it, reporting whether it was newly recorded.
+	Insert(ctx context.Context, device *Device) (bool, error)
+	Get(ctx context.Context, userID int64, fingerprint []byte) (*Device, error)
+	Touch(ctx context.Context, device *Device) error
+	CountForUser(ctx context.Context, userID int64) (int, error)
+	DeleteAllForUser(ctx context.Context, userID int64) error
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"github.com/jessicatarra/greenlight/internal/utils/validator"
+	"strings"
+	"time"
+)
+
+const (
+	DeviceAuthorizationPending  = "pending"
+	DeviceAuthorizationApproved = "approved"
+	DeviceAuthorizationDenied   = "denied"
+)
+
+// userCodeAlphabet leaves out vowels, so user codes cannot spell words, and
+// digits, which are easily confused with letters on a TV screen.
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+const userCodeLength = 8
+
+// DeviceAuthorization is an OAuth device authorization grant (RFC 8628) in
+// progress. Only the hashes of its device and user codes are stored; the
+// plaintexts are known when the authorization is created.
+type DeviceAuthorization struct {
+	ID             int64
+	DeviceCode     string
+	DeviceCodeHash []byte
+	UserCode       string
+	UserCodeHash   []byte
+	ClientID       string
+	// UserID is the user who approved or denied the authorization, or zero
+	// while it is pending.
+	UserID int64
+	Status string
+	// Interval is the minimum time the device must wait between polls.
+	Interval time.Duration
+	// LastPolledAt is when the device last polled, or the zero time if it
+	// has not polled yet.
+	LastPolledAt time.Time
+	CreatedAt    time.Time
+	Expiry       time.Time
+}
+
+// GenerateUserCode returns a random user code formatted as XXXX-XXXX.
+func GenerateUserCode() (string, error) {
+	randomBytes := make([]byte, userCodeLength)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	var code strings.Builder
+	for i, b := range randomBytes {
+		if i == userCodeLength/2 {
+			code.WriteByte('-')
+		}
+		// 256 is not a multiple of the alphabet size, but the bias is too
+		// small to matter for a short-lived code.
+		code.WriteByte(userCodeAlphabet[int(b)%len(userCodeAlphabet)])
+	}
+
+	return code.String(), nil
+}
+
+// NormalizeUserCode uppercases a user code as typed and drops the separators,
+// so that "bcdf-ghjk" and "BCDF GHJK" are the same code.
+func NormalizeUserCode(userCode string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ':
+			return -1
+		default:
+			return r
+		}
+	}, strings.ToUpper(userCode))
+}
+
+// HashUserCode returns the hash a user code is stored under.
+func HashUserCode(userCode string) []byte {
+	hash := sha256.Sum256([]byte(NormalizeUserCode(userCode)))
+	return hash[:]
+}
+
+type CreateDeviceAuthorizationRequest struct {
+	ClientID  string
+	Validator validator.Validator
+}
+
+type DeviceTokenRequest struct {
+	GrantType  string
+	DeviceCode string
+	ClientID   string
+	Validator  validator.Validator
+}
+
+type VerifyDeviceRequest struct {
+	UserCode  string
+	Action    string
+	Validator validator.Validator
+}
+
+type DeviceAuthorizationRepository interface {
+	New(ctx context.Context, clientID string, ttl time.Duration, interval time.Duration) (*DeviceAuthorization, error)
+	Insert(ctx context.Context, authorization *DeviceAuthorization) error
+	GetByUserCode(ctx context.Context, userCode string) (*DeviceAuthorization, error)
+	GetByDeviceCode(ctx context.Context, deviceCode string) (*DeviceAuthorization, error)
+	Answer(ctx context.Context, authorization *DeviceAuthorization) error
+	RecordPoll(ctx context.Context, authorization *DeviceAuthorization) error
+	Delete(ctx context.Context, id int64) error
+	DeleteExpired(ctx context.Context) error
+}
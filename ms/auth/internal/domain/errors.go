@@ -7,4 +7,26 @@ var (
 	ErrRecordNotFound        = errors.New("record not found")
 	ErrDuplicateEmail        = errors.New("duplicate email")
 	ErrPermissionNotIncluded = errors.New("permission not included")
+	ErrInvitationRequired    = errors.New("invitation required")
+	ErrInvalidInvitation     = errors.New("invalid invitation")
+	ErrAccountSuspended      = errors.New("account suspended")
+	ErrInvalidClaims         = errors.New("invalid token claims")
+	ErrStaleToken            = errors.New("stale token")
+	ErrRevokedToken          = errors.New("revoked token")
+	ErrDuplicatePasskey      = errors.New("duplicate passkey")
+	ErrAuthorizationPending  = errors.New("authorization pending")
+	ErrSlowDown              = errors.New("slow down")
+	ErrAccessDenied          = errors.New("access denied")
+	ErrExpiredToken          = errors.New("expired token")
+	ErrBulkEmailCompleted    = errors.New("bulk email completed")
 )
+
+// IsInvalidToken reports whether err means that an authentication token was
+// checked and turned down, as opposed to it not having been possible to
+// check it, such as when the database is down.
+func IsInvalidToken(err error) bool {
+	return errors.Is(err, ErrInvalidClaims) ||
+		errors.Is(err, ErrStaleToken) ||
+		errors.Is(err, ErrRevokedToken) ||
+		errors.Is(err, ErrRecordNotFound)
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "github.com/jessicatarra/greenlight/internal/utils/filters"
+
+// Metadata and Filters are those of every listing, including the monolith's.
+type Metadata = filters.Metadata
+
+type Filters = filters.Filters
+
+func CalculateMetadata(totalRecords, page, pageSize int) Metadata {
+	return filters.CalculateMetadata(totalRecords, page, pageSize)
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "github.com/jessicatarra/greenlight/internal/utils/validator"
+
+// Introspection is the RFC 7662 introspection response for a token.
+type Introspection struct {
+	Active   bool                `json:"active"`
+	Scope    string              `json:"scope,omitempty"`
+	Username string              `json:"username,omitempty"`
+	Exp      int64               `json:"exp,omitempty"`
+	Iat      int64               `json:"iat,omitempty"`
+	Sub      string              `json:"sub,omitempty"`
+	Act      *IntrospectionActor `json:"act,omitempty"`
+}
+
+type IntrospectionActor struct {
+	Sub string `json:"sub"`
+}
+
+type IntrospectTokenRequest struct {
+	TokenPlaintext string
+	TokenTypeHint  string
+	Validator      validator.Validator
+}
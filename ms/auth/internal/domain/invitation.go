@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"github.com/jessicatarra/greenlight/internal/utils/validator"
+	"time"
+)
+
+type Invitation struct {
+	Plaintext   string      `json:"-"`
+	Hash        []byte      `json:"-"`
+	Email       string      `json:"email"`
+	Permissions Permissions `json:"permissions"`
+	InvitedBy   int64       `json:"invited_by"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Expiry      time.Time   `json:"expiry"`
+}
+
+type CreateInvitationRequest struct {
+	Email       string    `json:"email"`
+	Permissions []string  `json:"permissions"`
+	Expiry      time.Time `json:"expiry"`
+	// Locale is the language of the invitation email, by default the one
+	// the admin's Accept-Language header asks for.
+	Locale    string              `json:"locale"`
+	Validator validator.Validator `json:"-"`
+}
+
+type InvitationRepository interface {
+	New(ctx context.Context, email string, permissions Permissions, invitedBy int64, ttl time.Duration) (*Invitation, error)
+	Insert(ctx context.Context, invitation *Invitation) error
+	GetForToken(ctx context.Context, tokenPlaintext string) (*Invitation, error)
+	DeleteAllForEmail(ctx context.Context, email string) error
+}
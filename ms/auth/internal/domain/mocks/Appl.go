@@ -3,8 +3,14 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	mock "github.com/stretchr/testify/mock"
+
+	net "net"
+
+	webauthn "github.com/jessicatarra/greenlight/internal/webauthn"
 )
 
 // Appl is an autogenerated mock type for the Appl type
@@ -12,9 +18,9 @@ type Appl struct {
 	mock.Mock
 }
 
-// ActivateUseCase provides a mock function with given fields: tokenPlainText
-func (_m *Appl) ActivateUseCase(tokenPlainText string) (*domain.User, error) {
-	ret := _m.Called(tokenPlainText)
+// ActivateUseCase provides a mock function with given fields: ctx, tokenPlainText
+func (_m *Appl) ActivateUseCase(ctx context.Context, tokenPlainText string) (*domain.User, error) {
+	ret := _m.Called(ctx, tokenPlainText)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ActivateUseCase")
@@ -22,19 +28,79 @@ func (_m *Appl) ActivateUseCase(tokenPlainText string) (*domain.User, error) {
 
 	var r0 *domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*domain.User, error)); ok {
-		return rf(tokenPlainText)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(ctx, tokenPlainText)
 	}
-	if rf, ok := ret.Get(0).(func(string) *domain.User); ok {
-		r0 = rf(tokenPlainText)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, tokenPlainText)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(tokenPlainText)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenPlainText)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BeginPasskeyLoginUseCase provides a mock function with given fields: ctx, email
+func (_m *Appl) BeginPasskeyLoginUseCase(ctx context.Context, email string) (*webauthn.CredentialRequestOptions, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginPasskeyLoginUseCase")
+	}
+
+	var r0 *webauthn.CredentialRequestOptions
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*webauthn.CredentialRequestOptions, error)); ok {
+		return rf(ctx, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *webauthn.CredentialRequestOptions); ok {
+		r0 = rf(ctx, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*webauthn.CredentialRequestOptions)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BeginPasskeyRegistrationUseCase provides a mock function with given fields: ctx, user
+func (_m *Appl) BeginPasskeyRegistrationUseCase(ctx context.Context, user *domain.User) (*webauthn.CredentialCreationOptions, error) {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BeginPasskeyRegistrationUseCase")
+	}
+
+	var r0 *webauthn.CredentialCreationOptions
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) (*webauthn.CredentialCreationOptions, error)); ok {
+		return rf(ctx, user)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) *webauthn.CredentialCreationOptions); ok {
+		r0 = rf(ctx, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*webauthn.CredentialCreationOptions)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.User) error); ok {
+		r1 = rf(ctx, user)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -42,9 +108,27 @@ func (_m *Appl) ActivateUseCase(tokenPlainText string) (*domain.User, error) {
 	return r0, r1
 }
 
-// CreateAuthTokenUseCase provides a mock function with given fields: userID
-func (_m *Appl) CreateAuthTokenUseCase(userID int64) ([]byte, error) {
-	ret := _m.Called(userID)
+// ChangePasswordUseCase provides a mock function with given fields: ctx, user, hashedPassword
+func (_m *Appl) ChangePasswordUseCase(ctx context.Context, user *domain.User, hashedPassword string) error {
+	ret := _m.Called(ctx, user, hashedPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePasswordUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string) error); ok {
+		r0 = rf(ctx, user, hashedPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateAuthTokenUseCase provides a mock function with given fields: ctx, userID
+func (_m *Appl) CreateAuthTokenUseCase(ctx context.Context, userID int64) ([]byte, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateAuthTokenUseCase")
@@ -52,19 +136,19 @@ func (_m *Appl) CreateAuthTokenUseCase(userID int64) ([]byte, error) {
 
 	var r0 []byte
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) ([]byte, error)); ok {
-		return rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]byte, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(int64) []byte); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []byte); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]byte)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -72,29 +156,29 @@ func (_m *Appl) CreateAuthTokenUseCase(userID int64) ([]byte, error) {
 	return r0, r1
 }
 
-// CreateUseCase provides a mock function with given fields: input, hashedPassword
-func (_m *Appl) CreateUseCase(input *domain.CreateUserRequest, hashedPassword string) (*domain.User, error) {
-	ret := _m.Called(input, hashedPassword)
+// CreateBulkEmailUseCase provides a mock function with given fields: ctx, input, createdBy
+func (_m *Appl) CreateBulkEmailUseCase(ctx context.Context, input *domain.CreateBulkEmailRequest, createdBy int64) (*domain.BulkEmail, error) {
+	ret := _m.Called(ctx, input, createdBy)
 
 	if len(ret) == 0 {
-		panic("no return value specified for CreateUseCase")
+		panic("no return value specified for CreateBulkEmailUseCase")
 	}
 
-	var r0 *domain.User
+	var r0 *domain.BulkEmail
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*domain.CreateUserRequest, string) (*domain.User, error)); ok {
-		return rf(input, hashedPassword)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.CreateBulkEmailRequest, int64) (*domain.BulkEmail, error)); ok {
+		return rf(ctx, input, createdBy)
 	}
-	if rf, ok := ret.Get(0).(func(*domain.CreateUserRequest, string) *domain.User); ok {
-		r0 = rf(input, hashedPassword)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.CreateBulkEmailRequest, int64) *domain.BulkEmail); ok {
+		r0 = rf(ctx, input, createdBy)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*domain.User)
+			r0 = ret.Get(0).(*domain.BulkEmail)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(*domain.CreateUserRequest, string) error); ok {
-		r1 = rf(input, hashedPassword)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.CreateBulkEmailRequest, int64) error); ok {
+		r1 = rf(ctx, input, createdBy)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -102,29 +186,155 @@ func (_m *Appl) CreateUseCase(input *domain.CreateUserRequest, hashedPassword st
 	return r0, r1
 }
 
-// GetByEmailUseCase provides a mock function with given fields: email
-func (_m *Appl) GetByEmailUseCase(email string) (*domain.User, error) {
-	ret := _m.Called(email)
+// CreateDeviceAuthorizationUseCase provides a mock function with given fields: ctx, clientID
+func (_m *Appl) CreateDeviceAuthorizationUseCase(ctx context.Context, clientID string) (*domain.DeviceAuthorization, error) {
+	ret := _m.Called(ctx, clientID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetByEmailUseCase")
+		panic("no return value specified for CreateDeviceAuthorizationUseCase")
+	}
+
+	var r0 *domain.DeviceAuthorization
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.DeviceAuthorization, error)); ok {
+		return rf(ctx, clientID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.DeviceAuthorization); ok {
+		r0 = rf(ctx, clientID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.DeviceAuthorization)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, clientID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateInvitationUseCase provides a mock function with given fields: ctx, input, invitedBy
+func (_m *Appl) CreateInvitationUseCase(ctx context.Context, input *domain.CreateInvitationRequest, invitedBy int64) (*domain.Invitation, error) {
+	ret := _m.Called(ctx, input, invitedBy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateInvitationUseCase")
+	}
+
+	var r0 *domain.Invitation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.CreateInvitationRequest, int64) (*domain.Invitation, error)); ok {
+		return rf(ctx, input, invitedBy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.CreateInvitationRequest, int64) *domain.Invitation); ok {
+		r0 = rf(ctx, input, invitedBy)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Invitation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.CreateInvitationRequest, int64) error); ok {
+		r1 = rf(ctx, input, invitedBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMagicLinkUseCase provides a mock function with given fields: ctx, email
+func (_m *Appl) CreateMagicLinkUseCase(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMagicLinkUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreatePasswordResetTokenUseCase provides a mock function with given fields: ctx, email
+func (_m *Appl) CreatePasswordResetTokenUseCase(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePasswordResetTokenUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateSessionUseCase provides a mock function with given fields: ctx, userID
+func (_m *Appl) CreateSessionUseCase(ctx context.Context, userID int64) (*domain.Token, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateSessionUseCase")
+	}
+
+	var r0 *domain.Token
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.Token, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Token); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Token)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUseCase provides a mock function with given fields: ctx, input, hashedPassword
+func (_m *Appl) CreateUseCase(ctx context.Context, input *domain.CreateUserRequest, hashedPassword string) (*domain.User, error) {
+	ret := _m.Called(ctx, input, hashedPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUseCase")
 	}
 
 	var r0 *domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*domain.User, error)); ok {
-		return rf(email)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.CreateUserRequest, string) (*domain.User, error)); ok {
+		return rf(ctx, input, hashedPassword)
 	}
-	if rf, ok := ret.Get(0).(func(string) *domain.User); ok {
-		r0 = rf(email)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.CreateUserRequest, string) *domain.User); ok {
+		r0 = rf(ctx, input, hashedPassword)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(email)
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.CreateUserRequest, string) error); ok {
+		r1 = rf(ctx, input, hashedPassword)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -132,17 +342,17 @@ func (_m *Appl) GetByEmailUseCase(email string) (*domain.User, error) {
 	return r0, r1
 }
 
-// UserPermissionUseCase provides a mock function with given fields: code, userID
-func (_m *Appl) UserPermissionUseCase(code string, userID int64) error {
-	ret := _m.Called(code, userID)
+// DeleteSessionUseCase provides a mock function with given fields: ctx, tokenPlaintext
+func (_m *Appl) DeleteSessionUseCase(ctx context.Context, tokenPlaintext string) error {
+	ret := _m.Called(ctx, tokenPlaintext)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UserPermissionUseCase")
+		panic("no return value specified for DeleteSessionUseCase")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
-		r0 = rf(code, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, tokenPlaintext)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -150,29 +360,197 @@ func (_m *Appl) UserPermissionUseCase(code string, userID int64) error {
 	return r0
 }
 
-// ValidateAuthTokenUseCase provides a mock function with given fields: token
-func (_m *Appl) ValidateAuthTokenUseCase(token string) (*domain.User, error) {
-	ret := _m.Called(token)
+// DeprovisionUserUseCase provides a mock function with given fields: ctx, id
+func (_m *Appl) DeprovisionUserUseCase(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
-		panic("no return value specified for ValidateAuthTokenUseCase")
+		panic("no return value specified for DeprovisionUserUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExchangeDeviceCodeUseCase provides a mock function with given fields: ctx, deviceCode, clientID
+func (_m *Appl) ExchangeDeviceCodeUseCase(ctx context.Context, deviceCode string, clientID string) ([]byte, error) {
+	ret := _m.Called(ctx, deviceCode, clientID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExchangeDeviceCodeUseCase")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]byte, error)); ok {
+		return rf(ctx, deviceCode, clientID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []byte); ok {
+		r0 = rf(ctx, deviceCode, clientID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, deviceCode, clientID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExchangeMagicLinkUseCase provides a mock function with given fields: ctx, tokenPlaintext
+func (_m *Appl) ExchangeMagicLinkUseCase(ctx context.Context, tokenPlaintext string) ([]byte, error) {
+	ret := _m.Called(ctx, tokenPlaintext)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExchangeMagicLinkUseCase")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]byte, error)); ok {
+		return rf(ctx, tokenPlaintext)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []byte); ok {
+		r0 = rf(ctx, tokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FinishPasskeyLoginUseCase provides a mock function with given fields: ctx, response
+func (_m *Appl) FinishPasskeyLoginUseCase(ctx context.Context, response *webauthn.AssertionResponse) (*domain.User, error) {
+	ret := _m.Called(ctx, response)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishPasskeyLoginUseCase")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *webauthn.AssertionResponse) (*domain.User, error)); ok {
+		return rf(ctx, response)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *webauthn.AssertionResponse) *domain.User); ok {
+		r0 = rf(ctx, response)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *webauthn.AssertionResponse) error); ok {
+		r1 = rf(ctx, response)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FinishPasskeyRegistrationUseCase provides a mock function with given fields: ctx, user, name, response
+func (_m *Appl) FinishPasskeyRegistrationUseCase(ctx context.Context, user *domain.User, name string, response *webauthn.AttestationResponse) (*domain.Passkey, error) {
+	ret := _m.Called(ctx, user, name, response)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FinishPasskeyRegistrationUseCase")
+	}
+
+	var r0 *domain.Passkey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string, *webauthn.AttestationResponse) (*domain.Passkey, error)); ok {
+		return rf(ctx, user, name, response)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string, *webauthn.AttestationResponse) *domain.Passkey); ok {
+		r0 = rf(ctx, user, name, response)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Passkey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.User, string, *webauthn.AttestationResponse) error); ok {
+		r1 = rf(ctx, user, name, response)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBulkEmailUseCase provides a mock function with given fields: ctx, id
+func (_m *Appl) GetBulkEmailUseCase(ctx context.Context, id int64) (*domain.BulkEmail, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBulkEmailUseCase")
+	}
+
+	var r0 *domain.BulkEmail
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.BulkEmail, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.BulkEmail); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.BulkEmail)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByEmailUseCase provides a mock function with given fields: ctx, email
+func (_m *Appl) GetByEmailUseCase(ctx context.Context, email string) (*domain.User, error) {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByEmailUseCase")
 	}
 
 	var r0 *domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*domain.User, error)); ok {
-		return rf(token)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(ctx, email)
 	}
-	if rf, ok := ret.Get(0).(func(string) *domain.User); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, email)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(token)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -180,6 +558,718 @@ func (_m *Appl) ValidateAuthTokenUseCase(token string) (*domain.User, error) {
 	return r0, r1
 }
 
+// GetByPasswordResetTokenUseCase provides a mock function with given fields: ctx, tokenPlaintext
+func (_m *Appl) GetByPasswordResetTokenUseCase(ctx context.Context, tokenPlaintext string) (*domain.User, error) {
+	ret := _m.Called(ctx, tokenPlaintext)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetByPasswordResetTokenUseCase")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(ctx, tokenPlaintext)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, tokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGroupUseCase provides a mock function with given fields: ctx, id, withMembers
+func (_m *Appl) GetGroupUseCase(ctx context.Context, id int64, withMembers bool) (*domain.Group, error) {
+	ret := _m.Called(ctx, id, withMembers)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGroupUseCase")
+	}
+
+	var r0 *domain.Group
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) (*domain.Group, error)); ok {
+		return rf(ctx, id, withMembers)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) *domain.Group); ok {
+		r0 = rf(ctx, id, withMembers)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Group)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, bool) error); ok {
+		r1 = rf(ctx, id, withMembers)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserUseCase provides a mock function with given fields: ctx, id
+func (_m *Appl) GetUserUseCase(ctx context.Context, id int64) (*domain.User, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserUseCase")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.User, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.User); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImpersonateUseCase provides a mock function with given fields: ctx, userID, actorID
+func (_m *Appl) ImpersonateUseCase(ctx context.Context, userID int64, actorID int64) ([]byte, error) {
+	ret := _m.Called(ctx, userID, actorID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImpersonateUseCase")
+	}
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) ([]byte, error)); ok {
+		return rf(ctx, userID, actorID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) []byte); ok {
+		r0 = rf(ctx, userID, actorID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, actorID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IntrospectTokenUseCase provides a mock function with given fields: ctx, tokenPlaintext
+func (_m *Appl) IntrospectTokenUseCase(ctx context.Context, tokenPlaintext string) (*domain.Introspection, error) {
+	ret := _m.Called(ctx, tokenPlaintext)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IntrospectTokenUseCase")
+	}
+
+	var r0 *domain.Introspection
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Introspection, error)); ok {
+		return rf(ctx, tokenPlaintext)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Introspection); ok {
+		r0 = rf(ctx, tokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Introspection)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListBulkEmailFailuresUseCase provides a mock function with given fields: ctx, id, filters
+func (_m *Appl) ListBulkEmailFailuresUseCase(ctx context.Context, id int64, filters domain.Filters) ([]*domain.BulkEmailFailure, domain.Metadata, error) {
+	ret := _m.Called(ctx, id, filters)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListBulkEmailFailuresUseCase")
+	}
+
+	var r0 []*domain.BulkEmailFailure
+	var r1 domain.Metadata
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.Filters) ([]*domain.BulkEmailFailure, domain.Metadata, error)); ok {
+		return rf(ctx, id, filters)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.Filters) []*domain.BulkEmailFailure); ok {
+		r0 = rf(ctx, id, filters)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.BulkEmailFailure)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.Filters) domain.Metadata); ok {
+		r1 = rf(ctx, id, filters)
+	} else {
+		r1 = ret.Get(1).(domain.Metadata)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64, domain.Filters) error); ok {
+		r2 = rf(ctx, id, filters)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListGroupsUseCase provides a mock function with given fields: ctx, withMembers
+func (_m *Appl) ListGroupsUseCase(ctx context.Context, withMembers bool) ([]*domain.Group, error) {
+	ret := _m.Called(ctx, withMembers)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListGroupsUseCase")
+	}
+
+	var r0 []*domain.Group
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) ([]*domain.Group, error)); ok {
+		return rf(ctx, withMembers)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) []*domain.Group); ok {
+		r0 = rf(ctx, withMembers)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Group)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, withMembers)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListOutboxEmailsUseCase provides a mock function with given fields: ctx, status, filters
+func (_m *Appl) ListOutboxEmailsUseCase(ctx context.Context, status string, filters domain.Filters) ([]*domain.OutboxEmail, domain.Metadata, error) {
+	ret := _m.Called(ctx, status, filters)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListOutboxEmailsUseCase")
+	}
+
+	var r0 []*domain.OutboxEmail
+	var r1 domain.Metadata
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Filters) ([]*domain.OutboxEmail, domain.Metadata, error)); ok {
+		return rf(ctx, status, filters)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, domain.Filters) []*domain.OutboxEmail); ok {
+		r0 = rf(ctx, status, filters)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.OutboxEmail)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, domain.Filters) domain.Metadata); ok {
+		r1 = rf(ctx, status, filters)
+	} else {
+		r1 = ret.Get(1).(domain.Metadata)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string, domain.Filters) error); ok {
+		r2 = rf(ctx, status, filters)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ListUsersUseCase provides a mock function with given fields: ctx, filter, filters
+func (_m *Appl) ListUsersUseCase(ctx context.Context, filter domain.UserFilter, filters domain.Filters) ([]*domain.User, domain.Metadata, error) {
+	ret := _m.Called(ctx, filter, filters)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsersUseCase")
+	}
+
+	var r0 []*domain.User
+	var r1 domain.Metadata
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.UserFilter, domain.Filters) ([]*domain.User, domain.Metadata, error)); ok {
+		return rf(ctx, filter, filters)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.UserFilter, domain.Filters) []*domain.User); ok {
+		r0 = rf(ctx, filter, filters)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.UserFilter, domain.Filters) domain.Metadata); ok {
+		r1 = rf(ctx, filter, filters)
+	} else {
+		r1 = ret.Get(1).(domain.Metadata)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, domain.UserFilter, domain.Filters) error); ok {
+		r2 = rf(ctx, filter, filters)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// NotifyRegistrationAttemptUseCase provides a mock function with given fields: ctx, email
+func (_m *Appl) NotifyRegistrationAttemptUseCase(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	if len(ret) == 0 {
+		panic("no return value specified for NotifyRegistrationAttemptUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ProvisionUserUseCase provides a mock function with given fields: ctx, user, hashedPassword
+func (_m *Appl) ProvisionUserUseCase(ctx context.Context, user *domain.User, hashedPassword string) error {
+	ret := _m.Called(ctx, user, hashedPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ProvisionUserUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string) error); ok {
+		r0 = rf(ctx, user, hashedPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReactivateUserUseCase provides a mock function with given fields: ctx, userID
+func (_m *Appl) ReactivateUserUseCase(ctx context.Context, userID int64) (*domain.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReactivateUserUseCase")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordSignInUseCase provides a mock function with given fields: ctx, user, ip, userAgent
+func (_m *Appl) RecordSignInUseCase(ctx context.Context, user *domain.User, ip net.IP, userAgent string) error {
+	ret := _m.Called(ctx, user, ip, userAgent)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordSignInUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, net.IP, string) error); ok {
+		r0 = rf(ctx, user, ip, userAgent)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RehashPasswordUseCase provides a mock function with given fields: ctx, user, hashedPassword
+func (_m *Appl) RehashPasswordUseCase(ctx context.Context, user *domain.User, hashedPassword string) error {
+	ret := _m.Called(ctx, user, hashedPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RehashPasswordUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string) error); ok {
+		r0 = rf(ctx, user, hashedPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReplayOutboxEmailUseCase provides a mock function with given fields: ctx, id
+func (_m *Appl) ReplayOutboxEmailUseCase(ctx context.Context, id int64) (*domain.OutboxEmail, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplayOutboxEmailUseCase")
+	}
+
+	var r0 *domain.OutboxEmail
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.OutboxEmail, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.OutboxEmail); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.OutboxEmail)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetPasswordUseCase provides a mock function with given fields: ctx, user, hashedPassword
+func (_m *Appl) ResetPasswordUseCase(ctx context.Context, user *domain.User, hashedPassword string) error {
+	ret := _m.Called(ctx, user, hashedPassword)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPasswordUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string) error); ok {
+		r0 = rf(ctx, user, hashedPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResumeBulkEmailUseCase provides a mock function with given fields: ctx, id
+func (_m *Appl) ResumeBulkEmailUseCase(ctx context.Context, id int64) (*domain.BulkEmail, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResumeBulkEmailUseCase")
+	}
+
+	var r0 *domain.BulkEmail
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.BulkEmail, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.BulkEmail); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.BulkEmail)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeSessionsUseCase provides a mock function with given fields: ctx, tokenPlaintext
+func (_m *Appl) RevokeSessionsUseCase(ctx context.Context, tokenPlaintext string) (*domain.User, error) {
+	ret := _m.Called(ctx, tokenPlaintext)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeSessionsUseCase")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(ctx, tokenPlaintext)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, tokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchUsersUseCase provides a mock function with given fields: ctx, query, offset, limit
+func (_m *Appl) SearchUsersUseCase(ctx context.Context, query domain.UserQuery, offset int, limit int) ([]*domain.User, int, error) {
+	ret := _m.Called(ctx, query, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchUsersUseCase")
+	}
+
+	var r0 []*domain.User
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.UserQuery, int, int) ([]*domain.User, int, error)); ok {
+		return rf(ctx, query, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.UserQuery, int, int) []*domain.User); ok {
+		r0 = rf(ctx, query, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.UserQuery, int, int) int); ok {
+		r1 = rf(ctx, query, offset, limit)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, domain.UserQuery, int, int) error); ok {
+		r2 = rf(ctx, query, offset, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SetGroupMembersUseCase provides a mock function with given fields: ctx, id, userIDs
+func (_m *Appl) SetGroupMembersUseCase(ctx context.Context, id int64, userIDs []int64) (*domain.Group, error) {
+	ret := _m.Called(ctx, id, userIDs)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetGroupMembersUseCase")
+	}
+
+	var r0 *domain.Group
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []int64) (*domain.Group, error)); ok {
+		return rf(ctx, id, userIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []int64) *domain.Group); ok {
+		r0 = rf(ctx, id, userIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Group)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, []int64) error); ok {
+		r1 = rf(ctx, id, userIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SuspendUserUseCase provides a mock function with given fields: ctx, userID
+func (_m *Appl) SuspendUserUseCase(ctx context.Context, userID int64) (*domain.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SuspendUserUseCase")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateProvisionedUserUseCase provides a mock function with given fields: ctx, user
+func (_m *Appl) UpdateProvisionedUserUseCase(ctx context.Context, user *domain.User) error {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateProvisionedUserUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) error); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserPermissionUseCase provides a mock function with given fields: ctx, code, userID
+func (_m *Appl) UserPermissionUseCase(ctx context.Context, code string, userID int64) error {
+	ret := _m.Called(ctx, code, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UserPermissionUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, code, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateAuthTokenUseCase provides a mock function with given fields: ctx, token
+func (_m *Appl) ValidateAuthTokenUseCase(ctx context.Context, token string) (*domain.User, error) {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateAuthTokenUseCase")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, token)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ValidateSessionUseCase provides a mock function with given fields: ctx, tokenPlaintext
+func (_m *Appl) ValidateSessionUseCase(ctx context.Context, tokenPlaintext string) (*domain.User, error) {
+	ret := _m.Called(ctx, tokenPlaintext)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateSessionUseCase")
+	}
+
+	var r0 *domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(ctx, tokenPlaintext)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, tokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// VerifyDeviceAuthorizationUseCase provides a mock function with given fields: ctx, userCode, user, approved
+func (_m *Appl) VerifyDeviceAuthorizationUseCase(ctx context.Context, userCode string, user *domain.User, approved bool) error {
+	ret := _m.Called(ctx, userCode, user, approved)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyDeviceAuthorizationUseCase")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.User, bool) error); ok {
+		r0 = rf(ctx, userCode, user, approved)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewAppl creates a new instance of Appl. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewAppl(t interface {
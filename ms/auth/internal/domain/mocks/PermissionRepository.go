@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,13 +14,14 @@ type PermissionRepository struct {
 	mock.Mock
 }
 
-// AddForUser provides a mock function with given fields: userID, codes
-func (_m *PermissionRepository) AddForUser(userID int64, codes ...string) error {
+// AddForUser provides a mock function with given fields: ctx, userID, codes
+func (_m *PermissionRepository) AddForUser(ctx context.Context, userID int64, codes ...string) error {
 	_va := make([]interface{}, len(codes))
 	for _i := range codes {
 		_va[_i] = codes[_i]
 	}
 	var _ca []interface{}
+	_ca = append(_ca, ctx)
 	_ca = append(_ca, userID)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
@@ -28,8 +31,34 @@ func (_m *PermissionRepository) AddForUser(userID int64, codes ...string) error
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64, ...string) error); ok {
-		r0 = rf(userID, codes...)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, ...string) error); ok {
+		r0 = rf(ctx, userID, codes...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AddUsers provides a mock function with given fields: ctx, permissionID, userIDs
+func (_m *PermissionRepository) AddUsers(ctx context.Context, permissionID int64, userIDs ...int64) error {
+	_va := make([]interface{}, len(userIDs))
+	for _i := range userIDs {
+		_va[_i] = userIDs[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, permissionID)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddUsers")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, ...int64) error); ok {
+		r0 = rf(ctx, permissionID, userIDs...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -37,9 +66,69 @@ func (_m *PermissionRepository) AddForUser(userID int64, codes ...string) error
 	return r0
 }
 
-// GetAllForUser provides a mock function with given fields: userID
-func (_m *PermissionRepository) GetAllForUser(userID int64) (domain.Permissions, error) {
-	ret := _m.Called(userID)
+// Get provides a mock function with given fields: ctx, id
+func (_m *PermissionRepository) Get(ctx context.Context, id int64) (*domain.Permission, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *domain.Permission
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.Permission, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Permission); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Permission)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAll provides a mock function with no fields
+func (_m *PermissionRepository) GetAll(ctx context.Context) ([]*domain.Permission, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAll")
+	}
+
+	var r0 []*domain.Permission
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*domain.Permission, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*domain.Permission); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Permission)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAllForUser provides a mock function with given fields: ctx, userID
+func (_m *PermissionRepository) GetAllForUser(ctx context.Context, userID int64) (domain.Permissions, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetAllForUser")
@@ -47,19 +136,19 @@ func (_m *PermissionRepository) GetAllForUser(userID int64) (domain.Permissions,
 
 	var r0 domain.Permissions
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (domain.Permissions, error)); ok {
-		return rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Permissions, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(int64) domain.Permissions); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Permissions); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(domain.Permissions)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -67,6 +156,62 @@ func (_m *PermissionRepository) GetAllForUser(userID int64) (domain.Permissions,
 	return r0, r1
 }
 
+// GetUsers provides a mock function with given fields: ctx, permissionID
+func (_m *PermissionRepository) GetUsers(ctx context.Context, permissionID int64) ([]*domain.User, error) {
+	ret := _m.Called(ctx, permissionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUsers")
+	}
+
+	var r0 []*domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*domain.User, error)); ok {
+		return rf(ctx, permissionID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.User); ok {
+		r0 = rf(ctx, permissionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, permissionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveUsers provides a mock function with given fields: ctx, permissionID, userIDs
+func (_m *PermissionRepository) RemoveUsers(ctx context.Context, permissionID int64, userIDs ...int64) error {
+	_va := make([]interface{}, len(userIDs))
+	for _i := range userIDs {
+		_va[_i] = userIDs[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, permissionID)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveUsers")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, ...int64) error); ok {
+		r0 = rf(ctx, permissionID, userIDs...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewPermissionRepository creates a new instance of PermissionRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewPermissionRepository(t interface {
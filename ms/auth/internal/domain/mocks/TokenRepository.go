@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	mock "github.com/stretchr/testify/mock"
 
@@ -14,17 +16,45 @@ type TokenRepository struct {
 	mock.Mock
 }
 
-// DeleteAllForUser provides a mock function with given fields: scope, userID
-func (_m *TokenRepository) DeleteAllForUser(scope string, userID int64) error {
-	ret := _m.Called(scope, userID)
+// Consume provides a mock function with given fields: ctx, scope, tokenPlaintext
+func (_m *TokenRepository) Consume(ctx context.Context, scope string, tokenPlaintext string) (int64, error) {
+	ret := _m.Called(ctx, scope, tokenPlaintext)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Consume")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (int64, error)); ok {
+		return rf(ctx, scope, tokenPlaintext)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int64); ok {
+		r0 = rf(ctx, scope, tokenPlaintext)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, scope, tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteAllForUser provides a mock function with given fields: ctx, scope, userID
+func (_m *TokenRepository) DeleteAllForUser(ctx context.Context, scope string, userID int64) error {
+	ret := _m.Called(ctx, scope, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteAllForUser")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, int64) error); ok {
-		r0 = rf(scope, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, scope, userID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -32,17 +62,47 @@ func (_m *TokenRepository) DeleteAllForUser(scope string, userID int64) error {
 	return r0
 }
 
-// Insert provides a mock function with given fields: token
-func (_m *TokenRepository) Insert(token *domain.Token) error {
-	ret := _m.Called(token)
+// Get provides a mock function with given fields: ctx, tokenPlaintext
+func (_m *TokenRepository) Get(ctx context.Context, tokenPlaintext string) (*domain.Token, error) {
+	ret := _m.Called(ctx, tokenPlaintext)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *domain.Token
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.Token, error)); ok {
+		return rf(ctx, tokenPlaintext)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Token); ok {
+		r0 = rf(ctx, tokenPlaintext)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Token)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenPlaintext)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Insert provides a mock function with given fields: ctx, token
+func (_m *TokenRepository) Insert(ctx context.Context, token *domain.Token) error {
+	ret := _m.Called(ctx, token)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Insert")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*domain.Token) error); ok {
-		r0 = rf(token)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.Token) error); ok {
+		r0 = rf(ctx, token)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -50,9 +110,9 @@ func (_m *TokenRepository) Insert(token *domain.Token) error {
 	return r0
 }
 
-// New provides a mock function with given fields: userID, ttl, scope
-func (_m *TokenRepository) New(userID int64, ttl time.Duration, scope string) (*domain.Token, error) {
-	ret := _m.Called(userID, ttl, scope)
+// New provides a mock function with given fields: ctx, userID, ttl, scope
+func (_m *TokenRepository) New(ctx context.Context, userID int64, ttl time.Duration, scope string) (*domain.Token, error) {
+	ret := _m.Called(ctx, userID, ttl, scope)
 
 	if len(ret) == 0 {
 		panic("no return value specified for New")
@@ -60,19 +120,19 @@ func (_m *TokenRepository) New(userID int64, ttl time.Duration, scope string) (*
 
 	var r0 *domain.Token
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64, time.Duration, string) (*domain.Token, error)); ok {
-		return rf(userID, ttl, scope)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Duration, string) (*domain.Token, error)); ok {
+		return rf(ctx, userID, ttl, scope)
 	}
-	if rf, ok := ret.Get(0).(func(int64, time.Duration, string) *domain.Token); ok {
-		r0 = rf(userID, ttl, scope)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Duration, string) *domain.Token); ok {
+		r0 = rf(ctx, userID, ttl, scope)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Token)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64, time.Duration, string) error); ok {
-		r1 = rf(userID, ttl, scope)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Duration, string) error); ok {
+		r1 = rf(ctx, userID, ttl, scope)
 	} else {
 		r1 = ret.Error(1)
 	}
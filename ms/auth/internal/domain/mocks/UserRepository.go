@@ -3,8 +3,12 @@
 package mocks
 
 import (
+	context "context"
+
 	domain "github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // UserRepository is an autogenerated mock type for the UserRepository type
@@ -12,9 +16,64 @@ type UserRepository struct {
 	mock.Mock
 }
 
-// GetForToken provides a mock function with given fields: tokenScope, tokenPlaintext
-func (_m *UserRepository) GetForToken(tokenScope string, tokenPlaintext string) (*domain.User, error) {
-	ret := _m.Called(tokenScope, tokenPlaintext)
+// DeleteUser provides a mock function with given fields: ctx, id
+func (_m *UserRepository) DeleteUser(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteUser")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAll provides a mock function with given fields: ctx, filter, filters
+func (_m *UserRepository) GetAll(ctx context.Context, filter domain.UserFilter, filters domain.Filters) ([]*domain.User, domain.Metadata, error) {
+	ret := _m.Called(ctx, filter, filters)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAll")
+	}
+
+	var r0 []*domain.User
+	var r1 domain.Metadata
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.UserFilter, domain.Filters) ([]*domain.User, domain.Metadata, error)); ok {
+		return rf(ctx, filter, filters)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.UserFilter, domain.Filters) []*domain.User); ok {
+		r0 = rf(ctx, filter, filters)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.UserFilter, domain.Filters) domain.Metadata); ok {
+		r1 = rf(ctx, filter, filters)
+	} else {
+		r1 = ret.Get(1).(domain.Metadata)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, domain.UserFilter, domain.Filters) error); ok {
+		r2 = rf(ctx, filter, filters)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetForToken provides a mock function with given fields: ctx, tokenScope, tokenPlaintext
+func (_m *UserRepository) GetForToken(ctx context.Context, tokenScope string, tokenPlaintext string) (*domain.User, error) {
+	ret := _m.Called(ctx, tokenScope, tokenPlaintext)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetForToken")
@@ -22,19 +81,19 @@ func (_m *UserRepository) GetForToken(tokenScope string, tokenPlaintext string)
 
 	var r0 *domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (*domain.User, error)); ok {
-		return rf(tokenScope, tokenPlaintext)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*domain.User, error)); ok {
+		return rf(ctx, tokenScope, tokenPlaintext)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) *domain.User); ok {
-		r0 = rf(tokenScope, tokenPlaintext)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.User); ok {
+		r0 = rf(ctx, tokenScope, tokenPlaintext)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(tokenScope, tokenPlaintext)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, tokenScope, tokenPlaintext)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -42,9 +101,9 @@ func (_m *UserRepository) GetForToken(tokenScope string, tokenPlaintext string)
 	return r0, r1
 }
 
-// GetUserByEmail provides a mock function with given fields: email
-func (_m *UserRepository) GetUserByEmail(email string) (*domain.User, error) {
-	ret := _m.Called(email)
+// GetUserByEmail provides a mock function with given fields: ctx, email
+func (_m *UserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	ret := _m.Called(ctx, email)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetUserByEmail")
@@ -52,19 +111,19 @@ func (_m *UserRepository) GetUserByEmail(email string) (*domain.User, error) {
 
 	var r0 *domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*domain.User, error)); ok {
-		return rf(email)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*domain.User, error)); ok {
+		return rf(ctx, email)
 	}
-	if rf, ok := ret.Get(0).(func(string) *domain.User); ok {
-		r0 = rf(email)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.User); ok {
+		r0 = rf(ctx, email)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(email)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, email)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -72,9 +131,9 @@ func (_m *UserRepository) GetUserByEmail(email string) (*domain.User, error) {
 	return r0, r1
 }
 
-// GetUserById provides a mock function with given fields: id
-func (_m *UserRepository) GetUserById(id int64) (*domain.User, error) {
-	ret := _m.Called(id)
+// GetUserById provides a mock function with given fields: ctx, id
+func (_m *UserRepository) GetUserById(ctx context.Context, id int64) (*domain.User, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetUserById")
@@ -82,19 +141,19 @@ func (_m *UserRepository) GetUserById(id int64) (*domain.User, error) {
 
 	var r0 *domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (*domain.User, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.User, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(int64) *domain.User); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.User); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -102,17 +161,35 @@ func (_m *UserRepository) GetUserById(id int64) (*domain.User, error) {
 	return r0, r1
 }
 
-// InsertNewUser provides a mock function with given fields: user, hashedPassword
-func (_m *UserRepository) InsertNewUser(user *domain.User, hashedPassword string) error {
-	ret := _m.Called(user, hashedPassword)
+// InsertNewUser provides a mock function with given fields: ctx, user, hashedPassword
+func (_m *UserRepository) InsertNewUser(ctx context.Context, user *domain.User, hashedPassword string) error {
+	ret := _m.Called(ctx, user, hashedPassword)
 
 	if len(ret) == 0 {
 		panic("no return value specified for InsertNewUser")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*domain.User, string) error); ok {
-		r0 = rf(user, hashedPassword)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User, string) error); ok {
+		r0 = rf(ctx, user, hashedPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeSessions provides a mock function with given fields: ctx, userID, revokedAt
+func (_m *UserRepository) RevokeSessions(ctx context.Context, userID int64, revokedAt time.Time) error {
+	ret := _m.Called(ctx, userID, revokedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeSessions")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) error); ok {
+		r0 = rf(ctx, userID, revokedAt)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -120,17 +197,54 @@ func (_m *UserRepository) InsertNewUser(user *domain.User, hashedPassword string
 	return r0
 }
 
-// UpdateUser provides a mock function with given fields: user
-func (_m *UserRepository) UpdateUser(user *domain.User) error {
-	ret := _m.Called(user)
+// Search provides a mock function with given fields: ctx, query, offset, limit
+func (_m *UserRepository) Search(ctx context.Context, query domain.UserQuery, offset int, limit int) ([]*domain.User, int, error) {
+	ret := _m.Called(ctx, query, offset, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Search")
+	}
+
+	var r0 []*domain.User
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.UserQuery, int, int) ([]*domain.User, int, error)); ok {
+		return rf(ctx, query, offset, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.UserQuery, int, int) []*domain.User); ok {
+		r0 = rf(ctx, query, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.UserQuery, int, int) int); ok {
+		r1 = rf(ctx, query, offset, limit)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, domain.UserQuery, int, int) error); ok {
+		r2 = rf(ctx, query, offset, limit)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// UpdateUser provides a mock function with given fields: ctx, user
+func (_m *UserRepository) UpdateUser(ctx context.Context, user *domain.User) error {
+	ret := _m.Called(ctx, user)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateUser")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*domain.User) error); ok {
-		r0 = rf(user)
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.User) error); ok {
+		r0 = rf(ctx, user)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"github.com/jessicatarra/greenlight/internal/utils/validator"
+	"time"
+)
+
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead"
+)
+
+// OutboxEmail is an email recorded in the same transaction as the change it
+// is about, and delivered by the outbox dispatcher once that transaction has
+// committed. A pending email is retried with backoff until it is sent or runs
+// out of attempts, when it is dead until replayed.
+type OutboxEmail struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Recipient string    `json:"recipient"`
+	Template  string    `json:"template"`
+	// Locale picks the translation of the template the email is sent in.
+	Locale string `json:"locale"`
+	// Data is never shown to admins, since it holds the plaintext of tokens
+	// such as activation and password reset tokens. It is cleared once the
+	// email is sent, and discarded if it holds tokens once the email is dead.
+	Data map[string]interface{} `json:"-"`
+	// Redacted reports that Data was discarded, so the email cannot be
+	// replayed; the user has to ask for a new token instead.
+	Redacted      bool      `json:"redacted,omitempty"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	LastError     string    `json:"last_error,omitempty"`
+	// SentAt is when the email was sent, or nil if it has not been.
+	SentAt *time.Time `json:"sent_at,omitempty"`
+}
+
+type ListOutboxEmailsRequest struct {
+	Status string
+	Filters
+	Validator validator.Validator
+}
+
+type OutboxRepository interface {
+	Insert(ctx context.Context, email *OutboxEmail) error
+	// ClaimDue returns up to limit pending emails that are due, counting an
+	// attempt for each and hiding them from other dispatchers for lease, so
+	// that an email whose dispatcher dies mid-send is retried afterwards.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*OutboxEmail, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+	MarkDead(ctx context.Context, id int64, lastError string) error
+	GetAll(ctx context.Context, status string, filters Filters) ([]*OutboxEmail, Metadata, error)
+	// Replay makes a dead email pending again with a fresh set of attempts.
+	// It returns ErrRecordNotFound when there is no dead email with id that
+	// can be replayed.
+	Replay(ctx context.Context, id int64) (*OutboxEmail, error)
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/jessicatarra/greenlight/internal/utils/validator"
+	"github.com/jessicatarra/greenlight/internal/webauthn"
+	"time"
+)
+
+// Passkey is a WebAuthn credential registered by a user.
+type Passkey struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"-"`
+	CredentialID []byte    `json:"-"`
+	PublicKey    []byte    `json:"-"`
+	SignCount    uint32    `json:"-"`
+	Transports   []string  `json:"transports"`
+	AAGUID       []byte    `json:"-"`
+	Name         string    `json:"name"`
+	CreatedAt    time.Time `json:"created_at"`
+	LastUsedAt   time.Time `json:"last_used_at"`
+}
+
+// Descriptor returns the passkey as listed in ceremony options.
+func (p *Passkey) Descriptor() webauthn.CredentialDescriptor {
+	return webauthn.CredentialDescriptor{Type: "public-key", ID: p.CredentialID, Transports: p.Transports}
+}
+
+// Credential returns the passkey in the form the relying party verifies
+// assertions against.
+func (p *Passkey) Credential() *webauthn.Credential {
+	return &webauthn.Credential{
+		ID:         p.CredentialID,
+		PublicKey:  p.PublicKey,
+		SignCount:  p.SignCount,
+		Transports: p.Transports,
+		AAGUID:     p.AAGUID,
+	}
+}
+
+// UserHandle is the opaque identifier of a user stored on their
+// authenticators.
+func UserHandle(userID int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	Name       string                       `json:"name"`
+	Credential webauthn.AttestationResponse `json:"credential"`
+	Validator  validator.Validator          `json:"-"`
+}
+
+type BeginPasskeyLoginRequest struct {
+	Email     string              `json:"email"`
+	Validator validator.Validator `json:"-"`
+}
+
+type FinishPasskeyLoginRequest struct {
+	Credential webauthn.AssertionResponse `json:"credential"`
+	Validator  validator.Validator        `json:"-"`
+}
+
+type PasskeyRepository interface {
+	Insert(ctx context.Context, passkey *Passkey) error
+	GetAllForUser(ctx context.Context, userID int64) ([]*Passkey, error)
+	GetByCredentialID(ctx context.Context, credentialID []byte) (*Passkey, error)
+	UpdateSignCount(ctx context.Context, passkey *Passkey) error
+}
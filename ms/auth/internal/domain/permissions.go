@@ -1,5 +1,7 @@
 package domain
 
+import "context"
+
 type Permissions []string
 
 func (p Permissions) Include(code string) bool {
@@ -11,7 +13,24 @@ func (p Permissions) Include(code string) bool {
 	return false
 }
 
+type Permission struct {
+	ID   int64  `json:"id"`
+	Code string `json:"code"`
+}
+
+// Group is a permission together with the users holding it, as exposed to
+// SCIM provisioning clients. Members is nil when it was not requested.
+type Group struct {
+	Permission
+	Members []*User
+}
+
 type PermissionRepository interface {
-	GetAllForUser(userID int64) (Permissions, error)
-	AddForUser(userID int64, codes ...string) error
+	GetAllForUser(ctx context.Context, userID int64) (Permissions, error)
+	AddForUser(ctx context.Context, userID int64, codes ...string) error
+	GetAll(ctx context.Context) ([]*Permission, error)
+	Get(ctx context.Context, id int64) (*Permission, error)
+	GetUsers(ctx context.Context, permissionID int64) ([]*User, error)
+	AddUsers(ctx context.Context, permissionID int64, userIDs ...int64) error
+	RemoveUsers(ctx context.Context, permissionID int64, userIDs ...int64) error
 }
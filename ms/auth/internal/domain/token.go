@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base32"
@@ -55,12 +56,29 @@ func (t *token) GenerateToken(userID int64, ttl time.Duration, scope string) (*T
 	return token, nil
 }
 
+type CreateMagicLinkRequest struct {
+	Email     string              `json:"email"`
+	Validator validator.Validator `json:"-"`
+}
+
+type ExchangeMagicLinkRequest struct {
+	TokenPlaintext string              `json:"token"`
+	Validator      validator.Validator `json:"-"`
+}
+
+type CreatePasswordResetTokenRequest struct {
+	Email     string              `json:"email"`
+	Validator validator.Validator `json:"-"`
+}
+
 type TokenInterface interface {
 	GenerateToken(userID int64, ttl time.Duration, scope string) (*Token, error)
 }
 
 type TokenRepository interface {
-	New(userID int64, ttl time.Duration, scope string) (*Token, error)
-	Insert(token *Token) error
-	DeleteAllForUser(scope string, userID int64) error
+	New(ctx context.Context, userID int64, ttl time.Duration, scope string) (*Token, error)
+	Insert(ctx context.Context, token *Token) error
+	DeleteAllForUser(ctx context.Context, scope string, userID int64) error
+	Consume(ctx context.Context, scope string, tokenPlaintext string) (int64, error)
+	Get(ctx context.Context, tokenPlaintext string) (*Token, error)
 }
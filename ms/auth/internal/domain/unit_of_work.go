@@ -0,0 +1,22 @@
+package domain
+
+import "context"
+
+// Repositories are the repositories a UnitOfWork hands to the function it
+// runs, all taking part in the same transaction.
+type Repositories struct {
+	Users       UserRepository
+	Tokens      TokenRepository
+	Permissions PermissionRepository
+	Invitations InvitationRepository
+	Outbox      OutboxRepository
+	Devices     DeviceRepository
+}
+
+// UnitOfWork runs a use case that writes through several repositories as one
+// transaction, so that it either takes effect entirely or not at all.
+type UnitOfWork interface {
+	// Do commits the changes fn made through repos when fn returns nil, and
+	// rolls them back when it returns an error or panics.
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
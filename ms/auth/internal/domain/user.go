@@ -1,7 +1,10 @@
 package domain
 
 import (
+	"context"
 	"github.com/jessicatarra/greenlight/internal/utils/validator"
+	"github.com/jessicatarra/greenlight/internal/webauthn"
+	"net"
 	"time"
 )
 
@@ -12,14 +15,59 @@ type User struct {
 	Email          string    `json:"email"`
 	HashedPassword string    `json:"-"`
 	Activated      bool      `json:"activated"`
+	Suspended      bool      `json:"suspended"`
 	Version        int       `json:"-"`
+	// Locale is the language emails are sent to the user in.
+	Locale string `json:"locale"`
+	// PermissionsVersion is bumped whenever the user's permissions or
+	// activation change, so tokens carrying an older copy of them can be
+	// rejected as stale.
+	PermissionsVersion int `json:"-"`
+	// SessionsRevokedAt is when the user last signed out everywhere;
+	// authentication tokens issued before then are rejected.
+	SessionsRevokedAt time.Time `json:"-"`
+	// Permissions holds the permission codes embedded in the authentication
+	// token, or nil when the token carries none.
+	Permissions Permissions `json:"-"`
+	// ActorID identifies the admin acting on behalf of the user when the
+	// request was authenticated with an impersonation token.
+	ActorID int64 `json:"-"`
 }
 
 type CreateUserRequest struct {
-	Name      string              `json:"name"`
-	Email     string              `json:"email"`
-	Password  string              `json:"password"`
-	Validator validator.Validator `json:"-"`
+	Name            string              `json:"name"`
+	Email           string              `json:"email"`
+	Password        string              `json:"password"`
+	InvitationToken string              `json:"invitation_token,omitempty"`
+	Validator       validator.Validator `json:"-"`
+	// Locale is taken from the Accept-Language header of the registration.
+	Locale string `json:"-"`
+}
+
+type ResetPasswordRequest struct {
+	Password       string              `json:"password"`
+	TokenPlaintext string              `json:"token"`
+	Validator      validator.Validator `json:"-"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string              `json:"current_password"`
+	NewPassword     string              `json:"new_password"`
+	Validator       validator.Validator `json:"-"`
+}
+
+type UserFilter struct {
+	Email         string    `json:"email,omitempty"`
+	Activated     *bool     `json:"activated,omitempty"`
+	Suspended     *bool     `json:"suspended,omitempty"`
+	CreatedAfter  time.Time `json:"created_after"`
+	CreatedBefore time.Time `json:"created_before"`
+}
+
+type ListUsersRequest struct {
+	UserFilter
+	Filters
+	Validator validator.Validator
 }
 
 var AnonymousUser = &User{}
@@ -29,18 +77,62 @@ func (u *User) IsAnonymous() bool {
 }
 
 type Appl interface {
-	CreateUseCase(input *CreateUserRequest, hashedPassword string) (*User, error)
-	ActivateUseCase(tokenPlainText string) (*User, error)
-	GetByEmailUseCase(email string) (*User, error)
-	CreateAuthTokenUseCase(userID int64) ([]byte, error)
-	ValidateAuthTokenUseCase(token string) (*User, error)
-	UserPermissionUseCase(code string, userID int64) error
+	CreateUseCase(ctx context.Context, input *CreateUserRequest, hashedPassword string) (*User, error)
+	NotifyRegistrationAttemptUseCase(ctx context.Context, email string) error
+	ActivateUseCase(ctx context.Context, tokenPlainText string) (*User, error)
+	GetByEmailUseCase(ctx context.Context, email string) (*User, error)
+	CreateAuthTokenUseCase(ctx context.Context, userID int64) ([]byte, error)
+	ValidateAuthTokenUseCase(ctx context.Context, token string) (*User, error)
+	CreateSessionUseCase(ctx context.Context, userID int64) (*Token, error)
+	ValidateSessionUseCase(ctx context.Context, tokenPlaintext string) (*User, error)
+	DeleteSessionUseCase(ctx context.Context, tokenPlaintext string) error
+	UserPermissionUseCase(ctx context.Context, code string, userID int64) error
+	CreateInvitationUseCase(ctx context.Context, input *CreateInvitationRequest, invitedBy int64) (*Invitation, error)
+	CreateMagicLinkUseCase(ctx context.Context, email string) error
+	ExchangeMagicLinkUseCase(ctx context.Context, tokenPlaintext string) ([]byte, error)
+	ListUsersUseCase(ctx context.Context, filter UserFilter, filters Filters) ([]*User, Metadata, error)
+	SuspendUserUseCase(ctx context.Context, userID int64) (*User, error)
+	ReactivateUserUseCase(ctx context.Context, userID int64) (*User, error)
+	ImpersonateUseCase(ctx context.Context, userID int64, actorID int64) ([]byte, error)
+	IntrospectTokenUseCase(ctx context.Context, tokenPlaintext string) (*Introspection, error)
+	CreatePasswordResetTokenUseCase(ctx context.Context, email string) error
+	GetByPasswordResetTokenUseCase(ctx context.Context, tokenPlaintext string) (*User, error)
+	ResetPasswordUseCase(ctx context.Context, user *User, hashedPassword string) error
+	ChangePasswordUseCase(ctx context.Context, user *User, hashedPassword string) error
+	RehashPasswordUseCase(ctx context.Context, user *User, hashedPassword string) error
+	RecordSignInUseCase(ctx context.Context, user *User, ip net.IP, userAgent string) error
+	RevokeSessionsUseCase(ctx context.Context, tokenPlaintext string) (*User, error)
+	BeginPasskeyRegistrationUseCase(ctx context.Context, user *User) (*webauthn.CredentialCreationOptions, error)
+	FinishPasskeyRegistrationUseCase(ctx context.Context, user *User, name string, response *webauthn.AttestationResponse) (*Passkey, error)
+	BeginPasskeyLoginUseCase(ctx context.Context, email string) (*webauthn.CredentialRequestOptions, error)
+	FinishPasskeyLoginUseCase(ctx context.Context, response *webauthn.AssertionResponse) (*User, error)
+	CreateDeviceAuthorizationUseCase(ctx context.Context, clientID string) (*DeviceAuthorization, error)
+	VerifyDeviceAuthorizationUseCase(ctx context.Context, userCode string, user *User, approved bool) error
+	ExchangeDeviceCodeUseCase(ctx context.Context, deviceCode string, clientID string) ([]byte, error)
+	SearchUsersUseCase(ctx context.Context, query UserQuery, offset, limit int) ([]*User, int, error)
+	GetUserUseCase(ctx context.Context, id int64) (*User, error)
+	ProvisionUserUseCase(ctx context.Context, user *User, hashedPassword string) error
+	UpdateProvisionedUserUseCase(ctx context.Context, user *User) error
+	DeprovisionUserUseCase(ctx context.Context, id int64) error
+	ListGroupsUseCase(ctx context.Context, withMembers bool) ([]*Group, error)
+	GetGroupUseCase(ctx context.Context, id int64, withMembers bool) (*Group, error)
+	SetGroupMembersUseCase(ctx context.Context, id int64, userIDs []int64) (*Group, error)
+	ListOutboxEmailsUseCase(ctx context.Context, status string, filters Filters) ([]*OutboxEmail, Metadata, error)
+	ReplayOutboxEmailUseCase(ctx context.Context, id int64) (*OutboxEmail, error)
+	CreateBulkEmailUseCase(ctx context.Context, input *CreateBulkEmailRequest, createdBy int64) (*BulkEmail, error)
+	GetBulkEmailUseCase(ctx context.Context, id int64) (*BulkEmail, error)
+	ListBulkEmailFailuresUseCase(ctx context.Context, id int64, filters Filters) ([]*BulkEmailFailure, Metadata, error)
+	ResumeBulkEmailUseCase(ctx context.Context, id int64) (*BulkEmail, error)
 }
 
 type UserRepository interface {
-	InsertNewUser(user *User, hashedPassword string) error
-	GetUserByEmail(email string) (*User, error)
-	UpdateUser(user *User) error
-	GetForToken(tokenScope string, tokenPlaintext string) (*User, error)
-	GetUserById(id int64) (*User, error)
+	InsertNewUser(ctx context.Context, user *User, hashedPassword string) error
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	UpdateUser(ctx context.Context, user *User) error
+	GetForToken(ctx context.Context, tokenScope string, tokenPlaintext string) (*User, error)
+	GetUserById(ctx context.Context, id int64) (*User, error)
+	GetAll(ctx context.Context, filter UserFilter, filters Filters) ([]*User, Metadata, error)
+	RevokeSessions(ctx context.Context, userID int64, revokedAt time.Time) error
+	Search(ctx context.Context, query UserQuery, offset, limit int) ([]*User, int, error)
+	DeleteUser(ctx context.Context, id int64) error
 }
@@ -0,0 +1,69 @@
+package domain
+
+// UserQuery is a condition on the fields of users, for searching them: one
+// of *UserCondition, *UserQueryAnd, *UserQueryOr, *UserQueryNot or
+// UserQueryConstant. A nil UserQuery matches every user.
+type UserQuery interface {
+	userQuery()
+}
+
+// UserField is a field of User that users can be searched by.
+type UserField string
+
+const (
+	UserFieldID        UserField = "id"
+	UserFieldEmail     UserField = "email"
+	UserFieldName      UserField = "name"
+	UserFieldActive    UserField = "active"
+	UserFieldCreatedAt UserField = "created_at"
+)
+
+// Comparison is how a UserCondition compares a field with its value.
+type Comparison string
+
+const (
+	CompareEqual          Comparison = "eq"
+	CompareNotEqual       Comparison = "ne"
+	CompareContains       Comparison = "co"
+	CompareStartsWith     Comparison = "sw"
+	CompareEndsWith       Comparison = "ew"
+	ComparePresent        Comparison = "pr"
+	CompareGreaterThan    Comparison = "gt"
+	CompareGreaterOrEqual Comparison = "ge"
+	CompareLessThan       Comparison = "lt"
+	CompareLessOrEqual    Comparison = "le"
+)
+
+// UserCondition compares Field with Value, which is an int64 for
+// UserFieldID, a bool for UserFieldActive, a time.Time for UserFieldCreatedAt
+// and a string otherwise. Text compares case insensitively, and Value is
+// unused for ComparePresent. A user is active when activated and not
+// suspended.
+type UserCondition struct {
+	Field      UserField
+	Comparison Comparison
+	Value      any
+}
+
+type UserQueryAnd struct {
+	Left  UserQuery
+	Right UserQuery
+}
+
+type UserQueryOr struct {
+	Left  UserQuery
+	Right UserQuery
+}
+
+type UserQueryNot struct {
+	Query UserQuery
+}
+
+// UserQueryConstant matches every user when true and none when false.
+type UserQueryConstant bool
+
+func (*UserCondition) userQuery()    {}
+func (*UserQueryAnd) userQuery()     {}
+func (*UserQueryOr) userQuery()      {}
+func (*UserQueryNot) userQuery()     {}
+func (UserQueryConstant) userQuery() {}
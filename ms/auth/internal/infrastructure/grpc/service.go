@@ -2,15 +2,19 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/golang/protobuf/ptypes/timestamp"
 	pb "github.com/jessicatarra/greenlight/api/proto"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Service interface {
 	ValidateAuthToken(ctx context.Context, request *pb.ValidateAuthTokenRequest) (*pb.User, error)
 	UserPermission(ctx context.Context, request *pb.UserPermissionRequest) (*empty.Empty, error)
+	ValidateSession(ctx context.Context, request *pb.ValidateSessionRequest) (*pb.User, error)
 }
 
 type Server struct {
@@ -24,36 +28,74 @@ func NewGRPCServer(appl domain.Appl) *Server {
 	}
 }
 
+// ValidateAuthToken identifies the user holding an authentication token.
+// Tokens that are invalid, stale or revoked are reported as Unauthenticated,
+// and any failure to check them as Internal, so that callers do not turn an
+// outage into 401 responses.
 func (s Server) ValidateAuthToken(ctx context.Context, request *pb.ValidateAuthTokenRequest) (*pb.User, error) {
-	user, err := s.Appl.ValidateAuthTokenUseCase(request.Token)
+	user, err := s.Appl.ValidateAuthTokenUseCase(ctx, request.Token)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, domain.ErrAccountSuspended):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case domain.IsInvalidToken(err):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
 	}
 
-	//createdAt, err := ptypes.TimestampProto(user.CreatedAt)
+	return protoUser(user), nil
+}
+
+// ValidateSession identifies the user holding a cookie session. Sessions that
+// have expired or been signed out are reported as NotFound, so that callers
+// can treat the request as anonymous.
+func (s Server) ValidateSession(ctx context.Context, request *pb.ValidateSessionRequest) (*pb.User, error) {
+	user, err := s.Appl.ValidateSessionUseCase(ctx, request.Session)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, domain.ErrAccountSuspended):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		default:
+			return nil, err
+		}
+	}
+
+	return protoUser(user), nil
+}
+
+func protoUser(user *domain.User) *pb.User {
 	createdAt := &timestamp.Timestamp{
 		Seconds: user.CreatedAt.Unix(),
 		Nanos:   int32(user.CreatedAt.Nanosecond()),
 	}
-	if err != nil {
-		return nil, err
-	}
 
 	return &pb.User{
-		Id:             user.ID,
-		CreatedAt:      createdAt,
-		Name:           user.Name,
-		Email:          user.Email,
-		HashedPassword: user.HashedPassword,
-		Activated:      user.Activated,
-		Version:        int32(user.Version),
-	}, nil
+		Id:                  user.ID,
+		CreatedAt:           createdAt,
+		Name:                user.Name,
+		Email:               user.Email,
+		HashedPassword:      user.HashedPassword,
+		Activated:           user.Activated,
+		Version:             int32(user.Version),
+		ActorId:             user.ActorID,
+		Permissions:         user.Permissions,
+		PermissionsEmbedded: user.Permissions != nil,
+	}
 }
 
 func (s Server) UserPermission(ctx context.Context, request *pb.UserPermissionRequest) (*empty.Empty, error) {
-	err := s.Appl.UserPermissionUseCase(request.Code, request.UserId)
+	err := s.Appl.UserPermissionUseCase(ctx, request.Code, request.UserId)
 	if err != nil {
-		return nil, err
+		switch {
+		case errors.Is(err, domain.ErrPermissionNotIncluded):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		default:
+			return nil, err
+		}
 	}
 
 	return &pb.Empty{}, nil
@@ -0,0 +1,49 @@
+//go:build auth
+// +build auth
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	pb "github.com/jessicatarra/greenlight/api/proto"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestServer_ValidateAuthToken(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "suspended", err: domain.ErrAccountSuspended, code: codes.PermissionDenied},
+		{name: "invalid claims", err: domain.ErrInvalidClaims, code: codes.Unauthenticated},
+		{name: "stale", err: domain.ErrStaleToken, code: codes.Unauthenticated},
+		{name: "revoked", err: domain.ErrRevokedToken, code: codes.Unauthenticated},
+		{name: "unknown user", err: domain.ErrRecordNotFound, code: codes.Unauthenticated},
+		{name: "database", err: errors.New("connection refused"), code: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockApp := &mocks.Appl{}
+			server := NewGRPCServer(mockApp)
+
+			mockApp.On("ValidateAuthTokenUseCase", mock.Anything, "token").Return(nil, tt.err)
+
+			// Act
+			user, err := server.ValidateAuthToken(context.Background(), &pb.ValidateAuthTokenRequest{Token: "token"})
+
+			// Assert
+			assert.Nil(t, user)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"errors"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/middleware"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+)
+
+// @Summary Activation landing page
+// @Description Renders the page linked from the welcome email. Activation itself happens when the page's form is submitted, so that link scanners fetching the URL do not activate the account.
+// @Tags Users
+// @Produce html
+// @Param token query string true "Token for user activation"
+// @Success 200
+// @Router /users/activated [get]
+func (h *handlers) showActivationPage(res http.ResponseWriter, req *http.Request) {
+	var input domain.ActivateUserRequest
+
+	input.TokenPlaintext = h.helpers.ReadString(req.URL.Query(), "token", "")
+
+	ValidateToken(&input)
+
+	if input.Validator.HasErrors() {
+		h.renderPage(res, req, http.StatusBadRequest, "activation_expired.gohtml", nil)
+		return
+	}
+
+	h.renderPage(res, req, http.StatusOK, "activation.gohtml", map[string]interface{}{
+		"token": input.TokenPlaintext,
+	})
+}
+
+// @Summary Confirm activation
+// @Description Activates a user account from the landing page form and renders the outcome
+// @Tags Users
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param token formData string true "Token for user activation"
+// @Success 200
+// @Router /users/activated [post]
+func (h *handlers) confirmActivation(res http.ResponseWriter, req *http.Request) {
+	var input domain.ActivateUserRequest
+
+	err := req.ParseForm()
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	input.TokenPlaintext = req.PostForm.Get("token")
+
+	ValidateToken(&input)
+
+	if input.Validator.HasErrors() {
+		h.renderPage(res, req, http.StatusBadRequest, "activation_expired.gohtml", nil)
+		return
+	}
+
+	user, err := h.appl.ActivateUseCase(req.Context(), input.TokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			h.renderPage(res, req, http.StatusBadRequest, "activation_expired.gohtml", nil)
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	h.renderPage(res, req, http.StatusOK, "activation_success.gohtml", map[string]interface{}{
+		"name": user.Name,
+	})
+}
+
+func (h *handlers) renderPage(res http.ResponseWriter, req *http.Request, status int, page string, data map[string]interface{}) {
+	// Forms posted by a browser holding a cookie session must carry its CSRF
+	// token.
+	if cookie, err := req.Cookie(middleware.CSRFCookieName); err == nil {
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		data["csrfToken"] = cookie.Value
+	}
+
+	err := response.HTML(res, status, page, data)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
@@ -0,0 +1,127 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"errors"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const activationToken = "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+
+func assertHTMLContains(t *testing.T, resRec *httptest.ResponseRecorder, fragment string) {
+	if !strings.HasPrefix(resRec.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("unexpected Content-Type: %q", resRec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(resRec.Body.String(), fragment) {
+		t.Errorf("expected response body to contain %q, got %s", fragment, resRec.Body.String())
+	}
+}
+
+func newActivationForm(token string) *http.Request {
+	form := url.Values{"token": {token}}
+	req := httptest.NewRequest(http.MethodPost, "/v1/users/activated", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestResource_ShowActivationPage(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/activated?token="+activationToken, nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.showActivationPage(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assertHTMLContains(t, resRec, `value="`+activationToken+`"`)
+		mockApp.AssertNotCalled(t, "ActivateUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - malformed token", func(t *testing.T) {
+		// Arrange
+		_, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/v1/users/activated?token=short", nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.showActivationPage(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+		assertHTMLContains(t, resRec, "no longer valid")
+	})
+}
+
+func TestResource_ConfirmActivation(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newActivationForm(activationToken)
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ActivateUseCase", mock.Anything, activationToken).Return(&domain.User{ID: 1, Name: "John Doe", Activated: true}, nil)
+
+		// Act
+		res.confirmActivation(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assertHTMLContains(t, resRec, "Thanks, John Doe.")
+	})
+
+	t.Run("error - expired token", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newActivationForm(activationToken)
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ActivateUseCase", mock.Anything, activationToken).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.confirmActivation(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+		assertHTMLContains(t, resRec, "no longer valid")
+	})
+
+	t.Run("error - malformed token", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newActivationForm("short")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.confirmActivation(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+		mockApp.AssertNotCalled(t, "ActivateUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - ActivateUseCase return error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newActivationForm(activationToken)
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ActivateUseCase", mock.Anything, activationToken).Return(nil, errors.New("error"))
+
+		// Act
+		res.confirmActivation(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/request"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+)
+
+// @Summary Send bulk email
+// @Description Sends a bulk email template, such as the announcement of a new release, to every user matching a filter in the background. Only the templates made for bulk sends can be used, not those of emails such as password resets. By default it goes to the activated users who are not suspended. The progress can be followed on the bulk email returned.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param bulk_email body domain.CreateBulkEmailRequest true "Template and user filter"
+// @Success 202 {object} domain.BulkEmail
+// @Router /admin/bulk-emails [post]
+func (h *handlers) createBulkEmail(res http.ResponseWriter, req *http.Request) {
+	var input domain.CreateBulkEmailRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	if input.Filter.Activated == nil {
+		activated := true
+		input.Filter.Activated = &activated
+	}
+	if input.Filter.Suspended == nil {
+		suspended := false
+		input.Filter.Suspended = &suspended
+	}
+
+	ValidateBulkEmail(&input, h.mailer.BulkTemplates())
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	bulk, err := h.appl.CreateBulkEmailUseCase(req.Context(), &input, contextGetUser(req).ID)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/admin/bulk-emails/%d", bulk.ID))
+
+	err = response.JSONWithHeaders(res, http.StatusAccepted, envelope{"bulk_email": bulk}, headers)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Show bulk email
+// @Description Shows the progress of a bulk email: how many users it has been sent to and how many it failed for.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Bulk email ID"
+// @Success 200 {object} domain.BulkEmail
+// @Router /admin/bulk-emails/{id} [get]
+func (h *handlers) showBulkEmail(res http.ResponseWriter, req *http.Request) {
+	id, err := h.helpers.ReadIDParam(req)
+	if err != nil {
+		_errors.NotFound(res, req)
+		return
+	}
+
+	bulk, err := h.appl.GetBulkEmailUseCase(req.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			_errors.NotFound(res, req)
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"bulk_email": bulk})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary List bulk email failures
+// @Description Pages through the users a bulk email could not be sent to, with the reason.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Bulk email ID"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Number of failures per page"
+// @Param sort query string false "Sort order"
+// @Success 200 {object} []domain.BulkEmailFailure "Failure list"
+// @Router /admin/bulk-emails/{id}/failures [get]
+func (h *handlers) listBulkEmailFailures(res http.ResponseWriter, req *http.Request) {
+	id, err := h.helpers.ReadIDParam(req)
+	if err != nil {
+		_errors.NotFound(res, req)
+		return
+	}
+
+	var input domain.ListBulkEmailFailuresRequest
+
+	qs := req.URL.Query()
+
+	input.Filters.Page = h.helpers.ReadInt(qs, "page", 1, &input.Validator)
+	input.Filters.PageSize = h.helpers.ReadInt(qs, "page_size", 20, &input.Validator)
+	input.Filters.Sort = h.helpers.ReadString(qs, "sort", "user_id")
+	input.Filters.SortSafelist = []string{"user_id", "created_at", "-user_id", "-created_at"}
+
+	ValidateFilters(&input.Validator, input.Filters)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	failures, metadata, err := h.appl.ListBulkEmailFailuresUseCase(req.Context(), id, input.Filters)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			_errors.NotFound(res, req)
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"failures": failures, "metadata": metadata})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Resume bulk email
+// @Description Queues a bulk email that has not been completed to be sent again from the user it stopped at, for when its job gave up.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Bulk email ID"
+// @Success 202 {object} domain.BulkEmail
+// @Router /admin/bulk-emails/{id}/resume [post]
+func (h *handlers) resumeBulkEmail(res http.ResponseWriter, req *http.Request) {
+	id, err := h.helpers.ReadIDParam(req)
+	if err != nil {
+		_errors.NotFound(res, req)
+		return
+	}
+
+	bulk, err := h.appl.ResumeBulkEmailUseCase(req.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			_errors.NotFound(res, req)
+		case errors.Is(err, domain.ErrBulkEmailCompleted):
+			_errors.Conflict(res, req, errors.New("the bulk email has already been sent to every user"))
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	err = response.JSON(res, http.StatusAccepted, envelope{"bulk_email": bulk})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
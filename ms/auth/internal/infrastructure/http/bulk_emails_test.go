@@ -0,0 +1,175 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"bytes"
+	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/internal/password"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setupBulkEmailHandlers(t *testing.T) (*mocks.Appl, Handlers) {
+	mockApp := &mocks.Appl{}
+
+	mail, err := mailer.New(mailer.NewMemoryTransport(10), "no-reply@example.org", "")
+	assert.NoError(t, err)
+
+	return mockApp, registerHandlers(handlerDeps{
+		appl:   mockApp,
+		policy: password.NewStandardPolicy(8, 72, 0),
+		hasher: password.NewHasher(nil, "", nil),
+		mailer: mail,
+	})
+}
+
+func TestResource_CreateBulkEmail(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupBulkEmailHandlers(t)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/bulk-emails", bytes.NewReader([]byte(`{"template": "release_announcement.gohtml", "filter": {"email": "example.com"}}`)))
+		req = contextSetUser(req, &domain.User{ID: 1, Activated: true})
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreateBulkEmailUseCase", mock.Anything, mock.MatchedBy(func(input *domain.CreateBulkEmailRequest) bool {
+			// Unless the filter says otherwise, only activated users who
+			// are not suspended are emailed.
+			return input.Template == "release_announcement.gohtml" && input.Filter.Email == "example.com" &&
+				*input.Filter.Activated && !*input.Filter.Suspended
+		}), int64(1)).Return(&domain.BulkEmail{ID: 3, Template: "release_announcement.gohtml", Status: domain.BulkEmailPending, Total: 2}, nil)
+
+		// Act
+		res.createBulkEmail(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusAccepted)
+		assert.Equal(t, "/v1/admin/bulk-emails/3", resRec.Header().Get("Location"))
+		var responseBody map[string]*domain.BulkEmail
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody["bulk_email"] == nil || responseBody["bulk_email"].Total != 2 {
+			t.Errorf("unexpected bulk email in response body: %v", responseBody["bulk_email"])
+		}
+	})
+
+	t.Run("error - transactional template", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupBulkEmailHandlers(t)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/bulk-emails", bytes.NewReader([]byte(`{"template": "token_password_reset.gohtml"}`)))
+		req = contextSetUser(req, &domain.User{ID: 1, Activated: true})
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.createBulkEmail(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "CreateBulkEmailUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - unknown template", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupBulkEmailHandlers(t)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/bulk-emails", bytes.NewReader([]byte(`{"template": "../user_welcome.gohtml"}`)))
+		req = contextSetUser(req, &domain.User{ID: 1, Activated: true})
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.createBulkEmail(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "CreateBulkEmailUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestResource_ListBulkEmailFailures(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		expectedFailures := []*domain.BulkEmailFailure{{UserID: 12, Recipient: "sarah@example.com", Error: "550 mailbox unavailable"}}
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/bulk-emails/3/failures", nil)
+		req = withIDParam(req, "3")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ListBulkEmailFailuresUseCase", mock.Anything, int64(3), mock.MatchedBy(func(f domain.Filters) bool {
+			return f.Page == 1 && f.PageSize == 20 && f.Sort == "user_id"
+		})).Return(expectedFailures, domain.CalculateMetadata(1, 1, 20), nil)
+
+		// Act
+		res.listBulkEmailFailures(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var responseBody struct {
+			Failures []*domain.BulkEmailFailure `json:"failures"`
+		}
+		assertResponseBody(t, resRec, &responseBody)
+		if len(responseBody.Failures) != 1 || responseBody.Failures[0].Error != "550 mailbox unavailable" {
+			t.Errorf("unexpected failures in response body: %v", responseBody.Failures)
+		}
+	})
+
+	t.Run("error - not found", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/bulk-emails/3/failures", nil)
+		req = withIDParam(req, "3")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ListBulkEmailFailuresUseCase", mock.Anything, int64(3), mock.Anything).Return(nil, domain.Metadata{}, domain.ErrRecordNotFound)
+
+		// Act
+		res.listBulkEmailFailures(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusNotFound)
+	})
+}
+
+func TestResource_ResumeBulkEmail(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/bulk-emails/3/resume", nil)
+		req = withIDParam(req, "3")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ResumeBulkEmailUseCase", mock.Anything, int64(3)).Return(&domain.BulkEmail{ID: 3, Status: domain.BulkEmailSending}, nil)
+
+		// Act
+		res.resumeBulkEmail(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusAccepted)
+	})
+
+	t.Run("error - completed", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/bulk-emails/3/resume", nil)
+		req = withIDParam(req, "3")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ResumeBulkEmailUseCase", mock.Anything, int64(3)).Return(nil, domain.ErrBulkEmailCompleted)
+
+		// Act
+		res.resumeBulkEmail(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusConflict)
+	})
+}
@@ -0,0 +1,25 @@
+package http
+
+import (
+	"context"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+)
+
+type contextKey string
+
+const userContextKey = contextKey("user")
+
+func contextSetUser(r *http.Request, user *domain.User) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	return r.WithContext(ctx)
+}
+
+func contextGetUser(r *http.Request) *domain.User {
+	user, ok := r.Context().Value(userContextKey).(*domain.User)
+	if !ok {
+		panic("missing user value in request context")
+	}
+
+	return user
+}
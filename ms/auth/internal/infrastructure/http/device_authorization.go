@@ -0,0 +1,223 @@
+package http
+
+import (
+	"errors"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// @Summary Start a device sign-in
+// @Description Issues a device code and a user code to a TV or console app, following RFC 8628. The app shows the user code and verification URI, then polls the token endpoint with the device code.
+// @Tags Authentication
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param client_id formData string true "Identifier of the app"
+// @Success 200 {object} map[string]interface{} "Device authorization"
+// @Router /oauth/device_authorization [post]
+func (h *handlers) createDeviceAuthorization(res http.ResponseWriter, req *http.Request) {
+	var input domain.CreateDeviceAuthorizationRequest
+
+	err := req.ParseForm()
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	input.ClientID = req.PostForm.Get("client_id")
+
+	ValidateDeviceAuthorization(&input)
+
+	if input.Validator.HasErrors() {
+		oauthError(res, req, http.StatusBadRequest, "invalid_request", "The client_id parameter is missing or invalid")
+		return
+	}
+
+	authorization, err := h.appl.CreateDeviceAuthorizationUseCase(req.Context(), input.ClientID)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	verificationURI := h.baseURL + "/v1/device"
+
+	env := envelope{
+		"device_code":               authorization.DeviceCode,
+		"user_code":                 authorization.UserCode,
+		"verification_uri":          verificationURI,
+		"verification_uri_complete": verificationURI + "?" + url.Values{"user_code": {authorization.UserCode}}.Encode(),
+		"expires_in":                int64(time.Until(authorization.Expiry).Seconds()),
+		"interval":                  int64(authorization.Interval.Seconds()),
+	}
+
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "no-store")
+
+	err = response.JSONWithHeaders(res, http.StatusOK, env, headers)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Poll for a device token
+// @Description Exchanges an approved device code for an authentication token, following RFC 8628. Until the user answers, the response is an authorization_pending error; polling faster than the interval yields slow_down and lengthens the interval by five seconds.
+// @Tags Authentication
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "urn:ietf:params:oauth:grant-type:device_code"
+// @Param device_code formData string true "Device code from the device authorization"
+// @Param client_id formData string true "Identifier of the app"
+// @Success 200 {object} map[string]string "Authentication token"
+// @Router /oauth/token [post]
+func (h *handlers) exchangeDeviceCode(res http.ResponseWriter, req *http.Request) {
+	var input domain.DeviceTokenRequest
+
+	err := req.ParseForm()
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	input.GrantType = req.PostForm.Get("grant_type")
+	input.DeviceCode = req.PostForm.Get("device_code")
+	input.ClientID = req.PostForm.Get("client_id")
+
+	if input.GrantType != deviceCodeGrantType {
+		oauthError(res, req, http.StatusBadRequest, "unsupported_grant_type", "Only the device code grant is supported")
+		return
+	}
+
+	ValidateDeviceToken(&input)
+
+	if input.Validator.HasErrors() {
+		oauthError(res, req, http.StatusBadRequest, "invalid_request", "The device_code and client_id parameters are required")
+		return
+	}
+
+	jwtBytes, err := h.appl.ExchangeDeviceCodeUseCase(req.Context(), input.DeviceCode, input.ClientID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAuthorizationPending):
+			oauthError(res, req, http.StatusBadRequest, "authorization_pending", "The user has not yet answered the request")
+		case errors.Is(err, domain.ErrSlowDown):
+			oauthError(res, req, http.StatusBadRequest, "slow_down", "Polling too often, wait five more seconds between requests")
+		case errors.Is(err, domain.ErrAccessDenied):
+			oauthError(res, req, http.StatusBadRequest, "access_denied", "The user denied the request")
+		case errors.Is(err, domain.ErrExpiredToken):
+			oauthError(res, req, http.StatusBadRequest, "expired_token", "The device code has expired, start again")
+		case errors.Is(err, domain.ErrRecordNotFound):
+			oauthError(res, req, http.StatusBadRequest, "invalid_grant", "The device code is invalid")
+		case errors.Is(err, domain.ErrAccountSuspended):
+			oauthError(res, req, http.StatusBadRequest, "invalid_grant", "The account is suspended")
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "no-store")
+
+	env := envelope{"access_token": string(jwtBytes), "token_type": "Bearer"}
+
+	err = response.JSONWithHeaders(res, http.StatusOK, env, headers)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// oauthError writes an error response in the format of RFC 6749, section 5.2,
+// which device clients expect from the token endpoint.
+func oauthError(res http.ResponseWriter, req *http.Request, status int, code string, description string) {
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "no-store")
+
+	err := response.JSONWithHeaders(res, status, envelope{"error": code, "error_description": description}, headers)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Device sign-in page
+// @Description Renders the page where a signed-in user enters the code shown by a TV or console app and approves or denies its sign-in
+// @Tags Authentication
+// @Produce html
+// @Param user_code query string false "Code shown by the device"
+// @Success 200
+// @Router /device [get]
+func (h *handlers) showDeviceVerificationPage(res http.ResponseWriter, req *http.Request) {
+	user := contextGetUser(req)
+
+	if user.ActorID != 0 {
+		_errors.NotPermitted(res, req)
+		return
+	}
+
+	h.renderPage(res, req, http.StatusOK, "device_verification.gohtml", map[string]interface{}{
+		"name":     user.Name,
+		"userCode": h.helpers.ReadString(req.URL.Query(), "user_code", ""),
+	})
+}
+
+// @Summary Approve or deny a device sign-in
+// @Description Signs the device showing the user code in to the signed-in account, or refuses it
+// @Tags Authentication
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param user_code formData string true "Code shown by the device"
+// @Param action formData string true "approve or deny"
+// @Success 200
+// @Router /device [post]
+func (h *handlers) verifyDevice(res http.ResponseWriter, req *http.Request) {
+	user := contextGetUser(req)
+
+	// Support staff acting as the user must not be able to sign devices in.
+	if user.ActorID != 0 {
+		_errors.NotPermitted(res, req)
+		return
+	}
+
+	var input domain.VerifyDeviceRequest
+
+	err := req.ParseForm()
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	input.UserCode = req.PostForm.Get("user_code")
+	input.Action = req.PostForm.Get("action")
+
+	ValidateVerifyDevice(&input)
+
+	if !input.Validator.HasErrors() {
+		err = h.appl.VerifyDeviceAuthorizationUseCase(req.Context(), input.UserCode, user, input.Action == "approve")
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrRecordNotFound):
+				input.Validator.AddFieldError("user_code", "That code is invalid or has expired")
+			default:
+				_errors.ServerError(res, req, err)
+				return
+			}
+		}
+	}
+
+	if input.Validator.HasErrors() {
+		h.renderPage(res, req, http.StatusBadRequest, "device_verification.gohtml", map[string]interface{}{
+			"name":     user.Name,
+			"userCode": input.UserCode,
+			"errors":   input.Validator.FieldErrors,
+		})
+		return
+	}
+
+	h.renderPage(res, req, http.StatusOK, "device_verification_success.gohtml", map[string]interface{}{
+		"approved": input.Action == "approve",
+	})
+}
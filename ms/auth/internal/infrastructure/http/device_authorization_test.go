@@ -0,0 +1,287 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"errors"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newFormRequest(method string, target string, form url.Values) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func newDeviceTokenRequest(deviceCode string) *http.Request {
+	return newFormRequest(http.MethodPost, "/v1/oauth/token", url.Values{
+		"grant_type":  {deviceCodeGrantType},
+		"device_code": {deviceCode},
+		"client_id":   {"living-room"},
+	})
+}
+
+func assertOAuthError(t *testing.T, resRec *httptest.ResponseRecorder, expectedError string) {
+	assertStatusCode(t, resRec, http.StatusBadRequest)
+
+	var responseBody map[string]string
+	assertResponseBody(t, resRec, &responseBody)
+	if responseBody["error"] != expectedError {
+		t.Errorf("unexpected error: got %q, want %q", responseBody["error"], expectedError)
+	}
+}
+
+func TestResource_CreateDeviceAuthorization(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		res.(*handlers).baseURL = "https://greenlight.example"
+		resRec := httptest.NewRecorder()
+
+		authorization := &domain.DeviceAuthorization{
+			DeviceCode: "GQRPVONORIEUPDJ6V4RTDIVSTQ",
+			UserCode:   "BCDF-GHJK",
+			Interval:   5 * time.Second,
+			Expiry:     time.Now().Add(10*time.Minute + time.Second),
+		}
+
+		mockApp.On("CreateDeviceAuthorizationUseCase", mock.Anything, "living-room").Return(authorization, nil)
+
+		// Act
+		res.createDeviceAuthorization(resRec, newFormRequest(http.MethodPost, "/v1/oauth/device_authorization", url.Values{"client_id": {"living-room"}}))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var responseBody map[string]interface{}
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody["device_code"] != authorization.DeviceCode || responseBody["user_code"] != authorization.UserCode {
+			t.Errorf("unexpected codes in %v", responseBody)
+		}
+		if responseBody["verification_uri"] != "https://greenlight.example/v1/device" {
+			t.Errorf("unexpected verification_uri %v", responseBody["verification_uri"])
+		}
+		if responseBody["verification_uri_complete"] != "https://greenlight.example/v1/device?user_code=BCDF-GHJK" {
+			t.Errorf("unexpected verification_uri_complete %v", responseBody["verification_uri_complete"])
+		}
+		if responseBody["expires_in"] != float64(600) || responseBody["interval"] != float64(5) {
+			t.Errorf("unexpected expires_in or interval in %v", responseBody)
+		}
+		if resRec.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("expected Cache-Control: no-store, got %q", resRec.Header().Get("Cache-Control"))
+		}
+	})
+
+	t.Run("error - missing client_id", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.createDeviceAuthorization(resRec, newFormRequest(http.MethodPost, "/v1/oauth/device_authorization", url.Values{}))
+
+		// Assert
+		assertOAuthError(t, resRec, "invalid_request")
+		mockApp.AssertNotCalled(t, "CreateDeviceAuthorizationUseCase", mock.Anything, mock.Anything)
+	})
+}
+
+func TestResource_ExchangeDeviceCode(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ExchangeDeviceCodeUseCase", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ", "living-room").Return([]byte("some.jwt.token"), nil)
+
+		// Act
+		res.exchangeDeviceCode(resRec, newDeviceTokenRequest("GQRPVONORIEUPDJ6V4RTDIVSTQ"))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var responseBody map[string]string
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody["access_token"] != "some.jwt.token" || responseBody["token_type"] != "Bearer" {
+			t.Errorf("unexpected response body %v", responseBody)
+		}
+	})
+
+	errorTests := []struct {
+		name          string
+		err           error
+		expectedError string
+	}{
+		{name: "authorization pending", err: domain.ErrAuthorizationPending, expectedError: "authorization_pending"},
+		{name: "slow down", err: domain.ErrSlowDown, expectedError: "slow_down"},
+		{name: "access denied", err: domain.ErrAccessDenied, expectedError: "access_denied"},
+		{name: "expired token", err: domain.ErrExpiredToken, expectedError: "expired_token"},
+		{name: "unknown device code", err: domain.ErrRecordNotFound, expectedError: "invalid_grant"},
+		{name: "suspended account", err: domain.ErrAccountSuspended, expectedError: "invalid_grant"},
+	}
+
+	for _, tc := range errorTests {
+		t.Run("error - "+tc.name, func(t *testing.T) {
+			// Arrange
+			mockApp, res := setupRouterAndMocks()
+			resRec := httptest.NewRecorder()
+
+			mockApp.On("ExchangeDeviceCodeUseCase", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ", "living-room").Return(nil, tc.err)
+
+			// Act
+			res.exchangeDeviceCode(resRec, newDeviceTokenRequest("GQRPVONORIEUPDJ6V4RTDIVSTQ"))
+
+			// Assert
+			assertOAuthError(t, resRec, tc.expectedError)
+		})
+	}
+
+	t.Run("error - unsupported grant type", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		req := newFormRequest(http.MethodPost, "/v1/oauth/token", url.Values{"grant_type": {"password"}, "client_id": {"living-room"}})
+
+		// Act
+		res.exchangeDeviceCode(resRec, req)
+
+		// Assert
+		assertOAuthError(t, resRec, "unsupported_grant_type")
+		mockApp.AssertNotCalled(t, "ExchangeDeviceCodeUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - missing device code", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.exchangeDeviceCode(resRec, newDeviceTokenRequest(""))
+
+		// Assert
+		assertOAuthError(t, resRec, "invalid_request")
+		mockApp.AssertNotCalled(t, "ExchangeDeviceCodeUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - ExchangeDeviceCodeUseCase return error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ExchangeDeviceCodeUseCase", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ", "living-room").Return(nil, errors.New("error"))
+
+		// Act
+		res.exchangeDeviceCode(resRec, newDeviceTokenRequest("GQRPVONORIEUPDJ6V4RTDIVSTQ"))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+}
+
+func TestResource_ShowDeviceVerificationPage(t *testing.T) {
+	// Arrange
+	_, res := setupRouterAndMocks()
+	resRec := httptest.NewRecorder()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/device?user_code=BCDF-GHJK", nil)
+	req = contextSetUser(req, &domain.User{ID: 1, Name: "John Doe", Activated: true})
+
+	// Act
+	res.showDeviceVerificationPage(resRec, req)
+
+	// Assert
+	assertStatusCode(t, resRec, http.StatusOK)
+	assertHTMLContains(t, resRec, `value="BCDF-GHJK"`)
+	assertHTMLContains(t, resRec, "John Doe")
+}
+
+func TestResource_VerifyDevice(t *testing.T) {
+	user := &domain.User{ID: 1, Name: "John Doe", Activated: true}
+
+	newVerifyDeviceRequest := func(userCode string, action string) *http.Request {
+		req := newFormRequest(http.MethodPost, "/v1/device", url.Values{"user_code": {userCode}, "action": {action}})
+		return contextSetUser(req, user)
+	}
+
+	t.Run("success - approve", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("VerifyDeviceAuthorizationUseCase", mock.Anything, "bcdf-ghjk", user, true).Return(nil)
+
+		// Act
+		res.verifyDevice(resRec, newVerifyDeviceRequest("bcdf-ghjk", "approve"))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assertHTMLContains(t, resRec, "Device signed in")
+	})
+
+	t.Run("success - deny", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("VerifyDeviceAuthorizationUseCase", mock.Anything, "BCDF-GHJK", user, false).Return(nil)
+
+		// Act
+		res.verifyDevice(resRec, newVerifyDeviceRequest("BCDF-GHJK", "deny"))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assertHTMLContains(t, resRec, "Sign-in denied")
+	})
+
+	t.Run("error - invalid user code", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.verifyDevice(resRec, newVerifyDeviceRequest("BCDF", "approve"))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+		assertHTMLContains(t, resRec, "Code must be 8 letters long")
+		mockApp.AssertNotCalled(t, "VerifyDeviceAuthorizationUseCase", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - unknown or expired user code", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("VerifyDeviceAuthorizationUseCase", mock.Anything, "BCDF-GHJK", user, true).Return(domain.ErrRecordNotFound)
+
+		// Act
+		res.verifyDevice(resRec, newVerifyDeviceRequest("BCDF-GHJK", "approve"))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+		assertHTMLContains(t, resRec, "That code is invalid or has expired")
+	})
+
+	t.Run("error - impersonating admin", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		req := newFormRequest(http.MethodPost, "/v1/device", url.Values{"user_code": {"BCDF-GHJK"}, "action": {"approve"}})
+		req = contextSetUser(req, &domain.User{ID: 1, Activated: true, ActorID: 2})
+
+		// Act
+		res.verifyDevice(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusForbidden)
+		mockApp.AssertNotCalled(t, "VerifyDeviceAuthorizationUseCase", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"errors"
 	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/mailer"
 	"github.com/jessicatarra/greenlight/internal/password"
 	"github.com/jessicatarra/greenlight/internal/request"
 	"github.com/jessicatarra/greenlight/internal/response"
@@ -10,6 +11,7 @@ import (
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
+	"strings"
 )
 
 type envelope map[string]interface{}
@@ -17,26 +19,170 @@ type envelope map[string]interface{}
 type Handlers interface {
 	createUser(res http.ResponseWriter, req *http.Request)
 	activateUser(res http.ResponseWriter, req *http.Request)
+	showActivationPage(res http.ResponseWriter, req *http.Request)
+	confirmActivation(res http.ResponseWriter, req *http.Request)
 	createAuthenticationToken(res http.ResponseWriter, req *http.Request)
+	createSession(res http.ResponseWriter, req *http.Request)
+	deleteSession(res http.ResponseWriter, req *http.Request)
+	createDeviceAuthorization(res http.ResponseWriter, req *http.Request)
+	exchangeDeviceCode(res http.ResponseWriter, req *http.Request)
+	showDeviceVerificationPage(res http.ResponseWriter, req *http.Request)
+	verifyDevice(res http.ResponseWriter, req *http.Request)
+	createInvitation(res http.ResponseWriter, req *http.Request)
+	createMagicLink(res http.ResponseWriter, req *http.Request)
+	exchangeMagicLink(res http.ResponseWriter, req *http.Request)
+	listUsers(res http.ResponseWriter, req *http.Request)
+	suspendUser(res http.ResponseWriter, req *http.Request)
+	reactivateUser(res http.ResponseWriter, req *http.Request)
+	impersonateUser(res http.ResponseWriter, req *http.Request)
+	listOutboxEmails(res http.ResponseWriter, req *http.Request)
+	replayOutboxEmail(res http.ResponseWriter, req *http.Request)
+	createBulkEmail(res http.ResponseWriter, req *http.Request)
+	showBulkEmail(res http.ResponseWriter, req *http.Request)
+	listBulkEmailFailures(res http.ResponseWriter, req *http.Request)
+	resumeBulkEmail(res http.ResponseWriter, req *http.Request)
+	introspectToken(res http.ResponseWriter, req *http.Request)
+	createPasswordResetToken(res http.ResponseWriter, req *http.Request)
+	resetPassword(res http.ResponseWriter, req *http.Request)
+	changePassword(res http.ResponseWriter, req *http.Request)
+	showRevokeSessionsPage(res http.ResponseWriter, req *http.Request)
+	revokeSessions(res http.ResponseWriter, req *http.Request)
+	beginPasskeyRegistration(res http.ResponseWriter, req *http.Request)
+	finishPasskeyRegistration(res http.ResponseWriter, req *http.Request)
+	beginPasskeyLogin(res http.ResponseWriter, req *http.Request)
+	finishPasskeyLogin(res http.ResponseWriter, req *http.Request)
+	listSCIMUsers(res http.ResponseWriter, req *http.Request)
+	showSCIMUser(res http.ResponseWriter, req *http.Request)
+	createSCIMUser(res http.ResponseWriter, req *http.Request)
+	replaceSCIMUser(res http.ResponseWriter, req *http.Request)
+	patchSCIMUser(res http.ResponseWriter, req *http.Request)
+	deleteSCIMUser(res http.ResponseWriter, req *http.Request)
+	listSCIMGroups(res http.ResponseWriter, req *http.Request)
+	showSCIMGroup(res http.ResponseWriter, req *http.Request)
+	replaceSCIMGroup(res http.ResponseWriter, req *http.Request)
+	patchSCIMGroup(res http.ResponseWriter, req *http.Request)
+	unsupportedSCIMGroupOperation(res http.ResponseWriter, req *http.Request)
+	showSCIMServiceProviderConfig(res http.ResponseWriter, req *http.Request)
+	listSCIMResourceTypes(res http.ResponseWriter, req *http.Request)
+	showInbox(res http.ResponseWriter, req *http.Request)
+	listEmailTemplates(res http.ResponseWriter, req *http.Request)
+	previewEmail(res http.ResponseWriter, req *http.Request)
 }
 
 type handlers struct {
-	appl    domain.Appl
-	helpers helpers.Helpers
+	appl           domain.Appl
+	helpers        helpers.Helpers
+	policy         *password.Policy
+	hasher         *password.Hasher
+	clientIPHeader string
+	baseURL        string
+	cookies        sessionCookies
+	// antiEnumeration hides whether an email address is registered from
+	// logins and registrations.
+	antiEnumeration bool
+	// mailer renders the email previews, lists the templates bulk emails
+	// can be sent from and, when it keeps emails in memory, holds the
+	// inbox.
+	mailer mailer.Mailer
 }
 
 func (s service) Handlers(router *httprouter.Router) {
-	res := registerHandlers(s.appl)
+	res := registerHandlers(handlerDeps{
+		appl:            s.appl,
+		policy:          s.passwordPolicy(),
+		hasher:          s.hasher,
+		clientIPHeader:  s.cfg.Devices.ClientIPHeader,
+		baseURL:         s.cfg.Public.BaseURL,
+		cookies:         s.sessionCookies(),
+		antiEnumeration: s.cfg.Signup.AntiEnumeration,
+		mailer:          s.mailer,
+	})
 
 	router.HandlerFunc(http.MethodPost, "/v1/users", res.createUser)
 	router.HandlerFunc(http.MethodPut, "/v1/users/activated", res.activateUser)
+	router.HandlerFunc(http.MethodGet, "/v1/users/activated", res.showActivationPage)
+	router.HandlerFunc(http.MethodPost, "/v1/users/activated", res.confirmActivation)
+	router.HandlerFunc(http.MethodPut, "/v1/users/password", res.resetPassword)
+	router.HandlerFunc(http.MethodPut, "/v1/users/me/password", s.requireActivatedUser(res.changePassword))
+	router.HandlerFunc(http.MethodPost, "/v1/users/me/passkeys/challenge", s.requireActivatedUser(res.beginPasskeyRegistration))
+	router.HandlerFunc(http.MethodPost, "/v1/users/me/passkeys", s.requireActivatedUser(res.finishPasskeyRegistration))
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/authentication", res.createAuthenticationToken)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/passkey/challenge", res.beginPasskeyLogin)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/passkey", res.finishPasskeyLogin)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/password-reset", res.createPasswordResetToken)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/magic-link", res.createMagicLink)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/magic-link/exchange", res.exchangeMagicLink)
+	router.HandlerFunc(http.MethodGet, "/v1/sessions/revoke", res.showRevokeSessionsPage)
+	router.HandlerFunc(http.MethodPost, "/v1/sessions/revoke", res.revokeSessions)
+	if s.cfg.Sessions.Enabled {
+		router.HandlerFunc(http.MethodPost, "/v1/sessions", res.createSession)
+		router.HandlerFunc(http.MethodDelete, "/v1/sessions", res.deleteSession)
+	}
+	router.HandlerFunc(http.MethodPost, "/v1/oauth/device_authorization", res.createDeviceAuthorization)
+	router.HandlerFunc(http.MethodPost, "/v1/oauth/token", res.exchangeDeviceCode)
+	router.HandlerFunc(http.MethodGet, "/v1/device", s.requireActivatedUser(res.showDeviceVerificationPage))
+	router.HandlerFunc(http.MethodPost, "/v1/device", s.requireActivatedUser(res.verifyDevice))
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/introspect", s.requireIntrospectionClient(res.introspectToken))
+	router.HandlerFunc(http.MethodPost, "/v1/invitations", s.requirePermission("users:admin", res.createInvitation))
+	router.HandlerFunc(http.MethodGet, "/v1/admin/users", s.requirePermission("users:admin", res.listUsers))
+	router.HandlerFunc(http.MethodPost, "/v1/admin/users/:id/suspend", s.requirePermission("users:admin", res.suspendUser))
+	router.HandlerFunc(http.MethodPost, "/v1/admin/users/:id/reactivate", s.requirePermission("users:admin", res.reactivateUser))
+	router.HandlerFunc(http.MethodPost, "/v1/admin/users/:id/impersonate", s.requirePermission("users:admin", res.impersonateUser))
+	router.HandlerFunc(http.MethodGet, "/v1/admin/outbox", s.requirePermission("users:admin", res.listOutboxEmails))
+	router.HandlerFunc(http.MethodPost, "/v1/admin/outbox/:id/replay", s.requirePermission("users:admin", res.replayOutboxEmail))
+	router.HandlerFunc(http.MethodPost, "/v1/admin/bulk-emails", s.requirePermission("users:admin", res.createBulkEmail))
+	router.HandlerFunc(http.MethodGet, "/v1/admin/bulk-emails/:id", s.requirePermission("users:admin", res.showBulkEmail))
+	router.HandlerFunc(http.MethodGet, "/v1/admin/bulk-emails/:id/failures", s.requirePermission("users:admin", res.listBulkEmailFailures))
+	router.HandlerFunc(http.MethodPost, "/v1/admin/bulk-emails/:id/resume", s.requirePermission("users:admin", res.resumeBulkEmail))
+	router.HandlerFunc(http.MethodGet, "/scim/v2/ServiceProviderConfig", s.requireSCIMClient(res.showSCIMServiceProviderConfig))
+	router.HandlerFunc(http.MethodGet, "/scim/v2/ResourceTypes", s.requireSCIMClient(res.listSCIMResourceTypes))
+	router.HandlerFunc(http.MethodGet, "/scim/v2/Users", s.requireSCIMClient(res.listSCIMUsers))
+	router.HandlerFunc(http.MethodPost, "/scim/v2/Users", s.requireSCIMClient(res.createSCIMUser))
+	router.HandlerFunc(http.MethodGet, "/scim/v2/Users/:id", s.requireSCIMClient(res.showSCIMUser))
+	router.HandlerFunc(http.MethodPut, "/scim/v2/Users/:id", s.requireSCIMClient(res.replaceSCIMUser))
+	router.HandlerFunc(http.MethodPatch, "/scim/v2/Users/:id", s.requireSCIMClient(res.patchSCIMUser))
+	router.HandlerFunc(http.MethodDelete, "/scim/v2/Users/:id", s.requireSCIMClient(res.deleteSCIMUser))
+	router.HandlerFunc(http.MethodGet, "/scim/v2/Groups", s.requireSCIMClient(res.listSCIMGroups))
+	router.HandlerFunc(http.MethodPost, "/scim/v2/Groups", s.requireSCIMClient(res.unsupportedSCIMGroupOperation))
+	router.HandlerFunc(http.MethodGet, "/scim/v2/Groups/:id", s.requireSCIMClient(res.showSCIMGroup))
+	router.HandlerFunc(http.MethodPut, "/scim/v2/Groups/:id", s.requireSCIMClient(res.replaceSCIMGroup))
+	router.HandlerFunc(http.MethodPatch, "/scim/v2/Groups/:id", s.requireSCIMClient(res.patchSCIMGroup))
+	router.HandlerFunc(http.MethodDelete, "/scim/v2/Groups/:id", s.requireSCIMClient(res.unsupportedSCIMGroupOperation))
+	// The inbox shows every captured email, tokens included, so it is only
+	// ever served in development, as are the email previews.
+	if s.cfg.Env == "development" {
+		if s.mailer.Inbox() != nil {
+			router.HandlerFunc(http.MethodGet, "/v1/dev/inbox", res.showInbox)
+		}
+		router.HandlerFunc(http.MethodGet, "/v1/dev/emails", res.listEmailTemplates)
+		router.HandlerFunc(http.MethodGet, "/v1/dev/emails/:template", res.previewEmail)
+	}
+}
+
+// handlerDeps holds what the handlers are built from, named so that
+// settings of the same type cannot be passed in the wrong order.
+type handlerDeps struct {
+	appl            domain.Appl
+	policy          *password.Policy
+	hasher          *password.Hasher
+	clientIPHeader  string
+	baseURL         string
+	cookies         sessionCookies
+	antiEnumeration bool
+	mailer          mailer.Mailer
 }
 
-func registerHandlers(appl domain.Appl) Handlers {
+func registerHandlers(deps handlerDeps) Handlers {
 	return &handlers{
-		appl:    appl,
-		helpers: helpers.New(),
+		appl:            deps.appl,
+		helpers:         helpers.New(),
+		policy:          deps.policy,
+		hasher:          deps.hasher,
+		clientIPHeader:  deps.clientIPHeader,
+		baseURL:         strings.TrimSuffix(deps.baseURL, "/"),
+		cookies:         deps.cookies,
+		antiEnumeration: deps.antiEnumeration,
+		mailer:          deps.mailer,
 	}
 }
 
@@ -57,37 +203,65 @@ func (h *handlers) createUser(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	existingUser, err := h.appl.GetByEmailUseCase(input.Email)
-	if err != nil && err.Error() != domain.ErrRecordNotFound.Error() {
-		_errors.ServerError(res, req, err)
-		return
+	input.Locale = mailer.MatchLocale(req.Header.Get("Accept-Language"))
+
+	// In anti-enumeration mode a taken email address is only discovered when
+	// the insert fails, after the same work as a successful registration.
+	var existingUser *domain.User
+	if !h.antiEnumeration {
+		existingUser, err = h.appl.GetByEmailUseCase(req.Context(), input.Email)
+		if err != nil && err.Error() != domain.ErrRecordNotFound.Error() {
+			_errors.ServerError(res, req, err)
+			return
+		}
 	}
 
-	ValidateUser(&input, existingUser)
+	ValidateUser(&input, existingUser, h.policy)
 
 	if input.Validator.HasErrors() {
 		_errors.FailedValidation(res, req, input.Validator)
 		return
 	}
 
-	hashedPassword, err := password.Hash(input.Password)
+	hashedPassword, err := h.hasher.Hash(req.Context(), input.Password)
 	if err != nil {
-		_errors.ServerError(res, req, err)
+		hashingError(res, req, err)
 		return
 	}
 
-	user, err := h.appl.CreateUseCase(&input, hashedPassword)
+	user, err := h.appl.CreateUseCase(req.Context(), &input, hashedPassword)
+	if errors.Is(err, domain.ErrDuplicateEmail) && h.antiEnumeration {
+		err = h.appl.NotifyRegistrationAttemptUseCase(req.Context(), input.Email)
+	}
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrDuplicateEmail):
 			input.Validator.AddError("email a user with this email address already exists")
 			_errors.FailedValidation(res, req, input.Validator)
+		case errors.Is(err, domain.ErrInvitationRequired):
+			input.Validator.AddFieldError("InvitationToken", "Invitation token is required")
+			_errors.FailedValidation(res, req, input.Validator)
+		case errors.Is(err, domain.ErrInvalidInvitation):
+			input.Validator.AddFieldError("InvitationToken", "Invitation token is invalid or has expired")
+			_errors.FailedValidation(res, req, input.Validator)
 		default:
 			_errors.ServerError(res, req, err)
 		}
 		return
 	}
 
+	if h.antiEnumeration {
+		// The response leaves out everything only a newly created account
+		// would have, such as its ID.
+		registration := envelope{"name": input.Name, "email": input.Email, "activated": input.InvitationToken != ""}
+
+		err = response.JSON(res, http.StatusCreated, envelope{"user": registration})
+		if err != nil {
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
 	err = response.JSON(res, http.StatusCreated, envelope{"user": user})
 	if err != nil {
 		_errors.ServerError(res, req, err)
@@ -116,7 +290,7 @@ func (h *handlers) activateUser(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	user, err := h.appl.ActivateUseCase(input.TokenPlaintext)
+	user, err := h.appl.ActivateUseCase(req.Context(), input.TokenPlaintext)
 	if err != nil {
 		_errors.ServerError(res, req, err)
 		return
@@ -145,7 +319,39 @@ func (h *handlers) createAuthenticationToken(res http.ResponseWriter, req *http.
 		return
 	}
 
-	existingUser, err := h.appl.GetByEmailUseCase(input.Email)
+	existingUser, ok := h.checkCredentials(res, req, &input)
+	if !ok {
+		return
+	}
+
+	jwtBytes, err := h.appl.CreateAuthTokenUseCase(req.Context(), existingUser.ID)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	// Failing to record the device must not stop the user from signing in.
+	err = h.appl.RecordSignInUseCase(req.Context(), existingUser, clientIP(req, h.clientIPHeader), req.UserAgent())
+	if err != nil {
+		_errors.ReportServerError(req, err)
+	}
+
+	err = response.JSON(res, http.StatusCreated, envelope{"authentication_token": string(jwtBytes)})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+
+}
+
+// checkCredentials looks up the user signing in with input and checks their
+// password. When the credentials are wrong or the account is suspended it
+// writes the error response and returns false.
+func (h *handlers) checkCredentials(res http.ResponseWriter, req *http.Request, input *domain.CreateAuthTokenRequest) (*domain.User, bool) {
+	if h.antiEnumeration {
+		return h.checkCredentialsUniformly(res, req, input)
+	}
+
+	existingUser, err := h.appl.GetByEmailUseCase(req.Context(), input.Email)
 	if err != nil {
 		switch {
 		case errors.Is(err, domain.ErrRecordNotFound):
@@ -153,35 +359,103 @@ func (h *handlers) createAuthenticationToken(res http.ResponseWriter, req *http.
 		default:
 			_errors.ServerError(res, req, err)
 		}
-		return
+		return nil, false
 	}
 
-	ValidateEmailForAuth(&input, existingUser)
+	ValidateEmailForAuth(input, existingUser)
 
 	if existingUser != nil {
-		passwordMatches, err := password.Matches(input.Password, existingUser.HashedPassword)
+		passwordMatches, err := h.hasher.Matches(req.Context(), input.Password, existingUser.HashedPassword)
 		if err != nil {
-			_errors.ServerError(res, req, err)
-			return
+			hashingError(res, req, err)
+			return nil, false
 		}
 
-		ValidatePasswordForAuth(&input, passwordMatches)
+		ValidatePasswordForAuth(input, passwordMatches)
 	}
 
 	if input.Validator.HasErrors() {
 		_errors.FailedValidation(res, req, input.Validator)
-		return
+		return nil, false
 	}
 
-	jwtBytes, err := h.appl.CreateAuthTokenUseCase(existingUser.ID)
-	if err != nil {
+	if existingUser.Suspended {
+		_errors.AccountSuspended(res, req)
+		return nil, false
+	}
+
+	h.rehashPassword(req, existingUser, input.Password)
+
+	return existingUser, true
+}
+
+// checkCredentialsUniformly is checkCredentials for anti-enumeration mode.
+// Unknown emails and wrong passwords get the same response, and both cost a
+// bcrypt comparison, so neither the answer nor its timing tells whether an
+// account exists.
+func (h *handlers) checkCredentialsUniformly(res http.ResponseWriter, req *http.Request, input *domain.CreateAuthTokenRequest) (*domain.User, bool) {
+	existingUser, err := h.appl.GetByEmailUseCase(req.Context(), input.Email)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
 		_errors.ServerError(res, req, err)
+		return nil, false
+	}
+
+	if existingUser == nil {
+		err = h.hasher.MatchesNothing(req.Context(), input.Password)
+		if err != nil {
+			hashingError(res, req, err)
+			return nil, false
+		}
+		_errors.InvalidCredentials(res, req)
+		return nil, false
+	}
+
+	passwordMatches, err := h.hasher.Matches(req.Context(), input.Password, existingUser.HashedPassword)
+	if err != nil {
+		hashingError(res, req, err)
+		return nil, false
+	}
+
+	if !passwordMatches {
+		_errors.InvalidCredentials(res, req)
+		return nil, false
+	}
+
+	if existingUser.Suspended {
+		_errors.AccountSuspended(res, req)
+		return nil, false
+	}
+
+	h.rehashPassword(req, existingUser, input.Password)
+
+	return existingUser, true
+}
+
+// rehashPassword replaces the stored hash of a user who has just proved
+// their password when it was not made with the current pepper. Failing to
+// does not stop the sign-in; the next one tries again.
+func (h *handlers) rehashPassword(req *http.Request, user *domain.User, plaintextPassword string) {
+	if !h.hasher.NeedsRehash(user.HashedPassword) {
 		return
 	}
 
-	err = response.JSON(res, http.StatusCreated, envelope{"authentication_token": string(jwtBytes)})
+	hashedPassword, err := h.hasher.Hash(req.Context(), plaintextPassword)
+	if err == nil {
+		err = h.appl.RehashPasswordUseCase(req.Context(), user, hashedPassword)
+	}
 	if err != nil {
-		_errors.ServerError(res, req, err)
+		_errors.ReportServerError(req, err)
 	}
+}
 
+// hashingError writes the response for a password that could not be hashed
+// or checked, asking the client to come back later when the hashing queue is
+// full.
+func hashingError(res http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, password.ErrSaturated):
+		_errors.ServiceUnavailable(res, req, hashingRetryAfter)
+	default:
+		_errors.ServerError(res, req, err)
+	}
 }
@@ -5,21 +5,31 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"github.com/jessicatarra/greenlight/internal/mailer"
 	"github.com/jessicatarra/greenlight/internal/password"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func setupRouterAndMocks() (*mocks.Appl, Handlers) {
 	mockApp := &mocks.Appl{}
 
-	res := registerHandlers(mockApp)
+	res := registerHandlers(handlerDeps{
+		appl:   mockApp,
+		policy: password.NewStandardPolicy(8, 72, 0),
+		hasher: password.NewHasher(nil, "", nil),
+		mailer: mailer.Mailer{},
+	})
 
 	return mockApp, res
 }
@@ -73,6 +83,7 @@ func TestResource_Create(t *testing.T) {
 			Name:     "John Doe",
 			Email:    "johndoe@example.com",
 			Password: "password123",
+			Locale:   "en",
 		}
 		expectedUser := &domain.User{
 			ID:    1,
@@ -86,8 +97,8 @@ func TestResource_Create(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock CreateUseCase and GetByEmailUseCase
-		mockApp.On("CreateUseCase", expectedInput, mock.AnythingOfType("string")).Return(expectedUser, nil)
-		mockApp.On("GetByEmailUseCase", "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(expectedUser, nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
 
 		// Act
 		res.createUser(resRec, req)
@@ -99,6 +110,28 @@ func TestResource_Create(t *testing.T) {
 		assertUserFields(t, responseBody, expectedUser)
 	})
 
+	t.Run("success - locale from Accept-Language", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		requestBody := createRequestBody()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", "fr-CH, es-AR;q=0.8, en;q=0.5")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreateUseCase", mock.Anything, mock.MatchedBy(func(input *domain.CreateUserRequest) bool {
+			return input.Locale == "es"
+		}), mock.AnythingOfType("string")).Return(&domain.User{ID: 1, Name: "John Doe", Email: "johndoe@example.com", Locale: "es"}, nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.createUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+	})
+
 	t.Run("error - GetByEmailUseCase return error", func(t *testing.T) {
 		// Arrange
 		mockApp, res := setupRouterAndMocks()
@@ -106,6 +139,7 @@ func TestResource_Create(t *testing.T) {
 			Name:     "John Doe",
 			Email:    "johndoe@example.com",
 			Password: "password123",
+			Locale:   "en",
 		}
 		expectedUser := &domain.User{
 			ID:    1,
@@ -119,8 +153,8 @@ func TestResource_Create(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock CreateUseCase and GetByEmailUseCase
-		mockApp.On("GetByEmailUseCase", expectedUser.Email).Return(nil, errors.New("error"))
-		mockApp.On("CreateUseCase", expectedInput, mock.AnythingOfType("string")).Return(expectedUser, nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(nil, errors.New("error"))
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(expectedUser, nil)
 
 		// Act
 		res.createUser(resRec, req)
@@ -136,6 +170,7 @@ func TestResource_Create(t *testing.T) {
 			Name:     "John Doe",
 			Email:    "johndoe@example.com",
 			Password: "password123",
+			Locale:   "en",
 		}
 		expectedUser := &domain.User{
 			ID:    1,
@@ -152,8 +187,8 @@ func TestResource_Create(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock CreateUseCase and GetByEmailUseCase
-		mockApp.On("CreateUseCase", expectedInput, mock.AnythingOfType("string")).Return(expectedUser, nil)
-		mockApp.On("GetByEmailUseCase", "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(expectedUser, nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
 
 		// Act
 		res.createUser(resRec, req)
@@ -169,6 +204,7 @@ func TestResource_Create(t *testing.T) {
 			Name:     "John Doe",
 			Email:    "johndoe@example.com",
 			Password: "password123",
+			Locale:   "en",
 		}
 		expectedUser := &domain.User{
 			ID:    1,
@@ -181,8 +217,8 @@ func TestResource_Create(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock CreateUseCase and GetByEmailUseCase
-		mockApp.On("CreateUseCase", expectedInput, mock.AnythingOfType("string")).Return(expectedUser, nil)
-		mockApp.On("GetByEmailUseCase", "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(expectedUser, nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
 
 		// Act
 		res.createUser(resRec, req)
@@ -199,6 +235,7 @@ func TestResource_Create(t *testing.T) {
 			Name:     "John Doe",
 			Email:    "johndoe@example.com",
 			Password: "password123",
+			Locale:   "en",
 		}
 
 		requestBody := createRequestBody()
@@ -207,14 +244,69 @@ func TestResource_Create(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock CreateUseCase and GetByEmailUseCase
-		mockApp.On("CreateUseCase", expectedInput, mock.AnythingOfType("string")).Return(nil, domain.ErrDuplicateEmail)
-		mockApp.On("GetByEmailUseCase", "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(nil, domain.ErrDuplicateEmail)
+		mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.createUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("error - CreateUseCase return ErrInvitationRequired error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		expectedInput := &domain.CreateUserRequest{
+			Name:     "John Doe",
+			Email:    "johndoe@example.com",
+			Password: "password123",
+			Locale:   "en",
+		}
+
+		requestBody := createRequestBody()
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		resRec := httptest.NewRecorder()
+
+		// Mock CreateUseCase and GetByEmailUseCase
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(nil, domain.ErrInvitationRequired)
+		mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.createUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		var responseBody map[string]map[string]string
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody["FieldErrors"]["InvitationToken"] == "" {
+			t.Errorf("expected 'InvitationToken' field error in response body, got %v", responseBody)
+		}
+	})
+
+	t.Run("error - invitation token with invalid length", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		requestBody := []byte(`{
+		"name": "John Doe",
+		"email": "johndoe@example.com",
+		"password": "password123",
+		"invitation_token": "short"
+	}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
 
 		// Act
 		res.createUser(resRec, req)
 
 		// Assert
 		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "CreateUseCase", mock.Anything, mock.Anything, mock.Anything)
 	})
 
 	t.Run("error - CreateUseCase return error", func(t *testing.T) {
@@ -225,6 +317,7 @@ func TestResource_Create(t *testing.T) {
 			Name:     "John Doe",
 			Email:    "johndoe@example.com",
 			Password: "password123",
+			Locale:   "en",
 		}
 
 		requestBody := createRequestBody()
@@ -233,8 +326,8 @@ func TestResource_Create(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock CreateUseCase and GetByEmailUseCase
-		mockApp.On("CreateUseCase", expectedInput, mock.AnythingOfType("string")).Return(nil, errors.New("error"))
-		mockApp.On("GetByEmailUseCase", "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(nil, errors.New("error"))
+		mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
 
 		// Act
 		res.createUser(resRec, req)
@@ -250,6 +343,7 @@ func TestResource_Create(t *testing.T) {
 			Name:     "John Doe",
 			Email:    "johndoe@example.com",
 			Password: "password123",
+			Locale:   "en",
 		}
 
 		requestBody := createRequestBody()
@@ -259,8 +353,8 @@ func TestResource_Create(t *testing.T) {
 		expectedErr := errors.New("The server encountered a problem and could not process your request")
 
 		// Mock CreateUseCase and GetByEmailUseCase
-		mockApp.On("CreateUseCase", expectedInput, mock.AnythingOfType("string")).Return(nil, expectedErr)
-		mockApp.On("GetByEmailUseCase", "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(nil, expectedErr)
+		mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
 
 		// Act
 		res.createUser(resRec, req)
@@ -299,7 +393,7 @@ func TestResource_Activate(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock ActivateUseCase
-		mockApp.On("ActivateUseCase", expectedInput.TokenPlaintext).Return(expectedUser, nil)
+		mockApp.On("ActivateUseCase", mock.Anything, expectedInput.TokenPlaintext).Return(expectedUser, nil)
 
 		// Act
 		res.activateUser(resRec, req)
@@ -309,7 +403,7 @@ func TestResource_Activate(t *testing.T) {
 		var responseBody map[string]*domain.User
 		assertResponseBody(t, resRec, &responseBody)
 		assertUserFields(t, responseBody, expectedUser)
-		mockApp.AssertCalled(t, "ActivateUseCase", expectedInput.TokenPlaintext)
+		mockApp.AssertCalled(t, "ActivateUseCase", mock.Anything, expectedInput.TokenPlaintext)
 	})
 
 	t.Run("error - validate token error", func(t *testing.T) {
@@ -324,7 +418,7 @@ func TestResource_Activate(t *testing.T) {
 		expectedErr := errors.New("The server encountered a problem and could not process your request")
 
 		// Mock ActivateUseCase
-		mockApp.On("ActivateUseCase", expectedInput.TokenPlaintext).Return(nil, expectedErr)
+		mockApp.On("ActivateUseCase", mock.Anything, expectedInput.TokenPlaintext).Return(nil, expectedErr)
 
 		// Act
 		res.activateUser(resRec, req)
@@ -346,7 +440,7 @@ func TestResource_Activate(t *testing.T) {
 		expectedErr := errors.New("The server encountered a problem and could not process your request")
 
 		// Mock ActivateUseCase
-		mockApp.On("ActivateUseCase", expectedInput.TokenPlaintext).Return(nil, expectedErr)
+		mockApp.On("ActivateUseCase", mock.Anything, expectedInput.TokenPlaintext).Return(nil, expectedErr)
 
 		// Act
 		res.activateUser(resRec, req)
@@ -362,7 +456,7 @@ func TestResource_Activate(t *testing.T) {
 				t.Errorf("unexpected error message: got %s, want %s", responseBody["Error"], expectedErr.Error())
 			}
 		}
-		mockApp.AssertCalled(t, "ActivateUseCase", expectedInput.TokenPlaintext)
+		mockApp.AssertCalled(t, "ActivateUseCase", mock.Anything, expectedInput.TokenPlaintext)
 	})
 }
 
@@ -390,8 +484,9 @@ func TestResource_AuthenticationToken(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock GetByEmailUseCase and CreateAuthTokenUseCase
-		mockApp.On("GetByEmailUseCase", expectedUser.Email).Return(expectedUser, nil)
-		mockApp.On("CreateAuthTokenUseCase", expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, expectedUser, mock.Anything, mock.Anything).Return(nil)
 
 		// Act
 		res.createAuthenticationToken(resRec, req)
@@ -402,6 +497,69 @@ func TestResource_AuthenticationToken(t *testing.T) {
 		assertResponseBody(t, resRec, &responseBody)
 	})
 
+	t.Run("success - rehash with current pepper", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		res.(*handlers).hasher = password.NewHasher(nil, "2026-01", map[string][]byte{"2026-01": []byte("0123456789abcdef0123456789abcdef")})
+		hashedPassword, _ := password.Hash("password123")
+		expectedUser := &domain.User{
+			ID:             1,
+			Email:          "johndoe@example.com",
+			HashedPassword: hashedPassword,
+			Activated:      true,
+		}
+
+		requestBody := []byte(`{"email": "johndoe@example.com", "password": "password123"}`)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/authentication", bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+		mockApp.On("RehashPasswordUseCase", mock.Anything, expectedUser, mock.MatchedBy(func(hashedPassword string) bool {
+			return strings.HasPrefix(hashedPassword, "$pepper$2026-01$")
+		})).Return(nil)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, expectedUser, mock.Anything, mock.Anything).Return(nil)
+
+		// Act
+		res.createAuthenticationToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		mockApp.AssertCalled(t, "RehashPasswordUseCase", mock.Anything, expectedUser, mock.Anything)
+	})
+
+	t.Run("success - rehash fails", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		res.(*handlers).hasher = password.NewHasher(nil, "2026-01", map[string][]byte{"2026-01": []byte("0123456789abcdef0123456789abcdef")})
+		hashedPassword, _ := password.Hash("password123")
+		expectedUser := &domain.User{
+			ID:             1,
+			Email:          "johndoe@example.com",
+			HashedPassword: hashedPassword,
+			Activated:      true,
+		}
+
+		requestBody := []byte(`{"email": "johndoe@example.com", "password": "password123"}`)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/authentication", bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+		mockApp.On("RehashPasswordUseCase", mock.Anything, expectedUser, mock.Anything).Return(domain.ErrEditConflict)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, expectedUser, mock.Anything, mock.Anything).Return(nil)
+
+		// Act
+		res.createAuthenticationToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+	})
+
 	t.Run("error - bad request status code", func(t *testing.T) {
 		// Arrange
 		mockApp, res := setupRouterAndMocks()
@@ -422,8 +580,9 @@ func TestResource_AuthenticationToken(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock GetByEmailUseCase and CreateAuthTokenUseCase
-		mockApp.On("GetByEmailUseCase", expectedUser.Email).Return(expectedUser, nil)
-		mockApp.On("CreateAuthTokenUseCase", expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, expectedUser, mock.Anything, mock.Anything).Return(nil)
 
 		// Act
 		res.createAuthenticationToken(resRec, req)
@@ -453,8 +612,9 @@ func TestResource_AuthenticationToken(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock GetByEmailUseCase and CreateAuthTokenUseCase
-		mockApp.On("GetByEmailUseCase", expectedUser.Email).Return(nil, domain.ErrRecordNotFound)
-		mockApp.On("CreateAuthTokenUseCase", expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(nil, domain.ErrRecordNotFound)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, expectedUser, mock.Anything, mock.Anything).Return(nil)
 
 		// Act
 		res.createAuthenticationToken(resRec, req)
@@ -484,8 +644,9 @@ func TestResource_AuthenticationToken(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock GetByEmailUseCase and CreateAuthTokenUseCase
-		mockApp.On("GetByEmailUseCase", expectedUser.Email).Return(nil, errors.New("error"))
-		mockApp.On("CreateAuthTokenUseCase", expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(nil, errors.New("error"))
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, expectedUser, mock.Anything, mock.Anything).Return(nil)
 
 		// Act
 		res.createAuthenticationToken(resRec, req)
@@ -513,8 +674,9 @@ func TestResource_AuthenticationToken(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock GetByEmailUseCase and CreateAuthTokenUseCase
-		mockApp.On("GetByEmailUseCase", expectedUser.Email).Return(expectedUser, nil)
-		mockApp.On("CreateAuthTokenUseCase", expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, expectedUser, mock.Anything, mock.Anything).Return(nil)
 
 		// Act
 		res.createAuthenticationToken(resRec, req)
@@ -545,8 +707,9 @@ func TestResource_AuthenticationToken(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock GetByEmailUseCase and CreateAuthTokenUseCase
-		mockApp.On("GetByEmailUseCase", expectedUser.Email).Return(expectedUser, nil)
-		mockApp.On("CreateAuthTokenUseCase", expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, expectedUser, mock.Anything, mock.Anything).Return(nil)
 
 		// Act
 		res.createAuthenticationToken(resRec, req)
@@ -577,8 +740,8 @@ func TestResource_AuthenticationToken(t *testing.T) {
 		resRec := httptest.NewRecorder()
 
 		// Mock GetByEmailUseCase and CreateAuthTokenUseCase
-		mockApp.On("GetByEmailUseCase", expectedUser.Email).Return(expectedUser, nil)
-		mockApp.On("CreateAuthTokenUseCase", expectedUser.ID).Return(nil, errors.New("error"))
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, expectedUser.ID).Return(nil, errors.New("error"))
 
 		// Act
 		res.createAuthenticationToken(resRec, req)
@@ -586,4 +749,217 @@ func TestResource_AuthenticationToken(t *testing.T) {
 		// Assert
 		assertStatusCode(t, resRec, http.StatusInternalServerError)
 	})
+
+	t.Run("error - suspended account", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		hashedPassword, _ := password.Hash("password123")
+		expectedUser := &domain.User{
+			ID:             1,
+			Name:           "John Doe",
+			Email:          "johndoe@example.com",
+			HashedPassword: hashedPassword,
+			Activated:      true,
+			Suspended:      true,
+		}
+
+		requestBody := []byte(`{
+		"email": "johndoe@example.com",
+		"password": "password123"
+		}`)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/authentication", bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+
+		// Act
+		res.createAuthenticationToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusForbidden)
+		mockApp.AssertNotCalled(t, "CreateAuthTokenUseCase", mock.Anything, mock.Anything)
+	})
+}
+
+func TestResource_CreateAntiEnumeration(t *testing.T) {
+	expectedInput := &domain.CreateUserRequest{
+		Name:     "John Doe",
+		Email:    "johndoe@example.com",
+		Password: "password123",
+		Locale:   "en",
+	}
+
+	t.Run("success - new email", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		res.(*handlers).antiEnumeration = true
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewBuffer(createRequestBody()))
+		req.Header.Set("Content-Type", "application/json")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(&domain.User{ID: 1, Name: "John Doe", Email: "johndoe@example.com"}, nil)
+
+		// Act
+		res.createUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		if body := strings.TrimSpace(resRec.Body.String()); strings.Contains(body, `"id"`) {
+			t.Errorf("response must not contain the user ID: %s", body)
+		}
+		mockApp.AssertNotCalled(t, "GetByEmailUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success - email already registered", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		res.(*handlers).antiEnumeration = true
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewBuffer(createRequestBody()))
+		req.Header.Set("Content-Type", "application/json")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(nil, domain.ErrDuplicateEmail)
+		mockApp.On("NotifyRegistrationAttemptUseCase", mock.Anything, "johndoe@example.com").Return(nil)
+
+		// Act
+		res.createUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		var responseBody map[string]map[string]interface{}
+		assertResponseBody(t, resRec, &responseBody)
+		expected := map[string]interface{}{"name": "John Doe", "email": "johndoe@example.com", "activated": false}
+		if !reflect.DeepEqual(responseBody["user"], expected) {
+			t.Errorf("unexpected response body: got %v, want %v", responseBody["user"], expected)
+		}
+		mockApp.AssertCalled(t, "NotifyRegistrationAttemptUseCase", mock.Anything, "johndoe@example.com")
+	})
+
+	t.Run("error - NotifyRegistrationAttemptUseCase return error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		res.(*handlers).antiEnumeration = true
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewBuffer(createRequestBody()))
+		req.Header.Set("Content-Type", "application/json")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreateUseCase", mock.Anything, expectedInput, mock.AnythingOfType("string")).Return(nil, domain.ErrDuplicateEmail)
+		mockApp.On("NotifyRegistrationAttemptUseCase", mock.Anything, "johndoe@example.com").Return(errors.New("error"))
+
+		// Act
+		res.createUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+}
+
+func TestResource_AuthenticationTokenAntiEnumeration(t *testing.T) {
+	hashedPassword, _ := password.Hash("password123")
+
+	newLoginRequest := func(plaintextPassword string) *http.Request {
+		requestBody := []byte(`{"email": "johndoe@example.com", "password": "` + plaintextPassword + `"}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/authentication", bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	tests := []struct {
+		name     string
+		user     *domain.User
+		err      error
+		password string
+	}{
+		{name: "unknown email", err: domain.ErrRecordNotFound, password: "password123"},
+		{name: "wrong password", user: &domain.User{ID: 1, Email: "johndoe@example.com", HashedPassword: hashedPassword}, password: "password124"},
+	}
+
+	var bodies []string
+
+	for _, tc := range tests {
+		t.Run("error - "+tc.name, func(t *testing.T) {
+			// Arrange
+			mockApp, res := setupRouterAndMocks()
+			res.(*handlers).antiEnumeration = true
+			resRec := httptest.NewRecorder()
+
+			mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(tc.user, tc.err)
+
+			// Act
+			start := time.Now()
+			res.createAuthenticationToken(resRec, newLoginRequest(tc.password))
+			elapsed := time.Since(start)
+
+			// Assert
+			assertStatusCode(t, resRec, http.StatusUnauthorized)
+			bodies = append(bodies, resRec.Body.String())
+			if elapsed < 50*time.Millisecond {
+				t.Errorf("expected a bcrypt comparison, the response took only %v", elapsed)
+			}
+			mockApp.AssertNotCalled(t, "CreateAuthTokenUseCase", mock.Anything, mock.Anything)
+		})
+	}
+
+	if len(bodies) == 2 && bodies[0] != bodies[1] {
+		t.Errorf("responses differ: %q and %q", bodies[0], bodies[1])
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		res.(*handlers).antiEnumeration = true
+		resRec := httptest.NewRecorder()
+		user := &domain.User{ID: 1, Email: "johndoe@example.com", HashedPassword: hashedPassword, Activated: true}
+
+		mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(user, nil)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, user.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, user, mock.Anything, mock.Anything).Return(nil)
+
+		// Act
+		res.createAuthenticationToken(resRec, newLoginRequest("password123"))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+	})
+}
+
+func TestResource_HashingSaturated(t *testing.T) {
+	// Arrange
+	mockApp, res := setupRouterAndMocks()
+	executor := password.NewExecutor(1, 0)
+	res.(*handlers).hasher = password.NewHasher(executor, "", nil)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go executor.Do(context.Background(), func() {
+		close(started)
+		<-release
+	})
+	<-started
+	defer close(release)
+
+	hashedPassword, _ := password.Hash("password123")
+	user := &domain.User{ID: 1, Email: "johndoe@example.com", HashedPassword: hashedPassword, Activated: true}
+
+	requestBody := []byte(`{"email": "johndoe@example.com", "password": "password123"}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/tokens/authentication", bytes.NewBuffer(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	resRec := httptest.NewRecorder()
+
+	mockApp.On("GetByEmailUseCase", mock.Anything, user.Email).Return(user, nil)
+
+	// Act
+	res.createAuthenticationToken(resRec, req)
+
+	// Assert
+	assertStatusCode(t, resRec, http.StatusServiceUnavailable)
+	if resRec.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected Retry-After: 1, got %q", resRec.Header().Get("Retry-After"))
+	}
+	mockApp.AssertNotCalled(t, "CreateAuthTokenUseCase", mock.Anything, mock.Anything)
 }
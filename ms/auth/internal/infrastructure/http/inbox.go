@@ -0,0 +1,80 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+)
+
+// @Summary Development inbox
+// @Description Lists the emails captured by the memory mail transport, newest first, so that the tokens they carry can be copied. Only served in development.
+// @Tags Development
+// @Produce html
+// @Success 200
+// @Router /dev/inbox [get]
+func (h *handlers) showInbox(res http.ResponseWriter, req *http.Request) {
+	h.renderPage(res, req, http.StatusOK, "inbox.gohtml", map[string]interface{}{
+		"messages": h.mailer.Inbox().Messages(),
+	})
+}
+
+// @Summary List email templates
+// @Description Lists the email templates and the locales they have been translated to, for previewing. Only served in development.
+// @Tags Development
+// @Produce json
+// @Success 200
+// @Router /dev/emails [get]
+func (h *handlers) listEmailTemplates(res http.ResponseWriter, req *http.Request) {
+	err := response.JSON(res, http.StatusOK, envelope{"templates": h.mailer.Templates(), "locales": h.mailer.Locales()})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Preview email
+// @Description Renders an email template with sample data, as it would be sent. Only served in development.
+// @Tags Development
+// @Produce html
+// @Produce plain
+// @Param template path string true "Template file name"
+// @Param locale query string false "Locale to render the email in"
+// @Param format query string false "Part to render (html or text)"
+// @Success 200
+// @Router /dev/emails/{template} [get]
+func (h *handlers) previewEmail(res http.ResponseWriter, req *http.Request) {
+	qs := req.URL.Query()
+
+	format := h.helpers.ReadString(qs, "format", "html")
+	if format != "html" && format != "text" {
+		_errors.BadRequest(res, req, fmt.Errorf("format must be html or text"))
+		return
+	}
+
+	template := httprouter.ParamsFromContext(req.Context()).ByName("template")
+
+	msg, err := h.mailer.Preview(template, mailer.NormalizeLocale(h.helpers.ReadString(qs, "locale", mailer.DefaultLocale)))
+	if err != nil {
+		switch {
+		case errors.Is(err, mailer.ErrTemplateNotFound):
+			_errors.NotFound(res, req)
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	if format == "text" {
+		res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		res.WriteHeader(http.StatusOK)
+		fmt.Fprintf(res, "Subject: %s\n\n%s", msg.Subject, msg.PlainBody)
+		return
+	}
+
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	res.WriteHeader(http.StatusOK)
+	fmt.Fprint(res, msg.HTMLBody)
+}
@@ -0,0 +1,123 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"github.com/jessicatarra/greenlight/internal/config"
+	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/internal/password"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResource_ShowInbox(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		inbox := mailer.NewMemoryTransport(10)
+		inbox.Send(mailer.Message{To: "john@example.com", Subject: "Welcome to Greenlight!", PlainBody: "token: " + activationToken})
+		mail, err := mailer.New(inbox, "no-reply@example.org", "")
+		assert.NoError(t, err)
+		res := registerHandlers(handlerDeps{
+			appl:   &mocks.Appl{},
+			policy: password.NewStandardPolicy(8, 72, 0),
+			hasher: password.NewHasher(nil, "", nil),
+			mailer: mail,
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/dev/inbox", nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.showInbox(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assertHTMLContains(t, resRec, activationToken)
+	})
+
+	t.Run("not served outside development", func(t *testing.T) {
+		// Arrange
+		var cfg config.Config
+		cfg.Env = "production"
+		mail, err := mailer.New(mailer.NewMemoryTransport(10), "no-reply@example.org", "")
+		assert.NoError(t, err)
+		api := NewService(&mocks.Appl{}, cfg, password.NewHasher(nil, "", nil), mail, slog.Default())
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/dev/inbox", nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		api.Routes().ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusNotFound)
+	})
+}
+
+func TestResource_PreviewEmail(t *testing.T) {
+	var cfg config.Config
+	cfg.Env = "development"
+	mail, err := mailer.New(mailer.NewMemoryTransport(10), "no-reply@example.org", "http://localhost:8082")
+	assert.NoError(t, err)
+	api := NewService(&mocks.Appl{}, cfg, password.NewHasher(nil, "", nil), mail, slog.Default())
+
+	t.Run("html", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest(http.MethodGet, "/v1/dev/emails/user_welcome.gohtml?locale=es", nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		api.Routes().ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assertHTMLContains(t, resRec, "Activar tu cuenta")
+		assertHTMLContains(t, resRec, `<p style="`)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest(http.MethodGet, "/v1/dev/emails/user_magic_link.gohtml?format=text", nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		api.Routes().ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assert.Equal(t, "text/plain; charset=utf-8", resRec.Header().Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(resRec.Body.String(), "Subject: Your Greenlight login link\n\n"))
+	})
+
+	t.Run("unknown template", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest(http.MethodGet, "/v1/dev/emails/missing.gohtml", nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		api.Routes().ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusNotFound)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		// Arrange
+		req := httptest.NewRequest(http.MethodGet, "/v1/dev/emails", nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		api.Routes().ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assert.Contains(t, resRec.Body.String(), `"user_welcome.gohtml"`)
+		assert.Contains(t, resRec.Body.String(), `"es"`)
+	})
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+)
+
+// @Summary Introspect token
+// @Description Reports whether a token issued by the auth module is active, following RFC 7662. Callers authenticate with client credentials (HTTP Basic) or an API key (X-Api-Key header).
+// @Tags Authentication
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "Hint about the type of the token"
+// @Success 200 {object} domain.Introspection
+// @Router /tokens/introspect [post]
+func (h *handlers) introspectToken(res http.ResponseWriter, req *http.Request) {
+	var input domain.IntrospectTokenRequest
+
+	err := req.ParseForm()
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	input.TokenPlaintext = req.PostForm.Get("token")
+	input.TokenTypeHint = req.PostForm.Get("token_type_hint")
+
+	ValidateIntrospection(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	introspection, err := h.appl.IntrospectTokenUseCase(req.Context(), input.TokenPlaintext)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "no-store")
+
+	err = response.JSONWithHeaders(res, http.StatusOK, introspection, headers)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
@@ -0,0 +1,176 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"errors"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newIntrospectionRequest(token string) *http.Request {
+	form := url.Values{}
+	if token != "" {
+		form.Set("token", token)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/tokens/introspect", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestResource_IntrospectToken(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		expected := &domain.Introspection{Active: true, Sub: "1", Username: "john@example.com", Scope: "movies:read"}
+
+		req := newIntrospectionRequest("some.jwt.token")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("IntrospectTokenUseCase", mock.Anything, "some.jwt.token").Return(expected, nil)
+
+		// Act
+		res.introspectToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var responseBody domain.Introspection
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody != *expected {
+			t.Errorf("unexpected introspection response: got %v, want %v", responseBody, *expected)
+		}
+		if resRec.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("expected Cache-Control: no-store, got %q", resRec.Header().Get("Cache-Control"))
+		}
+	})
+
+	t.Run("success - inactive token", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := newIntrospectionRequest("GQRPVONORIEUPDJ6V4RTDIVSTQ")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("IntrospectTokenUseCase", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(&domain.Introspection{Active: false}, nil)
+
+		// Act
+		res.introspectToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		if strings.TrimSpace(resRec.Body.String()) != "{\n\t\"active\": false\n}" {
+			t.Errorf("unexpected response body: %s", resRec.Body.String())
+		}
+	})
+
+	t.Run("error - missing token", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := newIntrospectionRequest("")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.introspectToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "IntrospectTokenUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - IntrospectTokenUseCase return error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := newIntrospectionRequest("GQRPVONORIEUPDJ6V4RTDIVSTQ")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("IntrospectTokenUseCase", mock.Anything, "GQRPVONORIEUPDJ6V4RTDIVSTQ").Return(nil, errors.New("error"))
+
+		// Act
+		res.introspectToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+}
+
+func TestService_RequireIntrospectionClient(t *testing.T) {
+	_, s := setupServiceAndMocks()
+	s.cfg.Introspection.Clients = map[string]string{"catalog": "s3cret"}
+	s.cfg.Introspection.APIKeys = []string{"key-123"}
+
+	t.Run("success - client credentials", func(t *testing.T) {
+		// Arrange
+		req := newIntrospectionRequest("token")
+		req.SetBasicAuth("catalog", "s3cret")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.requireIntrospectionClient(okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+	})
+
+	t.Run("success - api key", func(t *testing.T) {
+		// Arrange
+		req := newIntrospectionRequest("token")
+		req.Header.Set("X-Api-Key", "key-123")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.requireIntrospectionClient(okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+	})
+
+	t.Run("error - wrong secret", func(t *testing.T) {
+		// Arrange
+		req := newIntrospectionRequest("token")
+		req.SetBasicAuth("catalog", "wrong")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.requireIntrospectionClient(okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnauthorized)
+		if resRec.Header().Get("WWW-Authenticate") == "" {
+			t.Error("expected WWW-Authenticate header")
+		}
+	})
+
+	t.Run("error - unknown api key", func(t *testing.T) {
+		// Arrange
+		req := newIntrospectionRequest("token")
+		req.Header.Set("X-Api-Key", "nope")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.requireIntrospectionClient(okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnauthorized)
+	})
+
+	t.Run("error - no credentials", func(t *testing.T) {
+		// Arrange
+		req := newIntrospectionRequest("token")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.requireIntrospectionClient(okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnauthorized)
+	})
+}
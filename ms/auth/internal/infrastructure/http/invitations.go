@@ -0,0 +1,53 @@
+package http
+
+import (
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/internal/request"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+)
+
+// @Summary Create invitation
+// @Description Invites a new user by email. The invitation token is mailed to the recipient and can be redeemed once on registration.
+// @Tags Users
+// @Accept json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param request body domain.CreateInvitationRequest true "Invitation data"
+// @Success 201 {object} domain.Invitation
+// @Router /invitations [post]
+func (h *handlers) createInvitation(res http.ResponseWriter, req *http.Request) {
+	var input domain.CreateInvitationRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	if input.Locale == "" {
+		input.Locale = mailer.MatchLocale(req.Header.Get("Accept-Language"))
+	} else {
+		input.Locale = mailer.NormalizeLocale(input.Locale)
+	}
+
+	ValidateInvitation(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	invitation, err := h.appl.CreateInvitationUseCase(req.Context(), &input, contextGetUser(req).ID)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	err = response.JSON(res, http.StatusCreated, envelope{"invitation": invitation})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
@@ -0,0 +1,96 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"bytes"
+	"errors"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResource_CreateInvitation(t *testing.T) {
+	admin := &domain.User{ID: 1, Name: "Admin", Email: "admin@example.com", Activated: true}
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		expectedInvitation := &domain.Invitation{
+			Email:       "sarah@example.com",
+			Permissions: domain.Permissions{"movies:write"},
+			InvitedBy:   admin.ID,
+		}
+
+		requestBody := []byte(`{"email": "sarah@example.com", "permissions": ["movies:write"]}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/invitations", bytes.NewBuffer(requestBody))
+		req = contextSetUser(req, admin)
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreateInvitationUseCase", mock.Anything, mock.AnythingOfType("*domain.CreateInvitationRequest"), admin.ID).Return(expectedInvitation, nil)
+
+		// Act
+		res.createInvitation(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		var responseBody map[string]*domain.Invitation
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody["invitation"] == nil || responseBody["invitation"].Email != expectedInvitation.Email {
+			t.Errorf("unexpected invitation in response body: %v", responseBody["invitation"])
+		}
+	})
+
+	t.Run("error - bad request", func(t *testing.T) {
+		// Arrange
+		_, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/invitations", bytes.NewBuffer([]byte(`{"email": }`)))
+		req = contextSetUser(req, admin)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.createInvitation(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+	})
+
+	t.Run("error - failed validation", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		requestBody := []byte(`{"email": "not-an-email", "permissions": ["movies:write", "movies:write"]}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/invitations", bytes.NewBuffer(requestBody))
+		req = contextSetUser(req, admin)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.createInvitation(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "CreateInvitationUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - CreateInvitationUseCase return error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		requestBody := []byte(`{"email": "sarah@example.com"}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/invitations", bytes.NewBuffer(requestBody))
+		req = contextSetUser(req, admin)
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreateInvitationUseCase", mock.Anything, mock.AnythingOfType("*domain.CreateInvitationRequest"), admin.ID).Return(nil, errors.New("error"))
+
+		// Act
+		res.createInvitation(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+}
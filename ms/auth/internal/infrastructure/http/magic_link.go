@@ -0,0 +1,92 @@
+package http
+
+import (
+	"errors"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/request"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+)
+
+// @Summary Request magic link
+// @Description Emails a short-lived, single-use login token to the user. The response is the same whether or not the email address is registered.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.CreateMagicLinkRequest true "Request body"
+// @Success 202 {object} map[string]string
+// @Router /tokens/magic-link [post]
+func (h *handlers) createMagicLink(res http.ResponseWriter, req *http.Request) {
+	var input domain.CreateMagicLinkRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	ValidateMagicLinkEmail(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	err = h.appl.CreateMagicLinkUseCase(req.Context(), input.Email)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	env := envelope{"message": "if the email address is registered, a login link will be sent to it shortly"}
+
+	err = response.JSON(res, http.StatusAccepted, env)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Exchange magic link
+// @Description Exchanges a magic link token for an authentication token, activating the account if needed
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.ExchangeMagicLinkRequest true "Request body"
+// @Success 201 {object} map[string]string "Authentication token"
+// @Router /tokens/magic-link/exchange [post]
+func (h *handlers) exchangeMagicLink(res http.ResponseWriter, req *http.Request) {
+	var input domain.ExchangeMagicLinkRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	ValidateMagicLinkToken(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	jwtBytes, err := h.appl.ExchangeMagicLinkUseCase(req.Context(), input.TokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			input.Validator.AddFieldError("Token", "Invalid or expired magic link token")
+			_errors.FailedValidation(res, req, input.Validator)
+		case errors.Is(err, domain.ErrAccountSuspended):
+			_errors.AccountSuspended(res, req)
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	err = response.JSON(res, http.StatusCreated, envelope{"authentication_token": string(jwtBytes)})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
@@ -0,0 +1,113 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"bytes"
+	"errors"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResource_CreateMagicLink(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/magic-link", bytes.NewBuffer([]byte(`{"email": "johndoe@example.com"}`)))
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreateMagicLinkUseCase", mock.Anything, "johndoe@example.com").Return(nil)
+
+		// Act
+		res.createMagicLink(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusAccepted)
+	})
+
+	t.Run("error - failed validation", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/magic-link", bytes.NewBuffer([]byte(`{"email": "johndoe"}`)))
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.createMagicLink(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "CreateMagicLinkUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - CreateMagicLinkUseCase return error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/magic-link", bytes.NewBuffer([]byte(`{"email": "johndoe@example.com"}`)))
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreateMagicLinkUseCase", mock.Anything, "johndoe@example.com").Return(errors.New("error"))
+
+		// Act
+		res.createMagicLink(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+}
+
+func TestResource_ExchangeMagicLink(t *testing.T) {
+	token := "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/magic-link/exchange", bytes.NewBuffer([]byte(`{"token": "`+token+`"}`)))
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ExchangeMagicLinkUseCase", mock.Anything, token).Return([]byte("thisisasecreT"), nil)
+
+		// Act
+		res.exchangeMagicLink(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		var responseBody map[string]string
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody["authentication_token"] != "thisisasecreT" {
+			t.Errorf("unexpected authentication token: got %s", responseBody["authentication_token"])
+		}
+	})
+
+	t.Run("error - token already used or expired", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/magic-link/exchange", bytes.NewBuffer([]byte(`{"token": "`+token+`"}`)))
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ExchangeMagicLinkUseCase", mock.Anything, token).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.exchangeMagicLink(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("error - invalid token length", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/magic-link/exchange", bytes.NewBuffer([]byte(`{"token": "short"}`)))
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.exchangeMagicLink(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "ExchangeMagicLinkUseCase", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,207 @@
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/middleware"
+	"github.com/jessicatarra/greenlight/internal/scim"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+	"strings"
+)
+
+func (s service) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+
+		authorizationHeader := r.Header.Get("Authorization")
+
+		if authorizationHeader == "" {
+			if s.cfg.Sessions.Enabled {
+				w.Header().Add("Vary", "Cookie")
+
+				if cookie, err := r.Cookie(middleware.SessionCookieName); err == nil {
+					s.authenticateSession(w, r, cookie.Value, next)
+					return
+				}
+			}
+
+			r = contextSetUser(r, domain.AnonymousUser)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// SCIM provisioning clients present their own bearer tokens, which are
+		// checked by requireSCIMClient.
+		if strings.HasPrefix(r.URL.Path, "/scim/") {
+			r = contextSetUser(r, domain.AnonymousUser)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		headerParts := strings.Split(authorizationHeader, " ")
+
+		// Anything but a bearer token leaves the request anonymous, so that
+		// a bad header does not fail public routes. Basic credentials identify
+		// API clients and are checked by requireIntrospectionClient, and other
+		// headers are rejected by requireAuthenticatedUser.
+		if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+			r = contextSetUser(r, domain.AnonymousUser)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := s.appl.ValidateAuthTokenUseCase(r.Context(), headerParts[1])
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrAccountSuspended):
+				_errors.AccountSuspended(w, r)
+			case domain.IsInvalidToken(err):
+				_errors.InvalidAuthenticationToken(w, r)
+			default:
+				_errors.ServerError(w, r, err)
+			}
+			return
+		}
+
+		r = contextSetUser(r, user)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticateSession identifies the user by their session cookie. A cookie
+// for a session that has expired or been signed out leaves the request
+// anonymous, so that the browser can still reach public pages and sign in
+// again.
+func (s service) authenticateSession(w http.ResponseWriter, r *http.Request, session string, next http.Handler) {
+	user, err := s.appl.ValidateSessionUseCase(r.Context(), session)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			user = domain.AnonymousUser
+		case errors.Is(err, domain.ErrAccountSuspended):
+			_errors.AccountSuspended(w, r)
+			return
+		default:
+			_errors.ServerError(w, r, err)
+			return
+		}
+	}
+
+	r = contextSetUser(r, user)
+
+	next.ServeHTTP(w, r)
+}
+
+// requireAuthenticatedUser rejects anonymous requests, telling those that
+// sent an Authorization header authenticate could not use that it is invalid.
+func (s service) requireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := contextGetUser(r)
+
+		if user.IsAnonymous() {
+			if r.Header.Get("Authorization") != "" {
+				_errors.InvalidAuthenticationToken(w, r)
+				return
+			}
+
+			_errors.AuthenticationRequired(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+func (s service) requireActivatedUser(next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		user := contextGetUser(r)
+
+		if !user.Activated {
+			_errors.InactiveAccount(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return s.requireAuthenticatedUser(fn)
+}
+
+// requirePermission checks the permission codes embedded in the user's token
+// when it carries them, and asks the application otherwise.
+func (s service) requirePermission(code string, next http.HandlerFunc) http.HandlerFunc {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		user := contextGetUser(r)
+
+		if user.Permissions != nil {
+			if !user.Permissions.Include(code) {
+				_errors.NotPermitted(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		err := s.appl.UserPermissionUseCase(r.Context(), code, user.ID)
+		if err != nil {
+			switch {
+			case errors.Is(err, domain.ErrPermissionNotIncluded):
+				_errors.NotPermitted(w, r)
+			default:
+				_errors.ServerError(w, r, err)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+
+	return s.requireActivatedUser(fn)
+}
+
+// requireIntrospectionClient admits callers presenting configured client
+// credentials via HTTP Basic authentication, or a configured API key in the
+// X-Api-Key header.
+func (s service) requireIntrospectionClient(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if clientID, clientSecret, ok := r.BasicAuth(); ok {
+			expected, found := s.cfg.Introspection.Clients[clientID]
+			if found && subtle.ConstantTimeCompare([]byte(clientSecret), []byte(expected)) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+		} else if apiKey := r.Header.Get("X-Api-Key"); apiKey != "" {
+			for _, expected := range s.cfg.Introspection.APIKeys {
+				if subtle.ConstantTimeCompare([]byte(apiKey), []byte(expected)) == 1 {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+
+		_errors.InvalidClientCredentials(w, r)
+	}
+}
+
+// requireSCIMClient admits callers presenting one of the configured SCIM
+// bearer tokens. Failures are reported as SCIM errors, which is what
+// provisioning clients expect.
+func (s service) requireSCIMClient(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+			for _, expected := range s.cfg.SCIM.Tokens {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		scim.WriteError(w, scim.NewError(http.StatusUnauthorized, "", "A valid bearer token is required"))
+	}
+}
@@ -0,0 +1,371 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/middleware"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setupServiceAndMocks() (*mocks.Appl, service) {
+	mockApp := &mocks.Appl{}
+
+	return mockApp, service{appl: mockApp}
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestService_Authenticate(t *testing.T) {
+	t.Run("anonymous user", func(t *testing.T) {
+		// Arrange
+		_, s := setupServiceAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		resRec := httptest.NewRecorder()
+
+		var user *domain.User
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = contextGetUser(r)
+		})
+
+		// Act
+		s.authenticate(next).ServeHTTP(resRec, req)
+
+		// Assert
+		if user == nil || !user.IsAnonymous() {
+			t.Errorf("expected anonymous user in request context, got %v", user)
+		}
+	})
+
+	t.Run("authenticated user", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		expectedUser := &domain.User{ID: 1, Activated: true}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ValidateAuthTokenUseCase", mock.Anything, "token").Return(expectedUser, nil)
+
+		var user *domain.User
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = contextGetUser(r)
+		})
+
+		// Act
+		s.authenticate(next).ServeHTTP(resRec, req)
+
+		// Assert
+		if user != expectedUser {
+			t.Errorf("unexpected user in request context: got %v, want %v", user, expectedUser)
+		}
+	})
+
+	t.Run("error - invalid token", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ValidateAuthTokenUseCase", mock.Anything, "token").Return(nil, domain.ErrInvalidClaims)
+
+		// Act
+		s.authenticate(http.HandlerFunc(okHandler)).ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnauthorized)
+	})
+
+	t.Run("error - database", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ValidateAuthTokenUseCase", mock.Anything, "token").Return(nil, errors.New("connection refused"))
+
+		// Act
+		s.authenticate(http.HandlerFunc(okHandler)).ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+
+	t.Run("basic credentials are left to the route", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.SetBasicAuth("catalog", "s3cret")
+		resRec := httptest.NewRecorder()
+
+		var user *domain.User
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = contextGetUser(r)
+		})
+
+		// Act
+		s.authenticate(next).ServeHTTP(resRec, req)
+
+		// Assert
+		if user == nil || !user.IsAnonymous() {
+			t.Errorf("expected anonymous user in request context, got %v", user)
+		}
+		mockApp.AssertNotCalled(t, "ValidateAuthTokenUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("malformed header on a public route", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Token abc")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.authenticate(http.HandlerFunc(okHandler)).ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		mockApp.AssertNotCalled(t, "ValidateAuthTokenUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - malformed header on a protected route", func(t *testing.T) {
+		// Arrange
+		_, s := setupServiceAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Token abc")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.authenticate(s.requireAuthenticatedUser(okHandler)).ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnauthorized)
+		if resRec.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("unexpected WWW-Authenticate header: %q", resRec.Header().Get("WWW-Authenticate"))
+		}
+	})
+
+	t.Run("scim bearer tokens are left to the route", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+		req.Header.Set("Authorization", "Bearer scim-token")
+		resRec := httptest.NewRecorder()
+
+		var user *domain.User
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = contextGetUser(r)
+		})
+
+		// Act
+		s.authenticate(next).ServeHTTP(resRec, req)
+
+		// Assert
+		if user == nil || !user.IsAnonymous() {
+			t.Errorf("expected anonymous user in request context, got %v", user)
+		}
+		mockApp.AssertNotCalled(t, "ValidateAuthTokenUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("session cookie", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		s.cfg.Sessions.Enabled = true
+		expectedUser := &domain.User{ID: 1, Activated: true}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: "session"})
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ValidateSessionUseCase", mock.Anything, "session").Return(expectedUser, nil)
+
+		var user *domain.User
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = contextGetUser(r)
+		})
+
+		// Act
+		s.authenticate(next).ServeHTTP(resRec, req)
+
+		// Assert
+		if user != expectedUser {
+			t.Errorf("expected user %v in request context, got %v", expectedUser, user)
+		}
+	})
+
+	t.Run("ended session cookie", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		s.cfg.Sessions.Enabled = true
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: "session"})
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ValidateSessionUseCase", mock.Anything, "session").Return(nil, domain.ErrRecordNotFound)
+
+		var user *domain.User
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = contextGetUser(r)
+		})
+
+		// Act
+		s.authenticate(next).ServeHTTP(resRec, req)
+
+		// Assert
+		if user == nil || !user.IsAnonymous() {
+			t.Errorf("expected anonymous user in request context, got %v", user)
+		}
+	})
+
+	t.Run("session cookie with sessions disabled", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: "session"})
+		resRec := httptest.NewRecorder()
+
+		var user *domain.User
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = contextGetUser(r)
+		})
+
+		// Act
+		s.authenticate(next).ServeHTTP(resRec, req)
+
+		// Assert
+		if user == nil || !user.IsAnonymous() {
+			t.Errorf("expected anonymous user in request context, got %v", user)
+		}
+		mockApp.AssertNotCalled(t, "ValidateSessionUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - session of a suspended account", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		s.cfg.Sessions.Enabled = true
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: "session"})
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ValidateSessionUseCase", mock.Anything, "session").Return(nil, domain.ErrAccountSuspended)
+
+		// Act
+		s.authenticate(http.HandlerFunc(okHandler)).ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusForbidden)
+	})
+
+	t.Run("error - suspended account", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ValidateAuthTokenUseCase", mock.Anything, "token").Return(nil, domain.ErrAccountSuspended)
+
+		// Act
+		s.authenticate(http.HandlerFunc(okHandler)).ServeHTTP(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusForbidden)
+	})
+}
+
+func TestService_RequirePermission(t *testing.T) {
+	t.Run("success - embedded permissions", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		user := &domain.User{ID: 1, Activated: true, Permissions: domain.Permissions{"users:admin"}}
+		req := contextSetUser(httptest.NewRequest(http.MethodGet, "/", nil), user)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.requirePermission("users:admin", okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		mockApp.AssertNotCalled(t, "UserPermissionUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - permission not embedded", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		user := &domain.User{ID: 1, Activated: true, Permissions: domain.Permissions{"movies:read"}}
+		req := contextSetUser(httptest.NewRequest(http.MethodGet, "/", nil), user)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.requirePermission("users:admin", okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusForbidden)
+		mockApp.AssertNotCalled(t, "UserPermissionUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		req := contextSetUser(httptest.NewRequest(http.MethodGet, "/", nil), &domain.User{ID: 1, Activated: true})
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("UserPermissionUseCase", mock.Anything, "users:admin", int64(1)).Return(nil)
+
+		// Act
+		s.requirePermission("users:admin", okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+	})
+
+	t.Run("error - anonymous user", func(t *testing.T) {
+		// Arrange
+		_, s := setupServiceAndMocks()
+		req := contextSetUser(httptest.NewRequest(http.MethodGet, "/", nil), domain.AnonymousUser)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.requirePermission("users:admin", okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnauthorized)
+	})
+
+	t.Run("error - inactive account", func(t *testing.T) {
+		// Arrange
+		_, s := setupServiceAndMocks()
+		req := contextSetUser(httptest.NewRequest(http.MethodGet, "/", nil), &domain.User{ID: 1})
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.requirePermission("users:admin", okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusForbidden)
+	})
+
+	t.Run("error - permission not included", func(t *testing.T) {
+		// Arrange
+		mockApp, s := setupServiceAndMocks()
+		req := contextSetUser(httptest.NewRequest(http.MethodGet, "/", nil), &domain.User{ID: 1, Activated: true})
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("UserPermissionUseCase", mock.Anything, "users:admin", int64(1)).Return(domain.ErrPermissionNotIncluded)
+
+		// Act
+		s.requirePermission("users:admin", okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusForbidden)
+	})
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+)
+
+// @Summary List outbound emails
+// @Description Pages through the email outbox, optionally filtered by delivery status. The template data, which may hold tokens, is never shown.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Delivery status (pending, sent or dead)"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Number of emails per page"
+// @Param sort query string false "Sort order"
+// @Success 200 {object} []domain.OutboxEmail "Email list"
+// @Router /admin/outbox [get]
+func (h *handlers) listOutboxEmails(res http.ResponseWriter, req *http.Request) {
+	var input domain.ListOutboxEmailsRequest
+
+	qs := req.URL.Query()
+
+	input.Status = h.helpers.ReadString(qs, "status", "")
+
+	input.Filters.Page = h.helpers.ReadInt(qs, "page", 1, &input.Validator)
+	input.Filters.PageSize = h.helpers.ReadInt(qs, "page_size", 20, &input.Validator)
+	input.Filters.Sort = h.helpers.ReadString(qs, "sort", "-id")
+	input.Filters.SortSafelist = []string{"id", "created_at", "next_attempt_at", "-id", "-created_at", "-next_attempt_at"}
+
+	ValidateListOutboxEmails(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	emails, metadata, err := h.appl.ListOutboxEmailsUseCase(req.Context(), input.Status, input.Filters)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"emails": emails, "metadata": metadata})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Replay outbound email
+// @Description Queues a dead email for delivery again with a fresh set of attempts. Dead emails that held tokens are redacted and cannot be replayed.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Email ID"
+// @Success 200 {object} domain.OutboxEmail
+// @Router /admin/outbox/{id}/replay [post]
+func (h *handlers) replayOutboxEmail(res http.ResponseWriter, req *http.Request) {
+	id, err := h.helpers.ReadIDParam(req)
+	if err != nil {
+		_errors.NotFound(res, req)
+		return
+	}
+
+	email, err := h.appl.ReplayOutboxEmailUseCase(req.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			_errors.NotFound(res, req)
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"email": email})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
@@ -0,0 +1,113 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResource_ListOutboxEmails(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		expectedEmails := []*domain.OutboxEmail{{
+			ID:        9,
+			Recipient: "john@example.com",
+			Template:  "user_welcome.gohtml",
+			Data:      map[string]interface{}{"activationToken": "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU"},
+			Status:    domain.OutboxDead,
+			Attempts:  8,
+			LastError: "connection refused",
+		}}
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/outbox?status=dead", nil)
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ListOutboxEmailsUseCase", mock.Anything, domain.OutboxDead, mock.MatchedBy(func(f domain.Filters) bool {
+			return f.Page == 1 && f.PageSize == 20 && f.Sort == "-id"
+		})).Return(expectedEmails, domain.CalculateMetadata(1, 1, 20), nil)
+
+		// Act
+		res.listOutboxEmails(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var responseBody struct {
+			Emails []struct {
+				ID        int64  `json:"id"`
+				Status    string `json:"status"`
+				LastError string `json:"last_error"`
+			} `json:"emails"`
+		}
+		assertResponseBody(t, resRec, &responseBody)
+		if len(responseBody.Emails) != 1 || responseBody.Emails[0].LastError != "connection refused" {
+			t.Errorf("unexpected emails in response body: %v", responseBody.Emails)
+		}
+		if strings.Contains(resRec.Body.String(), "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU") {
+			t.Errorf("response body leaks template data: %s", resRec.Body.String())
+		}
+	})
+
+	t.Run("error - failed validation", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/outbox?status=lost&sort=recipient", nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.listOutboxEmails(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "ListOutboxEmailsUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestResource_ReplayOutboxEmail(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		expectedEmail := &domain.OutboxEmail{ID: 9, Recipient: "john@example.com", Template: "user_welcome.gohtml", Status: domain.OutboxPending}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/outbox/9/replay", nil)
+		req = withIDParam(req, "9")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ReplayOutboxEmailUseCase", mock.Anything, int64(9)).Return(expectedEmail, nil)
+
+		// Act
+		res.replayOutboxEmail(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var responseBody map[string]*domain.OutboxEmail
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody["email"] == nil || responseBody["email"].Status != domain.OutboxPending {
+			t.Errorf("unexpected email in response body: %v", responseBody["email"])
+		}
+	})
+
+	t.Run("error - not dead", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/outbox/9/replay", nil)
+		req = withIDParam(req, "9")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ReplayOutboxEmailUseCase", mock.Anything, int64(9)).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.replayOutboxEmail(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusNotFound)
+	})
+}
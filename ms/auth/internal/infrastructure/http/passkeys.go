@@ -0,0 +1,186 @@
+package http
+
+import (
+	"errors"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/request"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/internal/webauthn"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+)
+
+// @Summary Begin passkey registration
+// @Description Returns the options to pass to navigator.credentials.create for registering a passkey on the signed-in account
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} webauthn.CredentialCreationOptions
+// @Router /users/me/passkeys/challenge [post]
+func (h *handlers) beginPasskeyRegistration(res http.ResponseWriter, req *http.Request) {
+	user := contextGetUser(req)
+
+	// Support staff acting as the user must not be able to add a way in.
+	if user.ActorID != 0 {
+		_errors.NotPermitted(res, req)
+		return
+	}
+
+	options, err := h.appl.BeginPasskeyRegistrationUseCase(req.Context(), user)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"publicKey": options})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Finish passkey registration
+// @Description Verifies the credential created by the authenticator and registers it as a passkey of the signed-in account
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.FinishPasskeyRegistrationRequest true "Request body"
+// @Success 201 {object} domain.Passkey
+// @Router /users/me/passkeys [post]
+func (h *handlers) finishPasskeyRegistration(res http.ResponseWriter, req *http.Request) {
+	user := contextGetUser(req)
+
+	if user.ActorID != 0 {
+		_errors.NotPermitted(res, req)
+		return
+	}
+
+	var input domain.FinishPasskeyRegistrationRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	ValidatePasskeyRegistration(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	passkey, err := h.appl.FinishPasskeyRegistrationUseCase(req.Context(), user, input.Name, &input.Credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			input.Validator.AddFieldError("Credential", "Passkey registration has expired, please start again")
+			_errors.FailedValidation(res, req, input.Validator)
+		case errors.Is(err, webauthn.ErrInvalidResponse):
+			input.Validator.AddFieldError("Credential", "Credential could not be verified")
+			_errors.FailedValidation(res, req, input.Validator)
+		case errors.Is(err, domain.ErrDuplicatePasskey):
+			input.Validator.AddFieldError("Credential", "Passkey is already registered")
+			_errors.FailedValidation(res, req, input.Validator)
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	err = response.JSON(res, http.StatusCreated, envelope{"passkey": passkey})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Begin passkey sign-in
+// @Description Returns the options to pass to navigator.credentials.get for signing in with one of the account's passkeys. The same options are returned whether or not the account exists or has passkeys, and signing in with them fails when it does not.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.BeginPasskeyLoginRequest true "Request body"
+// @Success 200 {object} webauthn.CredentialRequestOptions
+// @Router /tokens/passkey/challenge [post]
+func (h *handlers) beginPasskeyLogin(res http.ResponseWriter, req *http.Request) {
+	var input domain.BeginPasskeyLoginRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	ValidatePasskeyLoginEmail(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	options, err := h.appl.BeginPasskeyLoginUseCase(req.Context(), input.Email)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"publicKey": options})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Finish passkey sign-in
+// @Description Verifies the assertion made by the authenticator and exchanges it for an authentication token
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.FinishPasskeyLoginRequest true "Request body"
+// @Success 201 {object} map[string]string "Authentication token"
+// @Router /tokens/passkey [post]
+func (h *handlers) finishPasskeyLogin(res http.ResponseWriter, req *http.Request) {
+	var input domain.FinishPasskeyLoginRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	ValidatePasskeyLogin(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	user, err := h.appl.FinishPasskeyLoginUseCase(req.Context(), &input.Credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound),
+			errors.Is(err, domain.ErrEditConflict),
+			errors.Is(err, webauthn.ErrInvalidResponse):
+			_errors.InvalidCredentials(res, req)
+		case errors.Is(err, domain.ErrAccountSuspended):
+			_errors.AccountSuspended(res, req)
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	jwtBytes, err := h.appl.CreateAuthTokenUseCase(req.Context(), user.ID)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	// Failing to record the device must not stop the user from signing in.
+	err = h.appl.RecordSignInUseCase(req.Context(), user, clientIP(req, h.clientIPHeader), req.UserAgent())
+	if err != nil {
+		_errors.ReportServerError(req, err)
+	}
+
+	err = response.JSON(res, http.StatusCreated, envelope{"authentication_token": string(jwtBytes)})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
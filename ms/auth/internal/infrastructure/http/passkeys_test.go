@@ -0,0 +1,281 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/webauthn"
+	"github.com/jessicatarra/greenlight/internal/webauthn/webauthntest"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const passkeyOrigin = "https://auth.example.com"
+
+var passkeyChallenge = []byte("GQRPVONORIEUPDJ6V4RTDIVSTQ")
+
+func newPasskeyUser() *domain.User {
+	return &domain.User{ID: 1, Name: "John Doe", Email: "johndoe@example.com", Activated: true}
+}
+
+func newRelyingParty() *webauthn.RelyingParty {
+	return webauthn.New("auth.example.com", "Greenlight", []string{passkeyOrigin}, webauthn.UserVerificationPreferred, time.Minute)
+}
+
+func newPasskeyRequest(t *testing.T, path string, body interface{}) *http.Request {
+	t.Helper()
+
+	js, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(js))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// createAttestation runs the browser side of a registration ceremony against
+// options, as they would arrive over the wire.
+func createAttestation(t *testing.T, options *webauthn.CredentialCreationOptions) *webauthn.AttestationResponse {
+	t.Helper()
+
+	js, err := json.Marshal(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded webauthn.CredentialCreationOptions
+	err = json.Unmarshal(js, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := webauthntest.New(passkeyOrigin).Create(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return response
+}
+
+func TestResource_BeginPasskeyRegistration(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		user := newPasskeyUser()
+		req := contextSetUser(httptest.NewRequest(http.MethodPost, "/v1/users/me/passkeys/challenge", nil), user)
+		resRec := httptest.NewRecorder()
+
+		options := newRelyingParty().CreationOptions(passkeyChallenge, webauthn.User{ID: domain.UserHandle(user.ID), Name: user.Email}, nil)
+		mockApp.On("BeginPasskeyRegistrationUseCase", mock.Anything, user).Return(options, nil)
+
+		// Act
+		res.beginPasskeyRegistration(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var responseBody struct {
+			PublicKey webauthn.CredentialCreationOptions `json:"publicKey"`
+		}
+		assertResponseBody(t, resRec, &responseBody)
+		if !bytes.Equal(responseBody.PublicKey.Challenge, passkeyChallenge) {
+			t.Errorf("unexpected challenge %q", responseBody.PublicKey.Challenge)
+		}
+	})
+
+	t.Run("error - impersonated user", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		user := newPasskeyUser()
+		user.ActorID = 2
+		req := contextSetUser(httptest.NewRequest(http.MethodPost, "/v1/users/me/passkeys/challenge", nil), user)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.beginPasskeyRegistration(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusForbidden)
+		mockApp.AssertNotCalled(t, "BeginPasskeyRegistrationUseCase", mock.Anything, mock.Anything)
+	})
+}
+
+func TestResource_FinishPasskeyRegistration(t *testing.T) {
+	user := newPasskeyUser()
+	options := newRelyingParty().CreationOptions(passkeyChallenge, webauthn.User{ID: domain.UserHandle(user.ID), Name: user.Email}, nil)
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		attestation := createAttestation(t, options)
+		req := contextSetUser(newPasskeyRequest(t, "/v1/users/me/passkeys", envelope{"name": "Laptop", "credential": attestation}), user)
+		resRec := httptest.NewRecorder()
+
+		// The response must survive the trip through JSON intact.
+		verified := mock.MatchedBy(func(response *webauthn.AttestationResponse) bool {
+			_, err := newRelyingParty().FinishRegistration(response, passkeyChallenge)
+			return err == nil
+		})
+		mockApp.On("FinishPasskeyRegistrationUseCase", mock.Anything, user, "Laptop", verified).Return(&domain.Passkey{ID: 1, Name: "Laptop"}, nil)
+
+		// Act
+		res.finishPasskeyRegistration(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		mockApp.AssertExpectations(t)
+	})
+
+	t.Run("error - missing name", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := contextSetUser(newPasskeyRequest(t, "/v1/users/me/passkeys", envelope{"credential": createAttestation(t, options)}), user)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.finishPasskeyRegistration(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "FinishPasskeyRegistrationUseCase", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	errorCases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "expired challenge", err: domain.ErrRecordNotFound, status: http.StatusUnprocessableEntity},
+		{name: "unverifiable credential", err: webauthn.ErrInvalidResponse, status: http.StatusUnprocessableEntity},
+		{name: "duplicate passkey", err: domain.ErrDuplicatePasskey, status: http.StatusUnprocessableEntity},
+		{name: "internal server error", err: errors.New("some error"), status: http.StatusInternalServerError},
+	}
+
+	for _, tc := range errorCases {
+		t.Run("error - "+tc.name, func(t *testing.T) {
+			// Arrange
+			mockApp, res := setupRouterAndMocks()
+			req := contextSetUser(newPasskeyRequest(t, "/v1/users/me/passkeys", envelope{"name": "Laptop", "credential": createAttestation(t, options)}), user)
+			resRec := httptest.NewRecorder()
+
+			mockApp.On("FinishPasskeyRegistrationUseCase", mock.Anything, user, "Laptop", mock.Anything).Return(nil, tc.err)
+
+			// Act
+			res.finishPasskeyRegistration(resRec, req)
+
+			// Assert
+			assertStatusCode(t, resRec, tc.status)
+		})
+	}
+}
+
+func TestResource_BeginPasskeyLogin(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newPasskeyRequest(t, "/v1/tokens/passkey/challenge", envelope{"email": "johndoe@example.com"})
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("BeginPasskeyLoginUseCase", mock.Anything, "johndoe@example.com").Return(newRelyingParty().RequestOptions(passkeyChallenge, nil), nil)
+
+		// Act
+		res.beginPasskeyLogin(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+	})
+
+	t.Run("error - database", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newPasskeyRequest(t, "/v1/tokens/passkey/challenge", envelope{"email": "johndoe@example.com"})
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("BeginPasskeyLoginUseCase", mock.Anything, "johndoe@example.com").Return(nil, errors.New("connection refused"))
+
+		// Act
+		res.beginPasskeyLogin(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+
+	t.Run("error - invalid email", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newPasskeyRequest(t, "/v1/tokens/passkey/challenge", envelope{"email": "johndoe"})
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.beginPasskeyLogin(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "BeginPasskeyLoginUseCase", mock.Anything, mock.Anything)
+	})
+}
+
+func TestResource_FinishPasskeyLogin(t *testing.T) {
+	assertion := &webauthn.AssertionResponse{ID: "AQID", RawID: []byte{1, 2, 3}, Type: "public-key"}
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		user := newPasskeyUser()
+		req := newPasskeyRequest(t, "/v1/tokens/passkey", envelope{"credential": assertion})
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("FinishPasskeyLoginUseCase", mock.Anything, mock.AnythingOfType("*webauthn.AssertionResponse")).Return(user, nil)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, user.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, user, mock.Anything, mock.Anything).Return(nil)
+
+		// Act
+		res.finishPasskeyLogin(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		var responseBody map[string]string
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody["authentication_token"] != "thisisasecreT" {
+			t.Errorf("unexpected response body %v", responseBody)
+		}
+	})
+
+	errorCases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "expired challenge", err: domain.ErrRecordNotFound, status: http.StatusUnauthorized},
+		{name: "unverifiable assertion", err: webauthn.ErrInvalidResponse, status: http.StatusUnauthorized},
+		{name: "replayed counter", err: domain.ErrEditConflict, status: http.StatusUnauthorized},
+		{name: "suspended account", err: domain.ErrAccountSuspended, status: http.StatusForbidden},
+		{name: "internal server error", err: errors.New("some error"), status: http.StatusInternalServerError},
+	}
+
+	for _, tc := range errorCases {
+		t.Run("error - "+tc.name, func(t *testing.T) {
+			// Arrange
+			mockApp, res := setupRouterAndMocks()
+			req := newPasskeyRequest(t, "/v1/tokens/passkey", envelope{"credential": assertion})
+			resRec := httptest.NewRecorder()
+
+			mockApp.On("FinishPasskeyLoginUseCase", mock.Anything, mock.Anything).Return(nil, tc.err)
+
+			// Act
+			res.finishPasskeyLogin(resRec, req)
+
+			// Assert
+			assertStatusCode(t, resRec, tc.status)
+			mockApp.AssertNotCalled(t, "CreateAuthTokenUseCase", mock.Anything, mock.Anything)
+		})
+	}
+}
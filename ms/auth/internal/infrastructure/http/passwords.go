@@ -0,0 +1,167 @@
+package http
+
+import (
+	"errors"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/request"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+)
+
+// @Summary Request password reset
+// @Description Emails a single-use password reset token to the user. The response is the same whether or not the email address is registered.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body domain.CreatePasswordResetTokenRequest true "Request body"
+// @Success 202 {object} map[string]string
+// @Router /tokens/password-reset [post]
+func (h *handlers) createPasswordResetToken(res http.ResponseWriter, req *http.Request) {
+	var input domain.CreatePasswordResetTokenRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	ValidatePasswordResetEmail(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	err = h.appl.CreatePasswordResetTokenUseCase(req.Context(), input.Email)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	env := envelope{"message": "if the email address is registered, password reset instructions will be sent to it shortly"}
+
+	err = response.JSON(res, http.StatusAccepted, env)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Reset password
+// @Description Sets a new password using a password reset token. Rejected passwords come back with their strength score and improvement hints.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body domain.ResetPasswordRequest true "Request body"
+// @Success 200 {object} map[string]string
+// @Router /users/password [put]
+func (h *handlers) resetPassword(res http.ResponseWriter, req *http.Request) {
+	var input domain.ResetPasswordRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	ValidatePasswordResetToken(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	user, err := h.appl.GetByPasswordResetTokenUseCase(req.Context(), input.TokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			input.Validator.AddFieldError("Token", "Invalid or expired password reset token")
+			_errors.FailedValidation(res, req, input.Validator)
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	ValidatePassword(&input.Validator, h.policy, "Password", input.Password, user.Name, user.Email)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	hashedPassword, err := h.hasher.Hash(req.Context(), input.Password)
+	if err != nil {
+		hashingError(res, req, err)
+		return
+	}
+
+	err = h.appl.ResetPasswordUseCase(req.Context(), user, hashedPassword)
+	if err != nil {
+		h.userUpdateError(res, req, err)
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"message": "your password was successfully reset"})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Change password
+// @Description Replaces the password of the signed-in user. Rejected passwords come back with their strength score and improvement hints.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body domain.ChangePasswordRequest true "Request body"
+// @Success 200 {object} map[string]string
+// @Router /users/me/password [put]
+func (h *handlers) changePassword(res http.ResponseWriter, req *http.Request) {
+	user := contextGetUser(req)
+
+	// Support staff acting as the user must not be able to lock them out.
+	if user.ActorID != 0 {
+		_errors.NotPermitted(res, req)
+		return
+	}
+
+	var input domain.ChangePasswordRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	passwordMatches, err := h.hasher.Matches(req.Context(), input.CurrentPassword, user.HashedPassword)
+	if err != nil {
+		hashingError(res, req, err)
+		return
+	}
+
+	ValidateCurrentPassword(&input, passwordMatches)
+
+	ValidatePassword(&input.Validator, h.policy, "NewPassword", input.NewPassword, user.Name, user.Email)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	hashedPassword, err := h.hasher.Hash(req.Context(), input.NewPassword)
+	if err != nil {
+		hashingError(res, req, err)
+		return
+	}
+
+	err = h.appl.ChangePasswordUseCase(req.Context(), user, hashedPassword)
+	if err != nil {
+		h.userUpdateError(res, req, err)
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"message": "your password was successfully changed"})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
@@ -0,0 +1,294 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"bytes"
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/internal/password"
+	"github.com/jessicatarra/greenlight/internal/utils/validator"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const passwordResetToken = "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+
+func setupStrictPolicyMocks() (*mocks.Appl, Handlers) {
+	mockApp := &mocks.Appl{}
+
+	res := registerHandlers(handlerDeps{
+		appl:   mockApp,
+		policy: password.NewStandardPolicy(8, 72, 3),
+		hasher: password.NewHasher(nil, "", nil),
+		mailer: mailer.Mailer{},
+	})
+
+	return mockApp, res
+}
+
+func assertPasswordDetails(t *testing.T, resRec *httptest.ResponseRecorder, key string) {
+	var responseBody struct {
+		FieldErrors  map[string]string
+		FieldDetails map[string]password.Strength
+	}
+	assertResponseBody(t, resRec, &responseBody)
+
+	if responseBody.FieldErrors[key] != "Password is too weak" {
+		t.Errorf("unexpected %s error: %q", key, responseBody.FieldErrors[key])
+	}
+
+	details, ok := responseBody.FieldDetails[key]
+	if !ok {
+		t.Fatalf("expected %s details in response body, got %s", key, resRec.Body.String())
+	}
+	if details.Score >= 3 {
+		t.Errorf("unexpected score: got %d, want less than 3", details.Score)
+	}
+	if len(details.Hints) == 0 {
+		t.Errorf("expected improvement hints, got none")
+	}
+}
+
+func TestResource_CreateUserWeakPassword(t *testing.T) {
+	// Arrange
+	mockApp, res := setupStrictPolicyMocks()
+	requestBody := []byte(`{"name": "John Doe", "email": "johndoe@example.com", "password": "johndoe2024"}`)
+	req := httptest.NewRequest(http.MethodPost, "/v1/users", bytes.NewBuffer(requestBody))
+	resRec := httptest.NewRecorder()
+
+	mockApp.On("GetByEmailUseCase", mock.Anything, "johndoe@example.com").Return(nil, domain.ErrRecordNotFound)
+
+	// Act
+	res.createUser(resRec, req)
+
+	// Assert
+	assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+	assertPasswordDetails(t, resRec, "Password")
+	mockApp.AssertNotCalled(t, "CreateUseCase", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResource_CreatePasswordResetToken(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		requestBody := []byte(`{"email": "johndoe@example.com"}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/password-reset", bytes.NewBuffer(requestBody))
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreatePasswordResetTokenUseCase", mock.Anything, "johndoe@example.com").Return(nil)
+
+		// Act
+		res.createPasswordResetToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusAccepted)
+		mockApp.AssertExpectations(t)
+	})
+
+	t.Run("error - invalid email", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		requestBody := []byte(`{"email": "not-an-email"}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/password-reset", bytes.NewBuffer(requestBody))
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.createPasswordResetToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "CreatePasswordResetTokenUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - CreatePasswordResetTokenUseCase return error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		requestBody := []byte(`{"email": "johndoe@example.com"}`)
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/password-reset", bytes.NewBuffer(requestBody))
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("CreatePasswordResetTokenUseCase", mock.Anything, "johndoe@example.com").Return(errors.New("error"))
+
+		// Act
+		res.createPasswordResetToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+}
+
+func TestResource_ResetPassword(t *testing.T) {
+	user := &domain.User{ID: 1, Name: "John Doe", Email: "johndoe@example.com", Activated: true}
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupStrictPolicyMocks()
+		requestBody := []byte(`{"password": "violet kettle drums at noon", "token": "` + passwordResetToken + `"}`)
+		req := httptest.NewRequest(http.MethodPut, "/v1/users/password", bytes.NewBuffer(requestBody))
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByPasswordResetTokenUseCase", mock.Anything, passwordResetToken).Return(user, nil)
+		mockApp.On("ResetPasswordUseCase", mock.Anything, user, mock.AnythingOfType("string")).Return(nil)
+
+		// Act
+		res.resetPassword(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		mockApp.AssertExpectations(t)
+	})
+
+	t.Run("error - weak password", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupStrictPolicyMocks()
+		requestBody := []byte(`{"password": "JohnDoe123", "token": "` + passwordResetToken + `"}`)
+		req := httptest.NewRequest(http.MethodPut, "/v1/users/password", bytes.NewBuffer(requestBody))
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByPasswordResetTokenUseCase", mock.Anything, passwordResetToken).Return(user, nil)
+
+		// Act
+		res.resetPassword(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		assertPasswordDetails(t, resRec, "Password")
+		mockApp.AssertNotCalled(t, "ResetPasswordUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - invalid or expired token", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		requestBody := []byte(`{"password": "violet kettle drums at noon", "token": "` + passwordResetToken + `"}`)
+		req := httptest.NewRequest(http.MethodPut, "/v1/users/password", bytes.NewBuffer(requestBody))
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByPasswordResetTokenUseCase", mock.Anything, passwordResetToken).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.resetPassword(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		var responseBody validator.Validator
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody.FieldErrors["Token"] == "" {
+			t.Errorf("expected Token error in response body, got %s", resRec.Body.String())
+		}
+	})
+
+	t.Run("error - malformed token", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		requestBody := []byte(`{"password": "violet kettle drums at noon", "token": "short"}`)
+		req := httptest.NewRequest(http.MethodPut, "/v1/users/password", bytes.NewBuffer(requestBody))
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.resetPassword(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "GetByPasswordResetTokenUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - edit conflict", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		requestBody := []byte(`{"password": "violet kettle drums at noon", "token": "` + passwordResetToken + `"}`)
+		req := httptest.NewRequest(http.MethodPut, "/v1/users/password", bytes.NewBuffer(requestBody))
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByPasswordResetTokenUseCase", mock.Anything, passwordResetToken).Return(user, nil)
+		mockApp.On("ResetPasswordUseCase", mock.Anything, user, mock.AnythingOfType("string")).Return(domain.ErrEditConflict)
+
+		// Act
+		res.resetPassword(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusConflict)
+	})
+}
+
+func TestResource_ChangePassword(t *testing.T) {
+	hashedPassword, _ := password.Hash("violet kettle drums at noon")
+	newUser := func() *domain.User {
+		return &domain.User{ID: 1, Name: "John Doe", Email: "johndoe@example.com", Activated: true, HashedPassword: hashedPassword}
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupStrictPolicyMocks()
+		user := newUser()
+		requestBody := []byte(`{"current_password": "violet kettle drums at noon", "new_password": "orange bicycle under the bridge"}`)
+		req := httptest.NewRequest(http.MethodPut, "/v1/users/me/password", bytes.NewBuffer(requestBody))
+		req = contextSetUser(req, user)
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ChangePasswordUseCase", mock.Anything, user, mock.AnythingOfType("string")).Return(nil)
+
+		// Act
+		res.changePassword(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		mockApp.AssertExpectations(t)
+	})
+
+	t.Run("error - incorrect current password", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupStrictPolicyMocks()
+		requestBody := []byte(`{"current_password": "wrong password", "new_password": "orange bicycle under the bridge"}`)
+		req := httptest.NewRequest(http.MethodPut, "/v1/users/me/password", bytes.NewBuffer(requestBody))
+		req = contextSetUser(req, newUser())
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.changePassword(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "ChangePasswordUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - new password contains email", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupStrictPolicyMocks()
+		requestBody := []byte(`{"current_password": "violet kettle drums at noon", "new_password": "johndoe!2024"}`)
+		req := httptest.NewRequest(http.MethodPut, "/v1/users/me/password", bytes.NewBuffer(requestBody))
+		req = contextSetUser(req, newUser())
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.changePassword(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		assertPasswordDetails(t, resRec, "NewPassword")
+		mockApp.AssertNotCalled(t, "ChangePasswordUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - impersonated user", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupStrictPolicyMocks()
+		user := newUser()
+		user.ActorID = 2
+		requestBody := []byte(`{"current_password": "violet kettle drums at noon", "new_password": "orange bicycle under the bridge"}`)
+		req := httptest.NewRequest(http.MethodPut, "/v1/users/me/password", bytes.NewBuffer(requestBody))
+		req = contextSetUser(req, user)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.changePassword(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusForbidden)
+		mockApp.AssertNotCalled(t, "ChangePasswordUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
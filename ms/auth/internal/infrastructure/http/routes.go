@@ -13,5 +13,5 @@ func (s service) Routes() http.Handler {
 
 	m := middleware.NewSharedMiddleware(&s.cfg, s.logger)
 
-	return m.RecoverPanic(m.RateLimit(m.EnableCORS(m.LogRequest(router))))
+	return m.RecoverPanic(m.RateLimit(m.EnableCORS(m.CSRF(s.authenticate(m.LogRequest(router))))))
 }
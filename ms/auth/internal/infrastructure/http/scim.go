@@ -0,0 +1,238 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/password"
+	"github.com/jessicatarra/greenlight/internal/scim"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	scimDefaultCount = 100
+	scimMaxCount     = 200
+)
+
+type scimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// scimUser is a User resource. A Greenlight user has a single email address,
+// which is both the userName and the primary work email, and a single name,
+// which is both the displayName and name.formatted.
+type scimUser struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *scimName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []scimEmail `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Password    string      `json:"password,omitempty"`
+	Meta        *scim.Meta  `json:"meta,omitempty"`
+}
+
+type scimMember struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// scimGroup is a Group resource. Every permission is a group, named after its
+// code, whose members are the users holding it.
+type scimGroup struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []scimMember `json:"members,omitempty"`
+	Meta        *scim.Meta   `json:"meta,omitempty"`
+}
+
+func (h *handlers) scimLocation(resource string, id int64) string {
+	return h.baseURL + "/scim/v2/" + resource + "/" + strconv.FormatInt(id, 10)
+}
+
+func (h *handlers) newSCIMUser(user *domain.User) *scimUser {
+	active := user.Activated && !user.Suspended
+	created := user.CreatedAt
+
+	return &scimUser{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          strconv.FormatInt(user.ID, 10),
+		UserName:    user.Email,
+		Name:        &scimName{Formatted: user.Name},
+		DisplayName: user.Name,
+		Emails:      []scimEmail{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      &created,
+			Location:     h.scimLocation("Users", user.ID),
+			Version:      `W/"` + strconv.Itoa(user.Version) + `"`,
+		},
+	}
+}
+
+func (h *handlers) newSCIMGroup(group *domain.Group) *scimGroup {
+	resource := &scimGroup{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          strconv.FormatInt(group.ID, 10),
+		DisplayName: group.Code,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Location:     h.scimLocation("Groups", group.ID),
+		},
+	}
+
+	for _, member := range group.Members {
+		resource.Members = append(resource.Members, scimMember{
+			Value:   strconv.FormatInt(member.ID, 10),
+			Ref:     h.scimLocation("Users", member.ID),
+			Display: member.Name,
+		})
+	}
+
+	return resource
+}
+
+// fullName picks the user's name from the attributes a provisioning client
+// may send, in order of preference.
+func (u *scimUser) fullName() string {
+	switch {
+	case u.DisplayName != "":
+		return u.DisplayName
+	case u.Name == nil:
+		return ""
+	case u.Name.Formatted != "":
+		return u.Name.Formatted
+	default:
+		return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+	}
+}
+
+// setSCIMActive maps the SCIM active flag onto the account state. Enabling
+// an account also activates it, since the identity provider vouches for the
+// address; disabling it suspends it.
+func setSCIMActive(user *domain.User, active bool) {
+	if active {
+		user.Activated = true
+		user.Suspended = false
+	} else {
+		user.Suspended = true
+	}
+}
+
+// scimError writes err as a SCIM error response. Errors that do not describe
+// a problem with the request are reported and answered with a 500.
+func scimError(res http.ResponseWriter, req *http.Request, err error) {
+	if scimErr := scim.AsError(err); scimErr != nil {
+		scim.WriteError(res, scimErr)
+		return
+	}
+
+	if errors.Is(err, password.ErrSaturated) {
+		res.Header().Set("Retry-After", strconv.Itoa(int(hashingRetryAfter.Seconds())))
+		scim.WriteError(res, scim.NewError(http.StatusServiceUnavailable, "", "the server is too busy to process your request, please try again later"))
+		return
+	}
+
+	_errors.ReportServerError(req, err)
+	scim.WriteError(res, scim.NewError(http.StatusInternalServerError, "", "the server encountered a problem and could not process your request"))
+}
+
+func scimNotFound(res http.ResponseWriter, resource string) {
+	scim.WriteError(res, scim.NewError(http.StatusNotFound, "", resource+" not found"))
+}
+
+func scimInvalidValue(detail string) *scim.Error {
+	return scim.NewError(http.StatusBadRequest, scim.ErrorInvalidValue, detail)
+}
+
+// decodeSCIMValue unmarshals the value of a PATCH operation on attribute.
+func decodeSCIMValue(value json.RawMessage, dst any, attribute string) error {
+	err := json.Unmarshal(value, dst)
+	if err != nil {
+		return scimInvalidValue("invalid value for " + attribute)
+	}
+	return nil
+}
+
+// @Summary SCIM service provider configuration
+// @Description Describes the SCIM features supported by the service
+// @Tags SCIM
+// @Produce json
+// @Security SCIMBearer
+// @Success 200 {object} map[string]interface{}
+// @Router /scim/v2/ServiceProviderConfig [get]
+func (h *handlers) showSCIMServiceProviderConfig(res http.ResponseWriter, req *http.Request) {
+	config := envelope{
+		"schemas":        []string{scim.SchemaServiceProviderConfig},
+		"patch":          envelope{"supported": true},
+		"bulk":           envelope{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         envelope{"supported": true, "maxResults": scimMaxCount},
+		"changePassword": envelope{"supported": true},
+		"sort":           envelope{"supported": false},
+		"etag":           envelope{"supported": false},
+		"authenticationSchemes": []envelope{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Authentication with a bearer token issued to the provisioning client",
+		}},
+		"meta": envelope{
+			"resourceType": "ServiceProviderConfig",
+			"location":     h.baseURL + "/scim/v2/ServiceProviderConfig",
+		},
+	}
+
+	err := scim.WriteJSON(res, http.StatusOK, config, nil)
+	if err != nil {
+		scimError(res, req, err)
+	}
+}
+
+// @Summary SCIM resource types
+// @Description Lists the SCIM resource types served
+// @Tags SCIM
+// @Produce json
+// @Security SCIMBearer
+// @Success 200 {object} scim.ListResponse
+// @Router /scim/v2/ResourceTypes [get]
+func (h *handlers) listSCIMResourceTypes(res http.ResponseWriter, req *http.Request) {
+	resourceType := func(name, schema string) envelope {
+		return envelope{
+			"schemas":  []string{scim.SchemaResourceType},
+			"id":       name,
+			"name":     name,
+			"endpoint": "/" + name + "s",
+			"schema":   schema,
+			"meta": envelope{
+				"resourceType": "ResourceType",
+				"location":     h.baseURL + "/scim/v2/ResourceTypes/" + name,
+			},
+		}
+	}
+
+	resourceTypes := []envelope{
+		resourceType("User", scim.SchemaUser),
+		resourceType("Group", scim.SchemaGroup),
+	}
+
+	page := scim.Page{StartIndex: 1, Count: len(resourceTypes)}
+
+	err := scim.WriteJSON(res, http.StatusOK, scim.NewListResponse(resourceTypes, len(resourceTypes), page, len(resourceTypes)), nil)
+	if err != nil {
+		scimError(res, req, err)
+	}
+}
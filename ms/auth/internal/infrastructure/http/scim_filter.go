@@ -0,0 +1,171 @@
+package http
+
+import (
+	"fmt"
+	"github.com/jessicatarra/greenlight/internal/scim"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scimUserFields maps the filterable attributes of a SCIM User onto the fields
+// users are searched by. A user has a single email, which doubles as the
+// userName and is reported as the primary work address.
+var scimUserFields = map[string]domain.UserField{
+	"id":             domain.UserFieldID,
+	"username":       domain.UserFieldEmail,
+	"emails":         domain.UserFieldEmail,
+	"emails.value":   domain.UserFieldEmail,
+	"displayname":    domain.UserFieldName,
+	"name.formatted": domain.UserFieldName,
+	"active":         domain.UserFieldActive,
+	"meta.created":   domain.UserFieldCreatedAt,
+}
+
+// scimEmailFields resolves the attributes inside emails[...], where type and
+// primary are the same for every user.
+var (
+	scimEmailFields    = map[string]domain.UserField{"value": domain.UserFieldEmail}
+	scimEmailConstants = map[string]any{"type": "work", "primary": true}
+)
+
+// scimUserQuery translates a SCIM filter on users into the query searching
+// them, returning an error wrapping scim.ErrInvalidFilter for filters on
+// attributes users cannot be searched by.
+func scimUserQuery(expr scim.Expression) (domain.UserQuery, error) {
+	return scimQuery(expr, scimUserFields, nil)
+}
+
+func scimQuery(expr scim.Expression, fields map[string]domain.UserField, constants map[string]any) (domain.UserQuery, error) {
+	switch e := expr.(type) {
+	case nil:
+		return nil, nil
+	case *scim.LogicalExpression:
+		left, err := scimQuery(e.Left, fields, constants)
+		if err != nil {
+			return nil, err
+		}
+		right, err := scimQuery(e.Right, fields, constants)
+		if err != nil {
+			return nil, err
+		}
+		if e.Operator == "and" {
+			return &domain.UserQueryAnd{Left: left, Right: right}, nil
+		}
+		return &domain.UserQueryOr{Left: left, Right: right}, nil
+	case *scim.NotExpression:
+		query, err := scimQuery(e.Expression, fields, constants)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.UserQueryNot{Query: query}, nil
+	case *scim.ValuePathExpression:
+		if !e.Path.Is("emails") {
+			return nil, fmt.Errorf("%w: unsupported attribute %q", scim.ErrInvalidFilter, e.Path)
+		}
+		return scimQuery(e.Filter, scimEmailFields, scimEmailConstants)
+	case *scim.AttributeExpression:
+		name := strings.ToLower(e.Path.Name)
+		if e.Path.SubAttribute != "" {
+			name += "." + strings.ToLower(e.Path.SubAttribute)
+		}
+		if value, ok := constants[name]; ok {
+			return scimConstant(e, value)
+		}
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unsupported attribute %q", scim.ErrInvalidFilter, e.Path)
+		}
+		return scimCondition(field, e)
+	}
+
+	return nil, fmt.Errorf("%w: unsupported expression", scim.ErrInvalidFilter)
+}
+
+func scimCondition(field domain.UserField, e *scim.AttributeExpression) (domain.UserQuery, error) {
+	comparison := domain.Comparison(e.Operator)
+
+	// Every field is set for every user.
+	switch {
+	case comparison == domain.ComparePresent:
+		return &domain.UserCondition{Field: field, Comparison: comparison}, nil
+	case e.Value == nil && comparison == domain.CompareEqual:
+		return domain.UserQueryConstant(false), nil
+	case e.Value == nil && comparison == domain.CompareNotEqual:
+		return domain.UserQueryConstant(true), nil
+	}
+
+	invalid := fmt.Errorf("%w: cannot compare %q with %s %v", scim.ErrInvalidFilter, e.Path, e.Operator, e.Value)
+
+	switch field {
+	case domain.UserFieldEmail, domain.UserFieldName:
+		value, ok := e.Value.(string)
+		if !ok {
+			return nil, invalid
+		}
+		return &domain.UserCondition{Field: field, Comparison: comparison, Value: value}, nil
+	case domain.UserFieldActive:
+		value, ok := e.Value.(bool)
+		if !ok || !equality(comparison) {
+			return nil, invalid
+		}
+		return &domain.UserCondition{Field: field, Comparison: comparison, Value: value}, nil
+	case domain.UserFieldCreatedAt:
+		s, ok := e.Value.(string)
+		if !ok {
+			return nil, invalid
+		}
+		value, err := time.Parse(time.RFC3339, s)
+		if err != nil || !ordering(comparison) {
+			return nil, invalid
+		}
+		return &domain.UserCondition{Field: field, Comparison: comparison, Value: value}, nil
+	case domain.UserFieldID:
+		s, ok := e.Value.(string)
+		if !ok || !equality(comparison) {
+			return nil, invalid
+		}
+		value, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			// No user has an id that is not a number.
+			return domain.UserQueryConstant(comparison == domain.CompareNotEqual), nil
+		}
+		return &domain.UserCondition{Field: field, Comparison: comparison, Value: value}, nil
+	}
+
+	return nil, invalid
+}
+
+// scimConstant evaluates a comparison with an attribute that has value for
+// every user.
+func scimConstant(e *scim.AttributeExpression, value any) (domain.UserQuery, error) {
+	if e.Operator != scim.OperatorPresent && e.Value != nil {
+		invalid := fmt.Errorf("%w: cannot compare %q with %s %v", scim.ErrInvalidFilter, e.Path, e.Operator, e.Value)
+
+		switch value.(type) {
+		case string:
+			if _, ok := e.Value.(string); !ok {
+				return nil, invalid
+			}
+		case bool:
+			if _, ok := e.Value.(bool); !ok || !equality(domain.Comparison(e.Operator)) {
+				return nil, invalid
+			}
+		}
+	}
+
+	return domain.UserQueryConstant(scim.Matches(e, map[string]any{e.Path.Name: value})), nil
+}
+
+func equality(comparison domain.Comparison) bool {
+	return comparison == domain.CompareEqual || comparison == domain.CompareNotEqual
+}
+
+func ordering(comparison domain.Comparison) bool {
+	switch comparison {
+	case domain.CompareEqual, domain.CompareNotEqual, domain.CompareGreaterThan, domain.CompareGreaterOrEqual, domain.CompareLessThan, domain.CompareLessOrEqual:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/scim"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSCIMUserQuery(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		filter string
+		query  domain.UserQuery
+	}{
+		{
+			name:   "userName equality",
+			filter: `userName eq "John@Example.com"`,
+			query:  &domain.UserCondition{Field: domain.UserFieldEmail, Comparison: domain.CompareEqual, Value: "John@Example.com"},
+		},
+		{
+			name:   "displayName contains",
+			filter: `displayName co "50%_off"`,
+			query:  &domain.UserCondition{Field: domain.UserFieldName, Comparison: domain.CompareContains, Value: "50%_off"},
+		},
+		{
+			name:   "active and created",
+			filter: `active eq true and meta.created ge "2024-01-02T03:04:05Z"`,
+			query: &domain.UserQueryAnd{
+				Left:  &domain.UserCondition{Field: domain.UserFieldActive, Comparison: domain.CompareEqual, Value: true},
+				Right: &domain.UserCondition{Field: domain.UserFieldCreatedAt, Comparison: domain.CompareGreaterOrEqual, Value: created},
+			},
+		},
+		{
+			name:   "email value path",
+			filter: `emails[type eq "work" and value sw "john"]`,
+			query: &domain.UserQueryAnd{
+				Left:  domain.UserQueryConstant(true),
+				Right: &domain.UserCondition{Field: domain.UserFieldEmail, Comparison: domain.CompareStartsWith, Value: "john"},
+			},
+		},
+		{
+			name:   "email value path with another type",
+			filter: `emails[type eq "home"]`,
+			query:  domain.UserQueryConstant(false),
+		},
+		{
+			name:   "not and id",
+			filter: `not (id eq "42") or id eq "abc"`,
+			query: &domain.UserQueryOr{
+				Left:  &domain.UserQueryNot{Query: &domain.UserCondition{Field: domain.UserFieldID, Comparison: domain.CompareEqual, Value: int64(42)}},
+				Right: domain.UserQueryConstant(false),
+			},
+		},
+		{
+			name:   "present",
+			filter: `name.formatted pr`,
+			query:  &domain.UserCondition{Field: domain.UserFieldName, Comparison: domain.ComparePresent},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			expr, err := scim.ParseFilter(tt.filter)
+			assert.NoError(t, err)
+
+			// Act
+			query, err := scimUserQuery(expr)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.query, query)
+		})
+	}
+
+	unsupported := []string{
+		`externalId eq "abc"`,
+		`active co "t"`,
+		`userName gt 3`,
+		`meta.created gt "yesterday"`,
+		`emails[primary eq "yes"]`,
+		`groups[value eq "1"]`,
+	}
+
+	for _, filter := range unsupported {
+		t.Run("Error - "+filter, func(t *testing.T) {
+			// Arrange
+			expr, err := scim.ParseFilter(filter)
+			assert.NoError(t, err)
+
+			// Act
+			_, err = scimUserQuery(expr)
+
+			// Assert
+			assert.True(t, errors.Is(err, scim.ErrInvalidFilter))
+		})
+	}
+}
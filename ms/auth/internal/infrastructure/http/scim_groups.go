@@ -0,0 +1,389 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/request"
+	"github.com/jessicatarra/greenlight/internal/scim"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+	"strconv"
+)
+
+// @Summary List SCIM groups
+// @Description Lists the permissions as SCIM groups, optionally narrowed by a SCIM filter
+// @Tags SCIM
+// @Produce json
+// @Security SCIMBearer
+// @Param filter query string false "SCIM filter, e.g. displayName eq \"movies:write\""
+// @Param startIndex query int false "1-based index of the first result"
+// @Param count query int false "Number of results per page"
+// @Param excludedAttributes query string false "Set to members to leave out group members"
+// @Success 200 {object} scim.ListResponse
+// @Router /scim/v2/Groups [get]
+func (h *handlers) listSCIMGroups(res http.ResponseWriter, req *http.Request) {
+	qs := req.URL.Query()
+
+	page, err := scim.ParsePage(qs, scimDefaultCount, scimMaxCount)
+	if err != nil {
+		scimError(res, req, err)
+		return
+	}
+
+	var filter scim.Expression
+
+	if qs.Get("filter") != "" {
+		filter, err = scim.ParseFilter(qs.Get("filter"))
+		if err != nil {
+			scimError(res, req, err)
+			return
+		}
+	}
+
+	withMembers := !scim.ExcludesAttribute(qs, "members")
+
+	// There are only a handful of permissions, so they are filtered here
+	// rather than in the database. Members are needed to filter on them.
+	groups, err := h.appl.ListGroupsUseCase(req.Context(), withMembers || filter != nil)
+	if err != nil {
+		scimError(res, req, err)
+		return
+	}
+
+	matches := []*scimGroup{}
+
+	for _, group := range groups {
+		resource := h.newSCIMGroup(group)
+
+		if filter != nil {
+			ok, err := matchesSCIMFilter(filter, resource)
+			if err != nil {
+				scimError(res, req, err)
+				return
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		if !withMembers {
+			resource.Members = nil
+		}
+
+		matches = append(matches, resource)
+	}
+
+	resources := matches[min(page.Offset(), len(matches)):]
+	resources = resources[:min(page.Count, len(resources))]
+
+	err = scim.WriteJSON(res, http.StatusOK, scim.NewListResponse(resources, len(matches), page, len(resources)), nil)
+	if err != nil {
+		scimError(res, req, err)
+	}
+}
+
+// @Summary Get SCIM group
+// @Tags SCIM
+// @Produce json
+// @Security SCIMBearer
+// @Param id path int true "Permission ID"
+// @Param excludedAttributes query string false "Set to members to leave out group members"
+// @Success 200 {object} scimGroup
+// @Router /scim/v2/Groups/{id} [get]
+func (h *handlers) showSCIMGroup(res http.ResponseWriter, req *http.Request) {
+	group, ok := h.scimGroupFromPath(res, req, !scim.ExcludesAttribute(req.URL.Query(), "members"))
+	if !ok {
+		return
+	}
+
+	err := scim.WriteJSON(res, http.StatusOK, h.newSCIMGroup(group), nil)
+	if err != nil {
+		scimError(res, req, err)
+	}
+}
+
+// @Summary Replace SCIM group
+// @Description Replaces the members of a group. The displayName of a group is its permission code and cannot be changed.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Security SCIMBearer
+// @Param id path int true "Permission ID"
+// @Param request body scimGroup true "Group resource"
+// @Success 200 {object} scimGroup
+// @Router /scim/v2/Groups/{id} [put]
+func (h *handlers) replaceSCIMGroup(res http.ResponseWriter, req *http.Request) {
+	group, ok := h.scimGroupFromPath(res, req, false)
+	if !ok {
+		return
+	}
+
+	var input scimGroup
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		scim.WriteError(res, scim.NewError(http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error()))
+		return
+	}
+
+	if input.DisplayName != "" && input.DisplayName != group.Code {
+		scimError(res, req, errGroupRenamed)
+		return
+	}
+
+	members := newSCIMMemberSet()
+
+	err = members.add(input.Members)
+	if err != nil {
+		scimError(res, req, err)
+		return
+	}
+
+	h.saveSCIMGroupMembers(res, req, group.ID, members)
+}
+
+// @Summary Update SCIM group
+// @Description Applies SCIM PATCH operations to the members of a group
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Security SCIMBearer
+// @Param id path int true "Permission ID"
+// @Param request body scim.PatchRequest true "PATCH operations"
+// @Success 200 {object} scimGroup
+// @Router /scim/v2/Groups/{id} [patch]
+func (h *handlers) patchSCIMGroup(res http.ResponseWriter, req *http.Request) {
+	group, ok := h.scimGroupFromPath(res, req, true)
+	if !ok {
+		return
+	}
+
+	var input scim.PatchRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		scim.WriteError(res, scim.NewError(http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error()))
+		return
+	}
+
+	err = input.Validate()
+	if err != nil {
+		scimError(res, req, err)
+		return
+	}
+
+	members := newSCIMMemberSet()
+	for _, member := range group.Members {
+		members.ids = append(members.ids, member.ID)
+	}
+
+	for _, op := range input.Operations {
+		err = members.apply(op, group.Code)
+		if err != nil {
+			scimError(res, req, err)
+			return
+		}
+	}
+
+	h.saveSCIMGroupMembers(res, req, group.ID, members)
+}
+
+// @Summary Create or delete SCIM group
+// @Description Groups are the service's permissions, which provisioning clients cannot create or delete
+// @Tags SCIM
+// @Security SCIMBearer
+// @Failure 501
+// @Router /scim/v2/Groups [post]
+func (h *handlers) unsupportedSCIMGroupOperation(res http.ResponseWriter, req *http.Request) {
+	scim.WriteError(res, scim.NewError(http.StatusNotImplemented, "", "groups are permissions and cannot be created or deleted"))
+}
+
+var errGroupRenamed = scim.NewError(http.StatusBadRequest, scim.ErrorMutability, "the displayName of a group is its permission code and cannot be changed")
+
+func (h *handlers) scimGroupFromPath(res http.ResponseWriter, req *http.Request, withMembers bool) (*domain.Group, bool) {
+	id, err := h.helpers.ReadIDParam(req)
+	if err != nil {
+		scimNotFound(res, "Group")
+		return nil, false
+	}
+
+	group, err := h.appl.GetGroupUseCase(req.Context(), id, withMembers)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			scimNotFound(res, "Group")
+		default:
+			scimError(res, req, err)
+		}
+		return nil, false
+	}
+
+	return group, true
+}
+
+func (h *handlers) saveSCIMGroupMembers(res http.ResponseWriter, req *http.Request, id int64, members *scimMemberSet) {
+	group, err := h.appl.SetGroupMembersUseCase(req.Context(), id, members.ids)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			scimError(res, req, scimInvalidValue("members must refer to existing users"))
+		default:
+			scimError(res, req, err)
+		}
+		return
+	}
+
+	err = scim.WriteJSON(res, http.StatusOK, h.newSCIMGroup(group), nil)
+	if err != nil {
+		scimError(res, req, err)
+	}
+}
+
+// matchesSCIMFilter evaluates filter against the JSON form of resource.
+func matchesSCIMFilter(filter scim.Expression, resource any) (bool, error) {
+	js, err := json.Marshal(resource)
+	if err != nil {
+		return false, err
+	}
+
+	var values map[string]any
+	err = json.Unmarshal(js, &values)
+	if err != nil {
+		return false, err
+	}
+
+	return scim.Matches(filter, values), nil
+}
+
+// scimMemberSet is the list of user IDs a group PATCH works on, kept in the
+// order members were added.
+type scimMemberSet struct {
+	ids []int64
+}
+
+func newSCIMMemberSet() *scimMemberSet {
+	return &scimMemberSet{ids: []int64{}}
+}
+
+func (s *scimMemberSet) add(members []scimMember) error {
+	for _, member := range members {
+		id, err := strconv.ParseInt(member.Value, 10, 64)
+		if err != nil || id < 1 {
+			return scimInvalidValue("members must refer to existing users")
+		}
+		if !s.contains(id) {
+			s.ids = append(s.ids, id)
+		}
+	}
+	return nil
+}
+
+func (s *scimMemberSet) contains(id int64) bool {
+	for _, existing := range s.ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+// removeWhere drops the members for which match returns true.
+func (s *scimMemberSet) removeWhere(match func(id int64) bool) {
+	kept := s.ids[:0]
+	for _, id := range s.ids {
+		if !match(id) {
+			kept = append(kept, id)
+		}
+	}
+	s.ids = kept
+}
+
+func (s *scimMemberSet) apply(op scim.PatchOperation, code string) error {
+	if op.Path == "" {
+		var values map[string]json.RawMessage
+		err := decodeSCIMValue(op.Value, &values, "operation without path")
+		if err != nil {
+			return err
+		}
+
+		for key, value := range values {
+			attribute, err := scim.ParseAttributePath(key)
+			if err != nil {
+				return scim.NewError(http.StatusBadRequest, scim.ErrorInvalidPath, err.Error())
+			}
+
+			err = s.set(op.Op, &scim.Path{Attribute: attribute}, value, code)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	path, err := scim.ParsePath(op.Path)
+	if err != nil {
+		return scim.NewError(http.StatusBadRequest, scim.ErrorInvalidPath, err.Error())
+	}
+
+	return s.set(op.Op, path, op.Value, code)
+}
+
+func (s *scimMemberSet) set(op string, path *scim.Path, value json.RawMessage, code string) error {
+	switch {
+	case path.Attribute.Is("displayName"):
+		var displayName string
+		err := decodeSCIMValue(value, &displayName, "displayName")
+		if err != nil {
+			return err
+		}
+		if op == scim.PatchRemove || displayName != code {
+			return errGroupRenamed
+		}
+		return nil
+	case !path.Attribute.Is("members"):
+		// Attributes the service does not store, such as externalId.
+		return nil
+	}
+
+	if path.Filter != nil {
+		// members[value eq "42"] selects existing members.
+		if op != scim.PatchRemove {
+			return scim.NewError(http.StatusBadRequest, scim.ErrorInvalidPath, "members can only be added without a filter")
+		}
+		s.removeWhere(func(id int64) bool {
+			return scim.Matches(path.Filter, map[string]any{"value": strconv.FormatInt(id, 10)})
+		})
+		return nil
+	}
+
+	var members []scimMember
+
+	if len(value) > 0 {
+		err := decodeSCIMValue(value, &members, "members")
+		if err != nil {
+			return err
+		}
+	}
+
+	switch op {
+	case scim.PatchAdd:
+		return s.add(members)
+	case scim.PatchReplace:
+		s.ids = []int64{}
+		return s.add(members)
+	default:
+		// Without a value every member is removed.
+		if len(members) == 0 {
+			s.ids = []int64{}
+			return nil
+		}
+		removed := newSCIMMemberSet()
+		err := removed.add(members)
+		if err != nil {
+			return err
+		}
+		s.removeWhere(removed.contains)
+		return nil
+	}
+}
@@ -0,0 +1,639 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jessicatarra/greenlight/internal/scim"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newSCIMRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", scim.ContentType)
+	return req
+}
+
+func assertSCIMError(t *testing.T, resRec *httptest.ResponseRecorder, status int, scimType string) {
+	t.Helper()
+
+	assertStatusCode(t, resRec, status)
+
+	if contentType := resRec.Header().Get("Content-Type"); contentType != scim.ContentType {
+		t.Errorf("unexpected content type %q", contentType)
+	}
+
+	var body scim.Error
+	assertResponseBody(t, resRec, &body)
+	if body.Status != fmt.Sprint(status) || body.Type != scimType {
+		t.Errorf("unexpected error body %+v", body)
+	}
+}
+
+func TestService_RequireSCIMClient(t *testing.T) {
+	_, s := setupServiceAndMocks()
+	s.cfg.SCIM.Tokens = []string{"scim-token"}
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		req := newSCIMRequest(http.MethodGet, "/scim/v2/Users", "")
+		req.Header.Set("Authorization", "Bearer scim-token")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		s.requireSCIMClient(okHandler)(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+	})
+
+	for name, header := range map[string]string{"wrong token": "Bearer nope", "basic credentials": "Basic c2NpbTpzY2lt", "no credentials": ""} {
+		t.Run("error - "+name, func(t *testing.T) {
+			// Arrange
+			req := newSCIMRequest(http.MethodGet, "/scim/v2/Users", "")
+			req.Header.Set("Authorization", header)
+			resRec := httptest.NewRecorder()
+
+			// Act
+			s.requireSCIMClient(okHandler)(resRec, req)
+
+			// Assert
+			assertSCIMError(t, resRec, http.StatusUnauthorized, "")
+			if resRec.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Error("expected WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestResource_ListSCIMUsers(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodGet, `/scim/v2/Users?filter=userName+eq+%22john%40example.com%22&startIndex=11&count=5`, "")
+		resRec := httptest.NewRecorder()
+
+		filter := mock.MatchedBy(func(query domain.UserQuery) bool {
+			c, ok := query.(*domain.UserCondition)
+			return ok && c.Field == domain.UserFieldEmail && c.Comparison == domain.CompareEqual && c.Value == "john@example.com"
+		})
+		mockApp.On("SearchUsersUseCase", mock.Anything, filter, 10, 5).
+			Return([]*domain.User{{ID: 2, Name: "John Doe", Email: "john@example.com", Activated: true}}, 11, nil)
+
+		// Act
+		res.listSCIMUsers(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var body struct {
+			TotalResults int        `json:"totalResults"`
+			StartIndex   int        `json:"startIndex"`
+			ItemsPerPage int        `json:"itemsPerPage"`
+			Resources    []scimUser `json:"Resources"`
+		}
+		assertResponseBody(t, resRec, &body)
+		if body.TotalResults != 11 || body.StartIndex != 11 || body.ItemsPerPage != 1 {
+			t.Errorf("unexpected paging %+v", body)
+		}
+		if body.Resources[0].ID != "2" || body.Resources[0].UserName != "john@example.com" || !*body.Resources[0].Active {
+			t.Errorf("unexpected resource %+v", body.Resources[0])
+		}
+	})
+
+	t.Run("error - malformed filter", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodGet, `/scim/v2/Users?filter=userName+like+%22john%22`, "")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.listSCIMUsers(resRec, req)
+
+		// Assert
+		assertSCIMError(t, resRec, http.StatusBadRequest, scim.ErrorInvalidFilter)
+		mockApp.AssertNotCalled(t, "SearchUsersUseCase", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - unsupported attribute", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodGet, `/scim/v2/Users?filter=externalId+eq+%22abc%22`, "")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.listSCIMUsers(resRec, req)
+
+		// Assert
+		assertSCIMError(t, resRec, http.StatusBadRequest, scim.ErrorInvalidFilter)
+		mockApp.AssertNotCalled(t, "SearchUsersUseCase", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - internal server error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodGet, "/scim/v2/Users", "")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("SearchUsersUseCase", mock.Anything, nil, 0, scimDefaultCount).Return(nil, 0, errors.New("some error"))
+
+		// Act
+		res.listSCIMUsers(resRec, req)
+
+		// Assert
+		assertSCIMError(t, resRec, http.StatusInternalServerError, "")
+	})
+}
+
+func TestResource_CreateSCIMUser(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodPost, "/scim/v2/Users", `{
+			"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+			"externalId": "00u1",
+			"userName": "john@example.com",
+			"name": {"givenName": "John", "familyName": "Doe"},
+			"active": true
+		}`)
+		resRec := httptest.NewRecorder()
+
+		user := mock.MatchedBy(func(user *domain.User) bool {
+			return user.Email == "john@example.com" && user.Name == "John Doe" && user.Activated && !user.Suspended
+		})
+		mockApp.On("ProvisionUserUseCase", mock.Anything, user, mock.AnythingOfType("string")).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.User).ID = 7
+		})
+
+		// Act
+		res.createSCIMUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		if location := resRec.Header().Get("Location"); location != "/scim/v2/Users/7" {
+			t.Errorf("unexpected location %q", location)
+		}
+		mockApp.AssertExpectations(t)
+	})
+
+	t.Run("success - inactive", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodPost, "/scim/v2/Users", `{"userName": "john@example.com", "displayName": "John Doe", "active": false}`)
+		resRec := httptest.NewRecorder()
+
+		user := mock.MatchedBy(func(user *domain.User) bool {
+			return !user.Activated && user.Suspended
+		})
+		mockApp.On("ProvisionUserUseCase", mock.Anything, user, mock.AnythingOfType("string")).Return(nil)
+
+		// Act
+		res.createSCIMUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		var body scimUser
+		assertResponseBody(t, resRec, &body)
+		if *body.Active {
+			t.Error("expected inactive user")
+		}
+	})
+
+	t.Run("error - duplicate userName", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodPost, "/scim/v2/Users", `{"userName": "john@example.com", "displayName": "John Doe"}`)
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ProvisionUserUseCase", mock.Anything, mock.Anything, mock.Anything).Return(domain.ErrDuplicateEmail)
+
+		// Act
+		res.createSCIMUser(resRec, req)
+
+		// Assert
+		assertSCIMError(t, resRec, http.StatusConflict, scim.ErrorUniqueness)
+	})
+
+	invalid := map[string]string{
+		"userName is not an email": `{"userName": "jdoe", "displayName": "John Doe"}`,
+		"name missing":             `{"userName": "john@example.com"}`,
+		"weak password":            `{"userName": "john@example.com", "displayName": "John Doe", "password": "short"}`,
+	}
+
+	for name, body := range invalid {
+		t.Run("error - "+name, func(t *testing.T) {
+			// Arrange
+			mockApp, res := setupRouterAndMocks()
+			req := newSCIMRequest(http.MethodPost, "/scim/v2/Users", body)
+			resRec := httptest.NewRecorder()
+
+			// Act
+			res.createSCIMUser(resRec, req)
+
+			// Assert
+			assertSCIMError(t, resRec, http.StatusBadRequest, scim.ErrorInvalidValue)
+			mockApp.AssertNotCalled(t, "ProvisionUserUseCase", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestResource_PatchSCIMUser(t *testing.T) {
+	newUser := func() *domain.User {
+		return &domain.User{ID: 2, Name: "John Doe", Email: "john@example.com", Activated: true, Version: 3}
+	}
+
+	tests := []struct {
+		name       string
+		operations string
+		check      func(user *domain.User) bool
+	}{
+		{
+			name:       "deactivate with a string boolean",
+			operations: `[{"op": "Replace", "path": "active", "value": "False"}]`,
+			check:      func(user *domain.User) bool { return user.Activated && user.Suspended },
+		},
+		{
+			name:       "replace without path",
+			operations: `[{"op": "replace", "value": {"displayName": "Johnny Doe", "externalId": "00u1", "active": true}}]`,
+			check:      func(user *domain.User) bool { return user.Name == "Johnny Doe" && !user.Suspended },
+		},
+		{
+			name:       "work email",
+			operations: `[{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "jd@example.com"}]`,
+			check:      func(user *domain.User) bool { return user.Email == "jd@example.com" },
+		},
+		{
+			name:       "given and family name",
+			operations: `[{"op": "replace", "path": "name.givenName", "value": "Jane"}, {"op": "replace", "path": "name.familyName", "value": "Roe"}]`,
+			check:      func(user *domain.User) bool { return user.Name == "Jane Roe" },
+		},
+		{
+			name:       "ignored attribute",
+			operations: `[{"op": "remove", "path": "title"}, {"op": "add", "path": "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department", "value": "Film"}]`,
+			check: func(user *domain.User) bool {
+				return user.Name == "John Doe" && user.Email == "john@example.com" && !user.Suspended
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run("success - "+tc.name, func(t *testing.T) {
+			// Arrange
+			mockApp, res := setupRouterAndMocks()
+			body := `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": ` + tc.operations + `}`
+			req := withIDParam(newSCIMRequest(http.MethodPatch, "/scim/v2/Users/2", body), "2")
+			resRec := httptest.NewRecorder()
+
+			mockApp.On("GetUserUseCase", mock.Anything, int64(2)).Return(newUser(), nil)
+			mockApp.On("UpdateProvisionedUserUseCase", mock.Anything, mock.MatchedBy(tc.check)).Return(nil)
+
+			// Act
+			res.patchSCIMUser(resRec, req)
+
+			// Assert
+			assertStatusCode(t, resRec, http.StatusOK)
+			mockApp.AssertExpectations(t)
+		})
+	}
+
+	errorCases := []struct {
+		name       string
+		operations string
+		scimType   string
+	}{
+		{name: "remove required attribute", operations: `[{"op": "remove", "path": "userName"}]`, scimType: scim.ErrorInvalidValue},
+		{name: "unknown operation", operations: `[{"op": "move", "path": "userName", "value": "x"}]`, scimType: scim.ErrorInvalidSyntax},
+		{name: "malformed path", operations: `[{"op": "replace", "path": "emails[type eq", "value": "x"}]`, scimType: scim.ErrorInvalidPath},
+		{name: "wrong value type", operations: `[{"op": "replace", "path": "active", "value": 1}]`, scimType: scim.ErrorInvalidValue},
+		{name: "invalid email", operations: `[{"op": "replace", "path": "userName", "value": "jdoe"}]`, scimType: scim.ErrorInvalidValue},
+	}
+
+	for _, tc := range errorCases {
+		t.Run("error - "+tc.name, func(t *testing.T) {
+			// Arrange
+			mockApp, res := setupRouterAndMocks()
+			body := `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": ` + tc.operations + `}`
+			req := withIDParam(newSCIMRequest(http.MethodPatch, "/scim/v2/Users/2", body), "2")
+			resRec := httptest.NewRecorder()
+
+			mockApp.On("GetUserUseCase", mock.Anything, int64(2)).Return(newUser(), nil)
+
+			// Act
+			res.patchSCIMUser(resRec, req)
+
+			// Assert
+			assertSCIMError(t, resRec, http.StatusBadRequest, tc.scimType)
+			mockApp.AssertNotCalled(t, "UpdateProvisionedUserUseCase", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("error - not found", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := withIDParam(newSCIMRequest(http.MethodPatch, "/scim/v2/Users/9", "{}"), "9")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetUserUseCase", mock.Anything, int64(9)).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.patchSCIMUser(resRec, req)
+
+		// Assert
+		assertSCIMError(t, resRec, http.StatusNotFound, "")
+	})
+}
+
+func TestResource_ReplaceSCIMUser(t *testing.T) {
+	// Arrange
+	mockApp, res := setupRouterAndMocks()
+	req := withIDParam(newSCIMRequest(http.MethodPut, "/scim/v2/Users/2", `{"userName": "jd@example.com", "name": {"formatted": "J. Doe"}, "active": true}`), "2")
+	resRec := httptest.NewRecorder()
+
+	mockApp.On("GetUserUseCase", mock.Anything, int64(2)).Return(&domain.User{ID: 2, Name: "John Doe", Email: "john@example.com", Suspended: true}, nil)
+	mockApp.On("UpdateProvisionedUserUseCase", mock.Anything, mock.MatchedBy(func(user *domain.User) bool {
+		return user.Email == "jd@example.com" && user.Name == "J. Doe" && user.Activated && !user.Suspended
+	})).Return(nil)
+
+	// Act
+	res.replaceSCIMUser(resRec, req)
+
+	// Assert
+	assertStatusCode(t, resRec, http.StatusOK)
+	mockApp.AssertExpectations(t)
+}
+
+func TestResource_DeleteSCIMUser(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := withIDParam(newSCIMRequest(http.MethodDelete, "/scim/v2/Users/2", ""), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("DeprovisionUserUseCase", mock.Anything, int64(2)).Return(nil)
+
+		// Act
+		res.deleteSCIMUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusNoContent)
+	})
+
+	t.Run("error - not found", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := withIDParam(newSCIMRequest(http.MethodDelete, "/scim/v2/Users/2", ""), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("DeprovisionUserUseCase", mock.Anything, int64(2)).Return(domain.ErrRecordNotFound)
+
+		// Act
+		res.deleteSCIMUser(resRec, req)
+
+		// Assert
+		assertSCIMError(t, resRec, http.StatusNotFound, "")
+	})
+}
+
+func newSCIMGroups() []*domain.Group {
+	return []*domain.Group{
+		{Permission: domain.Permission{ID: 1, Code: "movies:read"}, Members: []*domain.User{{ID: 1}, {ID: 2}}},
+		{Permission: domain.Permission{ID: 2, Code: "movies:write"}, Members: []*domain.User{{ID: 1, Name: "John Doe"}}},
+	}
+}
+
+func TestResource_ListSCIMGroups(t *testing.T) {
+	t.Run("success - filter and exclude members", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodGet, `/scim/v2/Groups?filter=displayName+eq+%22movies:write%22&excludedAttributes=members`, "")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ListGroupsUseCase", mock.Anything, true).Return(newSCIMGroups(), nil)
+
+		// Act
+		res.listSCIMGroups(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var body struct {
+			TotalResults int         `json:"totalResults"`
+			Resources    []scimGroup `json:"Resources"`
+		}
+		assertResponseBody(t, resRec, &body)
+		if body.TotalResults != 1 || len(body.Resources) != 1 {
+			t.Fatalf("unexpected response %+v", body)
+		}
+		if body.Resources[0].ID != "2" || body.Resources[0].Members != nil {
+			t.Errorf("unexpected resource %+v", body.Resources[0])
+		}
+	})
+
+	t.Run("success - filter on members", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodGet, `/scim/v2/Groups?filter=members%5Bvalue+eq+%222%22%5D`, "")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ListGroupsUseCase", mock.Anything, true).Return(newSCIMGroups(), nil)
+
+		// Act
+		res.listSCIMGroups(resRec, req)
+
+		// Assert
+		var body scim.ListResponse
+		assertResponseBody(t, resRec, &body)
+		if body.TotalResults != 1 {
+			t.Errorf("expected one group, got %d", body.TotalResults)
+		}
+	})
+
+	t.Run("success - paging", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodGet, `/scim/v2/Groups?startIndex=2&count=5&excludedAttributes=members`, "")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ListGroupsUseCase", mock.Anything, false).Return(newSCIMGroups(), nil)
+
+		// Act
+		res.listSCIMGroups(resRec, req)
+
+		// Assert
+		var body scim.ListResponse
+		assertResponseBody(t, resRec, &body)
+		if body.TotalResults != 2 || body.ItemsPerPage != 1 || body.StartIndex != 2 {
+			t.Errorf("unexpected paging %+v", body)
+		}
+	})
+}
+
+func TestResource_PatchSCIMGroup(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations string
+		members    []int64
+	}{
+		{
+			name:       "add members",
+			operations: `[{"op": "Add", "path": "members", "value": [{"value": "3"}, {"value": "1"}]}]`,
+			members:    []int64{1, 3},
+		},
+		{
+			name:       "remove member by filter",
+			operations: `[{"op": "remove", "path": "members[value eq \"1\"]"}]`,
+			members:    []int64{},
+		},
+		{
+			name:       "remove members by value",
+			operations: `[{"op": "remove", "path": "members", "value": [{"value": "1"}]}]`,
+			members:    []int64{},
+		},
+		{
+			name:       "replace without path",
+			operations: `[{"op": "replace", "value": {"id": "2", "displayName": "movies:write", "members": [{"value": "4"}]}}]`,
+			members:    []int64{4},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run("success - "+tc.name, func(t *testing.T) {
+			// Arrange
+			mockApp, res := setupRouterAndMocks()
+			body := `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": ` + tc.operations + `}`
+			req := withIDParam(newSCIMRequest(http.MethodPatch, "/scim/v2/Groups/2", body), "2")
+			resRec := httptest.NewRecorder()
+
+			mockApp.On("GetGroupUseCase", mock.Anything, int64(2), true).Return(newSCIMGroups()[1], nil)
+			mockApp.On("SetGroupMembersUseCase", mock.Anything, int64(2), tc.members).Return(newSCIMGroups()[1], nil)
+
+			// Act
+			res.patchSCIMGroup(resRec, req)
+
+			// Assert
+			assertStatusCode(t, resRec, http.StatusOK)
+			mockApp.AssertExpectations(t)
+		})
+	}
+
+	t.Run("error - rename", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		body := `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "displayName", "value": "editors"}]}`
+		req := withIDParam(newSCIMRequest(http.MethodPatch, "/scim/v2/Groups/2", body), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetGroupUseCase", mock.Anything, int64(2), true).Return(newSCIMGroups()[1], nil)
+
+		// Act
+		res.patchSCIMGroup(resRec, req)
+
+		// Assert
+		assertSCIMError(t, resRec, http.StatusBadRequest, scim.ErrorMutability)
+		mockApp.AssertNotCalled(t, "SetGroupMembersUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - unknown member", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		body := `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "add", "path": "members", "value": [{"value": "99"}]}]}`
+		req := withIDParam(newSCIMRequest(http.MethodPatch, "/scim/v2/Groups/2", body), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetGroupUseCase", mock.Anything, int64(2), true).Return(newSCIMGroups()[1], nil)
+		mockApp.On("SetGroupMembersUseCase", mock.Anything, int64(2), []int64{1, 99}).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.patchSCIMGroup(resRec, req)
+
+		// Assert
+		assertSCIMError(t, resRec, http.StatusBadRequest, scim.ErrorInvalidValue)
+	})
+
+	t.Run("error - group not found", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := withIDParam(newSCIMRequest(http.MethodPatch, "/scim/v2/Groups/9", "{}"), "9")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetGroupUseCase", mock.Anything, int64(9), true).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.patchSCIMGroup(resRec, req)
+
+		// Assert
+		assertSCIMError(t, resRec, http.StatusNotFound, "")
+	})
+}
+
+func TestResource_ReplaceSCIMGroup(t *testing.T) {
+	// Arrange
+	mockApp, res := setupRouterAndMocks()
+	req := withIDParam(newSCIMRequest(http.MethodPut, "/scim/v2/Groups/2", `{"displayName": "movies:write", "members": [{"value": "5"}, {"value": "6"}]}`), "2")
+	resRec := httptest.NewRecorder()
+
+	mockApp.On("GetGroupUseCase", mock.Anything, int64(2), false).Return(newSCIMGroups()[1], nil)
+	mockApp.On("SetGroupMembersUseCase", mock.Anything, int64(2), []int64{5, 6}).Return(newSCIMGroups()[1], nil)
+
+	// Act
+	res.replaceSCIMGroup(resRec, req)
+
+	// Assert
+	assertStatusCode(t, resRec, http.StatusOK)
+	mockApp.AssertExpectations(t)
+}
+
+func TestResource_UnsupportedSCIMGroupOperation(t *testing.T) {
+	// Arrange
+	_, res := setupRouterAndMocks()
+	req := newSCIMRequest(http.MethodPost, "/scim/v2/Groups", `{"displayName": "editors"}`)
+	resRec := httptest.NewRecorder()
+
+	// Act
+	res.unsupportedSCIMGroupOperation(resRec, req)
+
+	// Assert
+	assertSCIMError(t, resRec, http.StatusNotImplemented, "")
+}
+
+func TestResource_SCIMDiscovery(t *testing.T) {
+	t.Run("service provider config", func(t *testing.T) {
+		// Arrange
+		_, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodGet, "/scim/v2/ServiceProviderConfig", "")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.showSCIMServiceProviderConfig(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var body map[string]interface{}
+		assertResponseBody(t, resRec, &body)
+		if body["patch"].(map[string]interface{})["supported"] != true {
+			t.Errorf("expected PATCH to be supported, got %v", body)
+		}
+	})
+
+	t.Run("resource types", func(t *testing.T) {
+		// Arrange
+		_, res := setupRouterAndMocks()
+		req := newSCIMRequest(http.MethodGet, "/scim/v2/ResourceTypes", "")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.listSCIMResourceTypes(resRec, req)
+
+		// Assert
+		var body scim.ListResponse
+		assertResponseBody(t, resRec, &body)
+		if body.TotalResults != 2 {
+			t.Errorf("expected two resource types, got %d", body.TotalResults)
+		}
+	})
+}
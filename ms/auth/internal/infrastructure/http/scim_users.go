@@ -0,0 +1,492 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/request"
+	"github.com/jessicatarra/greenlight/internal/scim"
+	"github.com/jessicatarra/greenlight/internal/utils/validator"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// @Summary List SCIM users
+// @Description Pages through users as SCIM resources, optionally narrowed by a SCIM filter on id, userName, emails, displayName, name.formatted, active or meta.created
+// @Tags SCIM
+// @Produce json
+// @Security SCIMBearer
+// @Param filter query string false "SCIM filter, e.g. userName eq \"john@example.com\""
+// @Param startIndex query int false "1-based index of the first result"
+// @Param count query int false "Number of results per page"
+// @Success 200 {object} scim.ListResponse
+// @Router /scim/v2/Users [get]
+func (h *handlers) listSCIMUsers(res http.ResponseWriter, req *http.Request) {
+	qs := req.URL.Query()
+
+	page, err := scim.ParsePage(qs, scimDefaultCount, scimMaxCount)
+	if err != nil {
+		scimError(res, req, err)
+		return
+	}
+
+	var query domain.UserQuery
+
+	if qs.Get("filter") != "" {
+		filter, err := scim.ParseFilter(qs.Get("filter"))
+		if err != nil {
+			scimError(res, req, err)
+			return
+		}
+
+		query, err = scimUserQuery(filter)
+		if err != nil {
+			scimError(res, req, err)
+			return
+		}
+	}
+
+	users, totalResults, err := h.appl.SearchUsersUseCase(req.Context(), query, page.Offset(), page.Count)
+	if err != nil {
+		scimError(res, req, err)
+		return
+	}
+
+	resources := make([]*scimUser, 0, len(users))
+	for _, user := range users {
+		resources = append(resources, h.newSCIMUser(user))
+	}
+
+	err = scim.WriteJSON(res, http.StatusOK, scim.NewListResponse(resources, totalResults, page, len(resources)), nil)
+	if err != nil {
+		scimError(res, req, err)
+	}
+}
+
+// @Summary Get SCIM user
+// @Tags SCIM
+// @Produce json
+// @Security SCIMBearer
+// @Param id path int true "User ID"
+// @Success 200 {object} scimUser
+// @Router /scim/v2/Users/{id} [get]
+func (h *handlers) showSCIMUser(res http.ResponseWriter, req *http.Request) {
+	user, ok := h.scimUserFromPath(res, req)
+	if !ok {
+		return
+	}
+
+	err := scim.WriteJSON(res, http.StatusOK, h.newSCIMUser(user), nil)
+	if err != nil {
+		scimError(res, req, err)
+	}
+}
+
+// @Summary Provision SCIM user
+// @Description Creates a user on behalf of an identity provider. The account is activated straight away unless active is false, and no welcome email is sent. Without a password the user can only sign in by magic link or passkey.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Security SCIMBearer
+// @Param request body scimUser true "User resource"
+// @Success 201 {object} scimUser
+// @Router /scim/v2/Users [post]
+func (h *handlers) createSCIMUser(res http.ResponseWriter, req *http.Request) {
+	var input scimUser
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		scim.WriteError(res, scim.NewError(http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error()))
+		return
+	}
+
+	active := input.Active == nil || *input.Active
+	user := &domain.User{Name: input.fullName(), Email: input.UserName, Activated: active, Suspended: !active}
+
+	err = h.checkSCIMUser(user, input.Password)
+	if err != nil {
+		scimError(res, req, err)
+		return
+	}
+
+	hashedPassword, err := h.hashSCIMPassword(req.Context(), input.Password)
+	if err != nil {
+		scimError(res, req, err)
+		return
+	}
+
+	err = h.appl.ProvisionUserUseCase(req.Context(), user, hashedPassword)
+	if err != nil {
+		h.scimUserUpdateError(res, req, err)
+		return
+	}
+
+	resource := h.newSCIMUser(user)
+
+	headers := make(http.Header)
+	headers.Set("Location", resource.Meta.Location)
+
+	err = scim.WriteJSON(res, http.StatusCreated, resource, headers)
+	if err != nil {
+		scimError(res, req, err)
+	}
+}
+
+// @Summary Replace SCIM user
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Security SCIMBearer
+// @Param id path int true "User ID"
+// @Param request body scimUser true "User resource"
+// @Success 200 {object} scimUser
+// @Router /scim/v2/Users/{id} [put]
+func (h *handlers) replaceSCIMUser(res http.ResponseWriter, req *http.Request) {
+	user, ok := h.scimUserFromPath(res, req)
+	if !ok {
+		return
+	}
+
+	var input scimUser
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		scim.WriteError(res, scim.NewError(http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error()))
+		return
+	}
+
+	user.Email = input.UserName
+	user.Name = input.fullName()
+	setSCIMActive(user, input.Active == nil || *input.Active)
+
+	h.saveSCIMUser(res, req, user, input.Password)
+}
+
+// @Summary Update SCIM user
+// @Description Applies SCIM PATCH operations to a user. Attributes the service does not store, such as externalId, are accepted and ignored.
+// @Tags SCIM
+// @Accept json
+// @Produce json
+// @Security SCIMBearer
+// @Param id path int true "User ID"
+// @Param request body scim.PatchRequest true "PATCH operations"
+// @Success 200 {object} scimUser
+// @Router /scim/v2/Users/{id} [patch]
+func (h *handlers) patchSCIMUser(res http.ResponseWriter, req *http.Request) {
+	user, ok := h.scimUserFromPath(res, req)
+	if !ok {
+		return
+	}
+
+	var input scim.PatchRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		scim.WriteError(res, scim.NewError(http.StatusBadRequest, scim.ErrorInvalidSyntax, err.Error()))
+		return
+	}
+
+	err = input.Validate()
+	if err != nil {
+		scimError(res, req, err)
+		return
+	}
+
+	patch := scimUserPatch{user: user}
+
+	for _, op := range input.Operations {
+		err = patch.apply(op)
+		if err != nil {
+			scimError(res, req, err)
+			return
+		}
+	}
+
+	patch.finish()
+
+	h.saveSCIMUser(res, req, user, patch.password)
+}
+
+// @Summary Deprovision SCIM user
+// @Description Deletes a user along with their tokens, devices and passkeys
+// @Tags SCIM
+// @Security SCIMBearer
+// @Param id path int true "User ID"
+// @Success 204
+// @Router /scim/v2/Users/{id} [delete]
+func (h *handlers) deleteSCIMUser(res http.ResponseWriter, req *http.Request) {
+	id, err := h.helpers.ReadIDParam(req)
+	if err != nil {
+		scimNotFound(res, "User")
+		return
+	}
+
+	err = h.appl.DeprovisionUserUseCase(req.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			scimNotFound(res, "User")
+		default:
+			scimError(res, req, err)
+		}
+		return
+	}
+
+	res.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handlers) scimUserFromPath(res http.ResponseWriter, req *http.Request) (*domain.User, bool) {
+	id, err := h.helpers.ReadIDParam(req)
+	if err != nil {
+		scimNotFound(res, "User")
+		return nil, false
+	}
+
+	user, err := h.appl.GetUserUseCase(req.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			scimNotFound(res, "User")
+		default:
+			scimError(res, req, err)
+		}
+		return nil, false
+	}
+
+	return user, true
+}
+
+// saveSCIMUser validates and stores a user changed by PUT or PATCH, replacing
+// the password if plaintextPassword is set.
+func (h *handlers) saveSCIMUser(res http.ResponseWriter, req *http.Request, user *domain.User, plaintextPassword string) {
+	err := h.checkSCIMUser(user, plaintextPassword)
+	if err != nil {
+		scimError(res, req, err)
+		return
+	}
+
+	if plaintextPassword != "" {
+		user.HashedPassword, err = h.hasher.Hash(req.Context(), plaintextPassword)
+		if err != nil {
+			scimError(res, req, err)
+			return
+		}
+	}
+
+	err = h.appl.UpdateProvisionedUserUseCase(req.Context(), user)
+	if err != nil {
+		h.scimUserUpdateError(res, req, err)
+		return
+	}
+
+	err = scim.WriteJSON(res, http.StatusOK, h.newSCIMUser(user), nil)
+	if err != nil {
+		scimError(res, req, err)
+	}
+}
+
+func (h *handlers) scimUserUpdateError(res http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, domain.ErrDuplicateEmail):
+		scim.WriteError(res, scim.NewError(http.StatusConflict, scim.ErrorUniqueness, "a user with this userName already exists"))
+	case errors.Is(err, domain.ErrEditConflict):
+		scim.WriteError(res, scim.NewError(http.StatusConflict, "", "the user was modified concurrently, please try again"))
+	default:
+		scimError(res, req, err)
+	}
+}
+
+// checkSCIMUser applies the rules of the registration endpoint to a user
+// coming from a provisioning client.
+func (h *handlers) checkSCIMUser(user *domain.User, plaintextPassword string) error {
+	switch {
+	case !validator.Matches(user.Email, validator.RgxEmail):
+		return scimInvalidValue("userName must be a valid email address")
+	case user.Name == "":
+		return scimInvalidValue("displayName or name must be provided")
+	case len(user.Name) > 500:
+		return scimInvalidValue("name must not be more than 500 bytes long")
+	}
+
+	if plaintextPassword != "" {
+		if _, problem := h.policy.Check(plaintextPassword, user.Name, user.Email); problem != "" {
+			return scimInvalidValue("password: " + problem)
+		}
+	}
+
+	return nil
+}
+
+// hashSCIMPassword hashes the password given by the provisioning client, or
+// a random one nobody knows when it gave none.
+func (h *handlers) hashSCIMPassword(ctx context.Context, plaintextPassword string) (string, error) {
+	if plaintextPassword == "" {
+		b := make([]byte, 32)
+		_, err := rand.Read(b)
+		if err != nil {
+			return "", err
+		}
+		// bcrypt only looks at the first 72 bytes.
+		plaintextPassword = base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	return h.hasher.Hash(ctx, plaintextPassword)
+}
+
+// scimUserPatch applies PATCH operations to a user. Since only the full name
+// is stored, a givenName or familyName on its own replaces the whole name.
+type scimUserPatch struct {
+	user       *domain.User
+	password   string
+	nameSet    bool
+	givenName  *string
+	familyName *string
+}
+
+func (p *scimUserPatch) apply(op scim.PatchOperation) error {
+	if op.Path == "" {
+		var values map[string]json.RawMessage
+		err := decodeSCIMValue(op.Value, &values, "operation without path")
+		if err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			attribute, err := scim.ParseAttributePath(key)
+			if err != nil {
+				return scim.NewError(http.StatusBadRequest, scim.ErrorInvalidPath, err.Error())
+			}
+
+			err = p.set(&scim.Path{Attribute: attribute}, values[key])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	path, err := scim.ParsePath(op.Path)
+	if err != nil {
+		return scim.NewError(http.StatusBadRequest, scim.ErrorInvalidPath, err.Error())
+	}
+
+	if op.Op == scim.PatchRemove {
+		return p.remove(path)
+	}
+
+	return p.set(path, op.Value)
+}
+
+func (p *scimUserPatch) set(path *scim.Path, value json.RawMessage) error {
+	attribute := path.Attribute
+
+	switch {
+	case attribute.Is("userName"), attribute.Is("emails.value"):
+		return decodeSCIMValue(value, &p.user.Email, attribute.String())
+	case attribute.Is("emails") && path.Filter != nil:
+		// emails[type eq "work"].value: there is only one address to match.
+		if strings.EqualFold(path.SubAttribute, "value") {
+			return decodeSCIMValue(value, &p.user.Email, "emails.value")
+		}
+		var email scimEmail
+		err := decodeSCIMValue(value, &email, "emails")
+		if err != nil {
+			return err
+		}
+		p.user.Email = email.Value
+	case attribute.Is("emails"):
+		var emails []scimEmail
+		err := decodeSCIMValue(value, &emails, "emails")
+		if err != nil {
+			return err
+		}
+		for i, email := range emails {
+			if i == 0 || email.Primary {
+				p.user.Email = email.Value
+			}
+		}
+	case attribute.Is("displayName"), attribute.Is("name.formatted"):
+		p.nameSet = true
+		return decodeSCIMValue(value, &p.user.Name, attribute.String())
+	case attribute.Is("name.givenName"):
+		p.givenName = new(string)
+		return decodeSCIMValue(value, p.givenName, "name.givenName")
+	case attribute.Is("name.familyName"):
+		p.familyName = new(string)
+		return decodeSCIMValue(value, p.familyName, "name.familyName")
+	case attribute.Is("name"):
+		var name scimName
+		err := decodeSCIMValue(value, &name, "name")
+		if err != nil {
+			return err
+		}
+		if name.Formatted != "" {
+			p.user.Name, p.nameSet = name.Formatted, true
+		}
+		if name.GivenName != "" {
+			p.givenName = &name.GivenName
+		}
+		if name.FamilyName != "" {
+			p.familyName = &name.FamilyName
+		}
+	case attribute.Is("active"):
+		// Some clients send booleans as "True" and "False".
+		var active any
+		err := decodeSCIMValue(value, &active, "active")
+		if err != nil {
+			return err
+		}
+		switch v := active.(type) {
+		case bool:
+			setSCIMActive(p.user, v)
+		case string:
+			if !strings.EqualFold(v, "true") && !strings.EqualFold(v, "false") {
+				return scimInvalidValue("invalid value for active")
+			}
+			setSCIMActive(p.user, strings.EqualFold(v, "true"))
+		default:
+			return scimInvalidValue("invalid value for active")
+		}
+	case attribute.Is("password"):
+		return decodeSCIMValue(value, &p.password, "password")
+	}
+
+	return nil
+}
+
+func (p *scimUserPatch) remove(path *scim.Path) error {
+	for _, required := range []string{"userName", "emails", "displayName", "name", "active", "password"} {
+		if strings.EqualFold(path.Attribute.Name, required) {
+			return scimInvalidValue(path.Attribute.String() + " cannot be removed")
+		}
+	}
+
+	return nil
+}
+
+// finish settles the name once all operations have been applied.
+func (p *scimUserPatch) finish() {
+	if p.nameSet || (p.givenName == nil && p.familyName == nil) {
+		return
+	}
+
+	var parts []string
+	for _, part := range []*string{p.givenName, p.familyName} {
+		if part != nil && *part != "" {
+			parts = append(parts, *part)
+		}
+	}
+
+	p.user.Name = strings.Join(parts, " ")
+}
@@ -2,10 +2,22 @@ package http
 
 import (
 	"github.com/jessicatarra/greenlight/internal/config"
+	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/internal/password"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	"github.com/julienschmidt/httprouter"
 	"log/slog"
 	"net/http"
+	"time"
+)
+
+const (
+	defaultPasswordMinLength = 8
+	// bcrypt ignores everything past the first 72 bytes of a password.
+	maxPasswordLength = 72
+	// hashingRetryAfter is how long clients turned away by a full password
+	// hashing queue are asked to wait, about the time a few hashes take.
+	hashingRetryAfter = time.Second
 )
 
 type Service interface {
@@ -16,13 +28,40 @@ type Service interface {
 type service struct {
 	appl   domain.Appl
 	cfg    config.Config
+	hasher *password.Hasher
+	mailer mailer.Mailer
 	logger *slog.Logger
 }
 
-func NewService(appl domain.Appl, cfg config.Config, logger *slog.Logger) Service {
+func NewService(appl domain.Appl, cfg config.Config, hasher *password.Hasher, mail mailer.Mailer, logger *slog.Logger) Service {
 	return &service{
 		appl:   appl,
 		cfg:    cfg,
+		hasher: hasher,
+		mailer: mail,
 		logger: logger,
 	}
 }
+
+func (s service) sessionCookies() sessionCookies {
+	sameSite := http.SameSiteLaxMode
+	if s.cfg.Sessions.SameSite == "strict" {
+		sameSite = http.SameSiteStrictMode
+	}
+
+	return sessionCookies{domain: s.cfg.Sessions.CookieDomain, sameSite: sameSite}
+}
+
+func (s service) passwordPolicy() *password.Policy {
+	minLength := s.cfg.Password.MinLength
+	if minLength == 0 {
+		minLength = defaultPasswordMinLength
+	}
+
+	maxLength := s.cfg.Password.MaxLength
+	if maxLength == 0 || maxLength > maxPasswordLength {
+		maxLength = maxPasswordLength
+	}
+
+	return password.NewStandardPolicy(minLength, maxLength, s.cfg.Password.MinScore)
+}
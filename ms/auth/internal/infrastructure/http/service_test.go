@@ -5,6 +5,8 @@ package http
 
 import (
 	"github.com/jessicatarra/greenlight/internal/config"
+	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/internal/password"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
 	"github.com/stretchr/testify/assert"
 	"log/slog"
@@ -17,7 +19,7 @@ func TestNewService(t *testing.T) {
 	mockCfg := config.Config{}
 	mockLogger := slog.Logger{}
 
-	service := NewService(mockAppl, mockCfg, &mockLogger)
+	service := NewService(mockAppl, mockCfg, password.NewHasher(nil, "", nil), mailer.Mailer{}, &mockLogger)
 
 	assert.NotNil(t, service)
 }
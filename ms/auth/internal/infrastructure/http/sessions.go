@@ -0,0 +1,220 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/middleware"
+	"github.com/jessicatarra/greenlight/internal/request"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// sessionCookies holds the attributes shared by the session and CSRF cookies.
+type sessionCookies struct {
+	domain   string
+	sameSite http.SameSite
+}
+
+// set stores session in an HttpOnly cookie next to a fresh CSRF token, which
+// scripts on the page can read and must send back with state-changing
+// requests. It returns the CSRF token.
+func (c sessionCookies) set(res http.ResponseWriter, session *domain.Token) (string, error) {
+	randomBytes := make([]byte, 32)
+
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	csrfToken := base64.RawURLEncoding.EncodeToString(randomBytes)
+
+	http.SetCookie(res, c.cookie(middleware.SessionCookieName, session.Plaintext, session.Expiry, true))
+	http.SetCookie(res, c.cookie(middleware.CSRFCookieName, csrfToken, session.Expiry, false))
+
+	return csrfToken, nil
+}
+
+func (c sessionCookies) clear(res http.ResponseWriter) {
+	for _, name := range []string{middleware.SessionCookieName, middleware.CSRFCookieName} {
+		cookie := c.cookie(name, "", time.Unix(0, 0), name == middleware.SessionCookieName)
+		cookie.MaxAge = -1
+		http.SetCookie(res, cookie)
+	}
+}
+
+func (c sessionCookies) cookie(name, value string, expires time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   c.domain,
+		Expires:  expires,
+		HttpOnly: httpOnly,
+		Secure:   true,
+		SameSite: c.sameSite,
+	}
+}
+
+// @Summary Sign in with a session cookie
+// @Description Checks the user's credentials and starts a server-side session held in an HttpOnly cookie. State-changing requests authenticated by the cookie must send the returned CSRF token, which is also set in the greenlight_csrf cookie, in the X-CSRF-Token header. Only available when cookie sessions are enabled.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.CreateAuthTokenRequest true "Request body"
+// @Success 201 {object} map[string]interface{} "Signed-in user and CSRF token"
+// @Router /sessions [post]
+func (h *handlers) createSession(res http.ResponseWriter, req *http.Request) {
+	var input domain.CreateAuthTokenRequest
+
+	err := request.DecodeJSON(res, req, &input)
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	existingUser, ok := h.checkCredentials(res, req, &input)
+	if !ok {
+		return
+	}
+
+	session, err := h.appl.CreateSessionUseCase(req.Context(), existingUser.ID)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	// Failing to record the device must not stop the user from signing in.
+	err = h.appl.RecordSignInUseCase(req.Context(), existingUser, clientIP(req, h.clientIPHeader), req.UserAgent())
+	if err != nil {
+		_errors.ReportServerError(req, err)
+	}
+
+	csrfToken, err := h.cookies.set(res, session)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	env := envelope{"user": existingUser, "csrf_token": csrfToken, "expiry": session.Expiry}
+
+	err = response.JSON(res, http.StatusCreated, env)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Sign out of a cookie session
+// @Description Ends the session held in the session cookie and clears the session cookies. Requires the X-CSRF-Token header.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /sessions [delete]
+func (h *handlers) deleteSession(res http.ResponseWriter, req *http.Request) {
+	if cookie, err := req.Cookie(middleware.SessionCookieName); err == nil {
+		err = h.appl.DeleteSessionUseCase(req.Context(), cookie.Value)
+		if err != nil {
+			_errors.ServerError(res, req, err)
+			return
+		}
+	}
+
+	h.cookies.clear(res)
+
+	err := response.JSON(res, http.StatusOK, envelope{"message": "you have been signed out"})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Sign out everywhere landing page
+// @Description Renders the page linked from the new sign-in email. Sessions are only revoked when the page's form is submitted, so that link scanners fetching the URL have no effect.
+// @Tags Users
+// @Produce html
+// @Param token query string true "Token from the new sign-in email"
+// @Success 200
+// @Router /sessions/revoke [get]
+func (h *handlers) showRevokeSessionsPage(res http.ResponseWriter, req *http.Request) {
+	var input domain.RevokeSessionsRequest
+
+	input.TokenPlaintext = h.helpers.ReadString(req.URL.Query(), "token", "")
+
+	ValidateRevokeSessionsToken(&input)
+
+	if input.Validator.HasErrors() {
+		h.renderPage(res, req, http.StatusBadRequest, "revoke_sessions_expired.gohtml", nil)
+		return
+	}
+
+	h.renderPage(res, req, http.StatusOK, "revoke_sessions.gohtml", map[string]interface{}{
+		"token": input.TokenPlaintext,
+	})
+}
+
+// @Summary Sign out everywhere
+// @Description Revokes every authentication token of the user the link was sent to and forgets their known devices
+// @Tags Users
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param token formData string true "Token from the new sign-in email"
+// @Success 200
+// @Router /sessions/revoke [post]
+func (h *handlers) revokeSessions(res http.ResponseWriter, req *http.Request) {
+	var input domain.RevokeSessionsRequest
+
+	err := req.ParseForm()
+	if err != nil {
+		_errors.BadRequest(res, req, err)
+		return
+	}
+
+	input.TokenPlaintext = req.PostForm.Get("token")
+
+	ValidateRevokeSessionsToken(&input)
+
+	if input.Validator.HasErrors() {
+		h.renderPage(res, req, http.StatusBadRequest, "revoke_sessions_expired.gohtml", nil)
+		return
+	}
+
+	user, err := h.appl.RevokeSessionsUseCase(req.Context(), input.TokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			h.renderPage(res, req, http.StatusBadRequest, "revoke_sessions_expired.gohtml", nil)
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	h.renderPage(res, req, http.StatusOK, "revoke_sessions_success.gohtml", map[string]interface{}{
+		"name": user.Name,
+	})
+}
+
+// clientIP returns the address a request came from. When the service runs
+// behind a proxy that reports the client address in header, the first address
+// listed there wins; otherwise the connection's remote address is used.
+func clientIP(req *http.Request, header string) net.IP {
+	if header != "" {
+		if value := req.Header.Get(header); value != "" {
+			first, _, _ := strings.Cut(value, ",")
+			if ip := net.ParseIP(strings.TrimSpace(first)); ip != nil {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	return net.ParseIP(host)
+}
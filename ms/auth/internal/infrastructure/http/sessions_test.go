@@ -0,0 +1,280 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"bytes"
+	"errors"
+	"github.com/jessicatarra/greenlight/internal/middleware"
+	"github.com/jessicatarra/greenlight/internal/password"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const revokeSessionsToken = "GQRPVONORIEUPDJ6V4RTDIVSTQ"
+
+func newRevokeSessionsForm(token string) *http.Request {
+	form := url.Values{"token": {token}}
+	req := httptest.NewRequest(http.MethodPost, "/v1/sessions/revoke", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestResource_ShowRevokeSessionsPage(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/v1/sessions/revoke?token="+revokeSessionsToken, nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.showRevokeSessionsPage(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assertHTMLContains(t, resRec, `value="`+revokeSessionsToken+`"`)
+		mockApp.AssertNotCalled(t, "RevokeSessionsUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("success - form carries the CSRF token of a cookie session", func(t *testing.T) {
+		// Arrange
+		_, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/v1/sessions/revoke?token="+revokeSessionsToken, nil)
+		req.AddCookie(&http.Cookie{Name: middleware.CSRFCookieName, Value: "csrf-token"})
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.showRevokeSessionsPage(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assertHTMLContains(t, resRec, `name="csrf_token" value="csrf-token"`)
+	})
+
+	t.Run("error - malformed token", func(t *testing.T) {
+		// Arrange
+		_, res := setupRouterAndMocks()
+		req := httptest.NewRequest(http.MethodGet, "/v1/sessions/revoke?token=short", nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.showRevokeSessionsPage(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+	})
+}
+
+func TestResource_RevokeSessions(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("RevokeSessionsUseCase", mock.Anything, revokeSessionsToken).Return(&domain.User{ID: 1, Name: "John Doe"}, nil)
+
+		// Act
+		res.revokeSessions(resRec, newRevokeSessionsForm(revokeSessionsToken))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		assertHTMLContains(t, resRec, "John Doe")
+	})
+
+	t.Run("error - token already used or expired", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("RevokeSessionsUseCase", mock.Anything, revokeSessionsToken).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.revokeSessions(resRec, newRevokeSessionsForm(revokeSessionsToken))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+	})
+
+	t.Run("error - malformed token", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.revokeSessions(resRec, newRevokeSessionsForm("short"))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+		mockApp.AssertNotCalled(t, "RevokeSessionsUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - internal server error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("RevokeSessionsUseCase", mock.Anything, revokeSessionsToken).Return(nil, errors.New("some error"))
+
+		// Act
+		res.revokeSessions(resRec, newRevokeSessionsForm(revokeSessionsToken))
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+}
+
+func TestResource_AuthenticationTokenRecordsSignIn(t *testing.T) {
+	t.Run("success - sign-in bookkeeping failure does not fail the request", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		hashedPassword, _ := password.Hash("password123")
+		expectedUser := &domain.User{ID: 1, Email: "johndoe@example.com", HashedPassword: hashedPassword, Activated: true}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokens/authentication", bytes.NewBufferString(`{"email": "johndoe@example.com", "password": "password123"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "Firefox/118.0")
+		req.RemoteAddr = "203.0.113.7:51234"
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+		mockApp.On("CreateAuthTokenUseCase", mock.Anything, expectedUser.ID).Return([]byte("thisisasecreT"), nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, expectedUser, net.ParseIP("203.0.113.7"), "Firefox/118.0").Return(errors.New("some error"))
+
+		// Act
+		res.createAuthenticationToken(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		mockApp.AssertExpectations(t)
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		remoteAddr string
+		want       string
+	}{
+		{name: "remote address", remoteAddr: "203.0.113.7:51234", want: "203.0.113.7"},
+		{name: "header ignored when not configured", value: "198.51.100.1", remoteAddr: "203.0.113.7:51234", want: "203.0.113.7"},
+		{name: "configured header", header: "X-Forwarded-For", value: "198.51.100.1, 10.0.0.1", remoteAddr: "10.0.0.1:51234", want: "198.51.100.1"},
+		{name: "malformed header", header: "X-Forwarded-For", value: "unknown", remoteAddr: "203.0.113.7:51234", want: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.value != "" {
+				req.Header.Set("X-Forwarded-For", tt.value)
+			}
+
+			got := clientIP(req, tt.header)
+
+			if !got.Equal(net.ParseIP(tt.want)) {
+				t.Errorf("clientIP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResource_CreateSession(t *testing.T) {
+	newLoginRequest := func(pw string) *http.Request {
+		body := `{"email": "johndoe@example.com", "password": "` + pw + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/sessions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		res.(*handlers).cookies = sessionCookies{domain: "example.com", sameSite: http.SameSiteStrictMode}
+		hashedPassword, _ := password.Hash("password123")
+		expectedUser := &domain.User{ID: 1, Email: "johndoe@example.com", HashedPassword: hashedPassword, Activated: true}
+		session := &domain.Token{Plaintext: revokeSessionsToken, UserID: 1, Expiry: time.Now().Add(time.Hour)}
+		req := newLoginRequest("password123")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+		mockApp.On("CreateSessionUseCase", mock.Anything, expectedUser.ID).Return(session, nil)
+		mockApp.On("RecordSignInUseCase", mock.Anything, expectedUser, mock.Anything, mock.Anything).Return(nil)
+
+		// Act
+		res.createSession(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+
+		var responseBody map[string]interface{}
+		assertResponseBody(t, resRec, &responseBody)
+
+		cookies := map[string]*http.Cookie{}
+		for _, cookie := range resRec.Result().Cookies() {
+			cookies[cookie.Name] = cookie
+		}
+
+		sessionCookie := cookies[middleware.SessionCookieName]
+		if sessionCookie == nil || sessionCookie.Value != session.Plaintext || !sessionCookie.HttpOnly || !sessionCookie.Secure ||
+			sessionCookie.SameSite != http.SameSiteStrictMode || sessionCookie.Domain != "example.com" {
+			t.Errorf("unexpected session cookie %v", sessionCookie)
+		}
+
+		csrfCookie := cookies[middleware.CSRFCookieName]
+		if csrfCookie == nil || csrfCookie.HttpOnly || !csrfCookie.Secure || csrfCookie.Value != responseBody["csrf_token"] {
+			t.Errorf("unexpected CSRF cookie %v for body %v", csrfCookie, responseBody)
+		}
+	})
+
+	t.Run("error - wrong password", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		hashedPassword, _ := password.Hash("password123")
+		expectedUser := &domain.User{ID: 1, Email: "johndoe@example.com", HashedPassword: hashedPassword, Activated: true}
+		req := newLoginRequest("password124")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("GetByEmailUseCase", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
+
+		// Act
+		res.createSession(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		if len(resRec.Result().Cookies()) != 0 {
+			t.Error("expected no cookies to be set")
+		}
+		mockApp.AssertNotCalled(t, "CreateSessionUseCase", mock.Anything, mock.Anything)
+	})
+}
+
+func TestResource_DeleteSession(t *testing.T) {
+	// Arrange
+	mockApp, res := setupRouterAndMocks()
+	req := httptest.NewRequest(http.MethodDelete, "/v1/sessions", nil)
+	req.AddCookie(&http.Cookie{Name: middleware.SessionCookieName, Value: "session"})
+	resRec := httptest.NewRecorder()
+
+	mockApp.On("DeleteSessionUseCase", mock.Anything, "session").Return(nil)
+
+	// Act
+	res.deleteSession(resRec, req)
+
+	// Assert
+	assertStatusCode(t, resRec, http.StatusOK)
+	cookies := resRec.Result().Cookies()
+	if len(cookies) != 2 || cookies[0].MaxAge != -1 || cookies[1].MaxAge != -1 {
+		t.Errorf("expected both session cookies to be cleared, got %v", cookies)
+	}
+	mockApp.AssertExpectations(t)
+}
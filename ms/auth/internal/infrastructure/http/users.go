@@ -0,0 +1,180 @@
+package http
+
+import (
+	"errors"
+	_errors "github.com/jessicatarra/greenlight/internal/errors"
+	"github.com/jessicatarra/greenlight/internal/response"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"net/http"
+	"time"
+)
+
+// @Summary List users
+// @Description Pages through users, optionally filtered by email, activation state, suspension state and signup date
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param email query string false "Email (partial match)"
+// @Param activated query bool false "Activation state"
+// @Param suspended query bool false "Suspension state"
+// @Param created_after query string false "Signed up on or after this date (YYYY-MM-DD)"
+// @Param created_before query string false "Signed up before this date (YYYY-MM-DD)"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Number of users per page"
+// @Param sort query string false "Sort order"
+// @Success 200 {object} []domain.User "User list"
+// @Router /admin/users [get]
+func (h *handlers) listUsers(res http.ResponseWriter, req *http.Request) {
+	var input domain.ListUsersRequest
+
+	qs := req.URL.Query()
+
+	input.Email = h.helpers.ReadString(qs, "email", "")
+	input.Activated = h.helpers.ReadBool(qs, "activated", &input.Validator)
+	input.Suspended = h.helpers.ReadBool(qs, "suspended", &input.Validator)
+	input.CreatedAfter = h.helpers.ReadTime(qs, "created_after", time.DateOnly, &input.Validator)
+	input.CreatedBefore = h.helpers.ReadTime(qs, "created_before", time.DateOnly, &input.Validator)
+
+	input.Filters.Page = h.helpers.ReadInt(qs, "page", 1, &input.Validator)
+	input.Filters.PageSize = h.helpers.ReadInt(qs, "page_size", 20, &input.Validator)
+	input.Filters.Sort = h.helpers.ReadString(qs, "sort", "id")
+	input.Filters.SortSafelist = []string{"id", "email", "created_at", "-id", "-email", "-created_at"}
+
+	ValidateListUsers(&input)
+
+	if input.Validator.HasErrors() {
+		_errors.FailedValidation(res, req, input.Validator)
+		return
+	}
+
+	users, metadata, err := h.appl.ListUsersUseCase(req.Context(), input.UserFilter, input.Filters)
+	if err != nil {
+		_errors.ServerError(res, req, err)
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"users": users, "metadata": metadata})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Suspend user
+// @Description Suspends a user account. Suspended users cannot log in and their authentication tokens are rejected.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} domain.User
+// @Router /admin/users/{id}/suspend [post]
+func (h *handlers) suspendUser(res http.ResponseWriter, req *http.Request) {
+	id, err := h.helpers.ReadIDParam(req)
+	if err != nil {
+		_errors.NotFound(res, req)
+		return
+	}
+
+	if id == contextGetUser(req).ID {
+		_errors.BadRequest(res, req, errors.New("you cannot suspend your own account"))
+		return
+	}
+
+	user, err := h.appl.SuspendUserUseCase(req.Context(), id)
+	if err != nil {
+		h.userUpdateError(res, req, err)
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"user": user})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Reactivate user
+// @Description Lifts the suspension of a user account
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} domain.User
+// @Router /admin/users/{id}/reactivate [post]
+func (h *handlers) reactivateUser(res http.ResponseWriter, req *http.Request) {
+	id, err := h.helpers.ReadIDParam(req)
+	if err != nil {
+		_errors.NotFound(res, req)
+		return
+	}
+
+	user, err := h.appl.ReactivateUserUseCase(req.Context(), id)
+	if err != nil {
+		h.userUpdateError(res, req, err)
+		return
+	}
+
+	err = response.JSON(res, http.StatusOK, envelope{"user": user})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+// @Summary Impersonate user
+// @Description Issues a short-lived authentication token for a user. The token carries an act claim identifying the admin it was issued to, and stops working once that admin loses the users:admin permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Success 201 {object} domain.Token "Authentication token"
+// @Router /admin/users/{id}/impersonate [post]
+func (h *handlers) impersonateUser(res http.ResponseWriter, req *http.Request) {
+	id, err := h.helpers.ReadIDParam(req)
+	if err != nil {
+		_errors.NotFound(res, req)
+		return
+	}
+
+	admin := contextGetUser(req)
+
+	if admin.ActorID != 0 {
+		_errors.NotPermitted(res, req)
+		return
+	}
+
+	if id == admin.ID {
+		_errors.BadRequest(res, req, errors.New("you cannot impersonate your own account"))
+		return
+	}
+
+	jwtBytes, err := h.appl.ImpersonateUseCase(req.Context(), id, admin.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRecordNotFound):
+			_errors.NotFound(res, req)
+		case errors.Is(err, domain.ErrAccountSuspended):
+			_errors.BadRequest(res, req, errors.New("you cannot impersonate a suspended account"))
+		default:
+			_errors.ServerError(res, req, err)
+		}
+		return
+	}
+
+	err = response.JSON(res, http.StatusCreated, envelope{"authentication_token": string(jwtBytes)})
+	if err != nil {
+		_errors.ServerError(res, req, err)
+	}
+}
+
+func (h *handlers) userUpdateError(res http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, domain.ErrRecordNotFound):
+		_errors.NotFound(res, req)
+	case errors.Is(err, domain.ErrEditConflict):
+		_errors.EditConflict(res, req)
+	default:
+		_errors.ServerError(res, req, err)
+	}
+}
@@ -0,0 +1,291 @@
+//go:build auth
+// +build auth
+
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func withIDParam(req *http.Request, id string) *http.Request {
+	params := httprouter.Params{{Key: "id", Value: id}}
+	return req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, params))
+}
+
+func TestResource_ListUsers(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		expectedUsers := []*domain.User{{ID: 2, Email: "john@example.com", Activated: true, Suspended: true}}
+		activated := true
+		suspended := true
+		expectedFilter := domain.UserFilter{Email: "example.com", Activated: &activated, Suspended: &suspended}
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/users?email=example.com&activated=true&suspended=true&sort=-created_at", nil)
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ListUsersUseCase", mock.Anything, expectedFilter, mock.MatchedBy(func(f domain.Filters) bool {
+			return f.Page == 1 && f.PageSize == 20 && f.Sort == "-created_at"
+		})).Return(expectedUsers, domain.CalculateMetadata(1, 1, 20), nil)
+
+		// Act
+		res.listUsers(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var responseBody struct {
+			Users    []*domain.User  `json:"users"`
+			Metadata domain.Metadata `json:"metadata"`
+		}
+		assertResponseBody(t, resRec, &responseBody)
+		if len(responseBody.Users) != 1 || !responseBody.Users[0].Suspended {
+			t.Errorf("unexpected users in response body: %v", responseBody.Users)
+		}
+		if responseBody.Metadata.TotalRecords != 1 {
+			t.Errorf("unexpected metadata in response body: %v", responseBody.Metadata)
+		}
+	})
+
+	t.Run("error - failed validation", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/users?activated=maybe&created_after=yesterday&sort=password_hash", nil)
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.listUsers(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusUnprocessableEntity)
+		mockApp.AssertNotCalled(t, "ListUsersUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - ListUsersUseCase return error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/users", nil)
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ListUsersUseCase", mock.Anything, mock.Anything, mock.Anything).Return(nil, domain.Metadata{}, errors.New("error"))
+
+		// Act
+		res.listUsers(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+}
+
+func TestResource_SuspendUser(t *testing.T) {
+	admin := &domain.User{ID: 1, Name: "Admin", Email: "admin@example.com", Activated: true}
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		expectedUser := &domain.User{ID: 2, Email: "john@example.com", Activated: true, Suspended: true}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/2/suspend", nil)
+		req = withIDParam(contextSetUser(req, admin), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("SuspendUserUseCase", mock.Anything, int64(2)).Return(expectedUser, nil)
+
+		// Act
+		res.suspendUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var responseBody map[string]*domain.User
+		assertResponseBody(t, resRec, &responseBody)
+		assertUserFields(t, responseBody, expectedUser)
+	})
+
+	t.Run("error - invalid id", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/abc/suspend", nil)
+		req = withIDParam(contextSetUser(req, admin), "abc")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.suspendUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusNotFound)
+		mockApp.AssertNotCalled(t, "SuspendUserUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - suspend own account", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/1/suspend", nil)
+		req = withIDParam(contextSetUser(req, admin), "1")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.suspendUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+		mockApp.AssertNotCalled(t, "SuspendUserUseCase", mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - user not found", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/2/suspend", nil)
+		req = withIDParam(contextSetUser(req, admin), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("SuspendUserUseCase", mock.Anything, int64(2)).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.suspendUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusNotFound)
+	})
+
+	t.Run("error - edit conflict", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/2/suspend", nil)
+		req = withIDParam(contextSetUser(req, admin), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("SuspendUserUseCase", mock.Anything, int64(2)).Return(nil, domain.ErrEditConflict)
+
+		// Act
+		res.suspendUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusConflict)
+	})
+}
+
+func TestResource_ReactivateUser(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		expectedUser := &domain.User{ID: 2, Email: "john@example.com", Activated: true}
+
+		req := withIDParam(httptest.NewRequest(http.MethodPost, "/v1/admin/users/2/reactivate", nil), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ReactivateUserUseCase", mock.Anything, int64(2)).Return(expectedUser, nil)
+
+		// Act
+		res.reactivateUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusOK)
+		var responseBody map[string]*domain.User
+		assertResponseBody(t, resRec, &responseBody)
+		assertUserFields(t, responseBody, expectedUser)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := withIDParam(httptest.NewRequest(http.MethodPost, "/v1/admin/users/2/reactivate", nil), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ReactivateUserUseCase", mock.Anything, int64(2)).Return(nil, errors.New("error"))
+
+		// Act
+		res.reactivateUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusInternalServerError)
+	})
+}
+
+func TestResource_ImpersonateUser(t *testing.T) {
+	admin := &domain.User{ID: 1, Name: "Admin", Email: "admin@example.com", Activated: true}
+
+	t.Run("success", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/2/impersonate", nil)
+		req = withIDParam(contextSetUser(req, admin), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ImpersonateUseCase", mock.Anything, int64(2), admin.ID).Return([]byte("impersonation.token"), nil)
+
+		// Act
+		res.impersonateUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusCreated)
+		var responseBody map[string]string
+		assertResponseBody(t, resRec, &responseBody)
+		if responseBody["authentication_token"] != "impersonation.token" {
+			t.Errorf("unexpected token in response body: %v", responseBody)
+		}
+	})
+
+	t.Run("error - impersonate own account", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/1/impersonate", nil)
+		req = withIDParam(contextSetUser(req, admin), "1")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.impersonateUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusBadRequest)
+		mockApp.AssertNotCalled(t, "ImpersonateUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - already impersonating", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+		impersonated := &domain.User{ID: 3, Activated: true, ActorID: admin.ID}
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/2/impersonate", nil)
+		req = withIDParam(contextSetUser(req, impersonated), "2")
+		resRec := httptest.NewRecorder()
+
+		// Act
+		res.impersonateUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusForbidden)
+		mockApp.AssertNotCalled(t, "ImpersonateUseCase", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("error - user not found", func(t *testing.T) {
+		// Arrange
+		mockApp, res := setupRouterAndMocks()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/users/2/impersonate", nil)
+		req = withIDParam(contextSetUser(req, admin), "2")
+		resRec := httptest.NewRecorder()
+
+		mockApp.On("ImpersonateUseCase", mock.Anything, int64(2), admin.ID).Return(nil, domain.ErrRecordNotFound)
+
+		// Act
+		res.impersonateUser(resRec, req)
+
+		// Assert
+		assertStatusCode(t, resRec, http.StatusNotFound)
+	})
+}
@@ -1,25 +1,35 @@
 package http
 
 import (
+	"github.com/jessicatarra/greenlight/internal/mailer"
 	"github.com/jessicatarra/greenlight/internal/password"
 	"github.com/jessicatarra/greenlight/internal/utils/validator"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"time"
 )
 
-func ValidateUser(input *domain.CreateUserRequest, existingUser *domain.User) {
+const maxInvitationTTL = 30 * 24 * time.Hour
+
+func ValidateUser(input *domain.CreateUserRequest, existingUser *domain.User, policy *password.Policy) {
 	input.Validator.CheckField(input.Name != "", "name", "must be provided")
 	input.Validator.CheckField(len(input.Name) <= 500, "name", "must not be more than 500 bytes long")
 
 	ValidateEmail(input, existingUser)
 
-	ValidatePassword(input)
+	ValidatePassword(&input.Validator, policy, "Password", input.Password, input.Name, input.Email)
+
+	ValidateInvitationToken(input)
 }
 
-func ValidatePassword(input *domain.CreateUserRequest) {
-	input.Validator.CheckField(input.Password != "", "Password", "Password is required")
-	input.Validator.CheckField(len(input.Password) >= 8, "Password", "Password is too short")
-	input.Validator.CheckField(len(input.Password) <= 72, "Password", "Password is too long")
-	input.Validator.CheckField(validator.NotIn(input.Password, password.CommonPasswords...), "Password", "Password is too common")
+// ValidatePassword checks plaintext against policy, treating userInputs as
+// easily guessed words. A rejected password also gets its strength score and
+// improvement hints in the validation response.
+func ValidatePassword(v *validator.Validator, policy *password.Policy, key string, plaintext string, userInputs ...string) {
+	strength, problem := policy.Check(plaintext, userInputs...)
+	if problem != "" {
+		v.AddFieldError(key, problem)
+		v.AddFieldDetail(key, strength)
+	}
 }
 
 func ValidateEmail(input *domain.CreateUserRequest, existingUser *domain.User) {
@@ -33,6 +43,11 @@ func ValidateToken(input *domain.ActivateUserRequest) {
 	input.Validator.Check(len(input.TokenPlaintext) == 26, "token must be 26 bytes long")
 }
 
+func ValidateRevokeSessionsToken(input *domain.RevokeSessionsRequest) {
+	input.Validator.Check(input.TokenPlaintext != "", "token must be provided")
+	input.Validator.Check(len(input.TokenPlaintext) == 26, "token must be 26 bytes long")
+}
+
 func ValidateEmailForAuth(input *domain.CreateAuthTokenRequest, existingUser *domain.User) {
 	input.Validator.CheckField(input.Email != "", "Email", "Email is required")
 	input.Validator.CheckField(existingUser != nil, "Email", "Email address could not be found")
@@ -42,3 +57,118 @@ func ValidatePasswordForAuth(input *domain.CreateAuthTokenRequest, passwordMatch
 	input.Validator.CheckField(input.Password != "", "Password", "Password is required")
 	input.Validator.CheckField(passwordMatches, "Password", "Password is incorrect")
 }
+
+func ValidatePasswordResetEmail(input *domain.CreatePasswordResetTokenRequest) {
+	input.Validator.CheckField(input.Email != "", "Email", "Email is required")
+	input.Validator.CheckField(validator.Matches(input.Email, validator.RgxEmail), "Email", "Must be a valid email address")
+}
+
+func ValidatePasswordResetToken(input *domain.ResetPasswordRequest) {
+	input.Validator.CheckField(input.TokenPlaintext != "", "Token", "Token is required")
+	input.Validator.CheckField(len(input.TokenPlaintext) == 26, "Token", "Token must be 26 bytes long")
+}
+
+func ValidateCurrentPassword(input *domain.ChangePasswordRequest, passwordMatches bool) {
+	input.Validator.CheckField(input.CurrentPassword != "", "CurrentPassword", "Current password is required")
+	input.Validator.CheckField(passwordMatches, "CurrentPassword", "Current password is incorrect")
+}
+
+func ValidateInvitationToken(input *domain.CreateUserRequest) {
+	if input.InvitationToken != "" {
+		input.Validator.CheckField(len(input.InvitationToken) == 26, "InvitationToken", "Invitation token must be 26 bytes long")
+	}
+}
+
+func ValidateInvitation(input *domain.CreateInvitationRequest) {
+	input.Validator.CheckField(input.Email != "", "Email", "Email is required")
+	input.Validator.CheckField(validator.Matches(input.Email, validator.RgxEmail), "Email", "Must be a valid email address")
+
+	for _, code := range input.Permissions {
+		input.Validator.CheckField(validator.NotBlank(code), "Permissions", "Permissions must not contain blank values")
+	}
+	input.Validator.CheckField(validator.NoDuplicates(input.Permissions), "Permissions", "Permissions must not contain duplicate values")
+
+	if !input.Expiry.IsZero() {
+		input.Validator.CheckField(input.Expiry.After(time.Now()), "Expiry", "Expiry must be in the future")
+		input.Validator.CheckField(input.Expiry.Before(time.Now().Add(maxInvitationTTL)), "Expiry", "Expiry must not be more than 30 days in the future")
+	}
+
+	input.Validator.CheckField(mailer.SupportedLocale(input.Locale), "Locale", "Locale must be one emails are available in")
+}
+
+func ValidateMagicLinkEmail(input *domain.CreateMagicLinkRequest) {
+	input.Validator.CheckField(input.Email != "", "Email", "Email is required")
+	input.Validator.CheckField(validator.Matches(input.Email, validator.RgxEmail), "Email", "Must be a valid email address")
+}
+
+func ValidateMagicLinkToken(input *domain.ExchangeMagicLinkRequest) {
+	input.Validator.CheckField(input.TokenPlaintext != "", "Token", "Token is required")
+	input.Validator.CheckField(len(input.TokenPlaintext) == 26, "Token", "Token must be 26 bytes long")
+}
+
+func ValidatePasskeyRegistration(input *domain.FinishPasskeyRegistrationRequest) {
+	input.Validator.CheckField(input.Name != "", "Name", "Name is required")
+	input.Validator.CheckField(len(input.Name) <= 100, "Name", "Name must not be more than 100 bytes long")
+	input.Validator.CheckField(len(input.Credential.RawID) != 0, "Credential", "Credential is required")
+}
+
+func ValidatePasskeyLoginEmail(input *domain.BeginPasskeyLoginRequest) {
+	input.Validator.CheckField(input.Email != "", "Email", "Email is required")
+	input.Validator.CheckField(validator.Matches(input.Email, validator.RgxEmail), "Email", "Must be a valid email address")
+}
+
+func ValidatePasskeyLogin(input *domain.FinishPasskeyLoginRequest) {
+	input.Validator.CheckField(len(input.Credential.RawID) != 0, "Credential", "Credential is required")
+}
+
+func ValidateFilters(v *validator.Validator, f domain.Filters) {
+	v.CheckField(f.Page > 0, "page", "must be greater than zero")
+	v.CheckField(f.Page <= 10_000_000, "page", "must be a maximum of 10 million")
+	v.CheckField(f.PageSize > 0, "page_size", "must be greater than zero")
+	v.CheckField(f.PageSize <= 100, "page_size", "must be a maximum of 100")
+
+	v.CheckField(validator.In(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+}
+
+func ValidateListUsers(input *domain.ListUsersRequest) {
+	ValidateFilters(&input.Validator, input.Filters)
+
+	if !input.CreatedAfter.IsZero() && !input.CreatedBefore.IsZero() {
+		input.Validator.CheckField(input.CreatedAfter.Before(input.CreatedBefore), "created_after", "must be before created_before")
+	}
+}
+
+func ValidateListOutboxEmails(input *domain.ListOutboxEmailsRequest) {
+	ValidateFilters(&input.Validator, input.Filters)
+
+	input.Validator.CheckField(input.Status == "" || validator.In(input.Status, domain.OutboxPending, domain.OutboxSent, domain.OutboxDead), "status", "must be pending, sent or dead")
+}
+
+func ValidateBulkEmail(input *domain.CreateBulkEmailRequest, templates []string) {
+	input.Validator.CheckField(input.Template != "", "template", "must be provided")
+	input.Validator.CheckField(input.Template == "" || validator.In(input.Template, templates...), "template", "must be the name of a bulk email template")
+
+	if !input.Filter.CreatedAfter.IsZero() && !input.Filter.CreatedBefore.IsZero() {
+		input.Validator.CheckField(input.Filter.CreatedAfter.Before(input.Filter.CreatedBefore), "created_after", "must be before created_before")
+	}
+}
+
+func ValidateIntrospection(input *domain.IntrospectTokenRequest) {
+	input.Validator.CheckField(input.TokenPlaintext != "", "token", "must be provided")
+}
+
+func ValidateDeviceAuthorization(input *domain.CreateDeviceAuthorizationRequest) {
+	input.Validator.CheckField(input.ClientID != "", "client_id", "must be provided")
+	input.Validator.CheckField(len(input.ClientID) <= 200, "client_id", "must not be more than 200 bytes long")
+}
+
+func ValidateDeviceToken(input *domain.DeviceTokenRequest) {
+	input.Validator.CheckField(input.DeviceCode != "", "device_code", "must be provided")
+	input.Validator.CheckField(input.ClientID != "", "client_id", "must be provided")
+}
+
+func ValidateVerifyDevice(input *domain.VerifyDeviceRequest) {
+	input.Validator.CheckField(input.UserCode != "", "user_code", "Code is required")
+	input.Validator.CheckField(len(domain.NormalizeUserCode(input.UserCode)) == 8, "user_code", "Code must be 8 letters long")
+	input.Validator.CheckField(validator.In(input.Action, "approve", "deny"), "action", "Must be approve or deny")
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"database/sql"
+	"github.com/jessicatarra/greenlight/internal/database"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"time"
+)
+
+// NewAuditRepo returns the audit log the monolith also writes to.
+func NewAuditRepo(db *sql.DB, timeout time.Duration) domain.AuditRepository {
+	return database.AuditModel{DB: db, Timeout: queryTimeout(timeout)}
+}
@@ -0,0 +1,53 @@
+//go:build auth
+// +build auth
+
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAuditRepository_Insert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuditRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		event := &domain.AuditEvent{UserID: 2, ActorID: 1, Action: "impersonate", Resource: "users", ResourceID: 2}
+
+		mock.ExpectQuery("INSERT INTO audit_events").
+			WithArgs(event.UserID, event.ActorID, event.Action, event.Resource, event.ResourceID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(int64(1), time.Now()))
+
+		// Act
+		err := repo.Insert(context.Background(), event)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), event.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		// Arrange
+		event := &domain.AuditEvent{UserID: 2, Action: "impersonate", Resource: "users", ResourceID: 2}
+
+		mock.ExpectQuery("INSERT INTO audit_events").
+			WillReturnError(errors.New("some error"))
+
+		// Act
+		err := repo.Insert(context.Background(), event)
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
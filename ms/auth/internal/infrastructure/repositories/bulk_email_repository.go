@@ -0,0 +1,261 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"time"
+)
+
+type bulkEmailRepository struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func NewBulkEmailRepo(db *sql.DB, timeout time.Duration) domain.BulkEmailRepository {
+	return &bulkEmailRepository{db: db, timeout: queryTimeout(timeout)}
+}
+
+func (b *bulkEmailRepository) Insert(ctx context.Context, bulk *domain.BulkEmail) error {
+	filter, err := json.Marshal(bulk.Filter)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+        INSERT INTO bulk_emails (template, filter, created_by, total)
+        SELECT $6::text, $7::jsonb, $8::bigint, count(*)
+        FROM users
+        WHERE %s
+        RETURNING id, created_at, status, total, updated_at`, userFilterConditions)
+
+	args := append(userFilterArgs(bulk.Filter), bulk.Template, filter, bulk.CreatedBy)
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	return b.db.QueryRowContext(ctx, query, args...).Scan(
+		&bulk.ID,
+		&bulk.CreatedAt,
+		&bulk.Status,
+		&bulk.Total,
+		&bulk.UpdatedAt,
+	)
+}
+
+func (b *bulkEmailRepository) Get(ctx context.Context, id int64) (*domain.BulkEmail, error) {
+	query := `
+        SELECT id, created_at, template, filter, status, total, sent, failed, last_user_id, created_by, updated_at, completed_at
+        FROM bulk_emails
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	return scanBulkEmail(b.db.QueryRowContext(ctx, query, id))
+}
+
+// Acquire takes over a bulk email whose lock has run out, so that one whose
+// sender died part way can be resumed.
+func (b *bulkEmailRepository) Acquire(ctx context.Context, id int64, lockedUntil time.Time) (*domain.BulkEmail, error) {
+	query := `
+        UPDATE bulk_emails
+        SET status = $1, locked_until = $2, updated_at = $3
+        WHERE id = $4
+        AND status <> $5
+        AND (locked_until IS NULL OR locked_until <= $3)
+        RETURNING id, created_at, template, filter, status, total, sent, failed, last_user_id, created_by, updated_at, completed_at`
+
+	args := []interface{}{domain.BulkEmailSending, lockedUntil, time.Now(), id, domain.BulkEmailCompleted}
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	return scanBulkEmail(b.db.QueryRowContext(ctx, query, args...))
+}
+
+func (b *bulkEmailRepository) Release(ctx context.Context, id int64) error {
+	query := `
+        UPDATE bulk_emails
+        SET locked_until = NULL
+        WHERE id = $1`
+
+	return b.update(ctx, query, id)
+}
+
+func (b *bulkEmailRepository) Complete(ctx context.Context, id int64) error {
+	query := `
+        UPDATE bulk_emails
+        SET status = $1, locked_until = NULL, updated_at = $2, completed_at = $2
+        WHERE id = $3`
+
+	return b.update(ctx, query, domain.BulkEmailCompleted, time.Now(), id)
+}
+
+func (b *bulkEmailRepository) Recipients(ctx context.Context, bulk *domain.BulkEmail, limit int) ([]*domain.User, error) {
+	query := fmt.Sprintf(`
+        SELECT id, name, email, locale
+        FROM users
+        WHERE %s
+        AND id > $6
+        ORDER BY id
+        LIMIT $7`, userFilterConditions)
+
+	args := append(userFilterArgs(bulk.Filter), bulk.LastUserID, limit)
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*domain.User{}
+
+	for rows.Next() {
+		var user domain.User
+
+		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Locale)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// RecordDelivery records the failure, if any, and the progress in one
+// statement, so that a failure is never recorded without the progress past
+// it or the other way round.
+func (b *bulkEmailRepository) RecordDelivery(ctx context.Context, id int64, user *domain.User, sendError string) error {
+	query := `
+        WITH failure AS (
+            INSERT INTO bulk_email_failures (bulk_email_id, user_id, recipient, error)
+            SELECT $1::bigint, $2::bigint, $3::text, $4::text
+            WHERE $4::text <> ''
+            ON CONFLICT (bulk_email_id, user_id) DO UPDATE
+            SET recipient = EXCLUDED.recipient, error = EXCLUDED.error, created_at = NOW()
+        )
+        UPDATE bulk_emails
+        SET last_user_id = $2,
+            sent = sent + CASE WHEN $4::text = '' THEN 1 ELSE 0 END,
+            failed = failed + CASE WHEN $4::text = '' THEN 0 ELSE 1 END,
+            updated_at = $5
+        WHERE id = $1`
+
+	return b.update(ctx, query, id, user.ID, user.Email, sendError, time.Now())
+}
+
+func (b *bulkEmailRepository) GetFailures(ctx context.Context, id int64, filters domain.Filters) ([]*domain.BulkEmailFailure, domain.Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), user_id, recipient, error, created_at
+        FROM bulk_email_failures
+        WHERE bulk_email_id = $1
+        ORDER BY %s %s, user_id ASC
+        LIMIT $2 OFFSET $3`, filters.SortColumn(), filters.SortDirection())
+
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	rows, err := b.db.QueryContext(ctx, query, id, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, domain.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	failures := []*domain.BulkEmailFailure{}
+
+	for rows.Next() {
+		var failure domain.BulkEmailFailure
+
+		err := rows.Scan(&totalRecords, &failure.UserID, &failure.Recipient, &failure.Error, &failure.CreatedAt)
+		if err != nil {
+			return nil, domain.Metadata{}, err
+		}
+
+		failures = append(failures, &failure)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, domain.Metadata{}, err
+	}
+
+	metadata := domain.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return failures, metadata, nil
+}
+
+func (b *bulkEmailRepository) update(ctx context.Context, query string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, b.timeout)
+	defer cancel()
+
+	result, err := b.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func scanBulkEmail(row *sql.Row) (*domain.BulkEmail, error) {
+	var (
+		bulk        domain.BulkEmail
+		filter      []byte
+		completedAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&bulk.ID,
+		&bulk.CreatedAt,
+		&bulk.Template,
+		&filter,
+		&bulk.Status,
+		&bulk.Total,
+		&bulk.Sent,
+		&bulk.Failed,
+		&bulk.LastUserID,
+		&bulk.CreatedBy,
+		&bulk.UpdatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, domain.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	err = json.Unmarshal(filter, &bulk.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if completedAt.Valid {
+		bulk.CompletedAt = &completedAt.Time
+	}
+
+	return &bulk, nil
+}
@@ -0,0 +1,177 @@
+//go:build auth
+// +build auth
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var bulkEmailColumns = []string{"id", "created_at", "template", "filter", "status", "total", "sent", "failed", "last_user_id", "created_by", "updated_at", "completed_at"}
+
+func TestBulkEmailRepository_Insert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBulkEmailRepo(db, defaultTimeout)
+
+	// Arrange
+	activated := true
+	bulk := &domain.BulkEmail{
+		Template:  "release_announcement.gohtml",
+		Filter:    domain.UserFilter{Activated: &activated},
+		CreatedBy: 1,
+	}
+
+	mock.ExpectQuery("INSERT INTO bulk_emails(.+)SELECT(.+)count\\(\\*\\)(.+)FROM users").
+		WithArgs("", &activated, nil, sql.NullTime{}, sql.NullTime{}, "release_announcement.gohtml", sqlmock.AnyArg(), int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "status", "total", "updated_at"}).
+			AddRow(int64(3), time.Now(), domain.BulkEmailPending, 250, time.Now()))
+
+	// Act
+	err = repo.Insert(context.Background(), bulk)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), bulk.ID)
+	assert.Equal(t, 250, bulk.Total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkEmailRepository_Acquire(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBulkEmailRepo(db, defaultTimeout)
+	lockedUntil := time.Now().Add(5 * time.Minute)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		now := time.Now()
+
+		mock.ExpectQuery("UPDATE bulk_emails SET status = \\$1, locked_until = \\$2").
+			WithArgs(domain.BulkEmailSending, lockedUntil, sqlmock.AnyArg(), int64(3), domain.BulkEmailCompleted).
+			WillReturnRows(sqlmock.NewRows(bulkEmailColumns).
+				AddRow(int64(3), now, "release_announcement.gohtml", []byte(`{"activated":true,"created_after":"0001-01-01T00:00:00Z","created_before":"0001-01-01T00:00:00Z"}`), domain.BulkEmailSending, 250, 120, 2, int64(130), int64(1), now, nil))
+
+		// Act
+		bulk, err := repo.Acquire(context.Background(), 3, lockedUntil)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(130), bulk.LastUserID)
+		assert.True(t, *bulk.Filter.Activated)
+		assert.Nil(t, bulk.Filter.Suspended)
+		assert.Nil(t, bulk.CompletedAt)
+	})
+
+	t.Run("Error - locked or completed", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("UPDATE bulk_emails").
+			WithArgs(domain.BulkEmailSending, lockedUntil, sqlmock.AnyArg(), int64(4), domain.BulkEmailCompleted).
+			WillReturnRows(sqlmock.NewRows(bulkEmailColumns))
+
+		// Act
+		bulk, err := repo.Acquire(context.Background(), 4, lockedUntil)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, bulk)
+	})
+}
+
+func TestBulkEmailRepository_Recipients(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBulkEmailRepo(db, defaultTimeout)
+
+	// Arrange
+	activated := true
+	bulk := &domain.BulkEmail{Filter: domain.UserFilter{Activated: &activated}, LastUserID: 130}
+
+	mock.ExpectQuery("SELECT id, name, email, locale FROM users(.+)AND id > \\$6 ORDER BY id LIMIT \\$7").
+		WithArgs("", &activated, nil, sql.NullTime{}, sql.NullTime{}, int64(130), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email", "locale"}).
+			AddRow(int64(131), "John Doe", "john@example.com", "en").
+			AddRow(int64(135), "Juan Pérez", "juan@example.com", "es"))
+
+	// Act
+	users, err := repo.Recipients(context.Background(), bulk, 100)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "es", users[1].Locale)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkEmailRepository_RecordDelivery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBulkEmailRepo(db, defaultTimeout)
+	user := &domain.User{ID: 131, Email: "john@example.com"}
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("INSERT INTO bulk_email_failures(.+)UPDATE bulk_emails SET last_user_id = \\$2").
+			WithArgs(int64(3), int64(131), "john@example.com", "550 mailbox unavailable", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		err := repo.RecordDelivery(context.Background(), 3, user, "550 mailbox unavailable")
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("Error - not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("INSERT INTO bulk_email_failures").
+			WithArgs(int64(4), int64(131), "john@example.com", "", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Act
+		err := repo.RecordDelivery(context.Background(), 4, user, "")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
+
+func TestBulkEmailRepository_GetFailures(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewBulkEmailRepo(db, defaultTimeout)
+
+	// Arrange
+	filters := domain.Filters{Page: 1, PageSize: 20, Sort: "user_id", SortSafelist: []string{"user_id"}}
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) OVER\\(\\)(.+)FROM bulk_email_failures(.+)ORDER BY user_id ASC").
+		WithArgs(int64(3), 20, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "user_id", "recipient", "error", "created_at"}).
+			AddRow(1, int64(131), "john@example.com", "550 mailbox unavailable", time.Now()))
+
+	// Act
+	failures, metadata, err := repo.GetFailures(context.Background(), 3, filters)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, failures, 1)
+	assert.Equal(t, "550 mailbox unavailable", failures[0].Error)
+	assert.Equal(t, 1, metadata.TotalRecords)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
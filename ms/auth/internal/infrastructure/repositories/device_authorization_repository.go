@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"time"
+)
+
+const ScopeDeviceCode = "device-code"
+
+type deviceAuthorizationRepository struct {
+	db      dbtx
+	token   domain.TokenInterface
+	timeout time.Duration
+}
+
+func NewDeviceAuthorizationRepo(db *sql.DB, timeout time.Duration) domain.DeviceAuthorizationRepository {
+	return &deviceAuthorizationRepository{db: db, token: domain.NewToken(), timeout: queryTimeout(timeout)}
+}
+
+func (d *deviceAuthorizationRepository) New(ctx context.Context, clientID string, ttl time.Duration, interval time.Duration) (*domain.DeviceAuthorization, error) {
+	token, err := d.token.GenerateToken(0, ttl, ScopeDeviceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	userCode, err := domain.GenerateUserCode()
+	if err != nil {
+		return nil, err
+	}
+
+	authorization := &domain.DeviceAuthorization{
+		DeviceCode:     token.Plaintext,
+		DeviceCodeHash: token.Hash,
+		UserCode:       userCode,
+		UserCodeHash:   domain.HashUserCode(userCode),
+		ClientID:       clientID,
+		Status:         domain.DeviceAuthorizationPending,
+		Interval:       interval,
+		Expiry:         token.Expiry,
+	}
+
+	err = d.Insert(ctx, authorization)
+	return authorization, err
+}
+
+func (d *deviceAuthorizationRepository) Insert(ctx context.Context, authorization *domain.DeviceAuthorization) error {
+	query := `
+        INSERT INTO device_authorizations (device_code_hash, user_code_hash, client_id, status, poll_interval, expiry)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at`
+
+	args := []interface{}{
+		authorization.DeviceCodeHash,
+		authorization.UserCodeHash,
+		authorization.ClientID,
+		authorization.Status,
+		int64(authorization.Interval / time.Second),
+		authorization.Expiry,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	return d.db.QueryRowContext(ctx, query, args...).Scan(&authorization.ID, &authorization.CreatedAt)
+}
+
+// GetByUserCode returns the pending, unexpired authorization the user code
+// was issued for.
+func (d *deviceAuthorizationRepository) GetByUserCode(ctx context.Context, userCode string) (*domain.DeviceAuthorization, error) {
+	query := `
+        SELECT id, device_code_hash, user_code_hash, client_id, user_id, status, poll_interval, last_polled_at, created_at, expiry
+        FROM device_authorizations
+        WHERE user_code_hash = $1
+        AND status = $2
+        AND expiry > $3`
+
+	args := []interface{}{domain.HashUserCode(userCode), domain.DeviceAuthorizationPending, time.Now()}
+
+	return d.get(ctx, query, args...)
+}
+
+// GetByDeviceCode returns the authorization the device code was issued for,
+// even when it has expired, so that the device can be told so.
+func (d *deviceAuthorizationRepository) GetByDeviceCode(ctx context.Context, deviceCode string) (*domain.DeviceAuthorization, error) {
+	deviceCodeHash := sha256.Sum256([]byte(deviceCode))
+
+	query := `
+        SELECT id, device_code_hash, user_code_hash, client_id, user_id, status, poll_interval, last_polled_at, created_at, expiry
+        FROM device_authorizations
+        WHERE device_code_hash = $1`
+
+	return d.get(ctx, query, deviceCodeHash[:])
+}
+
+func (d *deviceAuthorizationRepository) get(ctx context.Context, query string, args ...interface{}) (*domain.DeviceAuthorization, error) {
+	var (
+		authorization domain.DeviceAuthorization
+		userID        sql.NullInt64
+		interval      int64
+		lastPolledAt  sql.NullTime
+	)
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	err := d.db.QueryRowContext(ctx, query, args...).Scan(
+		&authorization.ID,
+		&authorization.DeviceCodeHash,
+		&authorization.UserCodeHash,
+		&authorization.ClientID,
+		&userID,
+		&authorization.Status,
+		&interval,
+		&lastPolledAt,
+		&authorization.CreatedAt,
+		&authorization.Expiry,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, domain.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	authorization.UserID = userID.Int64
+	authorization.Interval = time.Duration(interval) * time.Second
+	authorization.LastPolledAt = lastPolledAt.Time
+
+	return &authorization, nil
+}
+
+// Answer records the user's approval or denial of a pending authorization.
+// It returns domain.ErrRecordNotFound when the authorization is no longer
+// pending, so that it is only ever answered once.
+func (d *deviceAuthorizationRepository) Answer(ctx context.Context, authorization *domain.DeviceAuthorization) error {
+	query := `
+        UPDATE device_authorizations
+        SET user_id = $1, status = $2
+        WHERE id = $3
+        AND status = $4`
+
+	args := []interface{}{
+		authorization.UserID,
+		authorization.Status,
+		authorization.ID,
+		domain.DeviceAuthorizationPending,
+	}
+
+	return d.update(ctx, query, args...)
+}
+
+// RecordPoll records when the device polled a pending authorization and the
+// interval it must now wait. Only those columns are written, so that a poll
+// racing the user's answer cannot undo it; once the authorization has been
+// answered it returns domain.ErrRecordNotFound.
+func (d *deviceAuthorizationRepository) RecordPoll(ctx context.Context, authorization *domain.DeviceAuthorization) error {
+	query := `
+        UPDATE device_authorizations
+        SET poll_interval = $1, last_polled_at = $2
+        WHERE id = $3
+        AND status = $4`
+
+	args := []interface{}{
+		int64(authorization.Interval / time.Second),
+		authorization.LastPolledAt,
+		authorization.ID,
+		domain.DeviceAuthorizationPending,
+	}
+
+	return d.update(ctx, query, args...)
+}
+
+func (d *deviceAuthorizationRepository) update(ctx context.Context, query string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	result, err := d.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete removes an authorization. It returns domain.ErrRecordNotFound when
+// the authorization is already gone, so that of two concurrent polls only one
+// gets to redeem it.
+func (d *deviceAuthorizationRepository) Delete(ctx context.Context, id int64) error {
+	query := `
+        DELETE FROM device_authorizations
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	result, err := d.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (d *deviceAuthorizationRepository) DeleteExpired(ctx context.Context) error {
+	query := `
+        DELETE FROM device_authorizations
+        WHERE expiry < $1`
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	_, err := d.db.ExecContext(ctx, query, time.Now())
+	return err
+}
@@ -0,0 +1,223 @@
+//go:build auth
+// +build auth
+
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var deviceAuthorizationColumns = []string{"id", "device_code_hash", "user_code_hash", "client_id", "user_id", "status", "poll_interval", "last_polled_at", "created_at", "expiry"}
+
+func TestDeviceAuthorizationRepository_New(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceAuthorizationRepo(db, defaultTimeout)
+
+	// Arrange
+	mock.ExpectQuery("INSERT INTO device_authorizations").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "living-room", domain.DeviceAuthorizationPending, int64(5), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(int64(3), time.Now()))
+
+	// Act
+	authorization, err := repo.New(context.Background(), "living-room", 10*time.Minute, 5*time.Second)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), authorization.ID)
+	assert.Len(t, authorization.DeviceCode, 26)
+	assert.Regexp(t, regexp.MustCompile(`^[BCDFGHJKLMNPQRSTVWXZ]{4}-[BCDFGHJKLMNPQRSTVWXZ]{4}$`), authorization.UserCode)
+
+	deviceCodeHash := sha256.Sum256([]byte(authorization.DeviceCode))
+	assert.Equal(t, deviceCodeHash[:], authorization.DeviceCodeHash)
+	assert.Equal(t, domain.HashUserCode(authorization.UserCode), authorization.UserCodeHash)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeviceAuthorizationRepository_GetByUserCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceAuthorizationRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		now := time.Now()
+
+		mock.ExpectQuery("SELECT (.+) FROM device_authorizations WHERE user_code_hash").
+			WithArgs(domain.HashUserCode("BCDF-GHJK"), domain.DeviceAuthorizationPending, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows(deviceAuthorizationColumns).
+				AddRow(int64(3), []byte{1}, []byte{2}, "living-room", nil, domain.DeviceAuthorizationPending, int64(5), nil, now, now.Add(10*time.Minute)))
+
+		// Act
+		authorization, err := repo.GetByUserCode(context.Background(), "bcdf ghjk")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), authorization.ID)
+		assert.Equal(t, int64(0), authorization.UserID)
+		assert.Equal(t, 5*time.Second, authorization.Interval)
+		assert.True(t, authorization.LastPolledAt.IsZero())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT (.+) FROM device_authorizations WHERE user_code_hash").
+			WillReturnError(sql.ErrNoRows)
+
+		// Act
+		authorization, err := repo.GetByUserCode(context.Background(), "BCDF-GHJK")
+
+		// Assert
+		assert.Nil(t, authorization)
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
+
+func TestDeviceAuthorizationRepository_GetByDeviceCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceAuthorizationRepo(db, defaultTimeout)
+
+	// Arrange
+	now := time.Now()
+	deviceCodeHash := sha256.Sum256([]byte("GQRPVONORIEUPDJ6V4RTDIVSTQ"))
+
+	mock.ExpectQuery("SELECT (.+) FROM device_authorizations WHERE device_code_hash").
+		WithArgs(deviceCodeHash[:]).
+		WillReturnRows(sqlmock.NewRows(deviceAuthorizationColumns).
+			AddRow(int64(3), deviceCodeHash[:], []byte{2}, "living-room", int64(7), domain.DeviceAuthorizationApproved, int64(10), now, now, now.Add(10*time.Minute)))
+
+	// Act
+	authorization, err := repo.GetByDeviceCode(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), authorization.UserID)
+	assert.Equal(t, domain.DeviceAuthorizationApproved, authorization.Status)
+	assert.Equal(t, 10*time.Second, authorization.Interval)
+	assert.True(t, authorization.LastPolledAt.Equal(now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeviceAuthorizationRepository_Answer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceAuthorizationRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		authorization := &domain.DeviceAuthorization{ID: 3, UserID: 7, Status: domain.DeviceAuthorizationApproved}
+
+		mock.ExpectExec("UPDATE device_authorizations SET user_id = \\$1, status = \\$2 WHERE id = \\$3 AND status = \\$4").
+			WithArgs(int64(7), domain.DeviceAuthorizationApproved, int64(3), domain.DeviceAuthorizationPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		err := repo.Answer(context.Background(), authorization)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Already answered", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("UPDATE device_authorizations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Act
+		err := repo.Answer(context.Background(), &domain.DeviceAuthorization{ID: 3, UserID: 7, Status: domain.DeviceAuthorizationDenied})
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
+
+func TestDeviceAuthorizationRepository_RecordPoll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceAuthorizationRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		now := time.Now()
+		authorization := &domain.DeviceAuthorization{ID: 3, Status: domain.DeviceAuthorizationPending, Interval: 10 * time.Second, LastPolledAt: now}
+
+		mock.ExpectExec("UPDATE device_authorizations SET poll_interval = \\$1, last_polled_at = \\$2 WHERE id = \\$3 AND status = \\$4").
+			WithArgs(int64(10), now, int64(3), domain.DeviceAuthorizationPending).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		err := repo.RecordPoll(context.Background(), authorization)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Answered meanwhile", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("UPDATE device_authorizations").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Act
+		err := repo.RecordPoll(context.Background(), &domain.DeviceAuthorization{ID: 3, Interval: 5 * time.Second, LastPolledAt: time.Now()})
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
+
+func TestDeviceAuthorizationRepository_Delete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceAuthorizationRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("DELETE FROM device_authorizations").
+			WithArgs(int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		err := repo.Delete(context.Background(), 3)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Already redeemed", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("DELETE FROM device_authorizations").
+			WithArgs(int64(3)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Act
+		err := repo.Delete(context.Background(), 3)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
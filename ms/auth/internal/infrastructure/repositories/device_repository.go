@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"time"
+)
+
+type deviceRepository struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func NewDeviceRepo(db *sql.DB, timeout time.Duration) domain.DeviceRepository {
+	return &deviceRepository{db: db, timeout: queryTimeout(timeout)}
+}
+
+// Insert tells a new row from an updated one by its xmax, which is only set
+// on the row the conflicting insert updated.
+func (d *deviceRepository) Insert(ctx context.Context, device *domain.Device) (bool, error) {
+	query := `
+        INSERT INTO devices (user_id, fingerprint, ip_prefix, user_agent)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (user_id, fingerprint) DO UPDATE SET last_seen_at = NOW()
+        RETURNING id, created_at, last_seen_at, (xmax = 0)`
+
+	args := []interface{}{device.UserID, device.Fingerprint, device.IPPrefix, device.UserAgent}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	var created bool
+
+	err := d.db.QueryRowContext(ctx, query, args...).Scan(&device.ID, &device.CreatedAt, &device.LastSeenAt, &created)
+	if err != nil {
+		return false, err
+	}
+
+	return created, nil
+}
+
+func (d *deviceRepository) Get(ctx context.Context, userID int64, fingerprint []byte) (*domain.Device, error) {
+	query := `
+        SELECT id, user_id, fingerprint, ip_prefix, user_agent, created_at, last_seen_at
+        FROM devices
+        WHERE user_id = $1 AND fingerprint = $2`
+
+	var device domain.Device
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	err := d.db.QueryRowContext(ctx, query, userID, fingerprint).Scan(
+		&device.ID,
+		&device.UserID,
+		&device.Fingerprint,
+		&device.IPPrefix,
+		&device.UserAgent,
+		&device.CreatedAt,
+		&device.LastSeenAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, domain.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &device, nil
+}
+
+func (d *deviceRepository) Touch(ctx context.Context, device *domain.Device) error {
+	query := `
+        UPDATE devices SET last_seen_at = NOW()
+        WHERE id = $1
+        RETURNING last_seen_at`
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	err := d.db.QueryRowContext(ctx, query, device.ID).Scan(&device.LastSeenAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return domain.ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *deviceRepository) CountForUser(ctx context.Context, userID int64) (int, error) {
+	query := `
+        SELECT count(*)
+        FROM devices
+        WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	var count int
+
+	err := d.db.QueryRowContext(ctx, query, userID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (d *deviceRepository) DeleteAllForUser(ctx context.Context, userID int64) error {
+	query := `
+        DELETE FROM devices
+        WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	_, err := d.db.ExecContext(ctx, query, userID)
+	return err
+}
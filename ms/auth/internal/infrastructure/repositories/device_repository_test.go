@@ -0,0 +1,201 @@
+//go:build auth
+// +build auth
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+var deviceColumns = []string{"id", "user_id", "fingerprint", "ip_prefix", "user_agent", "created_at", "last_seen_at"}
+
+func TestDeviceRepository_Insert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		device := domain.NewDevice(1, net.ParseIP("203.0.113.7"), "Firefox/118.0", domain.DeviceSensitivityMedium)
+		now := time.Now()
+
+		mock.ExpectQuery("INSERT INTO devices").
+			WithArgs(device.UserID, device.Fingerprint, device.IPPrefix, device.UserAgent).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_seen_at", "created"}).AddRow(int64(5), now, now, true))
+
+		// Act
+		created, err := repo.Insert(context.Background(), device)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, int64(5), device.ID)
+		assert.Equal(t, now, device.LastSeenAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Success - already recorded", func(t *testing.T) {
+		// Arrange
+		device := domain.NewDevice(1, net.ParseIP("203.0.113.7"), "Firefox/118.0", domain.DeviceSensitivityMedium)
+		now := time.Now()
+
+		mock.ExpectQuery("INSERT INTO devices (.+) ON CONFLICT (.+) RETURNING id, created_at, last_seen_at, \\(xmax = 0\\)").
+			WithArgs(device.UserID, device.Fingerprint, device.IPPrefix, device.UserAgent).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_seen_at", "created"}).AddRow(int64(5), now.Add(-time.Hour), now, false))
+
+		// Act
+		created, err := repo.Insert(context.Background(), device)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		// Arrange
+		device := domain.NewDevice(1, net.ParseIP("203.0.113.7"), "Firefox/118.0", domain.DeviceSensitivityMedium)
+
+		mock.ExpectQuery("INSERT INTO devices").
+			WillReturnError(errors.New("some error"))
+
+		// Act
+		_, err := repo.Insert(context.Background(), device)
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestDeviceRepository_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceRepo(db, defaultTimeout)
+
+	fingerprint := []byte("fingerprint")
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		now := time.Now()
+
+		mock.ExpectQuery("SELECT (.+) FROM devices").
+			WithArgs(int64(1), fingerprint).
+			WillReturnRows(sqlmock.NewRows(deviceColumns).
+				AddRow(int64(5), int64(1), fingerprint, "203.0.0.0/16", "Firefox/118.0", now, now))
+
+		// Act
+		device, err := repo.Get(context.Background(), 1, fingerprint)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), device.ID)
+		assert.Equal(t, "203.0.0.0/16", device.IPPrefix)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT (.+) FROM devices").
+			WithArgs(int64(1), fingerprint).
+			WillReturnError(sql.ErrNoRows)
+
+		// Act
+		device, err := repo.Get(context.Background(), 1, fingerprint)
+
+		// Assert
+		assert.Nil(t, device)
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
+
+func TestDeviceRepository_Touch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		device := &domain.Device{ID: 5}
+		now := time.Now()
+
+		mock.ExpectQuery("UPDATE devices").
+			WithArgs(device.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"last_seen_at"}).AddRow(now))
+
+		// Act
+		err := repo.Touch(context.Background(), device)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, now, device.LastSeenAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("UPDATE devices").
+			WithArgs(int64(6)).
+			WillReturnError(sql.ErrNoRows)
+
+		// Act
+		err := repo.Touch(context.Background(), &domain.Device{ID: 6})
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
+
+func TestDeviceRepository_CountForUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceRepo(db, defaultTimeout)
+
+	// Arrange
+	mock.ExpectQuery("SELECT count(.+) FROM devices").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	// Act
+	count, err := repo.CountForUser(context.Background(), 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeviceRepository_DeleteAllForUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewDeviceRepo(db, defaultTimeout)
+
+	// Arrange
+	mock.ExpectExec("DELETE FROM devices").
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// Act
+	err = repo.DeleteAllForUser(context.Background(), 1)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/lib/pq"
+	"time"
+)
+
+const ScopeInvitation = "invitation"
+
+type invitationRepository struct {
+	db      dbtx
+	token   domain.TokenInterface
+	timeout time.Duration
+}
+
+func NewInvitationRepo(db *sql.DB, timeout time.Duration) domain.InvitationRepository {
+	return &invitationRepository{db: db, token: domain.NewToken(), timeout: queryTimeout(timeout)}
+}
+
+func (i *invitationRepository) New(ctx context.Context, email string, permissions domain.Permissions, invitedBy int64, ttl time.Duration) (*domain.Invitation, error) {
+	token, err := i.token.GenerateToken(invitedBy, ttl, ScopeInvitation)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &domain.Invitation{
+		Plaintext:   token.Plaintext,
+		Hash:        token.Hash,
+		Email:       email,
+		Permissions: permissions,
+		InvitedBy:   invitedBy,
+		Expiry:      token.Expiry,
+	}
+
+	err = i.Insert(ctx, invitation)
+	return invitation, err
+}
+
+func (i *invitationRepository) Insert(ctx context.Context, invitation *domain.Invitation) error {
+	query := `
+        INSERT INTO invitations (hash, email, permissions, invited_by, expiry)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING created_at`
+
+	args := []interface{}{invitation.Hash, invitation.Email, pq.Array([]string(invitation.Permissions)), invitation.InvitedBy, invitation.Expiry}
+
+	ctx, cancel := context.WithTimeout(ctx, i.timeout)
+	defer cancel()
+
+	return i.db.QueryRowContext(ctx, query, args...).Scan(&invitation.CreatedAt)
+}
+
+func (i *invitationRepository) GetForToken(ctx context.Context, tokenPlaintext string) (*domain.Invitation, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+        SELECT hash, email, permissions, invited_by, created_at, expiry
+        FROM invitations
+        WHERE hash = $1
+        AND expiry > $2`
+
+	args := []interface{}{tokenHash[:], time.Now()}
+
+	var invitation domain.Invitation
+
+	ctx, cancel := context.WithTimeout(ctx, i.timeout)
+	defer cancel()
+
+	err := i.db.QueryRowContext(ctx, query, args...).Scan(
+		&invitation.Hash,
+		&invitation.Email,
+		pq.Array((*[]string)(&invitation.Permissions)),
+		&invitation.InvitedBy,
+		&invitation.CreatedAt,
+		&invitation.Expiry,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, domain.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &invitation, nil
+}
+
+func (i *invitationRepository) DeleteAllForEmail(ctx context.Context, email string) error {
+	query := `
+        DELETE FROM invitations
+        WHERE email = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, i.timeout)
+	defer cancel()
+
+	_, err := i.db.ExecContext(ctx, query, email)
+	return err
+}
@@ -0,0 +1,129 @@
+//go:build auth
+// +build auth
+
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain/mocks"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestInvitationRepository_New(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mockTokenInterface := mocks.TokenInterface{}
+	repo := &invitationRepository{
+		db:      db,
+		token:   &mockTokenInterface,
+		timeout: defaultTimeout,
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		invitedBy := int64(1)
+		ttl := 24 * time.Hour
+		permissions := domain.Permissions{"movies:write"}
+		token := &domain.Token{
+			Plaintext: "mock_token",
+			Hash:      []byte("mock_hash"),
+			UserID:    invitedBy,
+			Expiry:    time.Now().Add(ttl),
+			Scope:     ScopeInvitation,
+		}
+
+		mockTokenInterface.On("GenerateToken", invitedBy, ttl, ScopeInvitation).Return(token, nil)
+		mock.ExpectQuery("INSERT INTO invitations").
+			WithArgs(token.Hash, "sarah@example.com", `{"movies:write"}`, invitedBy, token.Expiry).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+
+		// Act
+		invitation, err := repo.New(context.Background(), "sarah@example.com", permissions, invitedBy, ttl)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, token.Plaintext, invitation.Plaintext)
+		assert.Equal(t, token.Expiry, invitation.Expiry)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestInvitationRepository_GetForToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewInvitationRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		rows := sqlmock.NewRows([]string{"hash", "email", "permissions", "invited_by", "created_at", "expiry"}).
+			AddRow([]byte("hash"), "sarah@example.com", `{"movies:write"}`, int64(1), time.Now(), time.Now().Add(time.Hour))
+		mock.ExpectQuery("SELECT").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(rows)
+
+		// Act
+		invitation, err := repo.GetForToken(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "sarah@example.com", invitation.Email)
+		assert.Equal(t, domain.Permissions{"movies:write"}, invitation.Permissions)
+	})
+
+	t.Run("Error - not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+
+		// Act
+		invitation, err := repo.GetForToken(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, invitation)
+	})
+}
+
+func TestInvitationRepository_DeleteAllForEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewInvitationRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("DELETE FROM invitations").
+			WithArgs("sarah@example.com").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		err := repo.DeleteAllForEmail(context.Background(), "sarah@example.com")
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("DELETE FROM invitations").
+			WithArgs("sarah@example.com").
+			WillReturnError(errors.New("some error"))
+
+		// Act
+		err := repo.DeleteAllForEmail(context.Background(), "sarah@example.com")
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
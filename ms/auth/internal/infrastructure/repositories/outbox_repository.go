@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"time"
+)
+
+type outboxRepository struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func NewOutboxRepo(db *sql.DB, timeout time.Duration) domain.OutboxRepository {
+	return &outboxRepository{db: db, timeout: queryTimeout(timeout)}
+}
+
+func (o *outboxRepository) Insert(ctx context.Context, email *domain.OutboxEmail) error {
+	data, err := json.Marshal(email.Data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO email_outbox (recipient, template, data, locale)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at, status, next_attempt_at`
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	return o.db.QueryRowContext(ctx, query, email.Recipient, email.Template, data, email.Locale).Scan(
+		&email.ID,
+		&email.CreatedAt,
+		&email.Status,
+		&email.NextAttemptAt,
+	)
+}
+
+// ClaimDue skips rows another dispatcher has locked, so that several
+// instances can drain the outbox at once without sending an email twice.
+func (o *outboxRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*domain.OutboxEmail, error) {
+	query := `
+        UPDATE email_outbox
+        SET attempts = attempts + 1, next_attempt_at = $1
+        WHERE id IN (
+            SELECT id
+            FROM email_outbox
+            WHERE status = $2
+            AND next_attempt_at <= $3
+            ORDER BY next_attempt_at, id
+            LIMIT $4
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, created_at, recipient, template, locale, data, status, attempts, next_attempt_at, last_error, sent_at`
+
+	now := time.Now()
+	args := []interface{}{now.Add(lease), domain.OutboxPending, now, limit}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	rows, err := o.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := []*domain.OutboxEmail{}
+
+	for rows.Next() {
+		email, err := scanOutboxEmail(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		emails = append(emails, email)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+// MarkSent also clears the data of the email, which is only needed to render
+// it and may hold the plaintext of tokens.
+func (o *outboxRepository) MarkSent(ctx context.Context, id int64) error {
+	query := `
+        UPDATE email_outbox
+        SET status = $1, sent_at = $2, data = '{}', last_error = ''
+        WHERE id = $3`
+
+	return o.update(ctx, query, domain.OutboxSent, time.Now(), id)
+}
+
+func (o *outboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	query := `
+        UPDATE email_outbox
+        SET last_error = $1, next_attempt_at = $2
+        WHERE id = $3`
+
+	return o.update(ctx, query, lastError, nextAttemptAt, id)
+}
+
+// MarkDead also discards the data of the email when it holds tokens, which
+// by convention are the keys ending in "Token", so that no usable token is
+// left lying in the outbox. Such an email can no longer be replayed.
+func (o *outboxRepository) MarkDead(ctx context.Context, id int64, lastError string) error {
+	query := `
+        UPDATE email_outbox
+        SET status = $1, last_error = $2,
+            data = CASE
+                WHEN EXISTS (SELECT 1 FROM jsonb_object_keys(data) AS key WHERE key LIKE '%Token') THEN NULL
+                ELSE data
+            END
+        WHERE id = $3`
+
+	return o.update(ctx, query, domain.OutboxDead, lastError, id)
+}
+
+func (o *outboxRepository) update(ctx context.Context, query string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	result, err := o.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (o *outboxRepository) GetAll(ctx context.Context, status string, filters domain.Filters) ([]*domain.OutboxEmail, domain.Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, created_at, recipient, template, locale, data, status, attempts, next_attempt_at, last_error, sent_at
+        FROM email_outbox
+        WHERE (status = $1 OR $1 = '')
+        ORDER BY %s %s, id ASC
+        LIMIT $2 OFFSET $3`, filters.SortColumn(), filters.SortDirection())
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	rows, err := o.db.QueryContext(ctx, query, status, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, domain.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	emails := []*domain.OutboxEmail{}
+
+	for rows.Next() {
+		var count int
+
+		email, err := scanOutboxEmail(countingScanner{rows, &count})
+		if err != nil {
+			return nil, domain.Metadata{}, err
+		}
+
+		totalRecords = count
+		emails = append(emails, email)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, domain.Metadata{}, err
+	}
+
+	metadata := domain.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return emails, metadata, nil
+}
+
+func (o *outboxRepository) Replay(ctx context.Context, id int64) (*domain.OutboxEmail, error) {
+	query := `
+        UPDATE email_outbox
+        SET status = $1, attempts = 0, next_attempt_at = $2
+        WHERE id = $3 AND status = $4 AND data IS NOT NULL
+        RETURNING id, created_at, recipient, template, locale, data, status, attempts, next_attempt_at, last_error, sent_at`
+
+	args := []interface{}{domain.OutboxPending, time.Now(), id, domain.OutboxDead}
+
+	ctx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	email, err := scanOutboxEmail(o.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, domain.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return email, nil
+}
+
+type outboxScanner interface {
+	Scan(dest ...any) error
+}
+
+// countingScanner scans the window count GetAll selects ahead of the columns
+// of an email into count.
+type countingScanner struct {
+	rows  outboxScanner
+	count *int
+}
+
+func (c countingScanner) Scan(dest ...any) error {
+	return c.rows.Scan(append([]any{c.count}, dest...)...)
+}
+
+func scanOutboxEmail(row outboxScanner) (*domain.OutboxEmail, error) {
+	var (
+		email  domain.OutboxEmail
+		data   []byte
+		sentAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&email.ID,
+		&email.CreatedAt,
+		&email.Recipient,
+		&email.Template,
+		&email.Locale,
+		&data,
+		&email.Status,
+		&email.Attempts,
+		&email.NextAttemptAt,
+		&email.LastError,
+		&sentAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if data == nil {
+		email.Redacted = true
+	} else {
+		// Numbers are kept as json.Number, so that IDs render in templates
+		// as they were given rather than as floats.
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		err = decoder.Decode(&email.Data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if sentAt.Valid {
+		email.SentAt = &sentAt.Time
+	}
+
+	return &email, nil
+}
@@ -0,0 +1,198 @@
+//go:build auth
+// +build auth
+
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var outboxColumns = []string{"id", "created_at", "recipient", "template", "locale", "data", "status", "attempts", "next_attempt_at", "last_error", "sent_at"}
+
+func TestOutboxRepository_Insert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOutboxRepo(db, defaultTimeout)
+
+	// Arrange
+	email := &domain.OutboxEmail{
+		Recipient: "john@example.com",
+		Template:  "user_welcome.gohtml",
+		Locale:    "es",
+		Data:      map[string]interface{}{"userID": int64(1234567)},
+	}
+
+	mock.ExpectQuery("INSERT INTO email_outbox").
+		WithArgs("john@example.com", "user_welcome.gohtml", []byte(`{"userID":1234567}`), "es").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "status", "next_attempt_at"}).
+			AddRow(int64(9), time.Now(), domain.OutboxPending, time.Now()))
+
+	// Act
+	err = repo.Insert(context.Background(), email)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), email.ID)
+	assert.Equal(t, domain.OutboxPending, email.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_ClaimDue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOutboxRepo(db, defaultTimeout)
+
+	// Arrange
+	now := time.Now()
+
+	mock.ExpectQuery("UPDATE email_outbox SET attempts = attempts \\+ 1(.+)FOR UPDATE SKIP LOCKED").
+		WithArgs(sqlmock.AnyArg(), domain.OutboxPending, sqlmock.AnyArg(), 20).
+		WillReturnRows(sqlmock.NewRows(outboxColumns).
+			AddRow(int64(9), now, "john@example.com", "user_welcome.gohtml", "en", []byte(`{"userID":1234567}`), domain.OutboxPending, 1, now.Add(time.Minute), "", nil))
+
+	// Act
+	emails, err := repo.ClaimDue(context.Background(), 20, time.Minute)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, emails, 1)
+	assert.Equal(t, "john@example.com", emails[0].Recipient)
+	assert.Equal(t, "en", emails[0].Locale)
+	assert.Equal(t, json.Number("1234567"), emails[0].Data["userID"])
+	assert.Nil(t, emails[0].SentAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_MarkSent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOutboxRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("UPDATE email_outbox SET status = \\$1, sent_at = \\$2, data = '{}'").
+			WithArgs(domain.OutboxSent, sqlmock.AnyArg(), int64(9)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		err := repo.MarkSent(context.Background(), 9)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("Error - not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("UPDATE email_outbox").
+			WithArgs(domain.OutboxSent, sqlmock.AnyArg(), int64(10)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Act
+		err := repo.MarkSent(context.Background(), 10)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
+
+func TestOutboxRepository_MarkDead(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOutboxRepo(db, defaultTimeout)
+
+	// Arrange
+	mock.ExpectExec("UPDATE email_outbox SET status = \\$1, last_error = \\$2, data = CASE WHEN EXISTS (.+) LIKE '%Token'\\) THEN NULL").
+		WithArgs(domain.OutboxDead, "mailbox unavailable", int64(9)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Act
+	err = repo.MarkDead(context.Background(), 9, "mailbox unavailable")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOutboxRepo(db, defaultTimeout)
+
+	// Arrange
+	now := time.Now()
+	filters := domain.Filters{Page: 1, PageSize: 20, Sort: "-id", SortSafelist: []string{"-id"}}
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) OVER\\(\\)(.+)FROM email_outbox(.+)ORDER BY id DESC").
+		WithArgs(domain.OutboxDead, 20, 0).
+		WillReturnRows(sqlmock.NewRows(append([]string{"count"}, outboxColumns...)).
+			AddRow(2, int64(9), now, "john@example.com", "user_welcome.gohtml", "en", []byte(`{}`), domain.OutboxDead, 8, now, "connection refused", nil).
+			AddRow(2, int64(4), now, "sarah@example.com", "user_magic_link.gohtml", "en", nil, domain.OutboxDead, 8, now, "connection refused", nil))
+
+	// Act
+	emails, metadata, err := repo.GetAll(context.Background(), domain.OutboxDead, filters)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, emails, 2)
+	assert.Equal(t, "connection refused", emails[1].LastError)
+	assert.False(t, emails[0].Redacted)
+	assert.True(t, emails[1].Redacted)
+	assert.Equal(t, 2, metadata.TotalRecords)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Replay(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOutboxRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		now := time.Now()
+
+		mock.ExpectQuery("UPDATE email_outbox SET status = \\$1, attempts = 0").
+			WithArgs(domain.OutboxPending, sqlmock.AnyArg(), int64(9), domain.OutboxDead).
+			WillReturnRows(sqlmock.NewRows(outboxColumns).
+				AddRow(int64(9), now, "john@example.com", "user_welcome.gohtml", "en", []byte(`{}`), domain.OutboxPending, 0, now, "connection refused", nil))
+
+		// Act
+		email, err := repo.Replay(context.Background(), 9)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, domain.OutboxPending, email.Status)
+		assert.Equal(t, 0, email.Attempts)
+	})
+
+	t.Run("Error - not dead or redacted", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("UPDATE email_outbox(.+)WHERE id = \\$3 AND status = \\$4 AND data IS NOT NULL").
+			WithArgs(domain.OutboxPending, sqlmock.AnyArg(), int64(10), domain.OutboxDead).
+			WillReturnRows(sqlmock.NewRows(outboxColumns))
+
+		// Act
+		email, err := repo.Replay(context.Background(), 10)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, email)
+	})
+}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/lib/pq"
+	"time"
+)
+
+type passkeyRepository struct {
+	db      dbtx
+	timeout time.Duration
+}
+
+func NewPasskeyRepo(db *sql.DB, timeout time.Duration) domain.PasskeyRepository {
+	return &passkeyRepository{db: db, timeout: queryTimeout(timeout)}
+}
+
+func (p *passkeyRepository) Insert(ctx context.Context, passkey *domain.Passkey) error {
+	query := `
+        INSERT INTO webauthn_credentials (user_id, credential_id, public_key, sign_count, transports, aaguid, name)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at, last_used_at`
+
+	args := []interface{}{
+		passkey.UserID,
+		passkey.CredentialID,
+		passkey.PublicKey,
+		int64(passkey.SignCount),
+		pq.Array(passkey.Transports),
+		passkey.AAGUID,
+		passkey.Name,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	err := p.db.QueryRowContext(ctx, query, args...).Scan(&passkey.ID, &passkey.CreatedAt, &passkey.LastUsedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "webauthn_credentials_credential_id_key"`:
+			return domain.ErrDuplicatePasskey
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *passkeyRepository) GetAllForUser(ctx context.Context, userID int64) ([]*domain.Passkey, error) {
+	query := `
+        SELECT id, user_id, credential_id, public_key, sign_count, transports, aaguid, name, created_at, last_used_at
+        FROM webauthn_credentials
+        WHERE user_id = $1
+        ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	passkeys := []*domain.Passkey{}
+
+	for rows.Next() {
+		passkey, err := scanPasskey(rows)
+		if err != nil {
+			return nil, err
+		}
+		passkeys = append(passkeys, passkey)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return passkeys, nil
+}
+
+func (p *passkeyRepository) GetByCredentialID(ctx context.Context, credentialID []byte) (*domain.Passkey, error) {
+	query := `
+        SELECT id, user_id, credential_id, public_key, sign_count, transports, aaguid, name, created_at, last_used_at
+        FROM webauthn_credentials
+        WHERE credential_id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	passkey, err := scanPasskey(p.db.QueryRowContext(ctx, query, credentialID))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, domain.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return passkey, nil
+}
+
+// UpdateSignCount stores the counter of the latest assertion. The update only
+// applies while the stored counter is lower, so two concurrent sign-ins with
+// the same counter value cannot both succeed.
+func (p *passkeyRepository) UpdateSignCount(ctx context.Context, passkey *domain.Passkey) error {
+	query := `
+        UPDATE webauthn_credentials SET sign_count = $1, last_used_at = NOW()
+        WHERE id = $2 AND (sign_count < $1 OR $1 = 0)
+        RETURNING last_used_at`
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	err := p.db.QueryRowContext(ctx, query, int64(passkey.SignCount), passkey.ID).Scan(&passkey.LastUsedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return domain.ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+type passkeyScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPasskey(row passkeyScanner) (*domain.Passkey, error) {
+	var passkey domain.Passkey
+	var signCount int64
+
+	err := row.Scan(
+		&passkey.ID,
+		&passkey.UserID,
+		&passkey.CredentialID,
+		&passkey.PublicKey,
+		&signCount,
+		pq.Array(&passkey.Transports),
+		&passkey.AAGUID,
+		&passkey.Name,
+		&passkey.CreatedAt,
+		&passkey.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey.SignCount = uint32(signCount)
+
+	return &passkey, nil
+}
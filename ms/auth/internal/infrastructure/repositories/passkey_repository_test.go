@@ -0,0 +1,185 @@
+//go:build auth
+// +build auth
+
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var passkeyColumns = []string{"id", "user_id", "credential_id", "public_key", "sign_count", "transports", "aaguid", "name", "created_at", "last_used_at"}
+
+func TestPasskeyRepository_Insert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPasskeyRepo(db, defaultTimeout)
+
+	passkey := func() *domain.Passkey {
+		return &domain.Passkey{
+			UserID:       1,
+			CredentialID: []byte{1, 2, 3},
+			PublicKey:    []byte{4, 5, 6},
+			Transports:   []string{"internal"},
+			AAGUID:       make([]byte, 16),
+			Name:         "Laptop",
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		p := passkey()
+		now := time.Now()
+
+		mock.ExpectQuery("INSERT INTO webauthn_credentials").
+			WithArgs(p.UserID, p.CredentialID, p.PublicKey, int64(0), pq.Array(p.Transports), p.AAGUID, p.Name).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "last_used_at"}).AddRow(int64(7), now, now))
+
+		// Act
+		err := repo.Insert(context.Background(), p)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), p.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Duplicate credential", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("INSERT INTO webauthn_credentials").
+			WillReturnError(errors.New(`pq: duplicate key value violates unique constraint "webauthn_credentials_credential_id_key"`))
+
+		// Act
+		err := repo.Insert(context.Background(), passkey())
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrDuplicatePasskey)
+	})
+}
+
+func TestPasskeyRepository_GetAllForUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPasskeyRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		now := time.Now()
+
+		mock.ExpectQuery("SELECT (.+) FROM webauthn_credentials").
+			WithArgs(int64(1)).
+			WillReturnRows(sqlmock.NewRows(passkeyColumns).
+				AddRow(int64(7), int64(1), []byte{1, 2, 3}, []byte{4, 5, 6}, int64(12), "{internal,hybrid}", make([]byte, 16), "Laptop", now, now))
+
+		// Act
+		passkeys, err := repo.GetAllForUser(context.Background(), 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, passkeys, 1)
+		assert.Equal(t, uint32(12), passkeys[0].SignCount)
+		assert.Equal(t, []string{"internal", "hybrid"}, passkeys[0].Transports)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT (.+) FROM webauthn_credentials").
+			WillReturnError(errors.New("some error"))
+
+		// Act
+		passkeys, err := repo.GetAllForUser(context.Background(), 1)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, passkeys)
+	})
+}
+
+func TestPasskeyRepository_GetByCredentialID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPasskeyRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		now := time.Now()
+
+		mock.ExpectQuery("SELECT (.+) FROM webauthn_credentials").
+			WithArgs([]byte{1, 2, 3}).
+			WillReturnRows(sqlmock.NewRows(passkeyColumns).
+				AddRow(int64(7), int64(1), []byte{1, 2, 3}, []byte{4, 5, 6}, int64(0), "{}", make([]byte, 16), "Laptop", now, now))
+
+		// Act
+		passkey, err := repo.GetByCredentialID(context.Background(), []byte{1, 2, 3})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), passkey.ID)
+		assert.Empty(t, passkey.Transports)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT (.+) FROM webauthn_credentials").
+			WithArgs([]byte{9}).
+			WillReturnError(sql.ErrNoRows)
+
+		// Act
+		passkey, err := repo.GetByCredentialID(context.Background(), []byte{9})
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, passkey)
+	})
+}
+
+func TestPasskeyRepository_UpdateSignCount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPasskeyRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		passkey := &domain.Passkey{ID: 7, SignCount: 13}
+
+		mock.ExpectQuery("UPDATE webauthn_credentials").
+			WithArgs(int64(13), int64(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"last_used_at"}).AddRow(time.Now()))
+
+		// Act
+		err := repo.UpdateSignCount(context.Background(), passkey)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Counter already used", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("UPDATE webauthn_credentials").
+			WithArgs(int64(13), int64(7)).
+			WillReturnError(sql.ErrNoRows)
+
+		// Act
+		err := repo.UpdateSignCount(context.Background(), &domain.Passkey{ID: 7, SignCount: 13})
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrEditConflict)
+	})
+}
@@ -3,20 +3,22 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	"github.com/lib/pq"
 	"time"
 )
 
 type permissionRepository struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func NewPermissionRepo(db *sql.DB) domain.PermissionRepository {
-	return &permissionRepository{db: db}
+func NewPermissionRepo(db *sql.DB, timeout time.Duration) domain.PermissionRepository {
+	return &permissionRepository{db: db, timeout: queryTimeout(timeout)}
 }
 
-func (p permissionRepository) GetAllForUser(userID int64) (domain.Permissions, error) {
+func (p permissionRepository) GetAllForUser(ctx context.Context, userID int64) (domain.Permissions, error) {
 	query := `
         SELECT permissions.code
         FROM permissions
@@ -24,7 +26,7 @@ func (p permissionRepository) GetAllForUser(userID int64) (domain.Permissions, e
         INNER JOIN users ON users_permissions.user_id = users.id
         WHERE users.id = $1`
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
 	defer cancel()
 
 	rows, err := p.db.QueryContext(ctx, query, userID)
@@ -52,13 +54,132 @@ func (p permissionRepository) GetAllForUser(userID int64) (domain.Permissions, e
 	return permissions, nil
 }
 
-func (p permissionRepository) AddForUser(userID int64, codes ...string) error {
+func (p permissionRepository) AddForUser(ctx context.Context, userID int64, codes ...string) error {
 	query := `
         INSERT INTO users_permissions
         SELECT $1, permissions.id FROM permissions WHERE permissions.code = ANY($2)`
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
 	defer cancel()
 
 	_, err := p.db.ExecContext(ctx, query, userID, pq.Array(codes))
 	return err
 }
+
+func (p permissionRepository) GetAll(ctx context.Context) ([]*domain.Permission, error) {
+	query := `
+        SELECT id, code
+        FROM permissions
+        ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []*domain.Permission{}
+
+	for rows.Next() {
+		var permission domain.Permission
+
+		err := rows.Scan(&permission.ID, &permission.Code)
+		if err != nil {
+			return nil, err
+		}
+
+		permissions = append(permissions, &permission)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (p permissionRepository) Get(ctx context.Context, id int64) (*domain.Permission, error) {
+	query := `
+        SELECT id, code
+        FROM permissions
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var permission domain.Permission
+
+	err := p.db.QueryRowContext(ctx, query, id).Scan(&permission.ID, &permission.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, domain.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &permission, nil
+}
+
+func (p permissionRepository) GetUsers(ctx context.Context, permissionID int64) ([]*domain.User, error) {
+	query := `
+        SELECT users.id, users.created_at, users.name, users.email, users.activated, users.suspended
+        FROM users
+        INNER JOIN users_permissions ON users_permissions.user_id = users.id
+        WHERE users_permissions.permission_id = $1
+        ORDER BY users.id`
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, query, permissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*domain.User{}
+
+	for rows.Next() {
+		var user domain.User
+
+		err := rows.Scan(&user.ID, &user.CreatedAt, &user.Name, &user.Email, &user.Activated, &user.Suspended)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, &user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (p permissionRepository) AddUsers(ctx context.Context, permissionID int64, userIDs ...int64) error {
+	query := `
+        INSERT INTO users_permissions (user_id, permission_id)
+        SELECT unnest($2::bigint[]), $1
+        ON CONFLICT DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	_, err := p.db.ExecContext(ctx, query, permissionID, pq.Array(userIDs))
+	return err
+}
+
+func (p permissionRepository) RemoveUsers(ctx context.Context, permissionID int64, userIDs ...int64) error {
+	query := `
+        DELETE FROM users_permissions
+        WHERE permission_id = $1 AND user_id = ANY($2)`
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	_, err := p.db.ExecContext(ctx, query, permissionID, pq.Array(userIDs))
+	return err
+}
@@ -4,10 +4,15 @@
 package repositories
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestPermissionRepository_GetAllForUser(t *testing.T) {
@@ -16,7 +21,7 @@ func TestPermissionRepository_GetAllForUser(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := NewPermissionRepo(db)
+	repo := NewPermissionRepo(db, defaultTimeout)
 
 	t.Run("Success", func(t *testing.T) {
 		// Arrange
@@ -27,7 +32,7 @@ func TestPermissionRepository_GetAllForUser(t *testing.T) {
 			WillReturnRows(rows)
 
 		// Act
-		permissions, err := repo.GetAllForUser(userID)
+		permissions, err := repo.GetAllForUser(context.Background(), userID)
 		// Assert
 		assert.NoError(t, err)
 		assert.NotNil(t, permissions)
@@ -41,7 +46,7 @@ func TestPermissionRepository_GetAllForUser(t *testing.T) {
 			WillReturnError(errors.New("some error"))
 
 		// Act
-		permissions, err := repo.GetAllForUser(userID)
+		permissions, err := repo.GetAllForUser(context.Background(), userID)
 		// Assert
 		assert.Error(t, err)
 		assert.Nil(t, permissions)
@@ -55,7 +60,7 @@ func TestPermissionRepository_AddForUser(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := NewPermissionRepo(db)
+	repo := NewPermissionRepo(db, defaultTimeout)
 
 	codes := []string{"movies:read"}
 
@@ -67,7 +72,7 @@ func TestPermissionRepository_AddForUser(t *testing.T) {
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		// Act
-		err := repo.AddForUser(userID, codes...)
+		err := repo.AddForUser(context.Background(), userID, codes...)
 		// Assert
 		assert.NoError(t, err)
 	})
@@ -81,9 +86,158 @@ func TestPermissionRepository_AddForUser(t *testing.T) {
 			WillReturnError(errors.New("some error"))
 
 		// Act
-		err := repo.AddForUser(userID, codes...)
+		err := repo.AddForUser(context.Background(), userID, codes...)
 		// Assert
 		assert.Error(t, err)
 	})
 
 }
+
+func TestPermissionRepository_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPermissionRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT id, code FROM permissions").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code"}).
+				AddRow(int64(1), "movies:read").
+				AddRow(int64(2), "movies:write"))
+
+		// Act
+		permissions, err := repo.GetAll(context.Background())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []*domain.Permission{{ID: 1, Code: "movies:read"}, {ID: 2, Code: "movies:write"}}, permissions)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT id, code FROM permissions").
+			WillReturnError(errors.New("some error"))
+
+		// Act
+		permissions, err := repo.GetAll(context.Background())
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, permissions)
+	})
+}
+
+func TestPermissionRepository_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPermissionRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT id, code FROM permissions").
+			WithArgs(int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "code"}).AddRow(int64(2), "movies:write"))
+
+		// Act
+		permission, err := repo.Get(context.Background(), 2)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "movies:write", permission.Code)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT id, code FROM permissions").
+			WithArgs(int64(9)).
+			WillReturnError(sql.ErrNoRows)
+
+		// Act
+		permission, err := repo.Get(context.Background(), 9)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+		assert.Nil(t, permission)
+	})
+}
+
+func TestPermissionRepository_GetUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPermissionRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT (.+) FROM users INNER JOIN users_permissions").
+			WithArgs(int64(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "name", "email", "activated", "suspended"}).
+				AddRow(int64(1), time.Now(), "John Doe", "johndoe@example.com", true, false))
+
+		// Act
+		users, err := repo.GetUsers(context.Background(), 2)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, "johndoe@example.com", users[0].Email)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT (.+) FROM users INNER JOIN users_permissions").
+			WillReturnError(errors.New("some error"))
+
+		// Act
+		users, err := repo.GetUsers(context.Background(), 2)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, users)
+	})
+}
+
+func TestPermissionRepository_AddUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPermissionRepo(db, defaultTimeout)
+
+	// Arrange
+	mock.ExpectExec("INSERT INTO users_permissions (.+) ON CONFLICT DO NOTHING").
+		WithArgs(int64(2), pq.Array([]int64{1, 3})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	// Act
+	err = repo.AddUsers(context.Background(), 2, 1, 3)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPermissionRepository_RemoveUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewPermissionRepo(db, defaultTimeout)
+
+	// Arrange
+	mock.ExpectExec("DELETE FROM users_permissions").
+		WithArgs(int64(2), pq.Array([]int64{4})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Act
+	err = repo.RemoveUsers(context.Background(), 2, 4)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"errors"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	"time"
 )
@@ -10,49 +12,119 @@ import (
 const (
 	ScopeActivation     = "activation"
 	ScopeAuthentication = "authentication"
+	ScopeMagicLink      = "magic-link"
+	ScopePasswordReset  = "password-reset"
+	ScopeRevokeSessions = "revoke-sessions"
+	ScopeSession        = "session"
+	// The plaintext of a WebAuthn token is the challenge of the ceremony.
+	ScopeWebAuthnRegistration = "webauthn-registration"
+	ScopeWebAuthnLogin        = "webauthn-login"
 )
 
 type tokenRepository struct {
-	db    *sql.DB
-	token domain.TokenInterface
+	db      dbtx
+	token   domain.TokenInterface
+	timeout time.Duration
 }
 
-func NewTokenRepo(db *sql.DB) domain.TokenRepository {
-	return &tokenRepository{db: db, token: domain.NewToken()}
+func NewTokenRepo(db *sql.DB, timeout time.Duration) domain.TokenRepository {
+	return &tokenRepository{db: db, token: domain.NewToken(), timeout: queryTimeout(timeout)}
 }
 
-func (t *tokenRepository) New(userID int64, ttl time.Duration, scope string) (*domain.Token, error) {
+func (t *tokenRepository) New(ctx context.Context, userID int64, ttl time.Duration, scope string) (*domain.Token, error) {
 	token, err := t.token.GenerateToken(userID, ttl, scope)
 	if err != nil {
 		return nil, err
 	}
 
-	err = t.Insert(token)
+	err = t.Insert(ctx, token)
 	return token, err
 }
 
-func (t *tokenRepository) Insert(token *domain.Token) error {
+func (t *tokenRepository) Insert(ctx context.Context, token *domain.Token) error {
 	query := `
         INSERT INTO tokens (hash, user_id, expiry, scope) 
         VALUES ($1, $2, $3, $4)`
 
 	args := []interface{}{token.Hash, token.UserID, token.Expiry, token.Scope}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
 	defer cancel()
 
 	_, err := t.db.ExecContext(ctx, query, args...)
 	return err
 }
 
-func (t *tokenRepository) DeleteAllForUser(scope string, userID int64) error {
+func (t *tokenRepository) DeleteAllForUser(ctx context.Context, scope string, userID int64) error {
 	query := `
         DELETE FROM tokens 
         WHERE scope = $1 AND user_id = $2`
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
 	defer cancel()
 
 	_, err := t.db.ExecContext(ctx, query, scope, userID)
 	return err
 }
+
+func (t *tokenRepository) Consume(ctx context.Context, scope string, tokenPlaintext string) (int64, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+        DELETE FROM tokens
+        WHERE hash = $1
+        AND scope = $2
+        AND expiry > $3
+        RETURNING user_id`
+
+	args := []interface{}{tokenHash[:], scope, time.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	var userID int64
+
+	err := t.db.QueryRowContext(ctx, query, args...).Scan(&userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, domain.ErrRecordNotFound
+		default:
+			return 0, err
+		}
+	}
+
+	return userID, nil
+}
+
+func (t *tokenRepository) Get(ctx context.Context, tokenPlaintext string) (*domain.Token, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `
+        SELECT hash, user_id, expiry, scope
+        FROM tokens
+        WHERE hash = $1
+        AND expiry > $2`
+
+	var token domain.Token
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	err := t.db.QueryRowContext(ctx, query, tokenHash[:], time.Now()).Scan(
+		&token.Hash,
+		&token.UserID,
+		&token.Expiry,
+		&token.Scope,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, domain.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &token, nil
+}
@@ -4,6 +4,7 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
@@ -21,8 +22,9 @@ func TestTokenRepository_New(t *testing.T) {
 	mockTokenInterface := mocks.TokenInterface{}
 
 	repo := &tokenRepository{
-		db:    db,
-		token: &mockTokenInterface,
+		db:      db,
+		token:   &mockTokenInterface,
+		timeout: defaultTimeout,
 	}
 
 	// Test cases
@@ -45,7 +47,7 @@ func TestTokenRepository_New(t *testing.T) {
 		mockTokenInterface.On("GenerateToken", userID, ttl, scope).Return(expectedToken, nil)
 
 		// Act
-		newToken, err := repo.New(userID, ttl, scope)
+		newToken, err := repo.New(context.Background(), userID, ttl, scope)
 
 		// Assert
 		assert.NoError(t, err)
@@ -62,8 +64,9 @@ func TestTokenRepository_NewError(t *testing.T) {
 	mockTokenInterface := mocks.TokenInterface{}
 
 	repo := &tokenRepository{
-		db:    db,
-		token: &mockTokenInterface,
+		db:      db,
+		token:   &mockTokenInterface,
+		timeout: defaultTimeout,
 	}
 
 	t.Run("Error", func(t *testing.T) {
@@ -78,7 +81,7 @@ func TestTokenRepository_NewError(t *testing.T) {
 		mockTokenInterface.On("GenerateToken", userID, ttl, scope).Return(nil, errors.New("some error"))
 
 		// Act
-		newToken, err := repo.New(userID, ttl, scope)
+		newToken, err := repo.New(context.Background(), userID, ttl, scope)
 
 		// Assert
 		assert.Error(t, err)
@@ -91,7 +94,7 @@ func TestTokenRepository_Insert(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := NewTokenRepo(db)
+	repo := NewTokenRepo(db, defaultTimeout)
 	hash := []byte("sampleHash")
 
 	// Test cases
@@ -109,7 +112,7 @@ func TestTokenRepository_Insert(t *testing.T) {
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		// Act
-		err := repo.Insert(expectedToken)
+		err := repo.Insert(context.Background(), expectedToken)
 
 		// Assert
 		assert.NoError(t, err)
@@ -129,7 +132,7 @@ func TestTokenRepository_Insert(t *testing.T) {
 			WillReturnError(sqlmock.ErrCancelled)
 
 		// Act
-		err := repo.Insert(token)
+		err := repo.Insert(context.Background(), token)
 
 		// Assert
 		assert.Error(t, err)
@@ -141,7 +144,7 @@ func TestTokenRepository_DeleteAllForUser(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := NewTokenRepo(db)
+	repo := NewTokenRepo(db, defaultTimeout)
 
 	// Test cases
 	t.Run("Success", func(t *testing.T) {
@@ -154,7 +157,7 @@ func TestTokenRepository_DeleteAllForUser(t *testing.T) {
 			WillReturnResult(sqlmock.NewResult(0, 2))
 
 		// Act
-		err := repo.DeleteAllForUser(scope, userID)
+		err := repo.DeleteAllForUser(context.Background(), scope, userID)
 
 		// Assert
 		assert.NoError(t, err)
@@ -170,34 +173,69 @@ func TestTokenRepository_DeleteAllForUser(t *testing.T) {
 			WillReturnError(sqlmock.ErrCancelled)
 
 		// Act
-		err := repo.DeleteAllForUser(scope, userID)
+		err := repo.DeleteAllForUser(context.Background(), scope, userID)
 
 		// Assert
 		assert.Error(t, err)
 	})
 }
 
+func TestTokenRepository_Consume(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewTokenRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("DELETE FROM tokens").
+			WithArgs(sqlmock.AnyArg(), ScopeMagicLink, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(int64(1)))
+
+		// Act
+		userID, err := repo.Consume(context.Background(), ScopeMagicLink, "GQRPVONORIEUPDJ6V4RTDIVSTQ")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), userID)
+	})
+
+	t.Run("Error - not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("DELETE FROM tokens").
+			WithArgs(sqlmock.AnyArg(), ScopeMagicLink, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+		// Act
+		_, err := repo.Consume(context.Background(), ScopeMagicLink, "GQRPVONORIEUPDJ6V4RTDIVSTQ")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
+
 func TestTokenRepository_GetUserById(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := NewUserRepo(db)
+	repo := NewUserRepo(db, defaultTimeout)
 
 	// Test cases
 	t.Run("Success", func(t *testing.T) {
 		// Arrange
 		userID := int64(1)
 
-		rows := sqlmock.NewRows([]string{"id", "created_at", "name", "email", "password_hash", "activated", "version"}).
-			AddRow(userID, time.Now(), "John Doe", "johndoe@example.com", "somehash", true, 1)
+		rows := sqlmock.NewRows([]string{"id", "created_at", "name", "email", "password_hash", "activated", "suspended", "version", "permissions_version", "sessions_revoked_at", "locale"}).
+			AddRow(userID, time.Now(), "John Doe", "johndoe@example.com", "somehash", true, false, 1, 1, time.Time{}, "en")
 
 		mock.ExpectQuery("SELECT").
 			WithArgs(userID).
 			WillReturnRows(rows)
 
 		// Act
-		user, err := repo.GetUserById(userID)
+		user, err := repo.GetUserById(context.Background(), userID)
 
 		// Assert
 		assert.NoError(t, err)
@@ -213,10 +251,59 @@ func TestTokenRepository_GetUserById(t *testing.T) {
 			WillReturnError(sqlmock.ErrCancelled)
 
 		// Act
-		user, err := repo.GetUserById(userID)
+		user, err := repo.GetUserById(context.Background(), userID)
 
 		// Assert
 		assert.Error(t, err)
 		assert.Nil(t, user)
 	})
 }
+
+func TestTokenRepository_Get(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewTokenRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		expiry := time.Now().Add(time.Hour)
+		mock.ExpectQuery("SELECT hash, user_id, expiry, scope").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"hash", "user_id", "expiry", "scope"}).AddRow([]byte("hash"), int64(1), expiry, ScopeActivation))
+
+		// Act
+		token, err := repo.Get(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), token.UserID)
+		assert.Equal(t, ScopeActivation, token.Scope)
+	})
+
+	t.Run("Error - not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT hash, user_id, expiry, scope").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"hash", "user_id", "expiry", "scope"}))
+
+		// Act
+		_, err := repo.Get(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ")
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+
+	t.Run("Error - default error", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT hash, user_id, expiry, scope").
+			WillReturnError(errors.New("some error"))
+
+		// Act
+		_, err := repo.Get(context.Background(), "GQRPVONORIEUPDJ6V4RTDIVSTQ")
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
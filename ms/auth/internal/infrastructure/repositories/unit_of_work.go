@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"time"
+)
+
+// dbtx is the part of *sql.DB the repositories use. *sql.Tx has it too, so
+// the same repository code runs inside and outside a transaction.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// queryTimeout returns the time a repository allows each query, which is
+// defaultTimeout unless configured otherwise. It only shortens the deadline
+// of the caller's context, never extends it.
+func queryTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return defaultTimeout
+	}
+	return timeout
+}
+
+type unitOfWork struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+func NewUnitOfWork(db *sql.DB, timeout time.Duration) domain.UnitOfWork {
+	return &unitOfWork{db: db, timeout: queryTimeout(timeout)}
+}
+
+// Do runs fn in a transaction that is rolled back if ctx is cancelled before
+// it commits.
+func (u *unitOfWork) Do(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// Rolling back a committed transaction does nothing.
+	defer tx.Rollback()
+
+	repos := domain.Repositories{
+		Users:       &userRepository{db: tx, timeout: u.timeout},
+		Tokens:      &tokenRepository{db: tx, token: domain.NewToken(), timeout: u.timeout},
+		Permissions: &permissionRepository{db: tx, timeout: u.timeout},
+		Invitations: &invitationRepository{db: tx, token: domain.NewToken(), timeout: u.timeout},
+		Outbox:      &outboxRepository{db: tx, timeout: u.timeout},
+		Devices:     &deviceRepository{db: tx, timeout: u.timeout},
+	}
+
+	err = fn(repos)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
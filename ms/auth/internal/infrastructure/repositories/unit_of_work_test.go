@@ -0,0 +1,121 @@
+//go:build auth
+// +build auth
+
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestUnitOfWork_Do(t *testing.T) {
+	t.Run("Commit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		unitOfWork := NewUnitOfWork(db, defaultTimeout)
+		user := &domain.User{Name: "John Doe", Email: "john@example.com", Locale: "en"}
+
+		// Arrange
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO users").
+			WithArgs(user.Name, user.Email, "hash", false, user.Locale).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "version"}).AddRow(int64(1), time.Now(), 1))
+		mock.ExpectExec("INSERT INTO users_permissions").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		// Act
+		err = unitOfWork.Do(context.Background(), func(repos domain.Repositories) error {
+			err := repos.Users.InsertNewUser(context.Background(), user, "hash")
+			if err != nil {
+				return err
+			}
+
+			return repos.Permissions.AddForUser(context.Background(), user.ID, "movies:read")
+		})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback on error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		unitOfWork := NewUnitOfWork(db, defaultTimeout)
+		user := &domain.User{Name: "John Doe", Email: "john@example.com", Locale: "en"}
+
+		// Arrange
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO users").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "version"}).AddRow(int64(1), time.Now(), 1))
+		mock.ExpectExec("INSERT INTO users_permissions").
+			WillReturnError(errors.New("database error"))
+		mock.ExpectRollback()
+
+		// Act
+		err = unitOfWork.Do(context.Background(), func(repos domain.Repositories) error {
+			err := repos.Users.InsertNewUser(context.Background(), user, "hash")
+			if err != nil {
+				return err
+			}
+
+			return repos.Permissions.AddForUser(context.Background(), user.ID, "movies:read")
+		})
+
+		// Assert
+		assert.EqualError(t, err, "database error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback on panic", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		unitOfWork := NewUnitOfWork(db, defaultTimeout)
+
+		// Arrange
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		// Act
+		assert.Panics(t, func() {
+			_ = unitOfWork.Do(context.Background(), func(repos domain.Repositories) error {
+				panic("boom")
+			})
+		})
+
+		// Assert
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Begin fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		unitOfWork := NewUnitOfWork(db, defaultTimeout)
+
+		// Arrange
+		mock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+
+		// Act
+		err = unitOfWork.Do(context.Background(), func(repos domain.Repositories) error {
+			t.Error("fn ran without a transaction")
+			return nil
+		})
+
+		// Assert
+		assert.EqualError(t, err, "connection refused")
+	})
+}
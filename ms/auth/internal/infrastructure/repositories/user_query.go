@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"fmt"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"strconv"
+	"strings"
+)
+
+type column struct {
+	expr string
+	text bool
+}
+
+// userColumns maps the fields users are searched by onto the users table.
+var userColumns = map[domain.UserField]column{
+	domain.UserFieldID:        {expr: "id"},
+	domain.UserFieldEmail:     {expr: "email", text: true},
+	domain.UserFieldName:      {expr: "name", text: true},
+	domain.UserFieldActive:    {expr: "(activated AND NOT suspended)"},
+	domain.UserFieldCreatedAt: {expr: "created_at"},
+}
+
+// sqlFilter translates a domain.UserQuery into a WHERE condition, collecting
+// the values it compares against as query parameters.
+type sqlFilter struct {
+	args []interface{}
+}
+
+func (f *sqlFilter) condition(query domain.UserQuery) (string, error) {
+	switch q := query.(type) {
+	case nil:
+		return "TRUE", nil
+	case domain.UserQueryConstant:
+		if q {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case *domain.UserQueryAnd:
+		return f.combine("AND", q.Left, q.Right)
+	case *domain.UserQueryOr:
+		return f.combine("OR", q.Left, q.Right)
+	case *domain.UserQueryNot:
+		inner, err := f.condition(q.Query)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(NOT %s)", inner), nil
+	case *domain.UserCondition:
+		col, ok := userColumns[q.Field]
+		if !ok {
+			return "", fmt.Errorf("unsupported user field %q", q.Field)
+		}
+		return f.compare(col, q), nil
+	}
+
+	return "", fmt.Errorf("unsupported user query %T", query)
+}
+
+func (f *sqlFilter) combine(operator string, left, right domain.UserQuery) (string, error) {
+	l, err := f.condition(left)
+	if err != nil {
+		return "", err
+	}
+	r, err := f.condition(right)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("(%s %s %s)", l, operator, r), nil
+}
+
+func (f *sqlFilter) compare(col column, q *domain.UserCondition) string {
+	// Every column is NOT NULL.
+	if q.Comparison == domain.ComparePresent {
+		if col.text {
+			return fmt.Sprintf("(%s <> '')", col.expr)
+		}
+		return "TRUE"
+	}
+
+	if value, ok := q.Value.(string); ok && col.text {
+		switch q.Comparison {
+		case domain.CompareContains:
+			return f.param("%s ILIKE %s", col.expr, "%"+escapeLike(value)+"%")
+		case domain.CompareStartsWith:
+			return f.param("%s ILIKE %s", col.expr, escapeLike(value)+"%")
+		case domain.CompareEndsWith:
+			return f.param("%s ILIKE %s", col.expr, "%"+escapeLike(value))
+		}
+		// Comparisons are case insensitive, as for the citext email column.
+		return f.param("lower(%s) "+sqlOperator(q.Comparison)+" lower(%s)", col.expr, value)
+	}
+
+	return f.param("%s "+sqlOperator(q.Comparison)+" %s", col.expr, q.Value)
+}
+
+// param adds value as the next query parameter and formats the condition
+// with the column and parameter placeholder.
+func (f *sqlFilter) param(format string, expr string, value interface{}) string {
+	f.args = append(f.args, value)
+	return "(" + fmt.Sprintf(format, expr, "$"+strconv.Itoa(len(f.args))) + ")"
+}
+
+func sqlOperator(comparison domain.Comparison) string {
+	switch comparison {
+	case domain.CompareNotEqual:
+		return "<>"
+	case domain.CompareGreaterThan:
+		return ">"
+	case domain.CompareGreaterOrEqual:
+		return ">="
+	case domain.CompareLessThan:
+		return "<"
+	case domain.CompareLessOrEqual:
+		return "<="
+	default:
+		return "="
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
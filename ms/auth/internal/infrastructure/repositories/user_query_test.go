@@ -0,0 +1,97 @@
+//go:build auth
+// +build auth
+
+package repositories
+
+import (
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSQLFilter_Condition(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		query     domain.UserQuery
+		condition string
+		args      []interface{}
+	}{
+		{
+			name:      "email equality",
+			query:     &domain.UserCondition{Field: domain.UserFieldEmail, Comparison: domain.CompareEqual, Value: "John@Example.com"},
+			condition: "(lower(email) = lower($1))",
+			args:      []interface{}{"John@Example.com"},
+		},
+		{
+			name:      "contains escapes wildcards",
+			query:     &domain.UserCondition{Field: domain.UserFieldName, Comparison: domain.CompareContains, Value: "50%_off"},
+			condition: "(name ILIKE $1)",
+			args:      []interface{}{`%50\%\_off%`},
+		},
+		{
+			name: "active and created",
+			query: &domain.UserQueryAnd{
+				Left:  &domain.UserCondition{Field: domain.UserFieldActive, Comparison: domain.CompareEqual, Value: true},
+				Right: &domain.UserCondition{Field: domain.UserFieldCreatedAt, Comparison: domain.CompareGreaterOrEqual, Value: created},
+			},
+			condition: "(((activated AND NOT suspended) = $1) AND (created_at >= $2))",
+			args:      []interface{}{true, created},
+		},
+		{
+			name: "starts with and constant",
+			query: &domain.UserQueryAnd{
+				Left:  domain.UserQueryConstant(true),
+				Right: &domain.UserCondition{Field: domain.UserFieldEmail, Comparison: domain.CompareStartsWith, Value: "john"},
+			},
+			condition: "(TRUE AND (email ILIKE $1))",
+			args:      []interface{}{"john%"},
+		},
+		{
+			name: "not and id",
+			query: &domain.UserQueryOr{
+				Left:  &domain.UserQueryNot{Query: &domain.UserCondition{Field: domain.UserFieldID, Comparison: domain.CompareEqual, Value: int64(42)}},
+				Right: domain.UserQueryConstant(false),
+			},
+			condition: "((NOT (id = $1)) OR FALSE)",
+			args:      []interface{}{int64(42)},
+		},
+		{
+			name:      "present",
+			query:     &domain.UserCondition{Field: domain.UserFieldName, Comparison: domain.ComparePresent},
+			condition: "(name <> '')",
+		},
+		{
+			name:      "nil",
+			condition: "TRUE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var f sqlFilter
+
+			// Act
+			condition, err := f.condition(tt.query)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.condition, condition)
+			assert.Equal(t, tt.args, f.args)
+		})
+	}
+
+	t.Run("Error - unsupported field", func(t *testing.T) {
+		// Arrange
+		var f sqlFilter
+
+		// Act
+		_, err := f.condition(&domain.UserCondition{Field: "password_hash", Comparison: domain.CompareEqual, Value: "x"})
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
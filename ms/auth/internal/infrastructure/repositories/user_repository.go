@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"errors"
+	"fmt"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	_ "github.com/lib/pq"
@@ -14,22 +15,23 @@ import (
 const defaultTimeout = 10 * time.Second
 
 type userRepository struct {
-	db *sql.DB
+	db      dbtx
+	timeout time.Duration
 }
 
-func NewUserRepo(db *sql.DB) domain.UserRepository {
-	return &userRepository{db: db}
+func NewUserRepo(db *sql.DB, timeout time.Duration) domain.UserRepository {
+	return &userRepository{db: db, timeout: queryTimeout(timeout)}
 }
 
-func (r *userRepository) InsertNewUser(user *domain.User, hashedPassword string) error {
+func (r *userRepository) InsertNewUser(ctx context.Context, user *domain.User, hashedPassword string) error {
 	query := `
-        INSERT INTO users (name, email, password_hash, activated) 
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO users (name, email, password_hash, activated, locale) 
+        VALUES ($1, $2, $3, $4, $5)
         RETURNING id, created_at, version`
 
-	args := []interface{}{user.Name, user.Email, hashedPassword, user.Activated}
+	args := []interface{}{user.Name, user.Email, hashedPassword, user.Activated, user.Locale}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Version)
@@ -45,15 +47,15 @@ func (r *userRepository) InsertNewUser(user *domain.User, hashedPassword string)
 	return nil
 }
 
-func (r *userRepository) GetUserByEmail(email string) (*domain.User, error) {
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-        SELECT id, created_at, name, email, password_hash, activated, version
+        SELECT id, created_at, name, email, password_hash, activated, suspended, version, permissions_version, sessions_revoked_at, locale
         FROM users
         WHERE email = $1`
 
 	var user domain.User
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
@@ -63,7 +65,11 @@ func (r *userRepository) GetUserByEmail(email string) (*domain.User, error) {
 		&user.Email,
 		&user.HashedPassword,
 		&user.Activated,
+		&user.Suspended,
 		&user.Version,
+		&user.PermissionsVersion,
+		&user.SessionsRevokedAt,
+		&user.Locale,
 	)
 
 	if err != nil {
@@ -78,25 +84,30 @@ func (r *userRepository) GetUserByEmail(email string) (*domain.User, error) {
 	return &user, nil
 }
 
-func (r *userRepository) UpdateUser(user *domain.User) error {
+// UpdateUser saves user if it is still at its version. Tokens carry the
+// activation state of their user, so changing it also bumps the permissions
+// version, making those tokens stale.
+func (r *userRepository) UpdateUser(ctx context.Context, user *domain.User) error {
 	query := `
-        UPDATE users SET name = $1, email = $2, password_hash = $3, activated = $4, version = version + 1
-        WHERE id = $5 AND version = $6
-        RETURNING version`
+        UPDATE users SET name = $1, email = $2, password_hash = $3, activated = $4, suspended = $5, version = version + 1,
+            permissions_version = CASE WHEN activated = $4 THEN permissions_version ELSE permissions_version + 1 END
+        WHERE id = $6 AND version = $7
+        RETURNING version, permissions_version`
 
 	args := []interface{}{
 		user.Name,
 		user.Email,
 		user.HashedPassword,
 		user.Activated,
+		user.Suspended,
 		user.ID,
 		user.Version,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&user.Version)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&user.Version, &user.PermissionsVersion)
 	if err != nil {
 		switch {
 		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
@@ -111,11 +122,11 @@ func (r *userRepository) UpdateUser(user *domain.User) error {
 	return nil
 }
 
-func (r *userRepository) GetForToken(tokenScope string, tokenPlaintext string) (*domain.User, error) {
+func (r *userRepository) GetForToken(ctx context.Context, tokenScope string, tokenPlaintext string) (*domain.User, error) {
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 
 	query := `
-        SELECT users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.version
+        SELECT users.id, users.created_at, users.name, users.email, users.password_hash, users.activated, users.suspended, users.version, users.permissions_version, users.sessions_revoked_at, users.locale
         FROM users
         INNER JOIN tokens
         ON users.id = tokens.user_id
@@ -127,7 +138,7 @@ func (r *userRepository) GetForToken(tokenScope string, tokenPlaintext string) (
 
 	var user domain.User
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	err := r.db.QueryRowContext(ctx, query, args...).Scan(
@@ -137,7 +148,11 @@ func (r *userRepository) GetForToken(tokenScope string, tokenPlaintext string) (
 		&user.Email,
 		&user.HashedPassword,
 		&user.Activated,
+		&user.Suspended,
 		&user.Version,
+		&user.PermissionsVersion,
+		&user.SessionsRevokedAt,
+		&user.Locale,
 	)
 	if err != nil {
 		switch {
@@ -151,15 +166,15 @@ func (r *userRepository) GetForToken(tokenScope string, tokenPlaintext string) (
 	return &user, nil
 }
 
-func (r *userRepository) GetUserById(id int64) (*domain.User, error) {
+func (r *userRepository) GetUserById(ctx context.Context, id int64) (*domain.User, error) {
 	query := `
-        SELECT id, created_at, name, email, password_hash, activated, version
+        SELECT id, created_at, name, email, password_hash, activated, suspended, version, permissions_version, sessions_revoked_at, locale
         FROM users
         WHERE id = $1`
 
 	var user domain.User
 
-	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
 	defer cancel()
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
@@ -169,7 +184,11 @@ func (r *userRepository) GetUserById(id int64) (*domain.User, error) {
 		&user.Email,
 		&user.HashedPassword,
 		&user.Activated,
+		&user.Suspended,
 		&user.Version,
+		&user.PermissionsVersion,
+		&user.SessionsRevokedAt,
+		&user.Locale,
 	)
 
 	if err != nil {
@@ -183,3 +202,202 @@ func (r *userRepository) GetUserById(id int64) (*domain.User, error) {
 
 	return &user, nil
 }
+
+func (r *userRepository) GetAll(ctx context.Context, filter domain.UserFilter, filters domain.Filters) ([]*domain.User, domain.Metadata, error) {
+	query := fmt.Sprintf(`
+        SELECT count(*) OVER(), id, created_at, name, email, password_hash, activated, suspended, version, permissions_version, sessions_revoked_at, locale
+        FROM users
+        WHERE %s
+        ORDER BY %s %s, id ASC
+        LIMIT $6 OFFSET $7`, userFilterConditions, filters.SortColumn(), filters.SortDirection())
+
+	args := append(userFilterArgs(filter), filters.Limit(), filters.Offset())
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, domain.Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	users := []*domain.User{}
+
+	for rows.Next() {
+		var user domain.User
+
+		err := rows.Scan(
+			&totalRecords,
+			&user.ID,
+			&user.CreatedAt,
+			&user.Name,
+			&user.Email,
+			&user.HashedPassword,
+			&user.Activated,
+			&user.Suspended,
+			&user.Version,
+			&user.PermissionsVersion,
+			&user.SessionsRevokedAt,
+			&user.Locale,
+		)
+		if err != nil {
+			return nil, domain.Metadata{}, err
+		}
+
+		users = append(users, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, domain.Metadata{}, err
+	}
+
+	metadata := domain.CalculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return users, metadata, nil
+}
+
+// userFilterConditions selects the users matching a domain.UserFilter, whose
+// fields are given as $1 to $5 by userFilterArgs. The email is matched as a
+// substring, with any wildcard in it taken literally.
+const userFilterConditions = `(email ILIKE '%' || $1 || '%' OR $1 = '')
+        AND ($2::bool IS NULL OR activated = $2)
+        AND ($3::bool IS NULL OR suspended = $3)
+        AND ($4::timestamptz IS NULL OR created_at >= $4)
+        AND ($5::timestamptz IS NULL OR created_at < $5)`
+
+func userFilterArgs(filter domain.UserFilter) []interface{} {
+	return []interface{}{
+		escapeLike(filter.Email),
+		filter.Activated,
+		filter.Suspended,
+		nullTime(filter.CreatedAfter),
+		nullTime(filter.CreatedBefore),
+	}
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func (r *userRepository) RevokeSessions(ctx context.Context, userID int64, revokedAt time.Time) error {
+	query := `
+        UPDATE users SET sessions_revoked_at = $1, version = version + 1
+        WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, revokedAt, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Search pages through the users matching filter, ordered by id, and
+// returns the total number of matches alongside the page.
+func (r *userRepository) Search(ctx context.Context, filter domain.UserQuery, offset, limit int) ([]*domain.User, int, error) {
+	var f sqlFilter
+
+	condition, err := f.condition(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	totalResults := 0
+
+	err = r.db.QueryRowContext(ctx, "SELECT count(*) FROM users WHERE "+condition, f.args...).Scan(&totalResults)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	users := []*domain.User{}
+
+	if totalResults == 0 || limit == 0 {
+		return users, totalResults, nil
+	}
+
+	query := fmt.Sprintf(`
+        SELECT id, created_at, name, email, password_hash, activated, suspended, version, permissions_version, sessions_revoked_at, locale
+        FROM users
+        WHERE %s
+        ORDER BY id
+        LIMIT $%d OFFSET $%d`, condition, len(f.args)+1, len(f.args)+2)
+
+	args := append(f.args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user domain.User
+
+		err := rows.Scan(
+			&user.ID,
+			&user.CreatedAt,
+			&user.Name,
+			&user.Email,
+			&user.HashedPassword,
+			&user.Activated,
+			&user.Suspended,
+			&user.Version,
+			&user.PermissionsVersion,
+			&user.SessionsRevokedAt,
+			&user.Locale,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		users = append(users, &user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return users, totalResults, nil
+}
+
+func (r *userRepository) DeleteUser(ctx context.Context, id int64) error {
+	query := `
+        DELETE FROM users
+        WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrRecordNotFound
+	}
+
+	return nil
+}
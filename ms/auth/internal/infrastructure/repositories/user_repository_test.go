@@ -4,6 +4,7 @@
 package repositories
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
 	"database/sql/driver"
@@ -28,7 +29,7 @@ func TestUserRepository_InsertNewUser(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := NewUserRepo(db)
+	repo := NewUserRepo(db, defaultTimeout)
 
 	hashedPassword, _ := password.Hash("password123!")
 
@@ -39,16 +40,17 @@ func TestUserRepository_InsertNewUser(t *testing.T) {
 			Email:          "johndoe@example.com",
 			HashedPassword: hashedPassword,
 			Activated:      true,
+			Locale:         "es",
 		}
 
 		rows := sqlmock.NewRows([]string{"id", "created_at", "version"}).
 			AddRow(1, time.Now(), 1)
 
 		mock.ExpectQuery("INSERT INTO users").
-			WithArgs(user.Name, user.Email, user.HashedPassword, user.Activated).WillReturnRows(rows)
+			WithArgs(user.Name, user.Email, user.HashedPassword, user.Activated, user.Locale).WillReturnRows(rows)
 
 		// Act
-		err := repo.InsertNewUser(user, hashedPassword)
+		err := repo.InsertNewUser(context.Background(), user, hashedPassword)
 
 		// Assert
 		assert.NoError(t, err)
@@ -64,11 +66,11 @@ func TestUserRepository_InsertNewUser(t *testing.T) {
 		}
 
 		mock.ExpectQuery("INSERT INTO users").
-			WithArgs(user.Name, user.Email, user.HashedPassword, user.Activated).
+			WithArgs(user.Name, user.Email, user.HashedPassword, user.Activated, user.Locale).
 			WillReturnError(errors.New("some error"))
 
 		// Act
-		err := repo.InsertNewUser(user, hashedPassword)
+		err := repo.InsertNewUser(context.Background(), user, hashedPassword)
 
 		// Assert
 		assert.Error(t, err)
@@ -80,22 +82,22 @@ func TestUserRepository_GetUserByEmail(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := NewUserRepo(db)
+	repo := NewUserRepo(db, defaultTimeout)
 	hash := []byte("sampleHash")
 
 	t.Run("Success", func(t *testing.T) {
 		// Arrange
 		email := "johndoe@example.com"
 
-		rows := sqlmock.NewRows([]string{"id", "created_at", "name", "email", "password_hash", "activated", "version"}).
-			AddRow(1, time.Now(), "John Doe", "johndoe@example.com", hash, true, 1)
+		rows := sqlmock.NewRows([]string{"id", "created_at", "name", "email", "password_hash", "activated", "suspended", "version", "permissions_version", "sessions_revoked_at", "locale"}).
+			AddRow(1, time.Now(), "John Doe", "johndoe@example.com", hash, true, false, 1, 1, time.Time{}, "en")
 
 		mock.ExpectQuery("SELECT").
 			WithArgs(email).
 			WillReturnRows(rows)
 
 		// Act
-		user, err := repo.GetUserByEmail(email)
+		user, err := repo.GetUserByEmail(context.Background(), email)
 
 		// Assert
 		assert.NoError(t, err)
@@ -111,7 +113,7 @@ func TestUserRepository_GetUserByEmail(t *testing.T) {
 			WillReturnError(errors.New("some error"))
 
 		// Act
-		user, err := repo.GetUserByEmail(email)
+		user, err := repo.GetUserByEmail(context.Background(), email)
 
 		// Assert
 		assert.Error(t, err)
@@ -124,7 +126,7 @@ func TestUserRepository_UpdateUser(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := NewUserRepo(db)
+	repo := NewUserRepo(db, defaultTimeout)
 	hashedPassword, _ := password.Hash("password123!")
 
 	t.Run("Success", func(t *testing.T) {
@@ -138,16 +140,44 @@ func TestUserRepository_UpdateUser(t *testing.T) {
 			Version:        1,
 		}
 
-		rows := sqlmock.NewRows([]string{"version"}).
-			AddRow(2)
+		rows := sqlmock.NewRows([]string{"version", "permissions_version"}).
+			AddRow(2, 1)
 
-		mock.ExpectQuery("UPDATE users").WithArgs(user.Name, user.Email, user.HashedPassword, user.Activated, user.ID, user.Version).WillReturnRows(rows)
+		mock.ExpectQuery("UPDATE users").WithArgs(user.Name, user.Email, user.HashedPassword, user.Activated, user.Suspended, user.ID, user.Version).WillReturnRows(rows)
 
 		// Act
-		err := repo.UpdateUser(user)
+		err := repo.UpdateUser(context.Background(), user)
 
 		// Assert
 		assert.NoError(t, err)
+		assert.Equal(t, 2, user.Version)
+	})
+
+	t.Run("Success - activation bumps the permissions version", func(t *testing.T) {
+		// Arrange
+		user := &domain.User{
+			ID:                 1,
+			Name:               "John Doe",
+			Email:              "johndoe@example.com",
+			HashedPassword:     hashedPassword,
+			Activated:          true,
+			Version:            1,
+			PermissionsVersion: 3,
+		}
+
+		rows := sqlmock.NewRows([]string{"version", "permissions_version"}).
+			AddRow(2, 4)
+
+		mock.ExpectQuery(`permissions_version = CASE WHEN activated = \$4 THEN permissions_version ELSE permissions_version \+ 1 END`).
+			WithArgs(user.Name, user.Email, user.HashedPassword, user.Activated, user.Suspended, user.ID, user.Version).
+			WillReturnRows(rows)
+
+		// Act
+		err := repo.UpdateUser(context.Background(), user)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 4, user.PermissionsVersion)
 	})
 
 	t.Run("Error", func(t *testing.T) {
@@ -162,11 +192,11 @@ func TestUserRepository_UpdateUser(t *testing.T) {
 		}
 
 		mock.ExpectExec("UPDATE users").
-			WithArgs(user.Name, user.Email, user.HashedPassword, user.Activated, user.Version, user.ID).
+			WithArgs(user.Name, user.Email, user.HashedPassword, user.Activated, user.Suspended, user.ID, user.Version).
 			WillReturnError(errors.New("some error"))
 
 		// Act
-		err := repo.UpdateUser(user)
+		err := repo.UpdateUser(context.Background(), user)
 
 		// Assert
 		assert.Error(t, err)
@@ -178,21 +208,21 @@ func TestUserRepository_GetUserById(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := NewUserRepo(db)
+	repo := NewUserRepo(db, defaultTimeout)
 
 	t.Run("Success", func(t *testing.T) {
 		// Arrange
 		userID := int64(1)
 
-		rows := sqlmock.NewRows([]string{"id", "created_at", "name", "email", "password_hash", "activated", "version"}).
-			AddRow(userID, time.Now(), "John Doe", "johndoe@example.com", "somehash", true, 1)
+		rows := sqlmock.NewRows([]string{"id", "created_at", "name", "email", "password_hash", "activated", "suspended", "version", "permissions_version", "sessions_revoked_at", "locale"}).
+			AddRow(userID, time.Now(), "John Doe", "johndoe@example.com", "somehash", true, false, 1, 1, time.Time{}, "en")
 
 		mock.ExpectQuery("SELECT").
 			WithArgs(userID).
 			WillReturnRows(rows)
 
 		// Act
-		user, err := repo.GetUserById(userID)
+		user, err := repo.GetUserById(context.Background(), userID)
 
 		// Assert
 		assert.NoError(t, err)
@@ -208,11 +238,57 @@ func TestUserRepository_GetUserById(t *testing.T) {
 			WillReturnError(errors.New("some error"))
 
 		// Act
-		user, err := repo.GetUserById(userID)
+		user, err := repo.GetUserById(context.Background(), userID)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, user)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		// Arrange
+		userID := int64(1)
+
+		rows := sqlmock.NewRows([]string{"id", "created_at", "name", "email", "password_hash", "activated", "suspended", "version", "permissions_version", "sessions_revoked_at", "locale"}).
+			AddRow(userID, time.Now(), "John Doe", "johndoe@example.com", "somehash", true, false, 1, 1, time.Time{}, "en")
+
+		mock.ExpectQuery("SELECT").
+			WithArgs(userID).
+			WillDelayFor(time.Second).
+			WillReturnRows(rows)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// Act
+		user, err := repo.GetUserById(ctx, userID)
+
+		// Assert
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, user)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		// Arrange
+		userID := int64(1)
+		repo := NewUserRepo(db, 10*time.Millisecond)
+
+		rows := sqlmock.NewRows([]string{"id", "created_at", "name", "email", "password_hash", "activated", "suspended", "version", "permissions_version", "sessions_revoked_at", "locale"}).
+			AddRow(userID, time.Now(), "John Doe", "johndoe@example.com", "somehash", true, false, 1, 1, time.Time{}, "en")
+
+		mock.ExpectQuery("SELECT").
+			WithArgs(userID).
+			WillDelayFor(time.Second).
+			WillReturnRows(rows)
+
+		// Act
+		started := time.Now()
+		user, err := repo.GetUserById(context.Background(), userID)
 
 		// Assert
 		assert.Error(t, err)
 		assert.Nil(t, user)
+		assert.Less(t, time.Since(started), time.Second)
 	})
 }
 
@@ -221,21 +297,21 @@ func TestUserRepository_GetForToken(t *testing.T) {
 	assert.NoError(t, err)
 	defer db.Close()
 
-	repo := NewUserRepo(db)
+	repo := NewUserRepo(db, defaultTimeout)
 
 	t.Run("Success", func(t *testing.T) {
 		tokenPlainText := "valid_token"
 		tokenHash := sha256.Sum256([]byte(tokenPlainText))
 		tokenScope := ScopeActivation
 
-		rows := sqlmock.NewRows([]string{"id", "created_at", "name", "email", "password_hash", "activated", "version"}).
-			AddRow(int64(1), time.Now(), "John Doe", "johndoe@example.com", "somehash", true, 1)
+		rows := sqlmock.NewRows([]string{"id", "created_at", "name", "email", "password_hash", "activated", "suspended", "version", "permissions_version", "sessions_revoked_at", "locale"}).
+			AddRow(int64(1), time.Now(), "John Doe", "johndoe@example.com", "somehash", true, false, 1, 1, time.Time{}, "en")
 
 		mock.ExpectQuery("SELECT").
 			WithArgs(tokenHash[:], tokenScope, AnyTime{}).
 			WillReturnRows(rows)
 
-		user, err := repo.GetForToken(tokenScope, tokenPlainText)
+		user, err := repo.GetForToken(context.Background(), tokenScope, tokenPlainText)
 
 		// Assert
 		assert.NoError(t, err)
@@ -251,7 +327,7 @@ func TestUserRepository_GetForToken(t *testing.T) {
 			WithArgs(tokenHash[:], tokenScope, AnyTime{}).
 			WillReturnError(sql.ErrNoRows)
 
-		_, err := repo.GetForToken(tokenScope, tokenPlainText)
+		_, err := repo.GetForToken(context.Background(), tokenScope, tokenPlainText)
 
 		// Assert
 		assert.Error(t, err)
@@ -266,10 +342,217 @@ func TestUserRepository_GetForToken(t *testing.T) {
 			WithArgs(tokenHash[:], tokenScope, AnyTime{}).
 			WillReturnError(errors.New("default error"))
 
-		_, err := repo.GetForToken(tokenScope, tokenPlainText)
+		_, err := repo.GetForToken(context.Background(), tokenScope, tokenPlainText)
 
 		// Assert
 		assert.Error(t, err)
 	})
 
 }
+
+func TestUserRepository_GetAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(db, defaultTimeout)
+
+	activated := true
+	filters := domain.Filters{Page: 1, PageSize: 20, Sort: "-created_at", SortSafelist: []string{"created_at", "-created_at"}}
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		filter := domain.UserFilter{Email: "example.com", Activated: &activated}
+
+		rows := sqlmock.NewRows([]string{"count", "id", "created_at", "name", "email", "password_hash", "activated", "suspended", "version", "permissions_version", "sessions_revoked_at", "locale"}).
+			AddRow(2, int64(1), time.Now(), "John Doe", "johndoe@example.com", "somehash", true, false, 1, 1, time.Time{}, "en").
+			AddRow(2, int64(2), time.Now(), "Jane Doe", "janedoe@example.com", "somehash", true, true, 3, 1, time.Time{}, "es")
+
+		mock.ExpectQuery("SELECT count\\(\\*\\) OVER\\(\\)").
+			WithArgs(filter.Email, &activated, nil, sql.NullTime{}, sql.NullTime{}, 20, 0).
+			WillReturnRows(rows)
+
+		// Act
+		users, metadata, err := repo.GetAll(context.Background(), filter, filters)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, users, 2)
+		assert.True(t, users[1].Suspended)
+		assert.Equal(t, 2, metadata.TotalRecords)
+		assert.Equal(t, 1, metadata.LastPage)
+	})
+
+	t.Run("Success - wildcards in the email", func(t *testing.T) {
+		// Arrange
+		filter := domain.UserFilter{Email: `john_doe%\`}
+
+		mock.ExpectQuery("SELECT count\\(\\*\\) OVER\\(\\)").
+			WithArgs(`john\_doe\%\\`, nil, nil, sql.NullTime{}, sql.NullTime{}, 20, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "id", "created_at", "name", "email", "password_hash", "activated", "suspended", "version", "permissions_version", "sessions_revoked_at", "locale"}))
+
+		// Act
+		users, _, err := repo.GetAll(context.Background(), filter, filters)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT count\\(\\*\\) OVER\\(\\)").
+			WillReturnError(errors.New("some error"))
+
+		// Act
+		users, _, err := repo.GetAll(context.Background(), domain.UserFilter{}, filters)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, users)
+	})
+}
+
+func TestUserRepository_RevokeSessions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		revokedAt := time.Now()
+
+		mock.ExpectExec("UPDATE users SET sessions_revoked_at").
+			WithArgs(revokedAt, int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		err := repo.RevokeSessions(context.Background(), 1, revokedAt)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("UPDATE users SET sessions_revoked_at").
+			WithArgs(AnyTime{}, int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Act
+		err := repo.RevokeSessions(context.Background(), 2, time.Now())
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
+
+func TestUserRepository_Search(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(db, defaultTimeout)
+
+	columns := []string{"id", "created_at", "name", "email", "password_hash", "activated", "suspended", "version", "permissions_version", "sessions_revoked_at", "locale"}
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		filter := &domain.UserCondition{Field: domain.UserFieldEmail, Comparison: domain.CompareEqual, Value: "johndoe@example.com"}
+
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM users WHERE \\(lower\\(email\\) = lower\\(\\$1\\)\\)").
+			WithArgs("johndoe@example.com").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("SELECT id, created_at, name, email, (.+) LIMIT \\$2 OFFSET \\$3").
+			WithArgs("johndoe@example.com", 10, 0).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(int64(1), time.Now(), "John Doe", "johndoe@example.com", "somehash", true, false, 1, 1, time.Time{}, "en"))
+
+		// Act
+		users, totalResults, err := repo.Search(context.Background(), filter, 0, 10)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, 1, totalResults)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Count only", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM users WHERE TRUE").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+		// Act
+		users, totalResults, err := repo.Search(context.Background(), nil, 0, 0)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+		assert.Equal(t, 42, totalResults)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unsupported field", func(t *testing.T) {
+		// Arrange
+		filter := &domain.UserCondition{Field: "password_hash", Comparison: domain.CompareEqual, Value: "x"}
+
+		// Act
+		users, _, err := repo.Search(context.Background(), filter, 0, 10)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, users)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		// Arrange
+		mock.ExpectQuery("SELECT count").
+			WillReturnError(errors.New("some error"))
+
+		// Act
+		users, _, err := repo.Search(context.Background(), nil, 0, 10)
+
+		// Assert
+		assert.Error(t, err)
+		assert.Nil(t, users)
+	})
+}
+
+func TestUserRepository_DeleteUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewUserRepo(db, defaultTimeout)
+
+	t.Run("Success", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("DELETE FROM users").
+			WithArgs(int64(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		// Act
+		err := repo.DeleteUser(context.Background(), 1)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		// Arrange
+		mock.ExpectExec("DELETE FROM users").
+			WithArgs(int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		// Act
+		err := repo.DeleteUser(context.Background(), 2)
+
+		// Assert
+		assert.ErrorIs(t, err, domain.ErrRecordNotFound)
+	})
+}
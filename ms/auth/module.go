@@ -6,8 +6,12 @@ import (
 	"errors"
 	"fmt"
 	pb "github.com/jessicatarra/greenlight/api/proto"
+	"github.com/jessicatarra/greenlight/internal/concurrent"
 	"github.com/jessicatarra/greenlight/internal/config"
+	"github.com/jessicatarra/greenlight/internal/mailer"
+	"github.com/jessicatarra/greenlight/internal/password"
 	appl "github.com/jessicatarra/greenlight/ms/auth/internal/application"
+	"github.com/jessicatarra/greenlight/ms/auth/internal/domain"
 	_grpc "github.com/jessicatarra/greenlight/ms/auth/internal/infrastructure/grpc"
 	_http "github.com/jessicatarra/greenlight/ms/auth/internal/infrastructure/http"
 	repo "github.com/jessicatarra/greenlight/ms/auth/internal/infrastructure/repositories"
@@ -28,14 +32,22 @@ const (
 )
 
 type module struct {
-	grpc   *grpc.Server
-	server *http.Server
-	logger *slog.Logger
-	cfg    *config.Config
+	grpc       *grpc.Server
+	server     *http.Server
+	dispatcher *appl.OutboxDispatcher
+	// dispatcherCtx is done once the outbox dispatcher should stop, after
+	// the email it is sending, if any.
+	dispatcherCtx  context.Context
+	stopDispatcher context.CancelFunc
+	// cancelRequests cancels the context of every request the server is
+	// still handling, and so any query they are waiting on.
+	cancelRequests context.CancelFunc
+	logger         *slog.Logger
+	cfg            *config.Config
 }
 
 func (m module) Start(wg *sync.WaitGroup) {
-	wg.Add(2)
+	wg.Add(3)
 	go func() {
 		defer wg.Done()
 		m.logger.Info("Starting Auth Module server", slog.Group("server", "addr", m.server.Addr))
@@ -64,28 +76,68 @@ func (m module) Start(wg *sync.WaitGroup) {
 		}
 		m.logger.Info("Stopped auth Module GRPC server", slog.Group("server", "addr", lis.Addr()))
 	}()
+
+	go func() {
+		defer wg.Done()
+		m.logger.Info("Starting Auth Module outbox dispatcher")
+		m.dispatcher.Run(m.dispatcherCtx)
+		m.logger.Info("Stopped auth Module outbox dispatcher")
+	}()
 }
 
 func (m module) Shutdown(ctx context.Context, cancel func()) {
 	defer cancel()
 
+	m.stopDispatcher()
 	m.grpc.GracefulStop()
 	err := m.server.Shutdown(ctx)
+	// Requests still running once the grace period is over are abandoned.
+	m.cancelRequests()
 	if err != nil {
 		return
 	}
 
 }
 
-func NewModule(db *sql.DB, cfg config.Config, wg *sync.WaitGroup, logger *slog.Logger) *module {
-	userRepo := repo.NewUserRepo(db)
-	tokenRepo := repo.NewTokenRepo(db)
-	permissionRepo := repo.NewPermissionRepo(db)
-	appl := appl.NewAppl(userRepo, tokenRepo, permissionRepo, wg, cfg)
-	api := _http.NewService(appl, cfg, logger)
+// NewModule wires up the auth module. The jobs it runs in the background,
+// such as sending bulk emails, are registered on jobs.
+func NewModule(db *sql.DB, cfg config.Config, mail mailer.Mailer, jobs *concurrent.Queue, hashing *password.Executor, logger *slog.Logger) *module {
+	userRepo := repo.NewUserRepo(db, cfg.DB.QueryTimeout)
+	tokenRepo := repo.NewTokenRepo(db, cfg.DB.QueryTimeout)
+	permissionRepo := repo.NewPermissionRepo(db, cfg.DB.QueryTimeout)
+	invitationRepo := repo.NewInvitationRepo(db, cfg.DB.QueryTimeout)
+	auditRepo := repo.NewAuditRepo(db, cfg.DB.QueryTimeout)
+	deviceRepo := repo.NewDeviceRepo(db, cfg.DB.QueryTimeout)
+	passkeyRepo := repo.NewPasskeyRepo(db, cfg.DB.QueryTimeout)
+	deviceAuthorizationRepo := repo.NewDeviceAuthorizationRepo(db, cfg.DB.QueryTimeout)
+	outboxRepo := repo.NewOutboxRepo(db, cfg.DB.QueryTimeout)
+	bulkEmailRepo := repo.NewBulkEmailRepo(db, cfg.DB.QueryTimeout)
+	unitOfWork := repo.NewUnitOfWork(db, cfg.DB.QueryTimeout)
+	application := appl.NewAppl(appl.Deps{
+		Users:                userRepo,
+		Tokens:               tokenRepo,
+		Permissions:          permissionRepo,
+		Invitations:          invitationRepo,
+		Audit:                auditRepo,
+		Devices:              deviceRepo,
+		Passkeys:             passkeyRepo,
+		DeviceAuthorizations: deviceAuthorizationRepo,
+		Outbox:               outboxRepo,
+		BulkEmails:           bulkEmailRepo,
+		Jobs:                 jobs,
+		UnitOfWork:           unitOfWork,
+	}, cfg)
+	dispatcher := appl.NewOutboxDispatcher(outboxRepo, mail, cfg, logger)
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	bulkEmails := appl.NewBulkEmailRunner(bulkEmailRepo, jobs, func() appl.BulkSender { return mail.Bulk(cfg.BulkEmail.Rate) }, cfg, logger)
+	concurrent.Register(jobs, domain.BulkEmailJobKind, bulkEmails.Run)
+	hasher := password.NewHasher(hashing, cfg.Password.CurrentPepper, cfg.Password.Peppers)
+	api := _http.NewService(application, cfg, hasher, mail, logger)
 
 	grpcServer := grpc.NewServer()
-	pb.RegisterAuthGRPCServiceServer(grpcServer, _grpc.NewGRPCServer(appl))
+	pb.RegisterAuthGRPCServiceServer(grpcServer, _grpc.NewGRPCServer(application))
+
+	base, cancelRequests := context.WithCancel(context.Background())
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Auth.HttpPort),
@@ -94,7 +146,17 @@ func NewModule(db *sql.DB, cfg config.Config, wg *sync.WaitGroup, logger *slog.L
 		IdleTimeout:  defaultIdleTimeout,
 		ReadTimeout:  defaultReadTimeout,
 		WriteTimeout: defaultWriteTimeout,
+		BaseContext:  func(net.Listener) context.Context { return base },
 	}
 
-	return &module{grpc: grpcServer, server: srv, logger: logger, cfg: &cfg}
+	return &module{
+		grpc:           grpcServer,
+		server:         srv,
+		dispatcher:     dispatcher,
+		dispatcherCtx:  dispatcherCtx,
+		cancelRequests: cancelRequests,
+		stopDispatcher: stopDispatcher,
+		logger:         logger,
+		cfg:            &cfg,
+	}
 }